@@ -18,6 +18,10 @@ package v1alpha1
 
 import (
 	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -25,31 +29,645 @@ const (
 	DeviceConfigDeletionFinalizer = "device-config-deletion-finalizer"
 
 	HabanaPCIVendorID = "1da3"
+	// HabanaPCIClass is the PCI class of the Habana AI accelerators
+	// (processing accelerators).
+	HabanaPCIClass = "1200"
+
+	DefaultMonitoringInterval        = "30s"
+	DefaultAlertTemperatureThreshold = 85
+	DefaultAlertMinDevicesPerNode    = 8
+	DefaultAlertFor                  = "5m"
+
+	DefaultNodeMetricsPort = 41611
+
+	DefaultTelemetryInterval           = "1m"
+	DefaultTelemetryTemperature        = 85
+	DefaultTelemetryMemoryUsagePercent = 95
+
+	DefaultWorkloadCapability = "SYS_RAWIO"
+	HabanaLogsEnvVar          = "HABANA_LOGS"
+	DefaultHabanaLogs         = "/var/log/habana_logs"
+	// HabanaVisibleModulesEnvVar lists the modules the Habana runtime uses.
+	HabanaVisibleModulesEnvVar = "HABANA_VISIBLE_MODULES"
+
+	// CDISpecDir is the directory of the CDI specs on the nodes.
+	CDISpecDir = "/var/run/cdi"
+
+	ContainerEngineContainerd = "containerd"
+	ContainerEngineCRIO       = "crio"
+
+	DefaultNetworkMTU              = 8000
+	DefaultNetworkAddressesPerNode = 24
+
+	DefaultKubeletRootDir = "/var/lib/kubelet"
+
+	DefaultSimulationDevicesPerNode = 8
 )
 
 // DeviceConfigSpec defines the desired state of DeviceConfig
 type DeviceConfigSpec struct {
-	//+kubebuilder:validation:Required
-	// DriverImage is the Habana driver image to use
-	DriverImage string `json:"driverImage"`
-	//+kubebuilder:validation:Required
-	// DriverVersion is the Habana driver version deployed
-	DriverVersion string `json:"driverVersion"`
+	//+kubebuilder:validation:Optional
+	// DriverImage is the Habana driver image to use, required in the
+	// container driver mode
+	DriverImage string `json:"driverImage,omitempty"`
+	//+kubebuilder:validation:Optional
+	// DriverVersion is the Habana driver version deployed, required in the
+	// container driver mode
+	DriverVersion string `json:"driverVersion,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Driver configures how the habanalabs kernel module is provided on the
+	// nodes
+	Driver DriverSpec `json:"driver,omitempty"`
 	//+kubebuilder:validation:Optional
 	// NodeSelector specifies a selector for the DeviceConfig
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Monitoring configures the Prometheus Operator resources managed for the DeviceConfig
+	Monitoring MonitoringSpec `json:"monitoring,omitempty"`
+	//+kubebuilder:validation:Optional
+	// NodeMetrics configures the node metrics exporter
+	NodeMetrics NodeMetricsSpec `json:"nodeMetrics,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Telemetry configures the collection of the node metrics by the operator
+	Telemetry TelemetrySpec `json:"telemetry,omitempty"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// ExpectedDevicesPerNode is the number of HPUs each node should advertise.
+	// The nodes advertising another number of HPUs are reported as degraded
+	ExpectedDevicesPerNode int32 `json:"expectedDevicesPerNode,omitempty"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=None;Label;Taint
+	// DegradedNodeRemediation is applied to the degraded nodes, defaults to None
+	DegradedNodeRemediation DegradedNodeRemediation `json:"degradedNodeRemediation,omitempty"`
+	//+kubebuilder:validation:Optional
+	// WorkloadInjection configures the injection of the Habana runtime
+	// requirements in the pods requesting HPUs
+	WorkloadInjection WorkloadInjectionSpec `json:"workloadInjection,omitempty"`
+	//+kubebuilder:validation:Optional
+	// CDI configures the generation of the Container Device Interface specs
+	// of the HPUs on the nodes
+	CDI CDISpec `json:"cdi,omitempty"`
+	//+kubebuilder:validation:Optional
+	// ContainerRuntime configures the installation of the Habana container
+	// runtime on the nodes
+	ContainerRuntime ContainerRuntimeSpec `json:"containerRuntime,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Network configures the scale-out network interfaces of the HPUs
+	Network NetworkSpec `json:"network,omitempty"`
+	//+kubebuilder:validation:Optional
+	// HugePages configures the 2Mi huge pages allocated on the nodes
+	HugePages HugePagesSpec `json:"hugePages,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Topology configures the export of the PCI and NUMA topology of the HPUs
+	Topology TopologySpec `json:"topology,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Kubelet configures the kubelet directories of the nodes, for the
+	// distributions which do not use the default ones
+	Kubelet KubeletSpec `json:"kubelet,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Simulation advertises simulated HPUs on nodes without HPUs, e.g. to test
+	// the scheduling of the workloads in CI clusters
+	Simulation SimulationSpec `json:"simulation,omitempty"`
+}
+
+// DriverMode is the way the habanalabs kernel module is provided on the nodes.
+type DriverMode string
+
+const (
+	// DriverModeContainer loads the module built in the driver image with KMM.
+	DriverModeContainer DriverMode = "container"
+	// DriverModeInTree loads the module shipped with the kernel of the nodes.
+	DriverModeInTree DriverMode = "inTree"
+	// DriverModeHostInstalled only verifies that the module installed on the
+	// nodes is loaded.
+	DriverModeHostInstalled DriverMode = "hostInstalled"
+)
+
+// DriverSpec configures how the habanalabs kernel module is provided on the
+// nodes
+type DriverSpec struct {
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=container;inTree;hostInstalled
+	// Mode is container by default, to load the module of the driver image.
+	// The inTree mode loads the module of the node kernel, and the
+	// hostInstalled mode verifies the module loaded by the host
+	Mode DriverMode `json:"mode,omitempty"`
+	//+kubebuilder:validation:Optional
+	// FirmwareImage is an image shipping the HPU firmware in
+	// /opt/lib/firmware/habanalabs, like the driver images, installed on the
+	// nodes in the inTree and hostInstalled modes
+	FirmwareImage string `json:"firmwareImage,omitempty"`
+}
+
+// SimulationSpec configures the simulated HPUs advertised on the nodes
+type SimulationSpec struct {
+	//+kubebuilder:validation:Optional
+	// Enabled replaces the driver and the device plugin with a simulated
+	// device plugin, advertising simulated HPUs on the nodes of the
+	// NodeSelector, which are labeled as nodes with HPUs and report synthetic
+	// metrics. The simulated HPUs are backed by /dev/null, so the workloads
+	// get no actual device mounted
+	Enabled bool `json:"enabled,omitempty"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=64
+	// DevicesPerNode is the number of simulated HPUs advertised by each node,
+	// 8 by default
+	DevicesPerNode int32 `json:"devicesPerNode,omitempty"`
+}
+
+// KubeletSpec configures the kubelet directories mounted by the components
+// deployed on the nodes
+type KubeletSpec struct {
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Pattern=`^/`
+	// RootDir is the kubelet root directory of the nodes, /var/lib/kubelet by
+	// default, or detected from the node annotations when DetectFromNodes is set
+	RootDir string `json:"rootDir,omitempty"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Pattern=`^/`
+	// DevicePluginsDir is the directory of the kubelet device plugin sockets,
+	// the device-plugins directory of RootDir by default
+	DevicePluginsDir string `json:"devicePluginsDir,omitempty"`
+	//+kubebuilder:validation:Optional
+	// DetectFromNodes detects the kubelet root directory from the annotations
+	// and labels of the nodes when RootDir is unset
+	DetectFromNodes bool `json:"detectFromNodes,omitempty"`
+}
+
+// TopologySpec configures the export of the NUMA topology of the HPUs and
+// network interfaces of the nodes, for topology-aware scheduling
+type TopologySpec struct {
+	//+kubebuilder:validation:Optional
+	// Enabled labels each node with the number of HPUs and network interfaces
+	// of its NUMA nodes, and reports the recommended Topology Manager policy
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// TopologyManagerPolicy is a kubelet Topology Manager policy.
+type TopologyManagerPolicy string
+
+const (
+	// TopologyManagerPolicyBestEffort prefers NUMA aligned allocations.
+	TopologyManagerPolicyBestEffort TopologyManagerPolicy = "best-effort"
+	// TopologyManagerPolicySingleNUMANode only admits the pods whose
+	// resources are allocated from a single NUMA node.
+	TopologyManagerPolicySingleNUMANode TopologyManagerPolicy = "single-numa-node"
+)
+
+// HugePagesSpec configures the allocation of the 2Mi huge pages of the nodes
+type HugePagesSpec struct {
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// Count is the number of 2Mi huge pages allocated on each node. The huge
+	// pages are not managed when unset
+	Count *int32 `json:"count,omitempty"`
+	//+kubebuilder:validation:Optional
+	// MachineConfigPool is the OpenShift MachineConfigPool of the nodes, whose
+	// kernel arguments allocate the huge pages. It is required on OpenShift, as
+	// changing the count reboots all the nodes of the pool, including the ones
+	// without HPUs, hence the nodes with HPUs should have a dedicated pool
+	MachineConfigPool string `json:"machineConfigPool,omitempty"`
+}
+
+// NetworkSpec configures the addressing and the MTU of the Gaudi scale-out
+// network interfaces of the nodes
+type NetworkSpec struct {
+	//+kubebuilder:validation:Optional
+	// Enabled configures the Habana network interfaces of each node
+	Enabled bool `json:"enabled,omitempty"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1280
+	//+kubebuilder:validation:Maximum=9216
+	// MTU is the MTU of the Habana network interfaces, 8000 by default
+	MTU int32 `json:"mtu,omitempty"`
+	//+kubebuilder:validation:Optional
+	// IPAM allocates the addresses of the nodes from a range
+	IPAM *NetworkIPAMSpec `json:"ipam,omitempty"`
+	//+kubebuilder:validation:Optional
+	// StaticAddresses sets the addresses of the given nodes, taking precedence
+	// over IPAM. The IPAM blocks holding them are not allocated to other nodes
+	StaticAddresses []NodeNetworkAddresses `json:"staticAddresses,omitempty"`
+}
+
+// NetworkIPAMSpec configures the allocation of the addresses of the Habana
+// network interfaces
+type NetworkIPAMSpec struct {
+	//+kubebuilder:validation:Required
+	// Range is the IPv4 CIDR the addresses are allocated from, e.g.
+	// 192.168.100.0/22, and whose prefix length the addresses use
+	Range string `json:"range"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// AddressesPerNode is the number of consecutive addresses allocated to
+	// each node, one per interface, 24 by default
+	AddressesPerNode int32 `json:"addressesPerNode,omitempty"`
+}
+
+// NodeNetworkAddresses are the static addresses of the Habana network
+// interfaces of a node
+type NodeNetworkAddresses struct {
+	//+kubebuilder:validation:Required
+	// NodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	//+kubebuilder:validation:Required
+	// Addresses are assigned to the Habana interfaces of the node in order, in
+	// CIDR notation, e.g. 192.168.100.1/24
+	Addresses []string `json:"addresses"`
+}
+
+// CDISpec configures the Container Device Interface (CDI) mode
+type CDISpec struct {
+	//+kubebuilder:validation:Optional
+	// Enabled writes the CDI specs of the HPUs under /var/run/cdi on each node,
+	// and configures the device plugin to return CDI device names
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// ContainerRuntimeSpec configures the installation of the Habana container
+// runtime and its registration in the container engine of the nodes
+type ContainerRuntimeSpec struct {
+	//+kubebuilder:validation:Optional
+	// Enabled installs the habana-container-runtime on each node and registers
+	// it as the habana runtime handler of the container engine
+	Enabled bool `json:"enabled,omitempty"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=containerd;crio
+	// Engine is the container engine of the nodes, detected on each node when
+	// not set
+	Engine string `json:"engine,omitempty"`
+}
+
+// WorkloadInjectionSpec configures the mutating pod webhook injecting the
+// Habana runtime requirements in the pods requesting HPUs
+type WorkloadInjectionSpec struct {
+	//+kubebuilder:validation:Optional
+	// Enabled turns on the injection in the pods targeting the DeviceConfig nodes
+	Enabled bool `json:"enabled,omitempty"`
+	//+kubebuilder:validation:Optional
+	// NamespaceOptIn restricts the injection to the namespaces labeled
+	// habana.ai/workload-injection=enabled. Otherwise, only the namespaces
+	// labeled habana.ai/workload-injection=disabled are skipped
+	NamespaceOptIn bool `json:"namespaceOptIn,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Capabilities are added to the containers requesting HPUs, defaults to SYS_RAWIO
+	Capabilities []corev1.Capability `json:"capabilities,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Env is set in the containers requesting HPUs, unless already set,
+	// defaults to HABANA_LOGS=/var/log/habana_logs. HABANA_VISIBLE_MODULES is
+	// also derived in the containers requesting all the HPUs of the nodes
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	//+kubebuilder:validation:Optional
+	// HugePages2Mi is requested by the containers requesting HPUs and memory,
+	// unless they request huge pages already
+	HugePages2Mi *resource.Quantity `json:"hugePages2Mi,omitempty"`
+}
+
+// DegradedNodeRemediation is the remediation applied to the degraded nodes.
+type DegradedNodeRemediation string
+
+const (
+	// DegradedNodeRemediationNone only reports the degraded nodes.
+	DegradedNodeRemediationNone DegradedNodeRemediation = "None"
+	// DegradedNodeRemediationLabel labels the degraded nodes.
+	DegradedNodeRemediationLabel DegradedNodeRemediation = "Label"
+	// DegradedNodeRemediationTaint taints the degraded nodes with NoSchedule.
+	DegradedNodeRemediationTaint DegradedNodeRemediation = "Taint"
+)
+
+// TelemetrySpec configures the periodic scraping of the node metrics exporters
+// by the operator, summarized in the DeviceConfig status
+type TelemetrySpec struct {
+	//+kubebuilder:validation:Optional
+	// Enabled turns on the collection of the node metrics by the operator
+	Enabled bool `json:"enabled,omitempty"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Pattern=`^([0-9]+(ms|s|m|h))+$`
+	// Interval is the node metrics collection interval, defaults to 1m
+	Interval string `json:"interval,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Thresholds configures the values above which a node is flagged
+	Thresholds TelemetryThresholdsSpec `json:"thresholds,omitempty"`
+}
+
+// TelemetryThresholdsSpec configures the values above which a node is flagged
+type TelemetryThresholdsSpec struct {
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// Temperature is the HPU temperature, in degrees Celsius, defaults to 85
+	Temperature int32 `json:"temperature,omitempty"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=100
+	// MemoryUsagePercent is the HPU memory usage, in percent, defaults to 95
+	MemoryUsagePercent int32 `json:"memoryUsagePercent,omitempty"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// Errors is the number of errors reported by the HPUs of a node, defaults to 0
+	Errors int64 `json:"errors,omitempty"`
+}
+
+//+kubebuilder:validation:XValidation:rule="!(has(self.hostNetwork) && self.hostNetwork && has(self.secure) && self.secure)",message="hostNetwork cannot be set along with secure, as the plain HTTP port would be exposed on the nodes"
+
+// NodeMetricsSpec configures the node metrics exporter
+type NodeMetricsSpec struct {
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65534
+	// Port is the port the node metrics exporter listens on, defaults to 41611.
+	// When secured, kube-rbac-proxy listens on the next port
+	Port int32 `json:"port,omitempty"`
+	//+kubebuilder:validation:Optional
+	// HostPort exposes the node metrics port on the nodes, defaults to true.
+	// It has no effect when the node metrics are secured or use the host network
+	HostPort *bool `json:"hostPort,omitempty"`
+	//+kubebuilder:validation:Optional
+	// HostNetwork runs the node metrics exporter in the network namespace of the nodes.
+	// It cannot be set along with Secure
+	HostNetwork bool `json:"hostNetwork,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Secure serves the node metrics only over TLS, through a kube-rbac-proxy
+	// sidecar authorizing the requests, instead of a plain HTTP hostPort
+	Secure bool `json:"secure,omitempty"`
+}
+
+// MonitoringSpec configures the ServiceMonitor and the PrometheusRule created
+// when the Prometheus Operator is installed
+type MonitoringSpec struct {
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Pattern=`^([0-9]+(ms|s|m|h))+$`
+	// Interval is the node metrics scrape interval, defaults to 30s
+	Interval string `json:"interval,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Alerts configures the thresholds of the default alerts
+	Alerts AlertsSpec `json:"alerts,omitempty"`
+}
+
+// AlertsSpec configures the thresholds of the default alerts
+type AlertsSpec struct {
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// TemperatureThreshold is the HPU temperature, in degrees Celsius, above which
+	// an alert is raised, defaults to 85
+	TemperatureThreshold int32 `json:"temperatureThreshold,omitempty"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	// MinDevicesPerNode is the number of HPUs reported by a node below which an
+	// alert is raised, defaults to 8
+	MinDevicesPerNode int32 `json:"minDevicesPerNode,omitempty"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Pattern=`^([0-9]+(ms|s|m|h))+$`
+	// For is how long an alert condition must hold before the alert fires,
+	// defaults to 5m
+	For string `json:"for,omitempty"`
+}
+
+// NodeCleanupState is the state of the cleanup of a node.
+type NodeCleanupState string
+
+const (
+	NodeCleanupPending   NodeCleanupState = "Pending"
+	NodeCleanupSucceeded NodeCleanupState = "Succeeded"
+	NodeCleanupFailed    NodeCleanupState = "Failed"
+)
+
+// NodeCleanupStatus is the result of the cleanup of a node, i.e. the removal
+// of the stale NFD feature files and habana.ai labels.
+type NodeCleanupStatus struct {
+	// NodeName is the name of the cleaned up node
+	NodeName string `json:"nodeName"`
+	// State is the state of the cleanup
+	State NodeCleanupState `json:"state"`
+	// Message gives details about the state of the cleanup
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the last time the state changed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// NodeTelemetry summarizes the node metrics of the HPUs of a node.
+type NodeTelemetry struct {
+	// NodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	// Devices is the number of HPUs reported by the node
+	Devices int32 `json:"devices"`
+	// MaxTemperature is the highest HPU temperature, in degrees Celsius
+	MaxTemperature int32 `json:"maxTemperature"`
+	// Utilization is the average HPU utilization, in percent
+	Utilization int32 `json:"utilization"`
+	// MemoryUsedBytes is the HPU memory used on the node
+	MemoryUsedBytes int64 `json:"memoryUsedBytes"`
+	// MemoryTotalBytes is the HPU memory available on the node
+	MemoryTotalBytes int64 `json:"memoryTotalBytes"`
+	// Errors is the number of errors reported by the HPUs of the node
+	Errors int64 `json:"errors"`
+	// ExceededThresholds lists the thresholds exceeded by the node
+	ExceededThresholds []string `json:"exceededThresholds,omitempty"`
+	// Message reports why the node metrics could not be collected
+	Message string `json:"message,omitempty"`
+}
+
+// TelemetryStatus summarizes the node metrics collected by the operator.
+type TelemetryStatus struct {
+	// LastCollectionTime is the last time the node metrics were collected
+	LastCollectionTime metav1.Time `json:"lastCollectionTime,omitempty"`
+	// NodesExceedingThresholds is the number of nodes exceeding a threshold
+	// or whose node metrics could not be collected
+	NodesExceedingThresholds int32 `json:"nodesExceedingThresholds"`
+	// MaxTemperature is the highest HPU temperature, in degrees Celsius
+	MaxTemperature int32 `json:"maxTemperature"`
+	// Nodes is the node metrics summary of each node
+	Nodes []NodeTelemetry `json:"nodes,omitempty"`
+}
+
+// NodeCapacity is the HPU capacity and allocation of a node.
+type NodeCapacity struct {
+	// NodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	// Allocatable is the number of HPUs allocatable on the node
+	Allocatable int64 `json:"allocatable"`
+	// Allocated is the number of HPUs requested by the pods of the node
+	Allocated int64 `json:"allocated"`
+}
+
+// HPUAllocation is the number of HPUs held by a pod.
+type HPUAllocation struct {
+	// Namespace is the namespace of the pod
+	Namespace string `json:"namespace"`
+	// Pod is the name of the pod
+	Pod string `json:"pod"`
+	// NodeName is the name of the node the pod runs on
+	NodeName string `json:"nodeName"`
+	// Devices is the number of HPUs requested by the pod
+	Devices int64 `json:"devices"`
+}
+
+// CapacityStatus is the HPU capacity and allocation across the nodes of the
+// DeviceConfig.
+type CapacityStatus struct {
+	// Allocatable is the number of HPUs allocatable on the nodes
+	Allocatable int64 `json:"allocatable"`
+	// Allocated is the number of HPUs requested by the pods of the nodes
+	Allocated int64 `json:"allocated"`
+	// Nodes is the HPU capacity and allocation of each node
+	Nodes []NodeCapacity `json:"nodes,omitempty"`
+	// AllocatingPods is the number of pods holding HPUs on the nodes
+	AllocatingPods int64 `json:"allocatingPods,omitempty"`
+	// Allocations lists the pods holding the most HPUs, at most 100 of them.
+	// The HPUs requested in each namespace are exported as operator metrics.
+	Allocations []HPUAllocation `json:"allocations,omitempty"`
+}
+
+// DegradedNodeStatus is a node advertising another number of HPUs than the
+// expected one.
+type DegradedNodeStatus struct {
+	// NodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	// Devices is the number of HPUs advertised by the node
+	Devices int64 `json:"devices"`
+}
+
+// NetworkStatus reports the scale-out network interfaces of the nodes
+type NetworkStatus struct {
+	// Ports is the number of Habana network interfaces across the nodes
+	Ports int32 `json:"ports"`
+	// PortsUp is the number of Habana network interfaces whose link is up
+	// across the nodes
+	PortsUp int32 `json:"portsUp"`
+	// Nodes reports the network interfaces of each node
+	Nodes []NodeNetworkStatus `json:"nodes,omitempty"`
+}
+
+// NodeNetworkStatus reports the Habana network interfaces of a node
+type NodeNetworkStatus struct {
+	// NodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	// Addresses are the addresses assigned to the interfaces of the node, in
+	// order
+	Addresses []string `json:"addresses,omitempty"`
+	// Ports is the number of Habana network interfaces reported by the node
+	Ports int32 `json:"ports"`
+	// PortsUp is the number of Habana network interfaces of the node whose
+	// link is up
+	PortsUp int32 `json:"portsUp"`
+	// Message tells why the node has no addresses, if any
+	Message string `json:"message,omitempty"`
+}
+
+// HugePagesStatus reports the 2Mi huge pages advertised by the nodes
+type HugePagesStatus struct {
+	// Expected is the hugepages-2Mi amount each node should advertise
+	Expected resource.Quantity `json:"expected"`
+	// NodesMatching is the number of nodes advertising the expected amount
+	NodesMatching int32 `json:"nodesMatching"`
+	// MismatchedNodes lists the nodes advertising another amount
+	MismatchedNodes []NodeHugePagesStatus `json:"mismatchedNodes,omitempty"`
+}
+
+// NodeHugePagesStatus is the hugepages-2Mi amount advertised by a node
+type NodeHugePagesStatus struct {
+	// NodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	// Allocatable is the hugepages-2Mi amount allocatable on the node
+	Allocatable resource.Quantity `json:"allocatable"`
+}
+
+// TopologyStatus reports the NUMA topology of the nodes
+type TopologyStatus struct {
+	// RecommendedPolicy is the kubelet Topology Manager policy recommended
+	// for the nodes, given their topology
+	RecommendedPolicy TopologyManagerPolicy `json:"recommendedPolicy,omitempty"`
+	// Nodes is the NUMA topology of each node
+	Nodes []NodeTopology `json:"nodes,omitempty"`
+}
+
+// NodeTopology is the NUMA topology of the HPUs of a node
+type NodeTopology struct {
+	// NodeName is the name of the node
+	NodeName string `json:"nodeName"`
+	// NUMANodes are the NUMA nodes of the node with HPUs
+	NUMANodes []NUMANodeTopology `json:"numaNodes,omitempty"`
+	// NICsAligned is true when every NUMA node with HPUs has network
+	// interfaces
+	NICsAligned bool `json:"nicsAligned"`
+}
+
+// NUMANodeTopology is the number of HPUs and network interfaces of a NUMA node
+type NUMANodeTopology struct {
+	// ID is the NUMA node ID
+	ID int32 `json:"id"`
+	// HPUs is the number of HPUs attached to the NUMA node
+	HPUs int32 `json:"hpus"`
+	// NICs is the number of network interfaces, other than the HPUs ones,
+	// attached to the NUMA node
+	NICs int32 `json:"nics"`
+}
+
+// KubeletStatus reports the kubelet directories detected from the nodes
+type KubeletStatus struct {
+	// RootDir is the kubelet root directory of the nodes
+	RootDir string `json:"rootDir"`
+	// DetectedFrom is the annotation or label the root directory was read
+	// from, empty when the nodes use the default one
+	DetectedFrom string `json:"detectedFrom,omitempty"`
+}
+
+// DriverStatus reports the kernel module of the nodes, in the inTree and
+// hostInstalled driver modes
+type DriverStatus struct {
+	// Mode is the driver mode of the nodes
+	Mode DriverMode `json:"mode"`
+	// NodesMissingModule lists the nodes where the habanalabs module is not
+	// loaded
+	NodesMissingModule []string `json:"nodesMissingModule,omitempty"`
 }
 
 // DeviceConfigStatus defines the observed state of DeviceConfig
 type DeviceConfigStatus struct {
 	// Conditions is a list of conditions representing the DeviceConfig's current state.
 	Conditions []metav1.Condition `json:"conditions"`
+	// Nodes is the list of nodes selected by the DeviceConfig
+	Nodes []string `json:"nodes,omitempty"`
+	// NodeCleanup tracks the cleanup of the nodes that left the DeviceConfig
+	// node selector, or of all its nodes when the DeviceConfig is deleted.
+	// The cleanups that succeeded, and the failed ones of the removed nodes,
+	// are pruned unless the DeviceConfig is being deleted
+	NodeCleanup []NodeCleanupStatus `json:"nodeCleanup,omitempty"`
+	// Telemetry summarizes the node metrics collected by the operator, when enabled
+	Telemetry *TelemetryStatus `json:"telemetry,omitempty"`
+	// Capacity is the HPU capacity and allocation across the nodes
+	Capacity *CapacityStatus `json:"capacity,omitempty"`
+	// DegradedNodes lists the nodes advertising another number of HPUs than
+	// ExpectedDevicesPerNode
+	DegradedNodes []DegradedNodeStatus `json:"degradedNodes,omitempty"`
+	// Network reports the scale-out network interfaces of the nodes, when
+	// their configuration is enabled
+	Network *NetworkStatus `json:"network,omitempty"`
+	// HugePages reports the 2Mi huge pages advertised by the nodes, when
+	// their allocation is managed
+	HugePages *HugePagesStatus `json:"hugePages,omitempty"`
+	// Topology reports the NUMA topology of the nodes, when its export is
+	// enabled
+	Topology *TopologyStatus `json:"topology,omitempty"`
+	// Kubelet reports the kubelet directories mounted on the nodes, when
+	// they are detected from the nodes
+	Kubelet *KubeletStatus `json:"kubelet,omitempty"`
+	// Driver reports the nodes missing the kernel module, when it is not
+	// loaded from the driver image
+	Driver *DriverStatus `json:"driver,omitempty"`
 }
 
 //+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="HPUs",type=integer,JSONPath=`.status.capacity.allocatable`
+//+kubebuilder:printcolumn:name="Allocated",type=integer,JSONPath=`.status.capacity.allocated`
+//+kubebuilder:printcolumn:name="Flagged Nodes",type=integer,JSONPath=`.status.telemetry.nodesExceedingThresholds`
+//+kubebuilder:printcolumn:name="Max Temp",type=integer,JSONPath=`.status.telemetry.maxTemperature`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
-// DeviceConfig is the Schema for the deviceconfigs API
+// DeviceConfig is the Schema for the deviceconfigs API. It is cluster-scoped,
+// and all the components it manages are deployed in the operator namespace.
 type DeviceConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -81,3 +699,146 @@ func (dc *DeviceConfig) GetNodeSelector() map[string]string {
 	}
 	return ns
 }
+
+func (dc *DeviceConfig) GetMonitoringInterval() string {
+	if dc.Spec.Monitoring.Interval == "" {
+		return DefaultMonitoringInterval
+	}
+	return dc.Spec.Monitoring.Interval
+}
+
+func (dc *DeviceConfig) GetAlertTemperatureThreshold() int32 {
+	if dc.Spec.Monitoring.Alerts.TemperatureThreshold == 0 {
+		return DefaultAlertTemperatureThreshold
+	}
+	return dc.Spec.Monitoring.Alerts.TemperatureThreshold
+}
+
+func (dc *DeviceConfig) GetAlertMinDevicesPerNode() int32 {
+	if dc.Spec.Monitoring.Alerts.MinDevicesPerNode == 0 {
+		return DefaultAlertMinDevicesPerNode
+	}
+	return dc.Spec.Monitoring.Alerts.MinDevicesPerNode
+}
+
+func (dc *DeviceConfig) GetAlertFor() string {
+	if dc.Spec.Monitoring.Alerts.For == "" {
+		return DefaultAlertFor
+	}
+	return dc.Spec.Monitoring.Alerts.For
+}
+
+func (dc *DeviceConfig) GetNodeMetricsPort() int32 {
+	if dc.Spec.NodeMetrics.Port == 0 {
+		return DefaultNodeMetricsPort
+	}
+	return dc.Spec.NodeMetrics.Port
+}
+
+// GetNodeMetricsSecurePort returns the port kube-rbac-proxy listens on when
+// the node metrics are secured.
+func (dc *DeviceConfig) GetNodeMetricsSecurePort() int32 {
+	return dc.GetNodeMetricsPort() + 1
+}
+
+// UsesNodeMetricsHostPort returns true if the plain HTTP node metrics port is
+// exposed on the nodes with a hostPort.
+func (dc *DeviceConfig) UsesNodeMetricsHostPort() bool {
+	if dc.Spec.NodeMetrics.Secure || dc.Spec.NodeMetrics.HostNetwork {
+		return false
+	}
+	return dc.Spec.NodeMetrics.HostPort == nil || *dc.Spec.NodeMetrics.HostPort
+}
+
+func (dc *DeviceConfig) GetTelemetryInterval() string {
+	if dc.Spec.Telemetry.Interval == "" {
+		return DefaultTelemetryInterval
+	}
+	return dc.Spec.Telemetry.Interval
+}
+
+func (dc *DeviceConfig) GetTelemetryTemperatureThreshold() int32 {
+	if dc.Spec.Telemetry.Thresholds.Temperature == 0 {
+		return DefaultTelemetryTemperature
+	}
+	return dc.Spec.Telemetry.Thresholds.Temperature
+}
+
+func (dc *DeviceConfig) GetTelemetryMemoryUsagePercentThreshold() int32 {
+	if dc.Spec.Telemetry.Thresholds.MemoryUsagePercent == 0 {
+		return DefaultTelemetryMemoryUsagePercent
+	}
+	return dc.Spec.Telemetry.Thresholds.MemoryUsagePercent
+}
+
+func (dc *DeviceConfig) GetDegradedNodeRemediation() DegradedNodeRemediation {
+	if dc.Spec.DegradedNodeRemediation == "" {
+		return DegradedNodeRemediationNone
+	}
+	return dc.Spec.DegradedNodeRemediation
+}
+
+func (dc *DeviceConfig) GetWorkloadInjectionCapabilities() []corev1.Capability {
+	if len(dc.Spec.WorkloadInjection.Capabilities) == 0 {
+		return []corev1.Capability{DefaultWorkloadCapability}
+	}
+	return dc.Spec.WorkloadInjection.Capabilities
+}
+
+func (dc *DeviceConfig) GetWorkloadInjectionEnv() []corev1.EnvVar {
+	if len(dc.Spec.WorkloadInjection.Env) == 0 {
+		return []corev1.EnvVar{{Name: HabanaLogsEnvVar, Value: DefaultHabanaLogs}}
+	}
+	return dc.Spec.WorkloadInjection.Env
+}
+
+func (dc *DeviceConfig) GetNetworkMTU() int32 {
+	if dc.Spec.Network.MTU == 0 {
+		return DefaultNetworkMTU
+	}
+	return dc.Spec.Network.MTU
+}
+
+func (dc *DeviceConfig) GetNetworkAddressesPerNode() int32 {
+	if dc.Spec.Network.IPAM == nil || dc.Spec.Network.IPAM.AddressesPerNode == 0 {
+		return DefaultNetworkAddressesPerNode
+	}
+	return dc.Spec.Network.IPAM.AddressesPerNode
+}
+
+func (dc *DeviceConfig) GetDriverMode() DriverMode {
+	if dc.Spec.Driver.Mode == "" {
+		return DriverModeContainer
+	}
+	return dc.Spec.Driver.Mode
+}
+
+func (dc *DeviceConfig) GetSimulationDevicesPerNode() int32 {
+	if dc.Spec.Simulation.DevicesPerNode == 0 {
+		return DefaultSimulationDevicesPerNode
+	}
+	return dc.Spec.Simulation.DevicesPerNode
+}
+
+// GetKubeletRootDir returns the kubelet root directory of the nodes, either
+// set in the spec or detected from the nodes.
+func (dc *DeviceConfig) GetKubeletRootDir() string {
+	if dc.Spec.Kubelet.RootDir != "" {
+		return dc.Spec.Kubelet.RootDir
+	}
+	if dc.Status.Kubelet != nil && dc.Status.Kubelet.RootDir != "" {
+		return dc.Status.Kubelet.RootDir
+	}
+	return DefaultKubeletRootDir
+}
+
+func (dc *DeviceConfig) GetKubeletDevicePluginsDir() string {
+	if dc.Spec.Kubelet.DevicePluginsDir != "" {
+		return dc.Spec.Kubelet.DevicePluginsDir
+	}
+	return path.Join(dc.GetKubeletRootDir(), "device-plugins")
+}
+
+func (dc *DeviceConfig) GetKubeletPodResourcesDir() string {
+	return path.Join(dc.GetKubeletRootDir(), "pod-resources")
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HPUAccessPolicySpec defines the desired state of HPUAccessPolicy
+type HPUAccessPolicySpec struct {
+	//+kubebuilder:validation:Optional
+	// DeviceConfigs are the names of the DeviceConfigs whose nodes are restricted.
+	// When they are all missing and NodeSelector is not set, all the nodes are
+	// restricted
+	DeviceConfigs []string `json:"deviceConfigs,omitempty"`
+	//+kubebuilder:validation:Optional
+	// NodeSelector selects a pool of restricted nodes. When neither DeviceConfigs
+	// nor NodeSelector are set, all the nodes are restricted
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	//+kubebuilder:validation:Optional
+	// Namespaces are allowed to request HPUs on the restricted nodes
+	Namespaces []NamespaceAccess `json:"namespaces,omitempty"`
+}
+
+// NamespaceAccess allows a namespace to request HPUs on the restricted nodes
+type NamespaceAccess struct {
+	//+kubebuilder:validation:Required
+	// Name is the name of the namespace
+	Name string `json:"name"`
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	// MaxHPUs is the maximum number of HPUs requested by the pods of the
+	// namespace on the restricted nodes, unlimited when unset
+	MaxHPUs *int64 `json:"maxHPUs,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// HPUAccessPolicy is the Schema for the hpuaccesspolicies API. It restricts
+// the namespaces allowed to request HPUs on a group of nodes, and is enforced
+// by the validating pod webhook.
+type HPUAccessPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HPUAccessPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// HPUAccessPolicyList contains a list of HPUAccessPolicy
+type HPUAccessPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HPUAccessPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HPUAccessPolicy{}, &HPUAccessPolicyList{})
+}
+
+// GetNamespaceAccess returns the access of the namespace, or nil when the
+// namespace is not allowed.
+func (p *HPUAccessPolicy) GetNamespaceAccess(namespace string) *NamespaceAccess {
+	for i := range p.Spec.Namespaces {
+		if p.Spec.Namespaces[i].Name == namespace {
+			return &p.Spec.Namespaces[i]
+		}
+	}
+	return nil
+}
@@ -22,10 +22,96 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertsSpec) DeepCopyInto(out *AlertsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertsSpec.
+func (in *AlertsSpec) DeepCopy() *AlertsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CDISpec) DeepCopyInto(out *CDISpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CDISpec.
+func (in *CDISpec) DeepCopy() *CDISpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CDISpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CapacityStatus) DeepCopyInto(out *CapacityStatus) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]NodeCapacity, len(*in))
+		copy(*out, *in)
+	}
+	if in.Allocations != nil {
+		in, out := &in.Allocations, &out.Allocations
+		*out = make([]HPUAllocation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CapacityStatus.
+func (in *CapacityStatus) DeepCopy() *CapacityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CapacityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerRuntimeSpec) DeepCopyInto(out *ContainerRuntimeSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerRuntimeSpec.
+func (in *ContainerRuntimeSpec) DeepCopy() *ContainerRuntimeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerRuntimeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DegradedNodeStatus) DeepCopyInto(out *DegradedNodeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DegradedNodeStatus.
+func (in *DegradedNodeStatus) DeepCopy() *DegradedNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DegradedNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeviceConfig) DeepCopyInto(out *DeviceConfig) {
 	*out = *in
@@ -88,6 +174,7 @@ func (in *DeviceConfigList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeviceConfigSpec) DeepCopyInto(out *DeviceConfigSpec) {
 	*out = *in
+	out.Driver = in.Driver
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = make(map[string]string, len(*in))
@@ -95,6 +182,17 @@ func (in *DeviceConfigSpec) DeepCopyInto(out *DeviceConfigSpec) {
 			(*out)[key] = val
 		}
 	}
+	out.Monitoring = in.Monitoring
+	in.NodeMetrics.DeepCopyInto(&out.NodeMetrics)
+	out.Telemetry = in.Telemetry
+	in.WorkloadInjection.DeepCopyInto(&out.WorkloadInjection)
+	out.CDI = in.CDI
+	out.ContainerRuntime = in.ContainerRuntime
+	in.Network.DeepCopyInto(&out.Network)
+	in.HugePages.DeepCopyInto(&out.HugePages)
+	out.Topology = in.Topology
+	out.Kubelet = in.Kubelet
+	out.Simulation = in.Simulation
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceConfigSpec.
@@ -112,11 +210,63 @@ func (in *DeviceConfigStatus) DeepCopyInto(out *DeviceConfigStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeCleanup != nil {
+		in, out := &in.NodeCleanup, &out.NodeCleanup
+		*out = make([]NodeCleanupStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Telemetry != nil {
+		in, out := &in.Telemetry, &out.Telemetry
+		*out = new(TelemetryStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		*out = new(CapacityStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DegradedNodes != nil {
+		in, out := &in.DegradedNodes, &out.DegradedNodes
+		*out = make([]DegradedNodeStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(NetworkStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HugePages != nil {
+		in, out := &in.HugePages, &out.HugePages
+		*out = new(HugePagesStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Topology != nil {
+		in, out := &in.Topology, &out.Topology
+		*out = new(TopologyStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Kubelet != nil {
+		in, out := &in.Kubelet, &out.Kubelet
+		*out = new(KubeletStatus)
+		**out = **in
+	}
+	if in.Driver != nil {
+		in, out := &in.Driver, &out.Driver
+		*out = new(DriverStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceConfigStatus.
@@ -128,3 +278,618 @@ func (in *DeviceConfigStatus) DeepCopy() *DeviceConfigStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriverSpec) DeepCopyInto(out *DriverSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriverSpec.
+func (in *DriverSpec) DeepCopy() *DriverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DriverSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriverStatus) DeepCopyInto(out *DriverStatus) {
+	*out = *in
+	if in.NodesMissingModule != nil {
+		in, out := &in.NodesMissingModule, &out.NodesMissingModule
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriverStatus.
+func (in *DriverStatus) DeepCopy() *DriverStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DriverStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HPUAccessPolicy) DeepCopyInto(out *HPUAccessPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HPUAccessPolicy.
+func (in *HPUAccessPolicy) DeepCopy() *HPUAccessPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(HPUAccessPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HPUAccessPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HPUAccessPolicyList) DeepCopyInto(out *HPUAccessPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HPUAccessPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HPUAccessPolicyList.
+func (in *HPUAccessPolicyList) DeepCopy() *HPUAccessPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(HPUAccessPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HPUAccessPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HPUAccessPolicySpec) DeepCopyInto(out *HPUAccessPolicySpec) {
+	*out = *in
+	if in.DeviceConfigs != nil {
+		in, out := &in.DeviceConfigs, &out.DeviceConfigs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]NamespaceAccess, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HPUAccessPolicySpec.
+func (in *HPUAccessPolicySpec) DeepCopy() *HPUAccessPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HPUAccessPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HPUAllocation) DeepCopyInto(out *HPUAllocation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HPUAllocation.
+func (in *HPUAllocation) DeepCopy() *HPUAllocation {
+	if in == nil {
+		return nil
+	}
+	out := new(HPUAllocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HugePagesSpec) DeepCopyInto(out *HugePagesSpec) {
+	*out = *in
+	if in.Count != nil {
+		in, out := &in.Count, &out.Count
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HugePagesSpec.
+func (in *HugePagesSpec) DeepCopy() *HugePagesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HugePagesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HugePagesStatus) DeepCopyInto(out *HugePagesStatus) {
+	*out = *in
+	out.Expected = in.Expected.DeepCopy()
+	if in.MismatchedNodes != nil {
+		in, out := &in.MismatchedNodes, &out.MismatchedNodes
+		*out = make([]NodeHugePagesStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HugePagesStatus.
+func (in *HugePagesStatus) DeepCopy() *HugePagesStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HugePagesStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletSpec) DeepCopyInto(out *KubeletSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletSpec.
+func (in *KubeletSpec) DeepCopy() *KubeletSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletStatus) DeepCopyInto(out *KubeletStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletStatus.
+func (in *KubeletStatus) DeepCopy() *KubeletStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
+	*out = *in
+	out.Alerts = in.Alerts
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
+func (in *MonitoringSpec) DeepCopy() *MonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NUMANodeTopology) DeepCopyInto(out *NUMANodeTopology) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NUMANodeTopology.
+func (in *NUMANodeTopology) DeepCopy() *NUMANodeTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(NUMANodeTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceAccess) DeepCopyInto(out *NamespaceAccess) {
+	*out = *in
+	if in.MaxHPUs != nil {
+		in, out := &in.MaxHPUs, &out.MaxHPUs
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceAccess.
+func (in *NamespaceAccess) DeepCopy() *NamespaceAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkIPAMSpec) DeepCopyInto(out *NetworkIPAMSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkIPAMSpec.
+func (in *NetworkIPAMSpec) DeepCopy() *NetworkIPAMSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkIPAMSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
+	*out = *in
+	if in.IPAM != nil {
+		in, out := &in.IPAM, &out.IPAM
+		*out = new(NetworkIPAMSpec)
+		**out = **in
+	}
+	if in.StaticAddresses != nil {
+		in, out := &in.StaticAddresses, &out.StaticAddresses
+		*out = make([]NodeNetworkAddresses, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSpec.
+func (in *NetworkSpec) DeepCopy() *NetworkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkStatus) DeepCopyInto(out *NetworkStatus) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]NodeNetworkStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkStatus.
+func (in *NetworkStatus) DeepCopy() *NetworkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeCapacity) DeepCopyInto(out *NodeCapacity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeCapacity.
+func (in *NodeCapacity) DeepCopy() *NodeCapacity {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeCapacity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeCleanupStatus) DeepCopyInto(out *NodeCleanupStatus) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeCleanupStatus.
+func (in *NodeCleanupStatus) DeepCopy() *NodeCleanupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeCleanupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeHugePagesStatus) DeepCopyInto(out *NodeHugePagesStatus) {
+	*out = *in
+	out.Allocatable = in.Allocatable.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeHugePagesStatus.
+func (in *NodeHugePagesStatus) DeepCopy() *NodeHugePagesStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeHugePagesStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeMetricsSpec) DeepCopyInto(out *NodeMetricsSpec) {
+	*out = *in
+	if in.HostPort != nil {
+		in, out := &in.HostPort, &out.HostPort
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeMetricsSpec.
+func (in *NodeMetricsSpec) DeepCopy() *NodeMetricsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeMetricsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkAddresses) DeepCopyInto(out *NodeNetworkAddresses) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkAddresses.
+func (in *NodeNetworkAddresses) DeepCopy() *NodeNetworkAddresses {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkAddresses)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkStatus) DeepCopyInto(out *NodeNetworkStatus) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkStatus.
+func (in *NodeNetworkStatus) DeepCopy() *NodeNetworkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTelemetry) DeepCopyInto(out *NodeTelemetry) {
+	*out = *in
+	if in.ExceededThresholds != nil {
+		in, out := &in.ExceededThresholds, &out.ExceededThresholds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTelemetry.
+func (in *NodeTelemetry) DeepCopy() *NodeTelemetry {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTelemetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTopology) DeepCopyInto(out *NodeTopology) {
+	*out = *in
+	if in.NUMANodes != nil {
+		in, out := &in.NUMANodes, &out.NUMANodes
+		*out = make([]NUMANodeTopology, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTopology.
+func (in *NodeTopology) DeepCopy() *NodeTopology {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTopology)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SimulationSpec) DeepCopyInto(out *SimulationSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SimulationSpec.
+func (in *SimulationSpec) DeepCopy() *SimulationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SimulationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TelemetrySpec) DeepCopyInto(out *TelemetrySpec) {
+	*out = *in
+	out.Thresholds = in.Thresholds
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TelemetrySpec.
+func (in *TelemetrySpec) DeepCopy() *TelemetrySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TelemetrySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TelemetryStatus) DeepCopyInto(out *TelemetryStatus) {
+	*out = *in
+	in.LastCollectionTime.DeepCopyInto(&out.LastCollectionTime)
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]NodeTelemetry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TelemetryStatus.
+func (in *TelemetryStatus) DeepCopy() *TelemetryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TelemetryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TelemetryThresholdsSpec) DeepCopyInto(out *TelemetryThresholdsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TelemetryThresholdsSpec.
+func (in *TelemetryThresholdsSpec) DeepCopy() *TelemetryThresholdsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TelemetryThresholdsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologySpec) DeepCopyInto(out *TopologySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologySpec.
+func (in *TopologySpec) DeepCopy() *TopologySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyStatus) DeepCopyInto(out *TopologyStatus) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]NodeTopology, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyStatus.
+func (in *TopologyStatus) DeepCopy() *TopologyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadInjectionSpec) DeepCopyInto(out *WorkloadInjectionSpec) {
+	*out = *in
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = make([]v1.Capability, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HugePages2Mi != nil {
+		in, out := &in.HugePages2Mi, &out.HugePages2Mi
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadInjectionSpec.
+func (in *WorkloadInjectionSpec) DeepCopy() *WorkloadInjectionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadInjectionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
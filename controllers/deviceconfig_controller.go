@@ -19,26 +19,80 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 
 	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/capacity"
+	"github.com/HabanaAI/habana-ai-operator/internal/certificates"
 	"github.com/HabanaAI/habana-ai-operator/internal/conditions"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/dependencies"
 	"github.com/HabanaAI/habana-ai-operator/internal/finalizers"
 	"github.com/HabanaAI/habana-ai-operator/internal/metrics"
 	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/monitoring"
+	nodeCDI "github.com/HabanaAI/habana-ai-operator/internal/node/cdi"
+	nodeCleanup "github.com/HabanaAI/habana-ai-operator/internal/node/cleanup"
+	nodeContainerRuntime "github.com/HabanaAI/habana-ai-operator/internal/node/containerruntime"
+	nodeDriver "github.com/HabanaAI/habana-ai-operator/internal/node/driver"
+	nodeHealth "github.com/HabanaAI/habana-ai-operator/internal/node/health"
+	nodeHugePages "github.com/HabanaAI/habana-ai-operator/internal/node/hugepages"
+	nodeKubelet "github.com/HabanaAI/habana-ai-operator/internal/node/kubelet"
 	nodeLabeler "github.com/HabanaAI/habana-ai-operator/internal/node/labeler"
 	nodeMetrics "github.com/HabanaAI/habana-ai-operator/internal/node/metrics"
+	nodeNetwork "github.com/HabanaAI/habana-ai-operator/internal/node/network"
+	nodeReadiness "github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	nodeSimulation "github.com/HabanaAI/habana-ai-operator/internal/node/simulation"
+	nodeTopology "github.com/HabanaAI/habana-ai-operator/internal/node/topology"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
 	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+	"github.com/HabanaAI/habana-ai-operator/internal/telemetry"
+)
+
+const (
+	// capacityRequeueDelay is the delay after which a DeviceConfig is
+	// reconciled again to refresh its HPU allocation, as the pods are not
+	// watched.
+	capacityRequeueDelay = 5 * time.Minute
+
+	// nodeReadinessRequeueDelay is the delay after which a DeviceConfig is
+	// reconciled again while some of its nodes are not ready, as the pods of
+	// the Module are not watched.
+	nodeReadinessRequeueDelay = 15 * time.Second
+
+	// nodeCleanupRequeueDelay is the delay after which a deleted DeviceConfig
+	// is reconciled again while its nodes are being cleaned up.
+	nodeCleanupRequeueDelay = 10 * time.Second
+
+	// hostPortConflictRequeueDelay is the delay after which a DeviceConfig is
+	// reconciled again when the node metrics ports are held on its nodes.
+	hostPortConflictRequeueDelay = time.Minute
 )
 
 // Reconciler reconciles a DeviceConfig object
@@ -50,12 +104,55 @@ type Reconciler struct {
 
 	mr  module.Reconciler
 	nmr nodeMetrics.Reconciler
+	cer certificates.Reconciler
+	mor monitoring.Reconciler
 	nlr nodeLabeler.Reconciler
+	ncr nodeCleanup.Reconciler
+	nhr nodeHealth.Reconciler
+	nrr nodeReadiness.Reconciler
+	cdr nodeCDI.Reconciler
+	crr nodeContainerRuntime.Reconciler
+	nwr nodeNetwork.Reconciler
+	hpr nodeHugePages.Reconciler
+	tpr nodeTopology.Reconciler
+	kbr nodeKubelet.Reconciler
+	smr nodeSimulation.Reconciler
+	dvr nodeDriver.Reconciler
+	tc  telemetry.Collector
+	ca  capacity.Accountant
+	rr  rbac.Reconciler
 
 	fu finalizers.Updater
 	cu conditions.Updater
 
 	nsv NodeSelectorValidator
+	hpv HostPortValidator
+	dep dependencies.Checker
+
+	// controller is used to start watching the resources of the dependencies
+	// once their API is served, as watching a missing kind prevents the
+	// manager from starting.
+	controller controller.Controller
+	watchesMu  sync.Mutex
+	watching   map[schema.GroupVersionKind]bool
+}
+
+// dependentWatch is a watch that can only be started once its dependency is available.
+type dependentWatch struct {
+	dependency dependencies.Dependency
+	object     client.Object
+}
+
+func newUnstructured(gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	return u
+}
+
+var dependentWatches = []dependentWatch{
+	{dependency: dependencies.KMM, object: &kmmv1beta1.Module{}},
+	{dependency: dependencies.Monitoring, object: newUnstructured(monitoring.ServiceMonitorGVK)},
+	{dependency: dependencies.Monitoring, object: newUnstructured(monitoring.PrometheusRuleGVK)},
 }
 
 func NewReconciler(
@@ -64,10 +161,28 @@ func NewReconciler(
 	recorder record.EventRecorder,
 	mr module.Reconciler,
 	nmr nodeMetrics.Reconciler,
+	cer certificates.Reconciler,
+	mor monitoring.Reconciler,
 	nlr nodeLabeler.Reconciler,
+	ncr nodeCleanup.Reconciler,
+	nhr nodeHealth.Reconciler,
+	nrr nodeReadiness.Reconciler,
+	cdr nodeCDI.Reconciler,
+	crr nodeContainerRuntime.Reconciler,
+	nwr nodeNetwork.Reconciler,
+	hpr nodeHugePages.Reconciler,
+	tpr nodeTopology.Reconciler,
+	kbr nodeKubelet.Reconciler,
+	smr nodeSimulation.Reconciler,
+	dvr nodeDriver.Reconciler,
+	tc telemetry.Collector,
+	ca capacity.Accountant,
+	rr rbac.Reconciler,
 	fu finalizers.Updater,
 	cu conditions.Updater,
 	nsv NodeSelectorValidator,
+	hpv HostPortValidator,
+	dep dependencies.Checker,
 ) *Reconciler {
 	return &Reconciler{
 		Client:   client,
@@ -75,10 +190,28 @@ func NewReconciler(
 		Recorder: recorder,
 		mr:       mr,
 		nmr:      nmr,
+		cer:      cer,
+		mor:      mor,
 		nlr:      nlr,
+		ncr:      ncr,
+		nhr:      nhr,
+		nrr:      nrr,
+		cdr:      cdr,
+		crr:      crr,
+		nwr:      nwr,
+		hpr:      hpr,
+		tpr:      tpr,
+		kbr:      kbr,
+		smr:      smr,
+		dvr:      dvr,
+		tc:       tc,
+		ca:       ca,
+		rr:       rr,
 		fu:       fu,
 		cu:       cu,
 		nsv:      nsv,
+		hpv:      hpv,
+		dep:      dep,
 	}
 }
 
@@ -87,8 +220,22 @@ func NewReconciler(
 //+kubebuilder:rbac:groups=habana.ai,resources=deviceconfigs/finalizers,verbs=update
 //+kubebuilder:rbac:groups="kmm.sigs.k8s.io",resources=modules,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="apps",resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups="",resources=nodes/status,verbs=patch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups="batch",resources=jobs,verbs=get;list;watch;create;update;patch;delete;deletecollection
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterroles;clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="authentication.k8s.io",resources=tokenreviews,verbs=create
+//+kubebuilder:rbac:groups="authorization.k8s.io",resources=subjectaccessreviews,verbs=create
+//+kubebuilder:rbac:urls=/metrics,verbs=get
+//+kubebuilder:rbac:groups="",namespace=habana-ai-operator,resources=secrets;configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="nfd.k8s-sigs.io",resources=nodefeaturerules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="monitoring.coreos.com",resources=servicemonitors;prometheusrules,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="machineconfiguration.openshift.io",resources=machineconfigs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="security.openshift.io",resources=securitycontextconstraints,resourceNames=privileged,verbs=use
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -105,8 +252,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	deviceConfig := &hlaiv1alpha1.DeviceConfig{}
 	err := r.Get(ctx, req.NamespacedName, deviceConfig)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			metrics.ReconciliationFailed.WithLabelValues(req.NamespacedName.Name).Set(0)
+		if apierrors.IsNotFound(err) {
+			metrics.DeleteDeviceConfigMetrics(req.NamespacedName.Name)
 			logger.Info("DeviceConfig resource not found. Ignoring since object must be deleted.")
 			return ctrl.Result{}, nil
 		}
@@ -118,6 +265,37 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(0)
 
 		if r.fu.ContainsDeletionFinalizer(deviceConfig) {
+			// The nodes are cleaned up and the container runtime reverted
+			// before releasing the finalizer, while the ServiceAccount of
+			// their Jobs still exists.
+			done, err := r.ncr.CleanupNodes(ctx, deviceConfig)
+			if err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to clean up the DeviceConfig nodes: %w", err)
+			}
+			if !done {
+				logger.Info("Waiting for the DeviceConfig nodes to be cleaned up", "resource", deviceConfig.Name)
+				return ctrl.Result{RequeueAfter: nodeCleanupRequeueDelay},
+					r.cu.SetConditionsDeleting(ctx, deviceConfig, "Waiting for the nodes to be cleaned up")
+			}
+			done, err = r.crr.RevertContainerRuntime(ctx, deviceConfig)
+			if err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to revert the container runtime: %w", err)
+			}
+			if !done {
+				logger.Info("Waiting for the container runtime to be reverted", "resource", deviceConfig.Name)
+				return ctrl.Result{RequeueAfter: nodeCleanupRequeueDelay},
+					r.cu.SetConditionsDeleting(ctx, deviceConfig, "Waiting for the container runtime to be reverted")
+			}
+			for _, ncs := range deviceConfig.Status.NodeCleanup {
+				if ncs.State == hlaiv1alpha1.NodeCleanupFailed {
+					r.Recorder.Event(
+						deviceConfig,
+						v1.EventTypeWarning,
+						conditions.ReasonNodeCleanupFailed,
+						fmt.Sprintf("Failed to clean up node %s: %s", ncs.NodeName, ncs.Message),
+					)
+				}
+			}
 			if err := r.deleteDeviceConfigResources(ctx, deviceConfig); err != nil {
 				return ctrl.Result{}, fmt.Errorf("failed to delete DeviceConfig resources: %w", err)
 			}
@@ -137,8 +315,10 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			"Conflicting DeviceConfig NodeSelectors found. Please add or update this DeviceConfig's NodeSelector accordingly.",
 		)
 		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		metrics.Conflicts.WithLabelValues(deviceConfig.Name, metrics.ConflictNodeSelector).Set(1)
 		return ctrl.Result{}, nil
 	}
+	metrics.Conflicts.WithLabelValues(deviceConfig.Name, metrics.ConflictNodeSelector).Set(0)
 
 	if !r.fu.ContainsDeletionFinalizer(deviceConfig) {
 		if err := r.fu.AddDeletionFinalizer(ctx, deviceConfig); err != nil {
@@ -146,7 +326,51 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		}
 	}
 
-	if err := r.mr.ReconcileModule(ctx, deviceConfig); err != nil {
+	if missing := r.dep.Missing(); len(missing) > 0 {
+		msg := fmt.Sprintf("Missing dependencies: %s", dependencies.FormatDependencies(missing))
+		reason := conditions.DependencyMissingReason(dependencies.Names(missing)...)
+		logger.Info(msg, "resource", deviceConfig.Name)
+		r.Recorder.Event(deviceConfig, v1.EventTypeWarning, reason, msg)
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		// The DeviceConfigs are reconciled again as soon as the dependency
+		// checker detects the missing APIs.
+		return ctrl.Result{}, r.cu.SetConditionsErrored(ctx, deviceConfig, reason, msg)
+	}
+
+	if err := r.ensureDependentWatches(); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.rr.ReconcileRBAC(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonRBACFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	// The kubelet directories mounted by the Module and the DaemonSets are
+	// resolved first.
+	if err := r.kbr.ReconcileKubelet(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonKubeletFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.smr.ReconcileSimulation(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonSimulationFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	start := time.Now()
+	err = r.mr.ReconcileModule(ctx, deviceConfig)
+	metrics.ObservePhaseDuration(deviceConfig.Name, metrics.PhaseModule, start)
+	if err != nil {
 		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonModuleFailed, err.Error()); cerr != nil {
 			err = fmt.Errorf("%s: %w", err.Error(), cerr)
 		}
@@ -154,7 +378,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
-	if err = r.nlr.ReconcileNodeLabeler(ctx, deviceConfig); err != nil {
+	if err := r.dvr.ReconcileDriver(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonDriverFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	start = time.Now()
+	err = r.nlr.ReconcileNodeLabeler(ctx, deviceConfig)
+	metrics.ObservePhaseDuration(deviceConfig.Name, metrics.PhaseLabeler, start)
+	if err != nil {
 		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonNodeLabelerFailed, err.Error()); cerr != nil {
 			err = fmt.Errorf("%s: %w", err.Error(), cerr)
 		}
@@ -162,24 +397,229 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
-	if err = r.nmr.ReconcileNodeMetrics(ctx, deviceConfig); err != nil {
+	if err = r.reconcileNodeMetricsCertificates(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonCertificatesFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	conflicts, err := r.hpv.CheckDeviceConfigForConflictingHostPorts(ctx, deviceConfig)
+	if err != nil {
 		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonNodeMetricsFailed, err.Error()); cerr != nil {
 			err = fmt.Errorf("%s: %w", err.Error(), cerr)
 		}
 		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
 		return ctrl.Result{}, err
 	}
+	metrics.Conflicts.WithLabelValues(deviceConfig.Name, metrics.ConflictHostPort).Set(float64(len(conflicts)))
+	// Only the node metrics rollout is held back by the conflicts, the other
+	// components are still reconciled.
+	hostPortConflictMsg := ""
+	if len(conflicts) > 0 {
+		hostPortConflictMsg = fmt.Sprintf("Node metrics ports already in use: %s", FormatHostPortConflicts(conflicts))
+		logger.Info(hostPortConflictMsg, "resource", deviceConfig.Name)
+		r.Recorder.Event(deviceConfig, v1.EventTypeWarning, conditions.ReasonHostPortConflict, hostPortConflictMsg)
+	} else {
+		start = time.Now()
+		err = r.nmr.ReconcileNodeMetrics(ctx, deviceConfig)
+		metrics.ObservePhaseDuration(deviceConfig.Name, metrics.PhaseMetrics, start)
+		if err != nil {
+			if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonNodeMetricsFailed, err.Error()); cerr != nil {
+				err = fmt.Errorf("%s: %w", err.Error(), cerr)
+			}
+			metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err = r.cdr.ReconcileCDI(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonCDIFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	if err = r.crr.ReconcileContainerRuntime(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonContainerRuntimeFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	if err = r.nwr.ReconcileNetwork(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonNetworkFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	if err = r.reconcileHugePages(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonHugePagesFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	if err = r.tpr.ReconcileTopology(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonTopologyFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	if r.dep.IsAvailable(dependencies.Monitoring) {
+		if err = r.mor.ReconcileMonitoring(ctx, deviceConfig); err != nil {
+			if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonMonitoringFailed, err.Error()); cerr != nil {
+				err = fmt.Errorf("%s: %w", err.Error(), cerr)
+			}
+			metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err = r.reconcileTelemetry(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonTelemetryFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	if err = r.ca.AccountCapacity(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonCapacityFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	if err = r.reconcileNodeHealth(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonNodeHealthFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	nodesReady, err := r.reconcileNodeReadiness(ctx, deviceConfig)
+	if err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonNodeReadinessFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	if err = r.nlr.ReconcileNodeLabels(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonNodeLabelerFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	if err = r.ncr.ReconcileNodeCleanup(ctx, deviceConfig); err != nil {
+		if cerr := r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonNodeCleanupFailed, err.Error()); cerr != nil {
+			err = fmt.Errorf("%s: %w", err.Error(), cerr)
+		}
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		return ctrl.Result{}, err
+	}
+
+	if hostPortConflictMsg != "" {
+		metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(1)
+		// Pods releasing the ports are not watched, hence the DeviceConfig
+		// is reconciled again periodically until the ports are free.
+		return ctrl.Result{RequeueAfter: hostPortConflictRequeueDelay},
+			r.cu.SetConditionsErrored(ctx, deviceConfig, conditions.ReasonHostPortConflict, hostPortConflictMsg)
+	}
 
 	metrics.ReconciliationFailed.WithLabelValues(deviceConfig.Name).Set(0)
+	metrics.LastSuccessfulReconciliation.WithLabelValues(deviceConfig.Name).SetToCurrentTime()
+	metrics.SetDriverInfo(deviceConfig.Name, deviceConfig.Spec.DriverImage, deviceConfig.Spec.DriverVersion)
 
 	r.Recorder.Event(
 		deviceConfig,
 		v1.EventTypeNormal,
 		"Reconciled",
-		fmt.Sprintf("Succesfully reconciled DeviceConfig %s/%s", deviceConfig.Namespace, deviceConfig.Name),
+		fmt.Sprintf("Succesfully reconciled DeviceConfig %s", deviceConfig.Name),
 	)
 
-	return ctrl.Result{}, r.cu.SetConditionsReady(ctx, deviceConfig, "Reconciled", "All resources have been successfully reconciled")
+	res := ctrl.Result{RequeueAfter: capacityRequeueDelay}
+	if interval := telemetryInterval(deviceConfig); interval > 0 && interval < res.RequeueAfter {
+		res.RequeueAfter = interval
+	}
+	if !nodesReady && nodeReadinessRequeueDelay < res.RequeueAfter {
+		res.RequeueAfter = nodeReadinessRequeueDelay
+	}
+
+	return res, r.cu.SetConditionsReady(ctx, deviceConfig, "Reconciled", "All resources have been successfully reconciled")
+}
+
+// reconcileTelemetry collects the node metrics into the DeviceConfig status,
+// which is persisted along with its conditions, and records an event when the
+// flagged nodes change.
+func (r *Reconciler) reconcileTelemetry(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error {
+	if !dc.Spec.Telemetry.Enabled {
+		dc.Status.Telemetry = nil
+		return nil
+	}
+
+	previous := telemetry.GetFlaggedNodes(dc)
+
+	if err := r.tc.CollectTelemetry(ctx, dc); err != nil {
+		return err
+	}
+
+	if flagged := telemetry.GetFlaggedNodes(dc); len(flagged) > 0 && nodesChanged(previous, flagged) {
+		r.Recorder.Event(
+			dc,
+			v1.EventTypeWarning,
+			conditions.ReasonNodesExceedingThresholds,
+			fmt.Sprintf("Nodes exceeding the telemetry thresholds: %s", strings.Join(flagged, ", ")),
+		)
+	}
+
+	return nil
+}
+
+// telemetryInterval returns the delay after which the node metrics of the
+// DeviceConfig are collected again, or 0 when the collection is disabled.
+func telemetryInterval(dc *hlaiv1alpha1.DeviceConfig) time.Duration {
+	if !dc.Spec.Telemetry.Enabled {
+		return 0
+	}
+	interval, err := time.ParseDuration(dc.GetTelemetryInterval())
+	if err != nil {
+		return 0
+	}
+	return interval
+}
+
+// usesOperatorCertificates returns true if the node metrics serving
+// certificate of the DeviceConfig is issued by the operator, rather than
+// by the OpenShift service CA operator.
+func (r *Reconciler) usesOperatorCertificates(dc *hlaiv1alpha1.DeviceConfig) bool {
+	return dc.Spec.NodeMetrics.Secure && !r.dep.IsAvailable(dependencies.ServiceCA)
+}
+
+func (r *Reconciler) reconcileNodeMetricsCertificates(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error {
+	if !dc.Spec.NodeMetrics.Secure {
+		return r.cer.DeleteCertificates(ctx, dc)
+	}
+
+	if r.usesOperatorCertificates(dc) {
+		return r.cer.ReconcileCertificates(ctx, dc)
+	}
+
+	return r.cer.ReconcileServiceCABundle(ctx, dc)
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -188,12 +628,191 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if err != nil {
 		return err
 	}
-	return ctrl.NewControllerManagedBy(mgr).
+
+	// DeviceConfigs are cluster-scoped while the resources they manage live in
+	// the operator namespace, so the resources are mapped back to their
+	// DeviceConfig via labels rather than owner references.
+	enqueueDeviceConfig := handler.EnqueueRequestsFromMapFunc(mapToDeviceConfig)
+
+	c, err := ctrl.NewControllerManagedBy(mgr).
 		Named("deviceconfig").
 		For(&hlaiv1alpha1.DeviceConfig{}).
-		Owns(&kmmv1beta1.Module{}).
-		Owns(&appsv1.DaemonSet{}).
-		Complete(r)
+		Watches(&source.Kind{Type: &appsv1.DaemonSet{}}, enqueueDeviceConfig).
+		Watches(&source.Kind{Type: &v1.Service{}}, enqueueDeviceConfig).
+		Watches(&source.Kind{Type: &v1.ServiceAccount{}}, enqueueDeviceConfig).
+		Watches(&source.Kind{Type: &rbacv1.Role{}}, enqueueDeviceConfig).
+		Watches(&source.Kind{Type: &rbacv1.RoleBinding{}}, enqueueDeviceConfig).
+		Watches(&source.Kind{Type: &rbacv1.ClusterRole{}}, enqueueDeviceConfig).
+		Watches(&source.Kind{Type: &rbacv1.ClusterRoleBinding{}}, enqueueDeviceConfig).
+		Watches(&source.Kind{Type: &v1.Secret{}}, enqueueDeviceConfig).
+		Watches(&source.Kind{Type: &v1.ConfigMap{}}, enqueueDeviceConfig).
+		Watches(&source.Kind{Type: &batchv1.Job{}}, enqueueDeviceConfig).
+		// Nodes joining or leaving the DeviceConfigs node selectors, or
+		// advertising a different number of HPUs, amount of huge pages or
+		// kubelet root directory
+		Watches(
+			&source.Kind{Type: &v1.Node{}},
+			handler.EnqueueRequestsFromMapFunc(r.mapToAllDeviceConfigs),
+			builder.WithPredicates(predicate.Or(
+				predicate.LabelChangedPredicate{},
+				allocatableHPUsChangedPredicate,
+				allocatableHugePagesChangedPredicate,
+				kubeletRootDirChangedPredicate,
+			)),
+		).
+		Watches(&source.Channel{Source: r.dep.Events()}, handler.EnqueueRequestsFromMapFunc(r.mapToAllDeviceConfigs)).
+		Build(r)
+	if err != nil {
+		return err
+	}
+
+	r.controller = c
+
+	return r.ensureDependentWatches()
+}
+
+// ensureDependentWatches starts the watches of the available dependencies,
+// provided that the controller has been set up.
+func (r *Reconciler) ensureDependentWatches() error {
+	if r.controller == nil {
+		return nil
+	}
+
+	r.watchesMu.Lock()
+	defer r.watchesMu.Unlock()
+
+	if r.watching == nil {
+		r.watching = make(map[schema.GroupVersionKind]bool)
+	}
+
+	for _, w := range dependentWatches {
+		gvk := w.dependency.GroupVersionKind
+		if u, ok := w.object.(*unstructured.Unstructured); ok {
+			gvk = u.GroupVersionKind()
+		}
+
+		if r.watching[gvk] || !r.dep.IsAvailable(w.dependency) {
+			continue
+		}
+
+		err := r.controller.Watch(&source.Kind{Type: w.object}, handler.EnqueueRequestsFromMapFunc(mapToDeviceConfig))
+		if err != nil {
+			return fmt.Errorf("failed to watch %s: %w", gvk.Kind, err)
+		}
+
+		r.watching[gvk] = true
+	}
+
+	return nil
+}
+
+// reconcileNodeReadiness reports the readiness of the DeviceConfig nodes, and
+// records an event when the nodes missing the module change.
+func (r *Reconciler) reconcileNodeReadiness(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) (bool, error) {
+	previous := nodesMissingModule(dc)
+
+	ready, err := r.nrr.ReconcileNodeReadiness(ctx, dc)
+	if err != nil {
+		return false, err
+	}
+
+	if current := nodesMissingModule(dc); len(current) > 0 && nodesChanged(previous, current) {
+		r.Recorder.Event(
+			dc,
+			v1.EventTypeWarning,
+			conditions.ReasonNodesMissingModule,
+			fmt.Sprintf("Nodes missing the %s module: %s", nodeDriver.ModuleName, strings.Join(current, ", ")),
+		)
+	}
+
+	return ready, nil
+}
+
+func nodesMissingModule(dc *hlaiv1alpha1.DeviceConfig) []string {
+	if dc.Status.Driver == nil {
+		return nil
+	}
+	return dc.Status.Driver.NodesMissingModule
+}
+
+// reconcileNodeHealth reports the nodes advertising an unexpected number of
+// HPUs in the DeviceConfig status, and records an event when the degraded
+// nodes change.
+func (r *Reconciler) reconcileNodeHealth(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error {
+	previous := degradedNodeNames(dc)
+
+	if err := r.nhr.ReconcileNodeHealth(ctx, dc); err != nil {
+		return err
+	}
+
+	if len(dc.Status.DegradedNodes) > 0 && nodesChanged(previous, degradedNodeNames(dc)) {
+		nodes := make([]string, 0, len(dc.Status.DegradedNodes))
+		for _, n := range dc.Status.DegradedNodes {
+			nodes = append(nodes, fmt.Sprintf("%s (%d/%d HPUs)", n.NodeName, n.Devices, dc.Spec.ExpectedDevicesPerNode))
+		}
+		r.Recorder.Event(
+			dc,
+			v1.EventTypeWarning,
+			conditions.ReasonNodesDegraded,
+			fmt.Sprintf("Nodes advertising an unexpected number of HPUs: %s", strings.Join(nodes, ", ")),
+		)
+	}
+
+	return nil
+}
+
+func degradedNodeNames(dc *hlaiv1alpha1.DeviceConfig) []string {
+	names := make([]string, 0, len(dc.Status.DegradedNodes))
+	for _, n := range dc.Status.DegradedNodes {
+		names = append(names, n.NodeName)
+	}
+	return names
+}
+
+// nodesChanged returns true if the current nodes differ from the previous
+// ones. The warning events listing nodes are only recorded when they change,
+// rather than on every reconciliation.
+func nodesChanged(previous, current []string) bool {
+	return !sets.NewString(previous...).Equal(sets.NewString(current...))
+}
+
+// reconcileHugePages allocates the huge pages of the DeviceConfig nodes, with a
+// MachineConfig when the OpenShift Machine Config Operator is installed, and
+// records an event when the nodes advertising an unexpected amount of huge
+// pages change.
+func (r *Reconciler) reconcileHugePages(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error {
+	previous := mismatchedHugePagesNodeNames(dc)
+
+	if err := r.hpr.ReconcileHugePages(ctx, dc, r.dep.IsAvailable(dependencies.MachineConfig)); err != nil {
+		return err
+	}
+
+	current := mismatchedHugePagesNodeNames(dc)
+	if len(current) > 0 && nodesChanged(previous, current) {
+		nodes := make([]string, 0, len(dc.Status.HugePages.MismatchedNodes))
+		for _, n := range dc.Status.HugePages.MismatchedNodes {
+			nodes = append(nodes, fmt.Sprintf("%s (%s/%s)", n.NodeName, n.Allocatable.String(), dc.Status.HugePages.Expected.String()))
+		}
+		r.Recorder.Event(
+			dc,
+			v1.EventTypeWarning,
+			conditions.ReasonHugePagesMismatch,
+			fmt.Sprintf("Nodes advertising an unexpected amount of %s: %s", nodeHugePages.ResourceName, strings.Join(nodes, ", ")),
+		)
+	}
+
+	return nil
+}
+
+func mismatchedHugePagesNodeNames(dc *hlaiv1alpha1.DeviceConfig) []string {
+	if dc.Status.HugePages == nil {
+		return nil
+	}
+	names := make([]string, 0, len(dc.Status.HugePages.MismatchedNodes))
+	for _, n := range dc.Status.HugePages.MismatchedNodes {
+		names = append(names, n.NodeName)
+	}
+	return names
 }
 
 func (r *Reconciler) deleteDeviceConfigResources(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
@@ -201,5 +820,139 @@ func (r *Reconciler) deleteDeviceConfigResources(ctx context.Context, cr *hlaiv1
 		return err
 	}
 
+	if err := r.dvr.DeleteDriver(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.nlr.DeleteNodeLabeler(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.nlr.DeleteNodeLabels(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.smr.DeleteSimulation(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.nmr.DeleteNodeMetrics(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.cdr.DeleteCDI(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.crr.DeleteContainerRuntime(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.nwr.DeleteNetwork(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.hpr.DeleteHugePages(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.cer.DeleteCertificates(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.mor.DeleteMonitoring(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.nhr.DeleteNodeHealth(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.nrr.DeleteNodeReadiness(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.ncr.DeleteNodeCleanupJobs(ctx, cr); err != nil {
+		return err
+	}
+
+	if err := r.rr.DeleteRBAC(ctx, cr); err != nil {
+		return err
+	}
+
 	return nil
 }
+
+var (
+	// allocatableHPUsChangedPredicate filters the node updates changing the
+	// number of HPUs advertised by the node.
+	allocatableHPUsChangedPredicate = allocatableChangedPredicate(constants.HabanaResourceName)
+
+	// allocatableHugePagesChangedPredicate filters the node updates changing
+	// the amount of 2Mi huge pages advertised by the node.
+	allocatableHugePagesChangedPredicate = allocatableChangedPredicate(nodeHugePages.ResourceName)
+
+	// kubeletRootDirChangedPredicate filters the node updates changing the
+	// annotations the kubelet root directory of the node is detected from.
+	kubeletRootDirChangedPredicate = predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldAnnotations := e.ObjectOld.GetAnnotations()
+			newAnnotations := e.ObjectNew.GetAnnotations()
+			for _, annotation := range nodeKubelet.RootDirAnnotations {
+				if oldAnnotations[annotation] != newAnnotations[annotation] {
+					return true
+				}
+			}
+			return false
+		},
+	}
+)
+
+// allocatableChangedPredicate filters the node updates changing the
+// allocatable amount of the resource.
+func allocatableChangedPredicate(name v1.ResourceName) predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*v1.Node)
+			if !ok {
+				return false
+			}
+			newNode, ok := e.ObjectNew.(*v1.Node)
+			if !ok {
+				return false
+			}
+			oldQuantity := oldNode.Status.Allocatable[name]
+			newQuantity := newNode.Status.Allocatable[name]
+			return !oldQuantity.Equal(newQuantity)
+		},
+	}
+}
+
+// mapToAllDeviceConfigs returns a reconcile request for every DeviceConfig.
+func (r *Reconciler) mapToAllDeviceConfigs(_ client.Object) []reconcile.Request {
+	list := &hlaiv1alpha1.DeviceConfigList{}
+	if err := r.List(context.Background(), list); err != nil {
+		ctrl.Log.WithName("deviceconfig").Error(err, "Failed to list DeviceConfigs")
+		return nil
+	}
+
+	reqs := make([]reconcile.Request, 0, len(list.Items))
+	for _, dc := range list.Items {
+		reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: dc.Name}})
+	}
+
+	return reqs
+}
+
+// mapToDeviceConfig returns a reconcile request for the DeviceConfig
+// referenced by the DeviceConfig label of the given object, if any.
+func mapToDeviceConfig(o client.Object) []reconcile.Request {
+	name, ok := o.GetLabels()[constants.DeviceConfigLabel]
+	if !ok || name == "" {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: name}},
+	}
+}
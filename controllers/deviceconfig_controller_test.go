@@ -23,7 +23,9 @@ import (
 	"time"
 
 	gomock "github.com/golang/mock/gomock"
+	appsv1 "k8s.io/api/apps/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -35,12 +37,30 @@ import (
 	. "github.com/onsi/gomega"
 
 	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/capacity"
+	"github.com/HabanaAI/habana-ai-operator/internal/certificates"
 	"github.com/HabanaAI/habana-ai-operator/internal/client"
 	"github.com/HabanaAI/habana-ai-operator/internal/conditions"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/dependencies"
 	"github.com/HabanaAI/habana-ai-operator/internal/finalizers"
 	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/monitoring"
+	nodeCDI "github.com/HabanaAI/habana-ai-operator/internal/node/cdi"
+	nodeCleanup "github.com/HabanaAI/habana-ai-operator/internal/node/cleanup"
+	nodeContainerRuntime "github.com/HabanaAI/habana-ai-operator/internal/node/containerruntime"
+	nodeDriver "github.com/HabanaAI/habana-ai-operator/internal/node/driver"
+	nodeHealth "github.com/HabanaAI/habana-ai-operator/internal/node/health"
+	nodeHugePages "github.com/HabanaAI/habana-ai-operator/internal/node/hugepages"
+	nodeKubelet "github.com/HabanaAI/habana-ai-operator/internal/node/kubelet"
 	nodeLabeler "github.com/HabanaAI/habana-ai-operator/internal/node/labeler"
 	nodeMetrics "github.com/HabanaAI/habana-ai-operator/internal/node/metrics"
+	nodeNetwork "github.com/HabanaAI/habana-ai-operator/internal/node/network"
+	nodeReadiness "github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	nodeSimulation "github.com/HabanaAI/habana-ai-operator/internal/node/simulation"
+	nodeTopology "github.com/HabanaAI/habana-ai-operator/internal/node/topology"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	"github.com/HabanaAI/habana-ai-operator/internal/telemetry"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 )
 
@@ -64,10 +84,28 @@ var _ = Describe("DeviceConfigReconciler", func() {
 				gCtrl *gomock.Controller
 				mr    *module.MockReconciler
 				nmr   *nodeMetrics.MockReconciler
+				cer   *certificates.MockReconciler
+				mor   *monitoring.MockReconciler
 				nlr   *nodeLabeler.MockReconciler
+				ncr   *nodeCleanup.MockReconciler
+				nhr   *nodeHealth.MockReconciler
+				nrr   *nodeReadiness.MockReconciler
+				cdr   *nodeCDI.MockReconciler
+				crr   *nodeContainerRuntime.MockReconciler
+				nwr   *nodeNetwork.MockReconciler
+				hpr   *nodeHugePages.MockReconciler
+				tpr   *nodeTopology.MockReconciler
+				kbr   *nodeKubelet.MockReconciler
+				smr   *nodeSimulation.MockReconciler
+				dvr   *nodeDriver.MockReconciler
+				tc    *telemetry.MockCollector
+				ca    *capacity.MockAccountant
+				rr    *rbac.MockReconciler
 				fu    *finalizers.MockUpdater
 				cu    *conditions.MockUpdater
 				nsv   *MockNodeSelectorValidator
+				hpv   *MockHostPortValidator
+				dep   *dependencies.MockChecker
 				r     *Reconciler
 				c     *client.MockClient
 			)
@@ -76,10 +114,28 @@ var _ = Describe("DeviceConfigReconciler", func() {
 				gCtrl = gomock.NewController(GinkgoT())
 				mr = module.NewMockReconciler(gCtrl)
 				nmr = nodeMetrics.NewMockReconciler(gCtrl)
+				cer = certificates.NewMockReconciler(gCtrl)
+				mor = monitoring.NewMockReconciler(gCtrl)
 				nlr = nodeLabeler.NewMockReconciler(gCtrl)
+				ncr = nodeCleanup.NewMockReconciler(gCtrl)
+				nhr = nodeHealth.NewMockReconciler(gCtrl)
+				nrr = nodeReadiness.NewMockReconciler(gCtrl)
+				cdr = nodeCDI.NewMockReconciler(gCtrl)
+				crr = nodeContainerRuntime.NewMockReconciler(gCtrl)
+				nwr = nodeNetwork.NewMockReconciler(gCtrl)
+				hpr = nodeHugePages.NewMockReconciler(gCtrl)
+				tpr = nodeTopology.NewMockReconciler(gCtrl)
+				kbr = nodeKubelet.NewMockReconciler(gCtrl)
+				smr = nodeSimulation.NewMockReconciler(gCtrl)
+				dvr = nodeDriver.NewMockReconciler(gCtrl)
+				tc = telemetry.NewMockCollector(gCtrl)
+				ca = capacity.NewMockAccountant(gCtrl)
+				rr = rbac.NewMockReconciler(gCtrl)
 				fu = finalizers.NewMockUpdater(gCtrl)
 				cu = conditions.NewMockUpdater(gCtrl)
 				nsv = NewMockNodeSelectorValidator(gCtrl)
+				hpv = NewMockHostPortValidator(gCtrl)
+				dep = dependencies.NewMockChecker(gCtrl)
 				c = client.NewMockClient(gCtrl)
 			})
 
@@ -87,7 +143,7 @@ var _ = Describe("DeviceConfigReconciler", func() {
 				BeforeEach(func() {
 					s := scheme.Scheme
 
-					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, nlr, fu, cu, nsv)
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
 
 					gomock.InOrder(
 						c.EXPECT().
@@ -107,7 +163,7 @@ var _ = Describe("DeviceConfigReconciler", func() {
 				BeforeEach(func() {
 					s := scheme.Scheme
 
-					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, nlr, fu, cu, nsv)
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
 
 					gomock.InOrder(
 						c.EXPECT().
@@ -129,7 +185,7 @@ var _ = Describe("DeviceConfigReconciler", func() {
 					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
 					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
 
-					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, nlr, fu, cu, nsv)
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
 
 					gomock.InOrder(
 						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
@@ -142,9 +198,28 @@ var _ = Describe("DeviceConfigReconciler", func() {
 						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
 						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
 						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
 						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
 						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
 						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						ca.EXPECT().AccountCapacity(ctx, dc).Return(nil),
+						nhr.EXPECT().ReconcileNodeHealth(ctx, dc).Return(nil),
+						nrr.EXPECT().ReconcileNodeReadiness(ctx, dc).Return(true, nil),
+						nlr.EXPECT().ReconcileNodeLabels(ctx, dc).Return(nil),
+						ncr.EXPECT().ReconcileNodeCleanup(ctx, dc).Return(nil),
 						cu.EXPECT().SetConditionsReady(ctx, dc, "Reconciled", gomock.Any()).Return(nil),
 					)
 				})
@@ -153,6 +228,325 @@ var _ = Describe("DeviceConfigReconciler", func() {
 					res, err := r.Reconcile(ctx, req)
 					Expect(err).ToNot(HaveOccurred())
 					Expect(res.Requeue).To(BeFalse())
+					Expect(res.RequeueAfter).To(Equal(capacityRequeueDelay))
+				})
+			})
+
+			When("the telemetry is enabled", func() {
+				tdc := makeTestDeviceConfig(telemetryEnabled())
+
+				var fakeRecorder *record.FakeRecorder
+
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					fakeRecorder = record.NewFakeRecorder(2)
+					r = NewReconciler(c, s, fakeRecorder, mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = tdc.ObjectMeta
+								d.Spec = tdc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, tdc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(tdc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, tdc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, tdc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, tdc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, tdc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, tdc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, tdc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, tdc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, tdc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, tdc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, tdc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, tdc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, tdc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, tdc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, tdc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, tdc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						tc.EXPECT().CollectTelemetry(ctx, tdc).DoAndReturn(
+							func(_ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.Status.Telemetry = &hlaiv1alpha1.TelemetryStatus{
+									Nodes: []hlaiv1alpha1.NodeTelemetry{{NodeName: "hot-node", ExceededThresholds: []string{"temperature"}}},
+								}
+								return nil
+							},
+						),
+						ca.EXPECT().AccountCapacity(ctx, gomock.Any()).Return(nil),
+						nhr.EXPECT().ReconcileNodeHealth(ctx, gomock.Any()).Return(nil),
+						nrr.EXPECT().ReconcileNodeReadiness(ctx, gomock.Any()).Return(true, nil),
+						nlr.EXPECT().ReconcileNodeLabels(ctx, gomock.Any()).Return(nil),
+						ncr.EXPECT().ReconcileNodeCleanup(ctx, gomock.Any()).Return(nil),
+						cu.EXPECT().SetConditionsReady(ctx, gomock.Any(), "Reconciled", gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should flag the nodes and requeue to collect the telemetry again", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.RequeueAfter).To(Equal(time.Minute))
+
+					msg := <-fakeRecorder.Events
+					Expect(msg).To(ContainSubstring("hot-node"))
+				})
+			})
+
+			When("a telemetry collection error occurs", func() {
+				tdc := makeTestDeviceConfig(telemetryEnabled())
+
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = tdc.ObjectMeta
+								d.Spec = tdc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, tdc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(tdc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, tdc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, tdc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, tdc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, tdc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, tdc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, tdc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, tdc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, tdc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, tdc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, tdc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, tdc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, tdc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, tdc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, tdc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, tdc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						tc.EXPECT().CollectTelemetry(ctx, tdc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, tdc, conditions.ReasonTelemetryFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("the node metrics are secured with operator issued certificates", func() {
+				sdc := makeTestDeviceConfig(secureNodeMetrics())
+
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = sdc.ObjectMeta
+								d.Spec = sdc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, sdc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(sdc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, sdc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, sdc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, sdc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, sdc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, sdc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, sdc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, sdc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.ServiceCA).Return(false),
+						cer.EXPECT().ReconcileCertificates(ctx, sdc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, sdc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, sdc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, sdc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, sdc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, sdc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, sdc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, sdc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						ca.EXPECT().AccountCapacity(ctx, sdc).Return(nil),
+						nhr.EXPECT().ReconcileNodeHealth(ctx, sdc).Return(nil),
+						nrr.EXPECT().ReconcileNodeReadiness(ctx, sdc).Return(true, nil),
+						nlr.EXPECT().ReconcileNodeLabels(ctx, sdc).Return(nil),
+						ncr.EXPECT().ReconcileNodeCleanup(ctx, sdc).Return(nil),
+						cu.EXPECT().SetConditionsReady(ctx, sdc, "Reconciled", gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should requeue to check the certificates again", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.RequeueAfter).To(Equal(capacityRequeueDelay))
+				})
+			})
+
+			When("a reconcile certificates error occurs", func() {
+				sdc := makeTestDeviceConfig(secureNodeMetrics())
+
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = sdc.ObjectMeta
+								d.Spec = sdc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, sdc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(sdc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, sdc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, sdc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, sdc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, sdc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, sdc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, sdc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, sdc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.ServiceCA).Return(true),
+						cer.EXPECT().ReconcileServiceCABundle(ctx, sdc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, sdc, conditions.ReasonCertificatesFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a reconcile RBAC error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonRBACFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a reconcile kubelet error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonKubeletFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a reconcile simulation error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonSimulationFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
 				})
 			})
 
@@ -162,7 +556,642 @@ var _ = Describe("DeviceConfigReconciler", func() {
 					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
 					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
 
-					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, nlr, fu, cu, nsv)
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonModuleFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a reconcile driver error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonDriverFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a dependency is missing", func() {
+				var fakeRecorder *record.FakeRecorder
+
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					fakeRecorder = record.NewFakeRecorder(1)
+					r = NewReconciler(c, s, fakeRecorder, mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return([]dependencies.Dependency{dependencies.KMM}),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, "DependencyMissing:KMM", gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should not reconcile any resource nor return an error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.Requeue).To(BeFalse())
+
+					msg := <-fakeRecorder.Events
+					Expect(msg).To(ContainSubstring("KMM"))
+				})
+			})
+
+			When("a reconcile NodeMetrics error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonNodeMetricsFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a reconcile CDI error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonCDIFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a reconcile ContainerRuntime error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonContainerRuntimeFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a reconcile Network error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonNetworkFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a reconcile HugePages error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(true),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, true).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonHugePagesFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a reconcile Topology error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonTopologyFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("the node metrics ports are held on a selected node", func() {
+				var fakeRecorder *record.FakeRecorder
+
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					fakeRecorder = record.NewFakeRecorder(1)
+					r = NewReconciler(c, s, fakeRecorder, mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					conflicts := []HostPortConflict{{NodeName: "node-1", Pod: "default/some-pod", Port: hlaiv1alpha1.DefaultNodeMetricsPort}}
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(conflicts, nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						ca.EXPECT().AccountCapacity(ctx, dc).Return(nil),
+						nhr.EXPECT().ReconcileNodeHealth(ctx, dc).Return(nil),
+						nrr.EXPECT().ReconcileNodeReadiness(ctx, dc).Return(true, nil),
+						nlr.EXPECT().ReconcileNodeLabels(ctx, dc).Return(nil),
+						ncr.EXPECT().ReconcileNodeCleanup(ctx, dc).Return(nil),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonHostPortConflict, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should only hold back the node metrics, record an event and requeue", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.RequeueAfter).To(Equal(hostPortConflictRequeueDelay))
+
+					msg := <-fakeRecorder.Events
+					Expect(msg).To(ContainSubstring("default/some-pod"))
+				})
+			})
+
+			When("a reconcile NodeCleanup error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						ca.EXPECT().AccountCapacity(ctx, dc).Return(nil),
+						nhr.EXPECT().ReconcileNodeHealth(ctx, dc).Return(nil),
+						nrr.EXPECT().ReconcileNodeReadiness(ctx, dc).Return(true, nil),
+						nlr.EXPECT().ReconcileNodeLabels(ctx, dc).Return(nil),
+						ncr.EXPECT().ReconcileNodeCleanup(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonNodeCleanupFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("an account capacity error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						ca.EXPECT().AccountCapacity(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonCapacityFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a reconcile node health error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						ca.EXPECT().AccountCapacity(ctx, dc).Return(nil),
+						nhr.EXPECT().ReconcileNodeHealth(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonNodeHealthFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a reconcile node readiness error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						ca.EXPECT().AccountCapacity(ctx, dc).Return(nil),
+						nhr.EXPECT().ReconcileNodeHealth(ctx, dc).Return(nil),
+						nrr.EXPECT().ReconcileNodeReadiness(ctx, dc).Return(false, errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonNodeReadinessFailed, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return the respective error", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some-error"))
+					Expect(res.Requeue).To(BeFalse())
+				})
+			})
+
+			When("a reconcile node labels error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
 
 					gomock.InOrder(
 						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
@@ -175,8 +1204,28 @@ var _ = Describe("DeviceConfigReconciler", func() {
 						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
 						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
 						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
-						mr.EXPECT().ReconcileModule(ctx, dc).Return(errors.New("some-error")),
-						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonModuleFailed, gomock.Any()).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						ca.EXPECT().AccountCapacity(ctx, dc).Return(nil),
+						nhr.EXPECT().ReconcileNodeHealth(ctx, dc).Return(nil),
+						nrr.EXPECT().ReconcileNodeReadiness(ctx, dc).Return(true, nil),
+						nlr.EXPECT().ReconcileNodeLabels(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonNodeLabelerFailed, gomock.Any()).Return(nil),
 					)
 				})
 
@@ -188,13 +1237,13 @@ var _ = Describe("DeviceConfigReconciler", func() {
 				})
 			})
 
-			When("a reconcile NodeMetrics error occurs", func() {
+			When("nodes are not ready", func() {
 				BeforeEach(func() {
 					s := scheme.Scheme
 					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
 					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
 
-					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, nlr, fu, cu, nsv)
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
 
 					gomock.InOrder(
 						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
@@ -207,10 +1256,274 @@ var _ = Describe("DeviceConfigReconciler", func() {
 						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
 						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
 						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
 						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
 						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
-						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(errors.New("some-error")),
-						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonNodeMetricsFailed, gomock.Any()).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						ca.EXPECT().AccountCapacity(ctx, dc).Return(nil),
+						nhr.EXPECT().ReconcileNodeHealth(ctx, dc).Return(nil),
+						nrr.EXPECT().ReconcileNodeReadiness(ctx, dc).Return(false, nil),
+						nlr.EXPECT().ReconcileNodeLabels(ctx, dc).Return(nil),
+						ncr.EXPECT().ReconcileNodeCleanup(ctx, dc).Return(nil),
+						cu.EXPECT().SetConditionsReady(ctx, dc, "Reconciled", gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should requeue to check the nodes readiness again", func() {
+					res, err := r.Reconcile(ctx, req)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.RequeueAfter).To(Equal(nodeReadinessRequeueDelay))
+				})
+			})
+
+			When("nodes are degraded", func() {
+				var fakeRecorder *record.FakeRecorder
+
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					fakeRecorder = record.NewFakeRecorder(2)
+					r = NewReconciler(c, s, fakeRecorder, mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						ca.EXPECT().AccountCapacity(ctx, dc).Return(nil),
+						nhr.EXPECT().ReconcileNodeHealth(ctx, dc).DoAndReturn(
+							func(_ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.Status.DegradedNodes = []hlaiv1alpha1.DegradedNodeStatus{{NodeName: "degraded-node", Devices: 7}}
+								return nil
+							},
+						),
+						nrr.EXPECT().ReconcileNodeReadiness(ctx, gomock.Any()).Return(true, nil),
+						nlr.EXPECT().ReconcileNodeLabels(ctx, gomock.Any()).Return(nil),
+						ncr.EXPECT().ReconcileNodeCleanup(ctx, gomock.Any()).Return(nil),
+						cu.EXPECT().SetConditionsReady(ctx, gomock.Any(), "Reconciled", gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should record an event listing the degraded nodes", func() {
+					_, err := r.Reconcile(ctx, req)
+					Expect(err).ToNot(HaveOccurred())
+
+					msg := <-fakeRecorder.Events
+					Expect(msg).To(ContainSubstring(conditions.ReasonNodesDegraded))
+					Expect(msg).To(ContainSubstring("degraded-node"))
+				})
+			})
+
+			When("nodes advertise an unexpected amount of huge pages", func() {
+				var fakeRecorder *record.FakeRecorder
+
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					fakeRecorder = record.NewFakeRecorder(2)
+					r = NewReconciler(c, s, fakeRecorder, mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).DoAndReturn(
+							func(_ interface{}, d *hlaiv1alpha1.DeviceConfig, _ bool) error {
+								d.Status.HugePages = &hlaiv1alpha1.HugePagesStatus{
+									Expected: resource.MustParse("1Gi"),
+									MismatchedNodes: []hlaiv1alpha1.NodeHugePagesStatus{
+										{NodeName: "mismatched-node", Allocatable: resource.MustParse("0")},
+									},
+								}
+								return nil
+							},
+						),
+						tpr.EXPECT().ReconcileTopology(ctx, gomock.Any()).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						ca.EXPECT().AccountCapacity(ctx, gomock.Any()).Return(nil),
+						nhr.EXPECT().ReconcileNodeHealth(ctx, gomock.Any()).Return(nil),
+						nrr.EXPECT().ReconcileNodeReadiness(ctx, gomock.Any()).Return(true, nil),
+						nlr.EXPECT().ReconcileNodeLabels(ctx, gomock.Any()).Return(nil),
+						ncr.EXPECT().ReconcileNodeCleanup(ctx, gomock.Any()).Return(nil),
+						cu.EXPECT().SetConditionsReady(ctx, gomock.Any(), "Reconciled", gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should record an event listing the mismatched nodes", func() {
+					_, err := r.Reconcile(ctx, req)
+					Expect(err).ToNot(HaveOccurred())
+
+					msg := <-fakeRecorder.Events
+					Expect(msg).To(ContainSubstring(conditions.ReasonHugePagesMismatch))
+					Expect(msg).To(ContainSubstring("mismatched-node (0/1Gi)"))
+				})
+			})
+
+			When("nodes miss the kernel module", func() {
+				var fakeRecorder *record.FakeRecorder
+
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					fakeRecorder = record.NewFakeRecorder(2)
+					r = NewReconciler(c, s, fakeRecorder, mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, gomock.Any()).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(false),
+						ca.EXPECT().AccountCapacity(ctx, gomock.Any()).Return(nil),
+						nhr.EXPECT().ReconcileNodeHealth(ctx, gomock.Any()).Return(nil),
+						nrr.EXPECT().ReconcileNodeReadiness(ctx, gomock.Any()).DoAndReturn(
+							func(_ interface{}, d *hlaiv1alpha1.DeviceConfig) (bool, error) {
+								d.Status.Driver = &hlaiv1alpha1.DriverStatus{
+									Mode:               hlaiv1alpha1.DriverModeHostInstalled,
+									NodesMissingModule: []string{"node-a", "node-b"},
+								}
+								return true, nil
+							},
+						),
+						nlr.EXPECT().ReconcileNodeLabels(ctx, gomock.Any()).Return(nil),
+						ncr.EXPECT().ReconcileNodeCleanup(ctx, gomock.Any()).Return(nil),
+						cu.EXPECT().SetConditionsReady(ctx, gomock.Any(), "Reconciled", gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should record an event listing the nodes missing the module", func() {
+					_, err := r.Reconcile(ctx, req)
+					Expect(err).ToNot(HaveOccurred())
+
+					msg := <-fakeRecorder.Events
+					Expect(msg).To(ContainSubstring(conditions.ReasonNodesMissingModule))
+					Expect(msg).To(ContainSubstring("node-a, node-b"))
+				})
+			})
+
+			When("a reconcile Monitoring error occurs", func() {
+				BeforeEach(func() {
+					s := scheme.Scheme
+					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
+
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+							func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+								d.ObjectMeta = dc.ObjectMeta
+								d.Spec = dc.Spec
+								return nil
+							},
+						),
+						nsv.EXPECT().CheckDeviceConfigForConflictingNodeSelector(ctx, dc).Return(nil),
+						fu.EXPECT().ContainsDeletionFinalizer(dc).Return(false),
+						fu.EXPECT().AddDeletionFinalizer(ctx, dc).Return(nil),
+						dep.EXPECT().Missing().Return(nil),
+						rr.EXPECT().ReconcileRBAC(ctx, dc).Return(nil),
+						kbr.EXPECT().ReconcileKubelet(ctx, dc).Return(nil),
+						smr.EXPECT().ReconcileSimulation(ctx, dc).Return(nil),
+						mr.EXPECT().ReconcileModule(ctx, dc).Return(nil),
+						dvr.EXPECT().ReconcileDriver(ctx, dc).Return(nil),
+						nlr.EXPECT().ReconcileNodeLabeler(ctx, dc).Return(nil),
+						cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+						hpv.EXPECT().CheckDeviceConfigForConflictingHostPorts(ctx, dc).Return(nil, nil),
+						nmr.EXPECT().ReconcileNodeMetrics(ctx, dc).Return(nil),
+						cdr.EXPECT().ReconcileCDI(ctx, dc).Return(nil),
+						crr.EXPECT().ReconcileContainerRuntime(ctx, dc).Return(nil),
+						nwr.EXPECT().ReconcileNetwork(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false),
+						hpr.EXPECT().ReconcileHugePages(ctx, dc, false).Return(nil),
+						tpr.EXPECT().ReconcileTopology(ctx, dc).Return(nil),
+						dep.EXPECT().IsAvailable(dependencies.Monitoring).Return(true),
+						mor.EXPECT().ReconcileMonitoring(ctx, dc).Return(errors.New("some-error")),
+						cu.EXPECT().SetConditionsErrored(ctx, dc, conditions.ReasonMonitoringFailed, gomock.Any()).Return(nil),
 					)
 				})
 
@@ -229,7 +1542,7 @@ var _ = Describe("DeviceConfigReconciler", func() {
 						Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
 						Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
 
-						r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, nlr, fu, cu, nsv)
+						r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
 
 						gomock.InOrder(
 							c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
@@ -297,10 +1610,28 @@ var _ = Describe("DeviceConfigReconciler", func() {
 				r = NewReconciler(c, s, fakeRecorder,
 					module.NewReconciler(c, s),
 					nodeMetrics.NewReconciler(c, s),
+					certificates.NewReconciler(c, s),
+					monitoring.NewReconciler(c, s),
 					nodeLabeler.NewReconciler(c, s),
+					nodeCleanup.NewReconciler(c, s),
+					nodeHealth.NewReconciler(c, s),
+					nodeReadiness.NewReconciler(c, s),
+					nodeCDI.NewReconciler(c, s),
+					nodeContainerRuntime.NewReconciler(c, s),
+					nodeNetwork.NewReconciler(c, s),
+					nodeHugePages.NewReconciler(c, s),
+					nodeTopology.NewReconciler(c, s),
+					nodeKubelet.NewReconciler(c, s),
+					nodeSimulation.NewReconciler(c, s),
+					nodeDriver.NewReconciler(c, s),
+					telemetry.NewCollector(c, nil),
+					capacity.NewAccountant(c),
+					rbac.NewReconciler(c, s),
 					finalizers.NewUpdater(c),
 					conditions.NewUpdater(c),
 					nsv,
+					NewHostPortValidator(c),
+					nil,
 				)
 
 				res, err := r.Reconcile(ctx, req)
@@ -319,7 +1650,23 @@ var _ = Describe("DeviceConfigReconciler", func() {
 				gCtrl *gomock.Controller
 				mr    *module.MockReconciler
 				nmr   *nodeMetrics.MockReconciler
+				cer   *certificates.MockReconciler
+				mor   *monitoring.MockReconciler
 				nlr   *nodeLabeler.MockReconciler
+				ncr   *nodeCleanup.MockReconciler
+				nhr   *nodeHealth.MockReconciler
+				nrr   *nodeReadiness.MockReconciler
+				cdr   *nodeCDI.MockReconciler
+				crr   *nodeContainerRuntime.MockReconciler
+				nwr   *nodeNetwork.MockReconciler
+				hpr   *nodeHugePages.MockReconciler
+				tpr   *nodeTopology.MockReconciler
+				kbr   *nodeKubelet.MockReconciler
+				smr   *nodeSimulation.MockReconciler
+				dvr   *nodeDriver.MockReconciler
+				tc    *telemetry.MockCollector
+				ca    *capacity.MockAccountant
+				rr    *rbac.MockReconciler
 				fu    *finalizers.MockUpdater
 				r     *Reconciler
 				c     *client.MockClient
@@ -329,12 +1676,93 @@ var _ = Describe("DeviceConfigReconciler", func() {
 				gCtrl = gomock.NewController(GinkgoT())
 				mr = module.NewMockReconciler(gCtrl)
 				nmr = nodeMetrics.NewMockReconciler(gCtrl)
+				cer = certificates.NewMockReconciler(gCtrl)
+				mor = monitoring.NewMockReconciler(gCtrl)
 				nlr = nodeLabeler.NewMockReconciler(gCtrl)
+				ncr = nodeCleanup.NewMockReconciler(gCtrl)
+				nhr = nodeHealth.NewMockReconciler(gCtrl)
+				nrr = nodeReadiness.NewMockReconciler(gCtrl)
+				cdr = nodeCDI.NewMockReconciler(gCtrl)
+				crr = nodeContainerRuntime.NewMockReconciler(gCtrl)
+				nwr = nodeNetwork.NewMockReconciler(gCtrl)
+				hpr = nodeHugePages.NewMockReconciler(gCtrl)
+				tpr = nodeTopology.NewMockReconciler(gCtrl)
+				kbr = nodeKubelet.NewMockReconciler(gCtrl)
+				smr = nodeSimulation.NewMockReconciler(gCtrl)
+				dvr = nodeDriver.NewMockReconciler(gCtrl)
+				tc = telemetry.NewMockCollector(gCtrl)
+				ca = capacity.NewMockAccountant(gCtrl)
+				rr = rbac.NewMockReconciler(gCtrl)
 				fu = finalizers.NewMockUpdater(gCtrl)
 				c = client.NewMockClient(gCtrl)
 			})
 
 			Context("which contains a deletion finalizer", func() {
+				Context("and the nodes are still being cleaned up", func() {
+					It("should requeue without releasing the finalizer", func() {
+						s := scheme.Scheme
+						Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+						Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+						cu := conditions.NewMockUpdater(gCtrl)
+
+						gomock.InOrder(
+							c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+								func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+									d.ObjectMeta = dc.ObjectMeta
+									d.Spec = dc.Spec
+									return nil
+								},
+							),
+						)
+
+						r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nil, nil, nil)
+
+						gomock.InOrder(
+							fu.EXPECT().ContainsDeletionFinalizer(dc).Return(true),
+							ncr.EXPECT().CleanupNodes(ctx, dc).Return(false, nil),
+							cu.EXPECT().SetConditionsDeleting(ctx, dc, gomock.Any()).Return(nil),
+						)
+
+						res, err := r.Reconcile(ctx, req)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(res.RequeueAfter).To(Equal(nodeCleanupRequeueDelay))
+					})
+				})
+
+				Context("and the container runtime is still being reverted", func() {
+					It("should requeue without releasing the finalizer", func() {
+						s := scheme.Scheme
+						Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+						Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
+
+						cu := conditions.NewMockUpdater(gCtrl)
+
+						gomock.InOrder(
+							c.EXPECT().Get(ctx, req.NamespacedName, gomock.Any()).DoAndReturn(
+								func(_ interface{}, _ interface{}, d *hlaiv1alpha1.DeviceConfig) error {
+									d.ObjectMeta = dc.ObjectMeta
+									d.Spec = dc.Spec
+									return nil
+								},
+							),
+						)
+
+						r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nil, nil, nil)
+
+						gomock.InOrder(
+							fu.EXPECT().ContainsDeletionFinalizer(dc).Return(true),
+							ncr.EXPECT().CleanupNodes(ctx, dc).Return(true, nil),
+							crr.EXPECT().RevertContainerRuntime(ctx, dc).Return(false, nil),
+							cu.EXPECT().SetConditionsDeleting(ctx, dc, gomock.Any()).Return(nil),
+						)
+
+						res, err := r.Reconcile(ctx, req)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(res.RequeueAfter).To(Equal(nodeCleanupRequeueDelay))
+					})
+				})
+
 				Context("and a deletion error occurs", func() {
 					It("should return an error", func() {
 						s := scheme.Scheme
@@ -351,10 +1779,12 @@ var _ = Describe("DeviceConfigReconciler", func() {
 							),
 						)
 
-						r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, nlr, fu, nil, nil)
+						r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, nil, nil, nil, nil)
 
 						gomock.InOrder(
 							fu.EXPECT().ContainsDeletionFinalizer(dc).Return(true),
+							ncr.EXPECT().CleanupNodes(ctx, dc).Return(true, nil),
+							crr.EXPECT().RevertContainerRuntime(ctx, dc).Return(true, nil),
 							mr.EXPECT().DeleteModule(ctx, dc).Return(errors.New("something went wrong")),
 						)
 
@@ -384,11 +1814,28 @@ var _ = Describe("DeviceConfigReconciler", func() {
 								),
 							)
 
-							r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, nlr, fu, nil, nil)
+							r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, nil, nil, nil, nil)
 
 							gomock.InOrder(
 								fu.EXPECT().ContainsDeletionFinalizer(dc).Return(true),
+								ncr.EXPECT().CleanupNodes(ctx, dc).Return(true, nil),
+								crr.EXPECT().RevertContainerRuntime(ctx, dc).Return(true, nil),
 								mr.EXPECT().DeleteModule(ctx, dc).Return(nil),
+								dvr.EXPECT().DeleteDriver(ctx, dc).Return(nil),
+								nlr.EXPECT().DeleteNodeLabeler(ctx, dc).Return(nil),
+								nlr.EXPECT().DeleteNodeLabels(ctx, dc).Return(nil),
+								smr.EXPECT().DeleteSimulation(ctx, dc).Return(nil),
+								nmr.EXPECT().DeleteNodeMetrics(ctx, dc).Return(nil),
+								cdr.EXPECT().DeleteCDI(ctx, dc).Return(nil),
+								crr.EXPECT().DeleteContainerRuntime(ctx, dc).Return(nil),
+								nwr.EXPECT().DeleteNetwork(ctx, dc).Return(nil),
+								hpr.EXPECT().DeleteHugePages(ctx, dc).Return(nil),
+								cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+								mor.EXPECT().DeleteMonitoring(ctx, dc).Return(nil),
+								nhr.EXPECT().DeleteNodeHealth(ctx, dc).Return(nil),
+								nrr.EXPECT().DeleteNodeReadiness(ctx, dc).Return(nil),
+								ncr.EXPECT().DeleteNodeCleanupJobs(ctx, dc).Return(nil),
+								rr.EXPECT().DeleteRBAC(ctx, dc).Return(nil),
 								fu.EXPECT().RemoveDeletionFinalizer(ctx, dc).Return(nil),
 							)
 
@@ -414,11 +1861,28 @@ var _ = Describe("DeviceConfigReconciler", func() {
 								),
 							)
 
-							r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, nlr, fu, nil, nil)
+							r = NewReconciler(c, s, record.NewFakeRecorder(1), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, nil, nil, nil, nil)
 
 							gomock.InOrder(
 								fu.EXPECT().ContainsDeletionFinalizer(dc).Return(true),
+								ncr.EXPECT().CleanupNodes(ctx, dc).Return(true, nil),
+								crr.EXPECT().RevertContainerRuntime(ctx, dc).Return(true, nil),
 								mr.EXPECT().DeleteModule(ctx, dc).Return(nil),
+								dvr.EXPECT().DeleteDriver(ctx, dc).Return(nil),
+								nlr.EXPECT().DeleteNodeLabeler(ctx, dc).Return(nil),
+								nlr.EXPECT().DeleteNodeLabels(ctx, dc).Return(nil),
+								smr.EXPECT().DeleteSimulation(ctx, dc).Return(nil),
+								nmr.EXPECT().DeleteNodeMetrics(ctx, dc).Return(nil),
+								cdr.EXPECT().DeleteCDI(ctx, dc).Return(nil),
+								crr.EXPECT().DeleteContainerRuntime(ctx, dc).Return(nil),
+								nwr.EXPECT().DeleteNetwork(ctx, dc).Return(nil),
+								hpr.EXPECT().DeleteHugePages(ctx, dc).Return(nil),
+								cer.EXPECT().DeleteCertificates(ctx, dc).Return(nil),
+								mor.EXPECT().DeleteMonitoring(ctx, dc).Return(nil),
+								nhr.EXPECT().DeleteNodeHealth(ctx, dc).Return(nil),
+								nrr.EXPECT().DeleteNodeReadiness(ctx, dc).Return(nil),
+								ncr.EXPECT().DeleteNodeCleanupJobs(ctx, dc).Return(nil),
+								rr.EXPECT().DeleteRBAC(ctx, dc).Return(nil),
 								fu.EXPECT().RemoveDeletionFinalizer(ctx, dc).Return(errors.New("some error")),
 							)
 
@@ -447,7 +1911,7 @@ var _ = Describe("DeviceConfigReconciler", func() {
 					Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
 					Expect(kmmv1beta1.AddToScheme(s)).ToNot(HaveOccurred())
 
-					r = NewReconciler(c, s, record.NewFakeRecorder(1), nil, nil, nil, fu, nil, nil)
+					r = NewReconciler(c, s, record.NewFakeRecorder(1), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, fu, nil, nil, nil, nil)
 
 					res, err := r.Reconcile(ctx, req)
 					Expect(err).ToNot(HaveOccurred())
@@ -458,6 +1922,214 @@ var _ = Describe("DeviceConfigReconciler", func() {
 	})
 })
 
+var _ = Describe("reconcileNodeReadiness", func() {
+	var (
+		nrr          *nodeReadiness.MockReconciler
+		fakeRecorder *record.FakeRecorder
+		r            *Reconciler
+		dc           *hlaiv1alpha1.DeviceConfig
+		ctx          context.Context
+	)
+
+	BeforeEach(func() {
+		nrr = nodeReadiness.NewMockReconciler(gomock.NewController(GinkgoT()))
+		fakeRecorder = record.NewFakeRecorder(1)
+		r = &Reconciler{Recorder: fakeRecorder, nrr: nrr}
+		dc = makeTestDeviceConfig()
+		ctx = context.TODO()
+	})
+
+	missing := func(names ...string) func(context.Context, *hlaiv1alpha1.DeviceConfig) (bool, error) {
+		return func(_ context.Context, d *hlaiv1alpha1.DeviceConfig) (bool, error) {
+			d.Status.Driver = &hlaiv1alpha1.DriverStatus{Mode: hlaiv1alpha1.DriverModeInTree, NodesMissingModule: names}
+			return len(names) == 0, nil
+		}
+	}
+
+	It("should not record the event again while the nodes missing the module are unchanged", func() {
+		_, err := missing("node-a")(ctx, dc)
+		Expect(err).ToNot(HaveOccurred())
+		nrr.EXPECT().ReconcileNodeReadiness(ctx, dc).DoAndReturn(missing("node-a"))
+
+		ready, err := r.reconcileNodeReadiness(ctx, dc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ready).To(BeFalse())
+		Expect(fakeRecorder.Events).ToNot(Receive())
+	})
+
+	It("should record the event when the nodes missing the module change", func() {
+		nrr.EXPECT().ReconcileNodeReadiness(ctx, dc).DoAndReturn(missing("node-a"))
+
+		_, err := r.reconcileNodeReadiness(ctx, dc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeRecorder.Events).To(Receive(ContainSubstring("node-a")))
+	})
+})
+
+var _ = Describe("reconcileNodeHealth", func() {
+	var (
+		nhr          *nodeHealth.MockReconciler
+		fakeRecorder *record.FakeRecorder
+		r            *Reconciler
+		dc           *hlaiv1alpha1.DeviceConfig
+		ctx          context.Context
+	)
+
+	BeforeEach(func() {
+		nhr = nodeHealth.NewMockReconciler(gomock.NewController(GinkgoT()))
+		fakeRecorder = record.NewFakeRecorder(1)
+		r = &Reconciler{Recorder: fakeRecorder, nhr: nhr}
+		dc = makeTestDeviceConfig()
+		ctx = context.TODO()
+	})
+
+	degrade := func(names ...string) func(context.Context, *hlaiv1alpha1.DeviceConfig) error {
+		return func(_ context.Context, d *hlaiv1alpha1.DeviceConfig) error {
+			d.Status.DegradedNodes = nil
+			for _, name := range names {
+				d.Status.DegradedNodes = append(d.Status.DegradedNodes, hlaiv1alpha1.DegradedNodeStatus{NodeName: name, Devices: 7})
+			}
+			return nil
+		}
+	}
+
+	It("should not record the event again while the degraded nodes are unchanged", func() {
+		dc.Status.DegradedNodes = []hlaiv1alpha1.DegradedNodeStatus{{NodeName: "degraded-node", Devices: 6}}
+		nhr.EXPECT().ReconcileNodeHealth(ctx, dc).DoAndReturn(degrade("degraded-node"))
+
+		Expect(r.reconcileNodeHealth(ctx, dc)).To(Succeed())
+		Expect(fakeRecorder.Events).ToNot(Receive())
+	})
+
+	It("should record the event when the degraded nodes change", func() {
+		dc.Status.DegradedNodes = []hlaiv1alpha1.DegradedNodeStatus{{NodeName: "degraded-node", Devices: 7}}
+		nhr.EXPECT().ReconcileNodeHealth(ctx, dc).DoAndReturn(degrade("degraded-node", "another-node"))
+
+		Expect(r.reconcileNodeHealth(ctx, dc)).To(Succeed())
+		Expect(fakeRecorder.Events).To(Receive(ContainSubstring("another-node")))
+	})
+})
+
+var _ = Describe("reconcileTelemetry", func() {
+	var (
+		tc           *telemetry.MockCollector
+		fakeRecorder *record.FakeRecorder
+		r            *Reconciler
+		dc           *hlaiv1alpha1.DeviceConfig
+		ctx          context.Context
+	)
+
+	BeforeEach(func() {
+		tc = telemetry.NewMockCollector(gomock.NewController(GinkgoT()))
+		fakeRecorder = record.NewFakeRecorder(1)
+		r = &Reconciler{Recorder: fakeRecorder, tc: tc}
+		dc = makeTestDeviceConfig(telemetryEnabled())
+		ctx = context.TODO()
+	})
+
+	flag := func(names ...string) func(context.Context, *hlaiv1alpha1.DeviceConfig) error {
+		return func(_ context.Context, d *hlaiv1alpha1.DeviceConfig) error {
+			d.Status.Telemetry = &hlaiv1alpha1.TelemetryStatus{}
+			for _, name := range names {
+				d.Status.Telemetry.Nodes = append(d.Status.Telemetry.Nodes, hlaiv1alpha1.NodeTelemetry{NodeName: name, ExceededThresholds: []string{"temperature"}})
+			}
+			return nil
+		}
+	}
+
+	It("should not record the event again while the flagged nodes are unchanged", func() {
+		Expect(flag("hot-node")(ctx, dc)).To(Succeed())
+		tc.EXPECT().CollectTelemetry(ctx, dc).DoAndReturn(flag("hot-node"))
+
+		Expect(r.reconcileTelemetry(ctx, dc)).To(Succeed())
+		Expect(fakeRecorder.Events).ToNot(Receive())
+	})
+
+	It("should record the event when the flagged nodes change", func() {
+		Expect(flag("hot-node")(ctx, dc)).To(Succeed())
+		tc.EXPECT().CollectTelemetry(ctx, dc).DoAndReturn(flag("hot-node", "another-node"))
+
+		Expect(r.reconcileTelemetry(ctx, dc)).To(Succeed())
+		Expect(fakeRecorder.Events).To(Receive(ContainSubstring("another-node")))
+	})
+})
+
+var _ = Describe("reconcileHugePages", func() {
+	var (
+		hpr          *nodeHugePages.MockReconciler
+		dep          *dependencies.MockChecker
+		fakeRecorder *record.FakeRecorder
+		r            *Reconciler
+		dc           *hlaiv1alpha1.DeviceConfig
+		ctx          context.Context
+	)
+
+	BeforeEach(func() {
+		gCtrl := gomock.NewController(GinkgoT())
+		hpr = nodeHugePages.NewMockReconciler(gCtrl)
+		dep = dependencies.NewMockChecker(gCtrl)
+		fakeRecorder = record.NewFakeRecorder(1)
+		r = &Reconciler{Recorder: fakeRecorder, hpr: hpr, dep: dep}
+		dc = makeTestDeviceConfig()
+		ctx = context.TODO()
+
+		dep.EXPECT().IsAvailable(dependencies.MachineConfig).Return(false)
+	})
+
+	mismatch := func(names ...string) func(context.Context, *hlaiv1alpha1.DeviceConfig, bool) error {
+		return func(_ context.Context, d *hlaiv1alpha1.DeviceConfig, _ bool) error {
+			d.Status.HugePages = &hlaiv1alpha1.HugePagesStatus{}
+			for _, name := range names {
+				d.Status.HugePages.MismatchedNodes = append(d.Status.HugePages.MismatchedNodes, hlaiv1alpha1.NodeHugePagesStatus{NodeName: name})
+			}
+			return nil
+		}
+	}
+
+	It("should not record the event again while the mismatched nodes are unchanged", func() {
+		Expect(mismatch("node-a")(ctx, dc, false)).To(Succeed())
+		hpr.EXPECT().ReconcileHugePages(ctx, dc, false).DoAndReturn(mismatch("node-a"))
+
+		Expect(r.reconcileHugePages(ctx, dc)).To(Succeed())
+		Expect(fakeRecorder.Events).ToNot(Receive())
+	})
+
+	It("should record the event when the mismatched nodes change", func() {
+		Expect(mismatch("node-a")(ctx, dc, false)).To(Succeed())
+		hpr.EXPECT().ReconcileHugePages(ctx, dc, false).DoAndReturn(mismatch("node-a", "node-b"))
+
+		Expect(r.reconcileHugePages(ctx, dc)).To(Succeed())
+		Expect(fakeRecorder.Events).To(Receive(ContainSubstring("node-b")))
+	})
+})
+
+var _ = Describe("mapToDeviceConfig", func() {
+	It("should return a request for the labelled DeviceConfig", func() {
+		ds := &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "a-daemonset",
+				Namespace: "a-namespace",
+				Labels:    map[string]string{constants.DeviceConfigLabel: testDeviceConfigName},
+			},
+		}
+
+		reqs := mapToDeviceConfig(ds)
+		Expect(reqs).To(HaveLen(1))
+		Expect(reqs[0].NamespacedName).To(Equal(types.NamespacedName{Name: testDeviceConfigName}))
+	})
+
+	It("should not return any request for an unlabelled object", func() {
+		ds := &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "a-daemonset",
+				Namespace: "a-namespace",
+			},
+		}
+
+		Expect(mapToDeviceConfig(ds)).To(BeEmpty())
+	})
+})
+
 func named(name string) deviceConfigOptions {
 	return func(c *hlaiv1alpha1.DeviceConfig) {
 		c.ObjectMeta.Name = name
@@ -477,6 +2149,18 @@ func nodeSelector(labels map[string]string) deviceConfigOptions {
 	}
 }
 
+func telemetryEnabled() deviceConfigOptions {
+	return func(c *hlaiv1alpha1.DeviceConfig) {
+		c.Spec.Telemetry.Enabled = true
+	}
+}
+
+func secureNodeMetrics() deviceConfigOptions {
+	return func(c *hlaiv1alpha1.DeviceConfig) {
+		c.Spec.NodeMetrics.Secure = true
+	}
+}
+
 type deviceConfigOptions func(*hlaiv1alpha1.DeviceConfig)
 
 func makeTestDeviceConfig(opts ...deviceConfigOptions) *hlaiv1alpha1.DeviceConfig {
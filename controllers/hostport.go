@@ -0,0 +1,165 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	nodeMetrics "github.com/HabanaAI/habana-ai-operator/internal/node/metrics"
+)
+
+//go:generate mockgen -source=hostport.go -package=controllers -destination=mock_hostport.go
+
+type HostPortValidator interface {
+	CheckDeviceConfigForConflictingHostPorts(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) ([]HostPortConflict, error)
+}
+
+// HostPortConflict is a pod holding, on a node selected by a DeviceConfig, a
+// port the node metrics of the DeviceConfig need on the node.
+type HostPortConflict struct {
+	NodeName string
+	Pod      string
+	Port     int32
+}
+
+func (c HostPortConflict) String() string {
+	return fmt.Sprintf("port %d on node %s is held by pod %s", c.Port, c.NodeName, c.Pod)
+}
+
+// FormatHostPortConflicts returns a human readable list of conflicts.
+func FormatHostPortConflicts(conflicts []HostPortConflict) string {
+	msgs := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		msgs = append(msgs, c.String())
+	}
+	return strings.Join(msgs, ", ")
+}
+
+type hostPortValidator struct {
+	client client.Reader
+}
+
+// NewHostPortValidator returns a HostPortValidator. The pods of all the
+// namespaces are listed, hence an uncached reader should be used, as the cache
+// of the manager may be restricted to the operator namespace.
+func NewHostPortValidator(c client.Reader) *hostPortValidator {
+	return &hostPortValidator{client: c}
+}
+
+// CheckDeviceConfigForConflictingHostPorts lists the pods, other than the node
+// metrics ones of the DeviceConfig, that hold the node metrics ports on the
+// nodes selected by the DeviceConfig.
+func (hpv *hostPortValidator) CheckDeviceConfigForConflictingHostPorts(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) ([]HostPortConflict, error) {
+	ports := nodeMetrics.GetNodeMetricsHostPorts(cr)
+	if len(ports) == 0 {
+		return nil, nil
+	}
+
+	nodeList := &v1.NodeList{}
+	err := hpv.client.List(ctx, nodeList, client.MatchingLabelsSelector{Selector: labels.Set(cr.GetNodeSelector()).AsSelector()})
+	if err != nil {
+		return nil, err
+	}
+
+	podsByNode, err := hpv.listPodsByNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ownLabels := labels.Set(nodeMetrics.GetNodeMetricsServiceLabels(cr)).AsSelector()
+
+	conflicts := []HostPortConflict{}
+	for _, node := range nodeList.Items {
+		for _, pod := range podsByNode[node.Name] {
+			if ownLabels.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+
+			for _, port := range ports {
+				if holdsHostPort(pod, port) {
+					conflicts = append(conflicts, HostPortConflict{
+						NodeName: node.Name,
+						Pod:      fmt.Sprintf("%s/%s", pod.Namespace, pod.Name),
+						Port:     port,
+					})
+				}
+			}
+		}
+	}
+
+	return conflicts, nil
+}
+
+// listPodsByNode lists the scheduled pods that are not terminated once, and
+// indexes them by node name.
+func (hpv *hostPortValidator) listPodsByNode(ctx context.Context) (map[string][]*v1.Pod, error) {
+	podList := &v1.PodList{}
+	err := hpv.client.List(ctx, podList, client.MatchingFieldsSelector{Selector: fields.AndSelectors(
+		fields.OneTermNotEqualSelector("spec.nodeName", ""),
+		fields.OneTermNotEqualSelector("status.phase", string(v1.PodSucceeded)),
+		fields.OneTermNotEqualSelector("status.phase", string(v1.PodFailed)),
+	)})
+	if err != nil {
+		return nil, err
+	}
+
+	podsByNode := map[string][]*v1.Pod{}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Spec.NodeName == "" || isTerminated(pod) {
+			continue
+		}
+		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+	}
+
+	return podsByNode, nil
+}
+
+func isTerminated(pod *v1.Pod) bool {
+	return pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+}
+
+// holdsHostPort returns true if the pod holds the given TCP port on its node,
+// with a hostPort or as a container port on the host network.
+func holdsHostPort(pod *v1.Pod, port int32) bool {
+	containers := make([]v1.Container, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	containers = append(containers, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+
+	for _, c := range containers {
+		for _, p := range c.Ports {
+			if p.Protocol != "" && p.Protocol != v1.ProtocolTCP {
+				continue
+			}
+
+			hostPort := p.HostPort
+			if pod.Spec.HostNetwork && hostPort == 0 {
+				hostPort = p.ContainerPort
+			}
+
+			if hostPort == port {
+				return true
+			}
+		}
+	}
+	return false
+}
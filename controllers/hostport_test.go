@@ -0,0 +1,152 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gomock "github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/client"
+	nodeMetrics "github.com/HabanaAI/habana-ai-operator/internal/node/metrics"
+)
+
+var _ = Describe("HostPortValidator", func() {
+	Describe("CheckDeviceConfigForConflictingHostPorts", func() {
+		node := makeTestNode(labelled(map[string]string{"matching": "label"}))
+		dc := makeTestDeviceConfig(nodeSelector(node.Labels))
+		port := dc.GetNodeMetricsPort()
+		ctx := context.TODO()
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+
+		check := func(cr *hlaiv1alpha1.DeviceConfig, objs ...ctrlclient.Object) []HostPortConflict {
+			c := fake.
+				NewClientBuilder().
+				WithScheme(s).
+				WithObjects(append([]ctrlclient.Object{node}, objs...)...).
+				Build()
+			hpv := NewHostPortValidator(c)
+
+			conflicts, err := hpv.CheckDeviceConfigForConflictingHostPorts(ctx, cr)
+			Expect(err).ToNot(HaveOccurred())
+			return conflicts
+		}
+
+		Context("with a client listing error", func() {
+			It("should return the error", func() {
+				gCtrl := gomock.NewController(GinkgoT())
+				c := client.NewMockClient(gCtrl)
+				c.EXPECT().
+					List(ctx, gomock.Any(), gomock.Any()).
+					Return(apierrors.NewServiceUnavailable("Service unavailable"))
+
+				_, err := NewHostPortValidator(c).CheckDeviceConfigForConflictingHostPorts(ctx, dc)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Service unavailable"))
+			})
+		})
+
+		Context("without any node metrics port held on the nodes", func() {
+			It("should not list anything", func() {
+				gCtrl := gomock.NewController(GinkgoT())
+				c := client.NewMockClient(gCtrl)
+				noHostPortDC := makeTestDeviceConfig(nodeSelector(node.Labels), func(c *hlaiv1alpha1.DeviceConfig) {
+					c.Spec.NodeMetrics.HostPort = pointer.Bool(false)
+				})
+
+				conflicts, err := NewHostPortValidator(c).CheckDeviceConfigForConflictingHostPorts(ctx, noHostPortDC)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(conflicts).To(BeEmpty())
+			})
+		})
+
+		Context("with a pod holding the port as a hostPort", func() {
+			It("should return the conflict", func() {
+				pod := makeTestPod("some-pod", node.Name, corev1.ContainerPort{ContainerPort: 8080, HostPort: port})
+
+				conflicts := check(dc, pod)
+				Expect(conflicts).To(Equal([]HostPortConflict{{NodeName: node.Name, Pod: "default/some-pod", Port: port}}))
+			})
+		})
+
+		Context("with a host network pod listening on the port", func() {
+			It("should return the conflict", func() {
+				pod := makeTestPod("some-pod", node.Name, corev1.ContainerPort{ContainerPort: port})
+				pod.Spec.HostNetwork = true
+
+				conflicts := check(dc, pod)
+				Expect(conflicts).To(HaveLen(1))
+			})
+		})
+
+		Context("with the port held on the host network of the node metrics", func() {
+			It("should return the conflict", func() {
+				hostNetworkDC := makeTestDeviceConfig(nodeSelector(node.Labels), func(c *hlaiv1alpha1.DeviceConfig) {
+					c.Spec.NodeMetrics.HostNetwork = true
+				})
+				pod := makeTestPod("some-pod", node.Name, corev1.ContainerPort{ContainerPort: port})
+				pod.Spec.HostNetwork = true
+
+				conflicts := check(hostNetworkDC, pod)
+				Expect(conflicts).To(Equal([]HostPortConflict{{NodeName: node.Name, Pod: "default/some-pod", Port: port}}))
+			})
+		})
+
+		Context("with pods not holding the port", func() {
+			It("should not return any conflict", func() {
+				own := makeTestPod("node-metrics", node.Name, corev1.ContainerPort{ContainerPort: port, HostPort: port})
+				own.Labels = nodeMetrics.GetNodeMetricsServiceLabels(dc)
+				otherNode := makeTestPod("other-node", "other-node", corev1.ContainerPort{ContainerPort: port, HostPort: port})
+				udp := makeTestPod("udp", node.Name, corev1.ContainerPort{ContainerPort: port, HostPort: port, Protocol: corev1.ProtocolUDP})
+				completed := makeTestPod("completed", node.Name, corev1.ContainerPort{ContainerPort: port, HostPort: port})
+				completed.Status.Phase = corev1.PodSucceeded
+				notHostNetwork := makeTestPod("not-host-network", node.Name, corev1.ContainerPort{ContainerPort: port})
+
+				conflicts := check(dc, own, otherNode, udp, completed, notHostNetwork)
+				Expect(conflicts).To(BeEmpty())
+			})
+		})
+	})
+})
+
+func makeTestPod(name, nodeName string, port corev1.ContainerPort) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+			Containers: []corev1.Container{
+				{
+					Name:  "main",
+					Ports: []corev1.ContainerPort{port},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: hostport.go
+
+// Package controllers is a generated GoMock package.
+package controllers
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockHostPortValidator is a mock of HostPortValidator interface.
+type MockHostPortValidator struct {
+	ctrl     *gomock.Controller
+	recorder *MockHostPortValidatorMockRecorder
+}
+
+// MockHostPortValidatorMockRecorder is the mock recorder for MockHostPortValidator.
+type MockHostPortValidatorMockRecorder struct {
+	mock *MockHostPortValidator
+}
+
+// NewMockHostPortValidator creates a new mock instance.
+func NewMockHostPortValidator(ctrl *gomock.Controller) *MockHostPortValidator {
+	mock := &MockHostPortValidator{ctrl: ctrl}
+	mock.recorder = &MockHostPortValidatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHostPortValidator) EXPECT() *MockHostPortValidatorMockRecorder {
+	return m.recorder
+}
+
+// CheckDeviceConfigForConflictingHostPorts mocks base method.
+func (m *MockHostPortValidator) CheckDeviceConfigForConflictingHostPorts(ctx context.Context, cr *v1alpha1.DeviceConfig) ([]HostPortConflict, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckDeviceConfigForConflictingHostPorts", ctx, cr)
+	ret0, _ := ret[0].([]HostPortConflict)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckDeviceConfigForConflictingHostPorts indicates an expected call of CheckDeviceConfigForConflictingHostPorts.
+func (mr *MockHostPortValidatorMockRecorder) CheckDeviceConfigForConflictingHostPorts(ctx, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckDeviceConfigForConflictingHostPorts", reflect.TypeOf((*MockHostPortValidator)(nil).CheckDeviceConfigForConflictingHostPorts), ctx, cr)
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/metrics"
+)
+
+// MaxAllocations is the maximum number of pods listed in the capacity status
+// of a DeviceConfig, to bound the size of the DeviceConfig.
+const MaxAllocations = 100
+
+//go:generate mockgen -source=capacity.go -package=capacity -destination=mock_capacity.go
+
+type Accountant interface {
+	AccountCapacity(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error
+}
+
+type accountant struct {
+	client client.Reader
+}
+
+// NewAccountant returns an Accountant. The pods of all the namespaces are
+// listed, hence an uncached reader should be used, as the cache of the manager
+// may be restricted to the operator namespace.
+func NewAccountant(c client.Reader) *accountant {
+	return &accountant{client: c}
+}
+
+// AccountCapacity sums up the HPUs allocatable on the nodes selected by the
+// DeviceConfig and the HPUs requested by the pods of these nodes, into the
+// DeviceConfig status and the operator metrics. Only the MaxAllocations pods
+// holding the most HPUs are listed in the status, the allocation of each
+// namespace is exported as metrics.
+func (a *accountant) AccountCapacity(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	nodeList := &corev1.NodeList{}
+	err := a.client.List(ctx, nodeList, client.MatchingLabelsSelector{Selector: labels.Set(cr.GetNodeSelector()).AsSelector()})
+	if err != nil {
+		return fmt.Errorf("could not list the DeviceConfig nodes: %w", err)
+	}
+
+	podsByNode, err := a.listPodsByNode(ctx)
+	if err != nil {
+		return err
+	}
+
+	status := &hlaiv1alpha1.CapacityStatus{}
+	byNamespace := map[string]int64{}
+	allocations := []hlaiv1alpha1.HPUAllocation{}
+	for _, node := range nodeList.Items {
+		nc := hlaiv1alpha1.NodeCapacity{NodeName: node.Name}
+		if q, ok := node.Status.Allocatable[constants.HabanaResourceName]; ok {
+			nc.Allocatable = q.Value()
+		}
+
+		for _, pod := range podsByNode[node.Name] {
+			devices := GetPodRequest(pod)
+			if devices == 0 {
+				continue
+			}
+
+			nc.Allocated += devices
+			byNamespace[pod.Namespace] += devices
+			allocations = append(allocations, hlaiv1alpha1.HPUAllocation{
+				Namespace: pod.Namespace,
+				Pod:       pod.Name,
+				NodeName:  node.Name,
+				Devices:   devices,
+			})
+		}
+
+		status.Allocatable += nc.Allocatable
+		status.Allocated += nc.Allocated
+		status.Nodes = append(status.Nodes, nc)
+	}
+
+	sort.Slice(status.Nodes, func(i, j int) bool { return status.Nodes[i].NodeName < status.Nodes[j].NodeName })
+	sort.Slice(allocations, func(i, j int) bool {
+		if allocations[i].Devices != allocations[j].Devices {
+			return allocations[i].Devices > allocations[j].Devices
+		}
+		if allocations[i].Namespace != allocations[j].Namespace {
+			return allocations[i].Namespace < allocations[j].Namespace
+		}
+		return allocations[i].Pod < allocations[j].Pod
+	})
+
+	status.AllocatingPods = int64(len(allocations))
+	if len(allocations) > MaxAllocations {
+		allocations = allocations[:MaxAllocations]
+	}
+	if len(allocations) > 0 {
+		status.Allocations = allocations
+	}
+
+	cr.Status.Capacity = status
+	metrics.SetHPUAllocation(cr.Name, status.Allocatable, byNamespace)
+
+	return nil
+}
+
+// listPodsByNode lists the scheduled pods that are not terminated once, and
+// indexes them by node name.
+func (a *accountant) listPodsByNode(ctx context.Context) (map[string][]*corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	err := a.client.List(ctx, podList, client.MatchingFieldsSelector{Selector: fields.AndSelectors(
+		fields.OneTermNotEqualSelector("spec.nodeName", ""),
+		fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+		fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+	)})
+	if err != nil {
+		return nil, fmt.Errorf("could not list the pods: %w", err)
+	}
+
+	podsByNode := map[string][]*corev1.Pod{}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Spec.NodeName == "" || isTerminated(pod) {
+			continue
+		}
+		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+	}
+
+	return podsByNode, nil
+}
+
+// GetPodRequest returns the number of HPUs requested by a pod, i.e. the
+// highest of the sum of the requests of its containers and of the request of
+// each of its init containers, as the scheduler does.
+func GetPodRequest(pod *corev1.Pod) int64 {
+	var total int64
+	for _, c := range pod.Spec.Containers {
+		total += containerRequest(&c)
+	}
+	for _, c := range pod.Spec.InitContainers {
+		if r := containerRequest(&c); r > total {
+			total = r
+		}
+	}
+	return total
+}
+
+// containerRequest returns the number of HPUs requested by a container.
+// Extended resources may only be set as limits, in which case the requests
+// default to the limits.
+func containerRequest(c *corev1.Container) int64 {
+	if q, ok := c.Resources.Requests[constants.HabanaResourceName]; ok {
+		return q.Value()
+	}
+	if q, ok := c.Resources.Limits[constants.HabanaResourceName]; ok {
+		return q.Value()
+	}
+	return 0
+}
+
+func isTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+
+	gomock "github.com/golang/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/client"
+	"github.com/HabanaAI/habana-ai-operator/internal/testutil"
+)
+
+var _ = Describe("AccountCapacity", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		ctx context.Context
+	)
+
+	selected := map[string]string{"hpu": "true"}
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+			Spec: hlaiv1alpha1.DeviceConfigSpec{
+				NodeSelector: selected,
+			},
+		}
+		ctx = context.TODO()
+	})
+
+	Context("with a client listing error", func() {
+		It("should return an error", func() {
+			c := client.NewMockClient(gomock.NewController(GinkgoT()))
+			c.EXPECT().
+				List(ctx, gomock.Any(), gomock.Any()).
+				Return(apierrors.NewServiceUnavailable("Service unavailable"))
+
+			err := NewAccountant(c).AccountCapacity(ctx, dc)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Service unavailable"))
+		})
+	})
+
+	Context("with pods holding HPUs", func() {
+		It("should report the capacity and the allocations of the selected nodes", func() {
+			completed := testutil.MakePod("team-a", "completed", testutil.OnNode("node-a"), testutil.RequestingHPUs(8), testutil.WithPhase(corev1.PodSucceeded))
+
+			objs := []ctrlclient.Object{
+				testutil.MakeNode("node-a", testutil.Labelled(selected), testutil.AdvertisingHPUs(8)),
+				testutil.MakeNode("node-b", testutil.Labelled(selected), testutil.AdvertisingHPUs(8)),
+				testutil.MakeNode("not-selected", testutil.AdvertisingHPUs(8)),
+				testutil.MakePod("team-a", "training", testutil.OnNode("node-a"), testutil.RequestingHPUs(4)),
+				testutil.MakePod("team-b", "inference", testutil.OnNode("node-b"), testutil.RequestingHPUs(1)),
+				testutil.MakePod("team-b", "cpu-only", testutil.OnNode("node-b")),
+				testutil.MakePod("team-c", "elsewhere", testutil.OnNode("not-selected"), testutil.RequestingHPUs(8)),
+				completed,
+			}
+
+			s := scheme.Scheme
+			Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+
+			Expect(NewAccountant(c).AccountCapacity(ctx, dc)).To(Succeed())
+
+			Expect(dc.Status.Capacity).To(Equal(&hlaiv1alpha1.CapacityStatus{
+				Allocatable:    16,
+				Allocated:      5,
+				AllocatingPods: 2,
+				Nodes: []hlaiv1alpha1.NodeCapacity{
+					{NodeName: "node-a", Allocatable: 8, Allocated: 4},
+					{NodeName: "node-b", Allocatable: 8, Allocated: 1},
+				},
+				Allocations: []hlaiv1alpha1.HPUAllocation{
+					{Namespace: "team-a", Pod: "training", NodeName: "node-a", Devices: 4},
+					{Namespace: "team-b", Pod: "inference", NodeName: "node-b", Devices: 1},
+				},
+			}))
+		})
+	})
+
+	Context("with more pods holding HPUs than listed in the status", func() {
+		It("should only list the pods holding the most HPUs", func() {
+			objs := []ctrlclient.Object{testutil.MakeNode("node-a", testutil.Labelled(selected), testutil.AdvertisingHPUs(1000))}
+			for i := 0; i < MaxAllocations+10; i++ {
+				objs = append(objs, testutil.MakePod("team-a", fmt.Sprintf("pod-%03d", i), testutil.OnNode("node-a"), testutil.RequestingHPUs(1)))
+			}
+			objs = append(objs, testutil.MakePod("team-b", "largest", testutil.OnNode("node-a"), testutil.RequestingHPUs(8)))
+
+			s := scheme.Scheme
+			Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+
+			Expect(NewAccountant(c).AccountCapacity(ctx, dc)).To(Succeed())
+
+			Expect(dc.Status.Capacity.Allocated).To(BeEquivalentTo(MaxAllocations + 18))
+			Expect(dc.Status.Capacity.AllocatingPods).To(BeEquivalentTo(MaxAllocations + 11))
+			Expect(dc.Status.Capacity.Allocations).To(HaveLen(MaxAllocations))
+			Expect(dc.Status.Capacity.Allocations[0].Pod).To(Equal("largest"))
+		})
+	})
+})
+
+var _ = Describe("GetPodRequest", func() {
+	It("should return the highest of the containers and init containers requests", func() {
+		pod := testutil.MakePod("a-namespace", "a-pod", testutil.OnNode("a-node"), testutil.RequestingHPUs(2))
+		pod.Spec.Containers = append(pod.Spec.Containers, pod.Spec.Containers[0])
+		Expect(GetPodRequest(pod)).To(BeEquivalentTo(4))
+
+		pod.Spec.InitContainers = []corev1.Container{testutil.MakePod("a-namespace", "a-pod", testutil.OnNode("a-node"), testutil.RequestingHPUs(8)).Spec.Containers[0]}
+		Expect(GetPodRequest(pod)).To(BeEquivalentTo(8))
+	})
+})
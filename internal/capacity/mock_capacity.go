@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: capacity.go
+
+// Package capacity is a generated GoMock package.
+package capacity
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAccountant is a mock of Accountant interface.
+type MockAccountant struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountantMockRecorder
+}
+
+// MockAccountantMockRecorder is the mock recorder for MockAccountant.
+type MockAccountantMockRecorder struct {
+	mock *MockAccountant
+}
+
+// NewMockAccountant creates a new mock instance.
+func NewMockAccountant(ctrl *gomock.Controller) *MockAccountant {
+	mock := &MockAccountant{ctrl: ctrl}
+	mock.recorder = &MockAccountantMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountant) EXPECT() *MockAccountantMockRecorder {
+	return m.recorder
+}
+
+// AccountCapacity mocks base method.
+func (m *MockAccountant) AccountCapacity(ctx context.Context, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccountCapacity", ctx, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AccountCapacity indicates an expected call of AccountCapacity.
+func (mr *MockAccountantMockRecorder) AccountCapacity(ctx, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountCapacity", reflect.TypeOf((*MockAccountant)(nil).AccountCapacity), ctx, cr)
+}
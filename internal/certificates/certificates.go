@@ -0,0 +1,503 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	nodeMetrics "github.com/HabanaAI/habana-ai-operator/internal/node/metrics"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	certificatesSuffix = "certificates"
+
+	// InjectCABundleAnnotation asks the OpenShift service CA operator to
+	// inject its CA bundle into a ConfigMap.
+	InjectCABundleAnnotation = "service.beta.openshift.io/inject-cabundle"
+
+	caValidity      = 2 * 365 * 24 * time.Hour
+	servingValidity = 90 * 24 * time.Hour
+)
+
+// ServiceCAGVK is the GroupVersionKind of the OpenShift service CA operator
+// configuration, which is only served on OpenShift.
+var ServiceCAGVK = schema.GroupVersionKind{
+	Group:   "operator.openshift.io",
+	Version: "v1",
+	Kind:    "ServiceCA",
+}
+
+//go:generate mockgen -source=certificates.go -package=certificates -destination=mock_certificates.go
+
+type Reconciler interface {
+	ReconcileCertificates(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	ReconcileServiceCABundle(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	DeleteCertificates(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	ReconcileCASecret(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) (*corev1.Secret, error)
+	SetDesiredCASecret(secret *corev1.Secret, cr *hlaiv1alpha1.DeviceConfig) error
+	ReconcileServingSecret(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig, ca *corev1.Secret) error
+	SetDesiredServingSecret(secret *corev1.Secret, cr *hlaiv1alpha1.DeviceConfig, ca *corev1.Secret) error
+	ReconcileCABundle(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig, ca *corev1.Secret) error
+	SetDesiredCABundle(cm *corev1.ConfigMap, cr *hlaiv1alpha1.DeviceConfig, ca *corev1.Secret) error
+	SetDesiredServiceCABundle(cm *corev1.ConfigMap, cr *hlaiv1alpha1.DeviceConfig) error
+}
+
+type CertificatesReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+	now    func() time.Time
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *CertificatesReconciler {
+	return &CertificatesReconciler{
+		client: c,
+		scheme: s,
+		now:    time.Now,
+	}
+}
+
+// GetCASecretName returns the name of the Secret holding the CA that issues
+// the node metrics serving certificate.
+func GetCASecretName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-ca", nodeMetrics.GetNodeMetricsName(cr))
+}
+
+// GetServingDNSNames returns the DNS names the node metrics serving
+// certificate is issued for.
+func GetServingDNSNames(cr *hlaiv1alpha1.DeviceConfig) []string {
+	name := nodeMetrics.GetNodeMetricsName(cr)
+	return []string{
+		name,
+		fmt.Sprintf("%s.%s", name, s.Settings.OperatorNamespace),
+		nodeMetrics.GetNodeMetricsServiceHostname(cr),
+		fmt.Sprintf("%s.cluster.local", nodeMetrics.GetNodeMetricsServiceHostname(cr)),
+	}
+}
+
+// ReconcileCertificates issues the node metrics serving certificate with a
+// CA managed by the operator, and publishes the CA in the CA bundle ConfigMap.
+// Both certificates are renewed once less than a third of their validity remains.
+func (r *CertificatesReconciler) ReconcileCertificates(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	ca, err := r.ReconcileCASecret(ctx, cr)
+	if err != nil {
+		return err
+	}
+
+	err = r.ReconcileServingSecret(ctx, cr, ca)
+	if err != nil {
+		return err
+	}
+
+	return r.ReconcileCABundle(ctx, cr, ca)
+}
+
+// ReconcileServiceCABundle creates the CA bundle ConfigMap for the OpenShift
+// service CA operator to inject its CA into. The serving certificate itself is
+// issued and rotated by the service CA operator, see the node metrics Service.
+func (r *CertificatesReconciler) ReconcileServiceCABundle(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	logger := log.FromContext(ctx)
+
+	cm, err := r.getOrNewConfigMap(ctx, nodeMetrics.GetNodeMetricsCABundleName(cr))
+	if err != nil {
+		return err
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, cm, func() error {
+		return r.SetDesiredServiceCABundle(cm, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch ConfigMap: %v", err)
+	}
+
+	logger.Info("Reconciled ConfigMap", "resource", cm.Name, "result", res)
+
+	return nil
+}
+
+func (r *CertificatesReconciler) ReconcileCASecret(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) (*corev1.Secret, error) {
+	logger := log.FromContext(ctx)
+
+	secret, err := r.getOrNewSecret(ctx, GetCASecretName(cr))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, secret, func() error {
+		return r.SetDesiredCASecret(secret, cr)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("could not create or patch Secret: %v", err)
+	}
+
+	logger.Info("Reconciled Secret", "resource", secret.Name, "result", res)
+
+	return secret, nil
+}
+
+func (r *CertificatesReconciler) ReconcileServingSecret(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, ca *corev1.Secret) error {
+	logger := log.FromContext(ctx)
+
+	secret, err := r.getOrNewSecret(ctx, nodeMetrics.GetNodeMetricsTLSSecretName(cr))
+	if err != nil {
+		return err
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, secret, func() error {
+		return r.SetDesiredServingSecret(secret, cr, ca)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch Secret: %v", err)
+	}
+
+	logger.Info("Reconciled Secret", "resource", secret.Name, "result", res)
+
+	return nil
+}
+
+func (r *CertificatesReconciler) ReconcileCABundle(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, ca *corev1.Secret) error {
+	logger := log.FromContext(ctx)
+
+	cm, err := r.getOrNewConfigMap(ctx, nodeMetrics.GetNodeMetricsCABundleName(cr))
+	if err != nil {
+		return err
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, cm, func() error {
+		return r.SetDesiredCABundle(cm, cr, ca)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch ConfigMap: %v", err)
+	}
+
+	logger.Info("Reconciled ConfigMap", "resource", cm.Name, "result", res)
+
+	return nil
+}
+
+func (r *CertificatesReconciler) getOrNewSecret(ctx context.Context, name string) (*corev1.Secret, error) {
+	existingSecret := &corev1.Secret{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: name}, existingSecret)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if exists {
+		return existingSecret, nil
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}, nil
+}
+
+func (r *CertificatesReconciler) getOrNewConfigMap(ctx context.Context, name string) (*corev1.ConfigMap, error) {
+	existingCM := &corev1.ConfigMap{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: name}, existingCM)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if exists {
+		return existingCM, nil
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}, nil
+}
+
+func (r *CertificatesReconciler) DeleteCertificates(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	objs := []client.Object{
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: nodeMetrics.GetNodeMetricsCABundleName(cr)}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: nodeMetrics.GetNodeMetricsTLSSecretName(cr)}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: GetCASecretName(cr)}},
+	}
+
+	for _, o := range objs {
+		o.SetNamespace(s.Settings.OperatorNamespace)
+
+		err := r.client.Delete(ctx, o)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %T %s: %w", o, o.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// SetDesiredCASecret keeps the CA of the Secret as long as it is valid and
+// does not need to be renewed, otherwise it issues a new self-signed CA.
+func (r *CertificatesReconciler) SetDesiredCASecret(secret *corev1.Secret, cr *hlaiv1alpha1.DeviceConfig) error {
+	if secret == nil {
+		return errors.New("secret cannot be nil")
+	}
+
+	secret.ObjectMeta.Labels = labelsForCertificates(cr)
+	secret.Type = corev1.SecretTypeTLS
+
+	if cert, _, err := parseKeyPair(secret); err == nil && cert.IsCA && !r.needsRenewal(cert) {
+		return nil
+	}
+
+	now := r.now()
+	template := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName: fmt.Sprintf("%s-%s", constants.HabanaAIOperatorName, GetCASecretName(cr)),
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	certPEM, keyPEM, err := issue(template, nil, nil)
+	if err != nil {
+		return fmt.Errorf("could not issue the CA certificate: %w", err)
+	}
+
+	secret.Data = map[string][]byte{
+		corev1.TLSCertKey:       certPEM,
+		corev1.TLSPrivateKeyKey: keyPEM,
+	}
+
+	return nil
+}
+
+// SetDesiredServingSecret keeps the serving certificate of the Secret as long
+// as it is issued by the given CA for the node metrics Service, and does not need
+// to be renewed, otherwise it issues a new one.
+func (r *CertificatesReconciler) SetDesiredServingSecret(secret *corev1.Secret, cr *hlaiv1alpha1.DeviceConfig, ca *corev1.Secret) error {
+	if secret == nil {
+		return errors.New("secret cannot be nil")
+	}
+
+	caCert, caKey, err := parseKeyPair(ca)
+	if err != nil {
+		return fmt.Errorf("invalid CA: %w", err)
+	}
+
+	secret.ObjectMeta.Labels = labelsForCertificates(cr)
+	secret.Type = corev1.SecretTypeTLS
+
+	dnsNames := GetServingDNSNames(cr)
+
+	if cert, _, err := parseKeyPair(secret); err == nil &&
+		cert.CheckSignatureFrom(caCert) == nil &&
+		equalStrings(cert.DNSNames, dnsNames) &&
+		!r.needsRenewal(cert) {
+		return nil
+	}
+
+	now := r.now()
+	template := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName: nodeMetrics.GetNodeMetricsServiceHostname(cr),
+		},
+		DNSNames:    dnsNames,
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(servingValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	certPEM, keyPEM, err := issue(template, caCert, caKey)
+	if err != nil {
+		return fmt.Errorf("could not issue the serving certificate: %w", err)
+	}
+
+	secret.Data = map[string][]byte{
+		corev1.TLSCertKey:       certPEM,
+		corev1.TLSPrivateKeyKey: keyPEM,
+	}
+
+	return nil
+}
+
+// SetDesiredCABundle publishes the CA in the CA bundle. The CAs already in
+// the bundle are kept until they expire, so that the serving certificates they
+// issued are still trusted until the pods pick up the renewed certificate.
+func (r *CertificatesReconciler) SetDesiredCABundle(cm *corev1.ConfigMap, cr *hlaiv1alpha1.DeviceConfig, ca *corev1.Secret) error {
+	if cm == nil {
+		return errors.New("configmap cannot be nil")
+	}
+
+	caCert, _, err := parseKeyPair(ca)
+	if err != nil {
+		return fmt.Errorf("invalid CA: %w", err)
+	}
+
+	cm.ObjectMeta.Labels = labelsForCertificates(cr)
+	delete(cm.ObjectMeta.Annotations, InjectCABundleAnnotation)
+
+	bundle := &bytes.Buffer{}
+	bundle.Write(ca.Data[corev1.TLSCertKey])
+
+	rest := []byte(cm.Data[nodeMetrics.CABundleKey])
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil || cert.Equal(caCert) || r.now().After(cert.NotAfter) {
+			continue
+		}
+
+		if err := pem.Encode(bundle, block); err != nil {
+			return err
+		}
+	}
+
+	cm.Data = map[string]string{
+		nodeMetrics.CABundleKey: bundle.String(),
+	}
+
+	return nil
+}
+
+// SetDesiredServiceCABundle annotates the ConfigMap for the OpenShift service
+// CA operator to inject its CA bundle into it.
+func (r *CertificatesReconciler) SetDesiredServiceCABundle(cm *corev1.ConfigMap, cr *hlaiv1alpha1.DeviceConfig) error {
+	if cm == nil {
+		return errors.New("configmap cannot be nil")
+	}
+
+	cm.ObjectMeta.Labels = labelsForCertificates(cr)
+
+	if cm.ObjectMeta.Annotations == nil {
+		cm.ObjectMeta.Annotations = make(map[string]string)
+	}
+	cm.ObjectMeta.Annotations[InjectCABundleAnnotation] = "true"
+
+	return nil
+}
+
+// needsRenewal returns true once less than a third of the validity of the
+// certificate remains.
+func (r *CertificatesReconciler) needsRenewal(cert *x509.Certificate) bool {
+	validity := cert.NotAfter.Sub(cert.NotBefore)
+	return r.now().After(cert.NotAfter.Add(-validity / 3))
+}
+
+func parseKeyPair(secret *corev1.Secret) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	if secret == nil {
+		return nil, nil, errors.New("secret cannot be nil")
+	}
+
+	pair, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("unsupported private key type")
+	}
+
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, key, nil
+}
+
+// issue generates a key and a certificate from the template, signed by the
+// given parent. The certificate is self-signed if the parent is nil.
+func issue(template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template.SerialNumber = serial
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	return certPEM, keyPEM, nil
+}
+
+func equalStrings(a, b []string) bool {
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
+
+// labelsForCertificates returns the labels for selecting the
+// resources belonging to the given DeviceConfig CR name.
+func labelsForCertificates(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
+		"app.kubernetes.io/component": certificatesSuffix,
+		constants.DeviceConfigLabel:   cr.Name,
+	}
+}
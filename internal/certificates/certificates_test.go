@@ -0,0 +1,315 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/client"
+	nodeMetrics "github.com/HabanaAI/habana-ai-operator/internal/node/metrics"
+)
+
+func parseCertificates(data []byte) []*x509.Certificate {
+	certs := []*x509.Certificate{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+		certs = append(certs, cert)
+	}
+}
+
+var _ = Describe("CertificatesReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		r   *CertificatesReconciler
+		c   *client.MockClient
+		ctx context.Context
+		now time.Time
+	)
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+			Spec: hlaiv1alpha1.DeviceConfigSpec{
+				NodeMetrics: hlaiv1alpha1.NodeMetricsSpec{
+					Secure: true,
+				},
+			},
+		}
+		c = client.NewMockClient(gomock.NewController(GinkgoT()))
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+
+		now = time.Now()
+
+		r = NewReconciler(c, s)
+		r.now = func() time.Time { return now }
+
+		ctx = context.TODO()
+	})
+
+	Describe("ReconcileCertificates", func() {
+		Context("with no client Get error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().
+						Get(ctx, gomock.Any(), gomock.Any()).
+						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, GetCASecretName(dc))).
+						AnyTimes(),
+				)
+			})
+
+			Context("with no client Create error", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().Create(ctx, gomock.Any()).Return(nil).Times(3),
+					)
+				})
+
+				It("should not return an error", func() {
+					Expect(r.ReconcileCertificates(ctx, dc)).ToNot(HaveOccurred())
+				})
+			})
+
+			Context("with client Create error", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().Create(ctx, gomock.Any()).Return(errors.New("some-error")),
+					)
+				})
+
+				It("should return an error", func() {
+					Expect(r.ReconcileCertificates(ctx, dc)).To(HaveOccurred())
+				})
+			})
+		})
+
+		Context("with client Get error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some-other-that-not-found-error")),
+				)
+			})
+
+			It("should return an error", func() {
+				Expect(r.ReconcileCertificates(ctx, dc)).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("DeleteCertificates", func() {
+		Context("with NotFound client Delete errors", func() {
+			BeforeEach(func() {
+				c.EXPECT().
+					Delete(ctx, gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{}, "")).
+					Times(3)
+			})
+
+			It("should not return an error", func() {
+				Expect(r.DeleteCertificates(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with a generic client Delete error", func() {
+			BeforeEach(func() {
+				c.EXPECT().Delete(ctx, gomock.Any()).Return(errors.New("some-error"))
+			})
+
+			It("should return an error", func() {
+				Expect(r.DeleteCertificates(ctx, dc)).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("SetDesiredCASecret", func() {
+		It("should return an error with a nil Secret as input", func() {
+			err := r.SetDesiredCASecret(nil, dc)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("secret cannot be nil"))
+		})
+
+		It("should issue a CA", func() {
+			ca := &corev1.Secret{}
+			Expect(r.SetDesiredCASecret(ca, dc)).ToNot(HaveOccurred())
+			Expect(ca.Type).To(Equal(corev1.SecretTypeTLS))
+
+			cert, _, err := parseKeyPair(ca)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cert.IsCA).To(BeTrue())
+		})
+
+		It("should keep a valid CA", func() {
+			ca := &corev1.Secret{}
+			Expect(r.SetDesiredCASecret(ca, dc)).ToNot(HaveOccurred())
+			issued := ca.Data[corev1.TLSCertKey]
+
+			Expect(r.SetDesiredCASecret(ca, dc)).ToNot(HaveOccurred())
+			Expect(ca.Data[corev1.TLSCertKey]).To(Equal(issued))
+		})
+
+		It("should renew a CA once less than a third of its validity remains", func() {
+			ca := &corev1.Secret{}
+			Expect(r.SetDesiredCASecret(ca, dc)).ToNot(HaveOccurred())
+			issued := ca.Data[corev1.TLSCertKey]
+
+			now = now.Add(caValidity * 3 / 4)
+
+			Expect(r.SetDesiredCASecret(ca, dc)).ToNot(HaveOccurred())
+			Expect(ca.Data[corev1.TLSCertKey]).ToNot(Equal(issued))
+		})
+	})
+
+	Describe("SetDesiredServingSecret", func() {
+		var ca *corev1.Secret
+
+		BeforeEach(func() {
+			ca = &corev1.Secret{}
+			Expect(r.SetDesiredCASecret(ca, dc)).ToNot(HaveOccurred())
+		})
+
+		It("should return an error with a nil Secret as input", func() {
+			err := r.SetDesiredServingSecret(nil, dc, ca)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("secret cannot be nil"))
+		})
+
+		It("should return an error with an invalid CA", func() {
+			Expect(r.SetDesiredServingSecret(&corev1.Secret{}, dc, &corev1.Secret{})).To(HaveOccurred())
+		})
+
+		It("should issue a serving certificate for the node metrics Service", func() {
+			secret := &corev1.Secret{}
+			Expect(r.SetDesiredServingSecret(secret, dc, ca)).ToNot(HaveOccurred())
+
+			cert, _, err := parseKeyPair(secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cert.DNSNames).To(ContainElement(nodeMetrics.GetNodeMetricsServiceHostname(dc)))
+
+			caCert, _, err := parseKeyPair(ca)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cert.CheckSignatureFrom(caCert)).ToNot(HaveOccurred())
+		})
+
+		It("should keep a valid serving certificate", func() {
+			secret := &corev1.Secret{}
+			Expect(r.SetDesiredServingSecret(secret, dc, ca)).ToNot(HaveOccurred())
+			issued := secret.Data[corev1.TLSCertKey]
+
+			Expect(r.SetDesiredServingSecret(secret, dc, ca)).ToNot(HaveOccurred())
+			Expect(secret.Data[corev1.TLSCertKey]).To(Equal(issued))
+		})
+
+		It("should issue a new serving certificate when the CA is renewed", func() {
+			secret := &corev1.Secret{}
+			Expect(r.SetDesiredServingSecret(secret, dc, ca)).ToNot(HaveOccurred())
+			issued := secret.Data[corev1.TLSCertKey]
+
+			renewed := &corev1.Secret{}
+			Expect(r.SetDesiredCASecret(renewed, dc)).ToNot(HaveOccurred())
+
+			Expect(r.SetDesiredServingSecret(secret, dc, renewed)).ToNot(HaveOccurred())
+			Expect(secret.Data[corev1.TLSCertKey]).ToNot(Equal(issued))
+		})
+
+		It("should renew a serving certificate once less than a third of its validity remains", func() {
+			secret := &corev1.Secret{}
+			Expect(r.SetDesiredServingSecret(secret, dc, ca)).ToNot(HaveOccurred())
+			issued := secret.Data[corev1.TLSCertKey]
+
+			now = now.Add(servingValidity * 3 / 4)
+
+			Expect(r.SetDesiredServingSecret(secret, dc, ca)).ToNot(HaveOccurred())
+			Expect(secret.Data[corev1.TLSCertKey]).ToNot(Equal(issued))
+		})
+	})
+
+	Describe("SetDesiredCABundle", func() {
+		var ca *corev1.Secret
+
+		BeforeEach(func() {
+			ca = &corev1.Secret{}
+			Expect(r.SetDesiredCASecret(ca, dc)).ToNot(HaveOccurred())
+		})
+
+		It("should return an error with a nil ConfigMap as input", func() {
+			err := r.SetDesiredCABundle(nil, dc, ca)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("configmap cannot be nil"))
+		})
+
+		It("should publish the CA", func() {
+			cm := &corev1.ConfigMap{}
+			Expect(r.SetDesiredCABundle(cm, dc, ca)).ToNot(HaveOccurred())
+			Expect(cm.Data).To(HaveKeyWithValue(nodeMetrics.CABundleKey, string(ca.Data[corev1.TLSCertKey])))
+		})
+
+		It("should keep the previous CA until it expires", func() {
+			cm := &corev1.ConfigMap{}
+			Expect(r.SetDesiredCABundle(cm, dc, ca)).ToNot(HaveOccurred())
+
+			renewed := &corev1.Secret{}
+			Expect(r.SetDesiredCASecret(renewed, dc)).ToNot(HaveOccurred())
+
+			Expect(r.SetDesiredCABundle(cm, dc, renewed)).ToNot(HaveOccurred())
+			Expect(parseCertificates([]byte(cm.Data[nodeMetrics.CABundleKey]))).To(HaveLen(2))
+
+			now = now.Add(caValidity + time.Hour)
+
+			Expect(r.SetDesiredCABundle(cm, dc, renewed)).ToNot(HaveOccurred())
+			Expect(parseCertificates([]byte(cm.Data[nodeMetrics.CABundleKey]))).To(HaveLen(1))
+		})
+	})
+
+	Describe("SetDesiredServiceCABundle", func() {
+		It("should return an error with a nil ConfigMap as input", func() {
+			err := r.SetDesiredServiceCABundle(nil, dc)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("configmap cannot be nil"))
+		})
+
+		It("should ask the service CA operator to inject its CA bundle", func() {
+			cm := &corev1.ConfigMap{}
+			Expect(r.SetDesiredServiceCABundle(cm, dc)).ToNot(HaveOccurred())
+			Expect(cm.Annotations).To(HaveKeyWithValue(InjectCABundleAnnotation, "true"))
+		})
+	})
+})
@@ -0,0 +1,178 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: certificates.go
+
+// Package certificates is a generated GoMock package.
+package certificates
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/api/core/v1"
+)
+
+// MockReconciler is a mock of Reconciler interface.
+type MockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcilerMockRecorder
+}
+
+// MockReconcilerMockRecorder is the mock recorder for MockReconciler.
+type MockReconcilerMockRecorder struct {
+	mock *MockReconciler
+}
+
+// NewMockReconciler creates a new mock instance.
+func NewMockReconciler(ctrl *gomock.Controller) *MockReconciler {
+	mock := &MockReconciler{ctrl: ctrl}
+	mock.recorder = &MockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// DeleteCertificates mocks base method.
+func (m *MockReconciler) DeleteCertificates(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCertificates", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCertificates indicates an expected call of DeleteCertificates.
+func (mr *MockReconcilerMockRecorder) DeleteCertificates(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCertificates", reflect.TypeOf((*MockReconciler)(nil).DeleteCertificates), ctx, dc)
+}
+
+// ReconcileCABundle mocks base method.
+func (m *MockReconciler) ReconcileCABundle(ctx context.Context, dc *v1alpha1.DeviceConfig, ca *v1.Secret) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileCABundle", ctx, dc, ca)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileCABundle indicates an expected call of ReconcileCABundle.
+func (mr *MockReconcilerMockRecorder) ReconcileCABundle(ctx, dc, ca interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileCABundle", reflect.TypeOf((*MockReconciler)(nil).ReconcileCABundle), ctx, dc, ca)
+}
+
+// ReconcileCASecret mocks base method.
+func (m *MockReconciler) ReconcileCASecret(ctx context.Context, dc *v1alpha1.DeviceConfig) (*v1.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileCASecret", ctx, dc)
+	ret0, _ := ret[0].(*v1.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileCASecret indicates an expected call of ReconcileCASecret.
+func (mr *MockReconcilerMockRecorder) ReconcileCASecret(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileCASecret", reflect.TypeOf((*MockReconciler)(nil).ReconcileCASecret), ctx, dc)
+}
+
+// ReconcileCertificates mocks base method.
+func (m *MockReconciler) ReconcileCertificates(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileCertificates", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileCertificates indicates an expected call of ReconcileCertificates.
+func (mr *MockReconcilerMockRecorder) ReconcileCertificates(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileCertificates", reflect.TypeOf((*MockReconciler)(nil).ReconcileCertificates), ctx, dc)
+}
+
+// ReconcileServiceCABundle mocks base method.
+func (m *MockReconciler) ReconcileServiceCABundle(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileServiceCABundle", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileServiceCABundle indicates an expected call of ReconcileServiceCABundle.
+func (mr *MockReconcilerMockRecorder) ReconcileServiceCABundle(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileServiceCABundle", reflect.TypeOf((*MockReconciler)(nil).ReconcileServiceCABundle), ctx, dc)
+}
+
+// ReconcileServingSecret mocks base method.
+func (m *MockReconciler) ReconcileServingSecret(ctx context.Context, dc *v1alpha1.DeviceConfig, ca *v1.Secret) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileServingSecret", ctx, dc, ca)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileServingSecret indicates an expected call of ReconcileServingSecret.
+func (mr *MockReconcilerMockRecorder) ReconcileServingSecret(ctx, dc, ca interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileServingSecret", reflect.TypeOf((*MockReconciler)(nil).ReconcileServingSecret), ctx, dc, ca)
+}
+
+// SetDesiredCABundle mocks base method.
+func (m *MockReconciler) SetDesiredCABundle(cm *v1.ConfigMap, cr *v1alpha1.DeviceConfig, ca *v1.Secret) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredCABundle", cm, cr, ca)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredCABundle indicates an expected call of SetDesiredCABundle.
+func (mr *MockReconcilerMockRecorder) SetDesiredCABundle(cm, cr, ca interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredCABundle", reflect.TypeOf((*MockReconciler)(nil).SetDesiredCABundle), cm, cr, ca)
+}
+
+// SetDesiredCASecret mocks base method.
+func (m *MockReconciler) SetDesiredCASecret(secret *v1.Secret, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredCASecret", secret, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredCASecret indicates an expected call of SetDesiredCASecret.
+func (mr *MockReconcilerMockRecorder) SetDesiredCASecret(secret, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredCASecret", reflect.TypeOf((*MockReconciler)(nil).SetDesiredCASecret), secret, cr)
+}
+
+// SetDesiredServiceCABundle mocks base method.
+func (m *MockReconciler) SetDesiredServiceCABundle(cm *v1.ConfigMap, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredServiceCABundle", cm, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredServiceCABundle indicates an expected call of SetDesiredServiceCABundle.
+func (mr *MockReconcilerMockRecorder) SetDesiredServiceCABundle(cm, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredServiceCABundle", reflect.TypeOf((*MockReconciler)(nil).SetDesiredServiceCABundle), cm, cr)
+}
+
+// SetDesiredServingSecret mocks base method.
+func (m *MockReconciler) SetDesiredServingSecret(secret *v1.Secret, cr *v1alpha1.DeviceConfig, ca *v1.Secret) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredServingSecret", secret, cr, ca)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredServingSecret indicates an expected call of SetDesiredServingSecret.
+func (mr *MockReconcilerMockRecorder) SetDesiredServingSecret(secret, cr, ca interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredServingSecret", reflect.TypeOf((*MockReconciler)(nil).SetDesiredServingSecret), secret, cr, ca)
+}
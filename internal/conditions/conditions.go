@@ -15,6 +15,7 @@ package conditions
 
 import (
 	"context"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,18 +29,54 @@ const (
 
 	Errored = "Errored"
 
-	ReasonModuleFailed      = "ModuleFailed"
-	ReasonNodeLabelerFailed = "NodeLabelerFailed"
-	ReasonNodeMetricsFailed = "NodeMetricsFailed"
+	ReasonRBACFailed             = "RBACFailed"
+	ReasonModuleFailed           = "ModuleFailed"
+	ReasonNodeLabelerFailed      = "NodeLabelerFailed"
+	ReasonNodeMetricsFailed      = "NodeMetricsFailed"
+	ReasonNodeCleanupFailed      = "NodeCleanupFailed"
+	ReasonMonitoringFailed       = "MonitoringFailed"
+	ReasonCertificatesFailed     = "CertificatesFailed"
+	ReasonTelemetryFailed        = "TelemetryFailed"
+	ReasonCapacityFailed         = "CapacityFailed"
+	ReasonNodeHealthFailed       = "NodeHealthFailed"
+	ReasonNodeReadinessFailed    = "NodeReadinessFailed"
+	ReasonCDIFailed              = "CDIFailed"
+	ReasonContainerRuntimeFailed = "ContainerRuntimeFailed"
+	ReasonNetworkFailed          = "NetworkFailed"
+	ReasonHugePagesFailed        = "HugePagesFailed"
+	ReasonTopologyFailed         = "TopologyFailed"
+	ReasonKubeletFailed          = "KubeletFailed"
+	ReasonSimulationFailed       = "SimulationFailed"
+	ReasonDriverFailed           = "DriverFailed"
 
 	ReasonConflictingNodeSelector = "ConflictingNodeSelector"
+	ReasonHostPortConflict        = "HostPortConflict"
+
+	ReasonNodesExceedingThresholds = "NodesExceedingThresholds"
+	ReasonNodesDegraded            = "NodesDegraded"
+	ReasonHugePagesMismatch        = "HugePagesMismatch"
+	ReasonNodesMissingModule       = "NodesMissingModule"
+
+	ReasonDependencyMissing = "DependencyMissing"
+
+	ReasonDeleting = "Deleting"
 )
 
+// DependencyMissingReason returns the reason naming the given missing
+// dependencies, e.g. DependencyMissing:KMM,NFD.
+func DependencyMissingReason(names ...string) string {
+	if len(names) == 0 {
+		return ReasonDependencyMissing
+	}
+	return ReasonDependencyMissing + ":" + strings.Join(names, ",")
+}
+
 //go:generate mockgen -source=conditions.go -package=conditions -destination=mock_conditions.go
 
 type Updater interface {
 	SetConditionsReady(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, reason, message string) error
 	SetConditionsErrored(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, reason, message string) error
+	SetConditionsDeleting(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, message string) error
 }
 
 type updater struct {
@@ -83,3 +120,22 @@ func (u *updater) SetConditionsErrored(ctx context.Context, cr *hlaiv1alpha1.Dev
 
 	return u.statusWriter.Update(ctx, cr)
 }
+
+// SetConditionsDeleting reports that the DeviceConfig is waiting for the
+// cleanup of its resources before being deleted.
+func (u *updater) SetConditionsDeleting(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, message string) error {
+	meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
+		Type:    Ready,
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonDeleting,
+		Message: message,
+	})
+
+	meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
+		Type:   Errored,
+		Status: metav1.ConditionFalse,
+		Reason: ReasonDeleting,
+	})
+
+	return u.statusWriter.Update(ctx, cr)
+}
@@ -125,4 +125,42 @@ var _ = Describe("ConditionsUpdater", func() {
 			})
 		})
 	})
+
+	Describe("SetConditionsDeleting", func() {
+		Context("with successful status update", func() {
+			BeforeEach(func() {
+				c.EXPECT().Update(context.TODO(), dc)
+
+				err := u.SetConditionsDeleting(context.TODO(), dc, "test message")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should have set the Ready condition as false", func() {
+				ready := dc.Status.Conditions[0]
+
+				Expect(ready.Type).To(Equal("Ready"))
+				Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+				Expect(ready.Reason).To(Equal("Deleting"))
+				Expect(ready.Message).To(Equal("test message"))
+			})
+
+			It("should have set the Errored condition as false", func() {
+				errored := dc.Status.Conditions[1]
+
+				Expect(errored.Type).To(Equal("Errored"))
+				Expect(errored.Status).To(Equal(metav1.ConditionFalse))
+				Expect(errored.Reason).To(Equal("Deleting"))
+			})
+		})
+	})
+
+	Describe("DependencyMissingReason", func() {
+		It("should name the missing dependencies", func() {
+			Expect(DependencyMissingReason("KMM", "NFD")).To(Equal("DependencyMissing:KMM,NFD"))
+		})
+
+		It("should default to the generic reason", func() {
+			Expect(DependencyMissingReason()).To(Equal(ReasonDependencyMissing))
+		})
+	})
 })
@@ -35,6 +35,20 @@ func (m *MockUpdater) EXPECT() *MockUpdaterMockRecorder {
 	return m.recorder
 }
 
+// SetConditionsDeleting mocks base method.
+func (m *MockUpdater) SetConditionsDeleting(ctx context.Context, cr *v1alpha1.DeviceConfig, message string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetConditionsDeleting", ctx, cr, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetConditionsDeleting indicates an expected call of SetConditionsDeleting.
+func (mr *MockUpdaterMockRecorder) SetConditionsDeleting(ctx, cr, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConditionsDeleting", reflect.TypeOf((*MockUpdater)(nil).SetConditionsDeleting), ctx, cr, message)
+}
+
 // SetConditionsErrored mocks base method.
 func (m *MockUpdater) SetConditionsErrored(ctx context.Context, cr *v1alpha1.DeviceConfig, reason, message string) error {
 	m.ctrl.T.Helper()
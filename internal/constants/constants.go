@@ -2,4 +2,25 @@ package constants
 
 const (
 	HabanaAIOperatorName = "habana-ai-operator"
+
+	// HabanaDeviceType is the type of device advertised by the device plugin.
+	HabanaDeviceType = "gaudi"
+
+	// HabanaResourceName is the extended resource advertised by the device
+	// plugin on the nodes and requested by the workloads.
+	HabanaResourceName = "habana.ai/" + HabanaDeviceType
+
+	// HPUPresentLabel is set by NFD on the nodes with HPUs, which run the
+	// Module and the node components.
+	HPUPresentLabel = "habana.ai/hpu." + HabanaDeviceType + ".present"
+
+	// DeviceConfigLabel is set on every resource managed on behalf of a
+	// DeviceConfig. As DeviceConfigs are cluster-scoped, it is used instead
+	// of owner references to select the resources of a given DeviceConfig.
+	DeviceConfigLabel = "habana.ai/deviceconfig"
+
+	// FeaturesDir is the NFD local feature files directory where the former
+	// node labeler DaemonSet wrote the Habana feature files, as the network
+	// and topology DaemonSets still do.
+	FeaturesDir = "/etc/kubernetes/node-feature-discovery/features.d"
 )
@@ -0,0 +1,296 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dependencies
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/certificates"
+	"github.com/HabanaAI/habana-ai-operator/internal/monitoring"
+	nodeHugePages "github.com/HabanaAI/habana-ai-operator/internal/node/hugepages"
+	nodeLabeler "github.com/HabanaAI/habana-ai-operator/internal/node/labeler"
+	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
+)
+
+const (
+	// DefaultRefreshInterval is the default interval between two discoveries
+	// of the dependencies APIs.
+	DefaultRefreshInterval = 30 * time.Second
+)
+
+// Dependency is an API the operator depends on.
+type Dependency struct {
+	// Name is the short name of the dependency, e.g. KMM.
+	Name string
+	// GroupVersionKind is the kind the operator uses from the dependency.
+	GroupVersionKind schema.GroupVersionKind
+	// Optional dependencies only enable additional features, they are never
+	// reported as missing.
+	Optional bool
+}
+
+func (d Dependency) String() string {
+	return fmt.Sprintf("%s (%s)", d.Name, d.GroupVersionKind.GroupKind())
+}
+
+var (
+	KMM = Dependency{
+		Name:             "KMM",
+		GroupVersionKind: kmmv1beta1.GroupVersion.WithKind("Module"),
+	}
+
+	NFD = Dependency{
+		Name:             "NFD",
+		GroupVersionKind: nodeLabeler.NodeFeatureRuleGVK,
+	}
+
+	Monitoring = Dependency{
+		Name:             "Prometheus Operator",
+		GroupVersionKind: monitoring.ServiceMonitorGVK,
+		Optional:         true,
+	}
+
+	// ServiceCA is only served on OpenShift, where the service CA operator
+	// issues the node metrics serving certificates.
+	ServiceCA = Dependency{
+		Name:             "OpenShift service CA",
+		GroupVersionKind: certificates.ServiceCAGVK,
+		Optional:         true,
+	}
+
+	// MachineConfig is only served on OpenShift, where the Machine Config
+	// Operator allocates the huge pages of the nodes.
+	MachineConfig = Dependency{
+		Name:             "OpenShift Machine Config",
+		GroupVersionKind: nodeHugePages.MachineConfigGVK,
+		Optional:         true,
+	}
+
+	// All lists the dependencies of the operator.
+	All = []Dependency{KMM, NFD, Monitoring, ServiceCA, MachineConfig}
+)
+
+//go:generate mockgen -source=dependencies.go -package=dependencies -destination=mock_dependencies.go
+
+type Checker interface {
+	Refresh(ctx context.Context) error
+	IsAvailable(d Dependency) bool
+	Missing() []Dependency
+	ServedVersions(d Dependency) []string
+	Events() <-chan event.GenericEvent
+	ReadyzCheck(req *http.Request) error
+}
+
+type status struct {
+	available bool
+	versions  []string
+}
+
+type DependencyChecker struct {
+	discovery    discovery.DiscoveryInterface
+	dependencies []Dependency
+	interval     time.Duration
+
+	mu     sync.RWMutex
+	status map[string]status
+
+	events chan event.GenericEvent
+}
+
+func NewChecker(d discovery.DiscoveryInterface, interval time.Duration, deps ...Dependency) *DependencyChecker {
+	return &DependencyChecker{
+		discovery:    d,
+		dependencies: deps,
+		interval:     interval,
+		status:       make(map[string]status, len(deps)),
+		events:       make(chan event.GenericEvent, 1),
+	}
+}
+
+// Refresh discovers the APIs of the dependencies. When a dependency becomes
+// available, including when it is discovered for the first time, an event is
+// sent on the Events channel so that the DeviceConfigs can be reconciled
+// again. A dependency that fails to be discovered keeps its last known status.
+func (c *DependencyChecker) Refresh(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+
+	groups, err := c.discovery.ServerGroups()
+	if err != nil {
+		return fmt.Errorf("failed to discover the API groups: %w", err)
+	}
+
+	served := make(map[string][]string)
+	for _, g := range groups.Groups {
+		for _, v := range g.Versions {
+			served[g.Name] = append(served[g.Name], v.Version)
+		}
+	}
+
+	errs := []error{}
+	becameAvailable := false
+	for _, d := range c.dependencies {
+		gvk := d.GroupVersionKind
+
+		available, err := c.servesKind(gvk)
+		if err != nil {
+			// Keep the last known status of the dependency, the other
+			// dependencies are still refreshed
+			errs = append(errs, fmt.Errorf("failed to refresh %s: %w", d, err))
+			continue
+		}
+
+		st := status{available: available, versions: served[gvk.Group]}
+
+		c.mu.Lock()
+		previous, known := c.status[d.Name]
+		c.status[d.Name] = st
+		c.mu.Unlock()
+
+		if !known || previous.available != available {
+			logger.Info("Dependency status changed", "dependency", d.Name, "available", available, "versions", st.versions)
+		}
+		if available && (!known || !previous.available) {
+			becameAvailable = true
+		}
+	}
+
+	if becameAvailable {
+		select {
+		case c.events <- event.GenericEvent{Object: &hlaiv1alpha1.DeviceConfig{}}:
+		default:
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *DependencyChecker) servesKind(gvk schema.GroupVersionKind) (bool, error) {
+	resources, err := c.discovery.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to discover the %s resources: %w", gvk.GroupVersion(), err)
+	}
+
+	for _, r := range resources.APIResources {
+		if r.Kind == gvk.Kind {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (c *DependencyChecker) IsAvailable(d Dependency) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.status[d.Name].available
+}
+
+// Missing returns the required dependencies that are not available, including
+// the ones that have not been discovered yet.
+func (c *DependencyChecker) Missing() []Dependency {
+	missing := []Dependency{}
+	for _, d := range c.dependencies {
+		if !d.Optional && !c.IsAvailable(d) {
+			missing = append(missing, d)
+		}
+	}
+	return missing
+}
+
+func (c *DependencyChecker) ServedVersions(d Dependency) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.status[d.Name].versions
+}
+
+func (c *DependencyChecker) Events() <-chan event.GenericEvent {
+	return c.events
+}
+
+// ReadyzCheck reports the operator as not ready as long as a dependency is missing.
+func (c *DependencyChecker) ReadyzCheck(_ *http.Request) error {
+	missing := c.Missing()
+	if len(missing) > 0 {
+		return fmt.Errorf("missing dependencies: %s", FormatDependencies(missing))
+	}
+	return nil
+}
+
+// Start periodically refreshes the dependencies until the context is done.
+func (c *DependencyChecker) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil {
+				logger.Error(err, "Failed to refresh the dependencies")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection returns false, as every replica needs to know about
+// the dependencies to report its readiness.
+func (c *DependencyChecker) NeedLeaderElection() bool {
+	return false
+}
+
+// Names returns the short names of the dependencies, stripped of the
+// characters that are not valid in a condition reason.
+func Names(deps []Dependency) []string {
+	names := make([]string, 0, len(deps))
+	for _, d := range deps {
+		names = append(names, strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+				return r
+			}
+			return -1
+		}, d.Name))
+	}
+	return names
+}
+
+// FormatDependencies returns a human readable list of dependencies.
+func FormatDependencies(deps []Dependency) string {
+	names := make([]string, 0, len(deps))
+	for _, d := range deps {
+		names = append(names, d.String())
+	}
+	return strings.Join(names, ", ")
+}
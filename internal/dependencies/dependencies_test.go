@@ -0,0 +1,195 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dependencies
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func resourceListFor(d Dependency) *metav1.APIResourceList {
+	return &metav1.APIResourceList{
+		GroupVersion: d.GroupVersionKind.GroupVersion().String(),
+		APIResources: []metav1.APIResource{
+			{Kind: d.GroupVersionKind.Kind},
+		},
+	}
+}
+
+// failingDiscovery fails to discover the resources of a group version.
+type failingDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	failing string
+}
+
+func (d *failingDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	if groupVersion == d.failing {
+		return nil, apierrors.NewServiceUnavailable("Service unavailable")
+	}
+	return d.FakeDiscovery.ServerResourcesForGroupVersion(groupVersion)
+}
+
+var _ = Describe("DependencyChecker", func() {
+	var (
+		fd  *fakediscovery.FakeDiscovery
+		c   *DependencyChecker
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		fd = &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+		c = NewChecker(fd, DefaultRefreshInterval, All...)
+		ctx = context.TODO()
+	})
+
+	Context("before the first refresh", func() {
+		It("should report all the dependencies as missing", func() {
+			Expect(c.Missing()).To(Equal([]Dependency{KMM, NFD}))
+			Expect(c.ReadyzCheck(nil)).To(HaveOccurred())
+		})
+	})
+
+	Context("with all the dependencies installed", func() {
+		BeforeEach(func() {
+			fd.Resources = []*metav1.APIResourceList{resourceListFor(KMM), resourceListFor(NFD)}
+			Expect(c.Refresh(ctx)).ToNot(HaveOccurred())
+		})
+
+		It("should not report any missing dependency", func() {
+			Expect(c.Missing()).To(BeEmpty())
+			Expect(c.IsAvailable(Monitoring)).To(BeFalse())
+			Expect(c.IsAvailable(ServiceCA)).To(BeFalse())
+			Expect(c.IsAvailable(KMM)).To(BeTrue())
+			Expect(c.IsAvailable(NFD)).To(BeTrue())
+			Expect(c.ReadyzCheck(nil)).ToNot(HaveOccurred())
+		})
+
+		It("should report the served versions", func() {
+			Expect(c.ServedVersions(KMM)).To(ConsistOf(KMM.GroupVersionKind.Version))
+		})
+	})
+
+	Context("with the Prometheus Operator installed", func() {
+		BeforeEach(func() {
+			fd.Resources = []*metav1.APIResourceList{resourceListFor(KMM), resourceListFor(NFD), resourceListFor(Monitoring)}
+			Expect(c.Refresh(ctx)).ToNot(HaveOccurred())
+		})
+
+		It("should report the optional dependency as available", func() {
+			Expect(c.IsAvailable(Monitoring)).To(BeTrue())
+		})
+	})
+
+	Context("with KMM not installed", func() {
+		BeforeEach(func() {
+			fd.Resources = []*metav1.APIResourceList{resourceListFor(NFD)}
+			Expect(c.Refresh(ctx)).ToNot(HaveOccurred())
+		})
+
+		It("should report KMM as missing", func() {
+			Expect(c.Missing()).To(Equal([]Dependency{KMM}))
+
+			err := c.ReadyzCheck(nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("KMM"))
+		})
+
+		It("should send an event once KMM is installed", func() {
+			// NFD was discovered by the first refresh
+			Expect(c.Events()).To(Receive())
+			Expect(c.Events()).ToNot(Receive())
+
+			fd.Resources = append(fd.Resources, resourceListFor(KMM))
+			Expect(c.Refresh(ctx)).ToNot(HaveOccurred())
+
+			Expect(c.Missing()).To(BeEmpty())
+			Expect(c.Events()).To(Receive())
+		})
+	})
+
+	Context("with a dependency available on the first refresh", func() {
+		It("should send an event", func() {
+			fd.Resources = []*metav1.APIResourceList{resourceListFor(KMM), resourceListFor(NFD)}
+			Expect(c.Refresh(ctx)).ToNot(HaveOccurred())
+
+			Expect(c.Events()).To(Receive())
+		})
+	})
+
+	Context("with a dependency failing to be discovered", func() {
+		var fc *failingDiscovery
+
+		BeforeEach(func() {
+			fd.Resources = []*metav1.APIResourceList{resourceListFor(KMM), resourceListFor(NFD)}
+			fc = &failingDiscovery{FakeDiscovery: fd, failing: KMM.GroupVersionKind.GroupVersion().String()}
+			c = NewChecker(fc, DefaultRefreshInterval, All...)
+		})
+
+		It("should refresh the other dependencies and return the error", func() {
+			err := c.Refresh(ctx)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("KMM"))
+
+			Expect(c.IsAvailable(KMM)).To(BeFalse())
+			Expect(c.IsAvailable(NFD)).To(BeTrue())
+		})
+
+		It("should keep the last known status of the dependency", func() {
+			fc.failing = ""
+			Expect(c.Refresh(ctx)).ToNot(HaveOccurred())
+			Expect(c.IsAvailable(KMM)).To(BeTrue())
+
+			fc.failing = KMM.GroupVersionKind.GroupVersion().String()
+			Expect(c.Refresh(ctx)).To(HaveOccurred())
+			Expect(c.IsAvailable(KMM)).To(BeTrue())
+		})
+	})
+
+	Context("with a group served without the expected kind", func() {
+		BeforeEach(func() {
+			fd.Resources = []*metav1.APIResourceList{
+				{GroupVersion: KMM.GroupVersionKind.GroupVersion().String()},
+				resourceListFor(NFD),
+			}
+			Expect(c.Refresh(ctx)).ToNot(HaveOccurred())
+		})
+
+		It("should report the dependency as missing", func() {
+			Expect(c.IsAvailable(KMM)).To(BeFalse())
+		})
+	})
+
+	Context("with a discovery error", func() {
+		BeforeEach(func() {
+			fd.Resources = []*metav1.APIResourceList{{GroupVersion: "an/invalid/group-version"}}
+		})
+
+		It("should return an error", func() {
+			Expect(c.Refresh(ctx)).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Names", func() {
+	It("should only keep the characters valid in a condition reason", func() {
+		Expect(Names([]Dependency{KMM, Monitoring})).To(Equal([]string{"KMM", "PrometheusOperator"}))
+	})
+})
@@ -0,0 +1,121 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: dependencies.go
+
+// Package dependencies is a generated GoMock package.
+package dependencies
+
+import (
+	context "context"
+	http "net/http"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	event "sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// MockChecker is a mock of Checker interface.
+type MockChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockCheckerMockRecorder
+}
+
+// MockCheckerMockRecorder is the mock recorder for MockChecker.
+type MockCheckerMockRecorder struct {
+	mock *MockChecker
+}
+
+// NewMockChecker creates a new mock instance.
+func NewMockChecker(ctrl *gomock.Controller) *MockChecker {
+	mock := &MockChecker{ctrl: ctrl}
+	mock.recorder = &MockCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChecker) EXPECT() *MockCheckerMockRecorder {
+	return m.recorder
+}
+
+// Events mocks base method.
+func (m *MockChecker) Events() <-chan event.GenericEvent {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Events")
+	ret0, _ := ret[0].(<-chan event.GenericEvent)
+	return ret0
+}
+
+// Events indicates an expected call of Events.
+func (mr *MockCheckerMockRecorder) Events() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Events", reflect.TypeOf((*MockChecker)(nil).Events))
+}
+
+// IsAvailable mocks base method.
+func (m *MockChecker) IsAvailable(d Dependency) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAvailable", d)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsAvailable indicates an expected call of IsAvailable.
+func (mr *MockCheckerMockRecorder) IsAvailable(d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAvailable", reflect.TypeOf((*MockChecker)(nil).IsAvailable), d)
+}
+
+// Missing mocks base method.
+func (m *MockChecker) Missing() []Dependency {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Missing")
+	ret0, _ := ret[0].([]Dependency)
+	return ret0
+}
+
+// Missing indicates an expected call of Missing.
+func (mr *MockCheckerMockRecorder) Missing() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Missing", reflect.TypeOf((*MockChecker)(nil).Missing))
+}
+
+// ReadyzCheck mocks base method.
+func (m *MockChecker) ReadyzCheck(req *http.Request) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadyzCheck", req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReadyzCheck indicates an expected call of ReadyzCheck.
+func (mr *MockCheckerMockRecorder) ReadyzCheck(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadyzCheck", reflect.TypeOf((*MockChecker)(nil).ReadyzCheck), req)
+}
+
+// Refresh mocks base method.
+func (m *MockChecker) Refresh(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockCheckerMockRecorder) Refresh(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockChecker)(nil).Refresh), ctx)
+}
+
+// ServedVersions mocks base method.
+func (m *MockChecker) ServedVersions(d Dependency) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServedVersions", d)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// ServedVersions indicates an expected call of ServedVersions.
+func (mr *MockCheckerMockRecorder) ServedVersions(d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServedVersions", reflect.TypeOf((*MockChecker)(nil).ServedVersions), d)
+}
@@ -14,10 +14,23 @@ limitations under the License.
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
+const (
+	// Reconciliation phases timed by ReconcilePhaseDuration.
+	PhaseModule  = "module"
+	PhaseLabeler = "labeler"
+	PhaseMetrics = "metrics"
+
+	// Conflicts types counted by Conflicts.
+	ConflictNodeSelector = "node_selector"
+	ConflictHostPort     = "host_port"
+)
+
 var (
 	ReconciliationFailed = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -26,10 +39,152 @@ var (
 		},
 		[]string{"device_config"},
 	)
+
+	LastSuccessfulReconciliation = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "habana_ai_operator_last_successful_reconciliation_timestamp_seconds",
+			Help: "Reports the Unix time of the last successful reconciliation per DeviceConfig.",
+		},
+		[]string{"device_config"},
+	)
+
+	ReconcilePhaseDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "habana_ai_operator_reconcile_phase_duration_seconds",
+			Help:    "Reports the duration of the reconciliation phases per DeviceConfig.",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+		},
+		[]string{"device_config", "phase"},
+	)
+
+	ComponentNodesDesired = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "habana_ai_operator_component_nodes_desired",
+			Help: "Reports the number of nodes that should run a component per DeviceConfig.",
+		},
+		[]string{"device_config", "component"},
+	)
+
+	ComponentNodesReady = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "habana_ai_operator_component_nodes_ready",
+			Help: "Reports the number of nodes running a ready component per DeviceConfig.",
+		},
+		[]string{"device_config", "component"},
+	)
+
+	DriverInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "habana_ai_operator_driver_info",
+			Help: "Reports the driver image and version per DeviceConfig.",
+		},
+		[]string{"device_config", "driver_image", "driver_version"},
+	)
+
+	Conflicts = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "habana_ai_operator_conflicts",
+			Help: "Reports the number of conflicts preventing the reconciliation per DeviceConfig.",
+		},
+		[]string{"device_config", "type"},
+	)
+
+	HPUAllocatable = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "habana_ai_operator_hpu_allocatable",
+			Help: "Reports the number of HPUs allocatable on the nodes per DeviceConfig.",
+		},
+		[]string{"device_config"},
+	)
+
+	HPUAllocated = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "habana_ai_operator_hpu_allocated",
+			Help: "Reports the number of HPUs requested by the pods of the nodes per DeviceConfig and namespace.",
+		},
+		[]string{"device_config", "namespace"},
+	)
+
+	DegradedNodes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "habana_ai_operator_degraded_nodes",
+			Help: "Reports the number of nodes advertising another number of HPUs than expected per DeviceConfig.",
+		},
+		[]string{"device_config"},
+	)
+
+	HPUAccessViolations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "habana_ai_operator_hpu_access_violations_total",
+			Help: "Counts the pods denied by the HPUAccessPolicies per policy and namespace.",
+		},
+		[]string{"policy", "namespace"},
+	)
+
+	deviceConfigVecs = []*prometheus.MetricVec{
+		ReconciliationFailed.MetricVec,
+		LastSuccessfulReconciliation.MetricVec,
+		ReconcilePhaseDuration.MetricVec,
+		ComponentNodesDesired.MetricVec,
+		ComponentNodesReady.MetricVec,
+		DriverInfo.MetricVec,
+		Conflicts.MetricVec,
+		HPUAllocatable.MetricVec,
+		HPUAllocated.MetricVec,
+		DegradedNodes.MetricVec,
+	}
 )
 
 func init() {
 	metrics.Registry.MustRegister(
 		ReconciliationFailed,
+		LastSuccessfulReconciliation,
+		ReconcilePhaseDuration,
+		ComponentNodesDesired,
+		ComponentNodesReady,
+		DriverInfo,
+		Conflicts,
+		HPUAllocatable,
+		HPUAllocated,
+		DegradedNodes,
+		HPUAccessViolations,
 	)
 }
+
+// ObservePhaseDuration records the time elapsed since start for the given
+// reconciliation phase of a DeviceConfig.
+func ObservePhaseDuration(deviceConfig, phase string, start time.Time) {
+	ReconcilePhaseDuration.WithLabelValues(deviceConfig, phase).Observe(time.Since(start).Seconds())
+}
+
+// SetComponentNodes records the desired and ready node counts of a component
+// of a DeviceConfig.
+func SetComponentNodes(deviceConfig, component string, desired, ready int32) {
+	ComponentNodesDesired.WithLabelValues(deviceConfig, component).Set(float64(desired))
+	ComponentNodesReady.WithLabelValues(deviceConfig, component).Set(float64(ready))
+}
+
+// SetDriverInfo records the driver of a DeviceConfig, dropping the series of
+// its former driver.
+func SetDriverInfo(deviceConfig, image, version string) {
+	DriverInfo.DeletePartialMatch(prometheus.Labels{"device_config": deviceConfig})
+	DriverInfo.WithLabelValues(deviceConfig, image, version).Set(1)
+}
+
+// SetHPUAllocation records the HPUs allocatable on the nodes of a DeviceConfig
+// and the HPUs requested in each namespace, dropping the series of the
+// namespaces no longer holding HPUs.
+func SetHPUAllocation(deviceConfig string, allocatable int64, allocatedByNamespace map[string]int64) {
+	HPUAllocatable.WithLabelValues(deviceConfig).Set(float64(allocatable))
+	HPUAllocated.DeletePartialMatch(prometheus.Labels{"device_config": deviceConfig})
+	for namespace, allocated := range allocatedByNamespace {
+		HPUAllocated.WithLabelValues(deviceConfig, namespace).Set(float64(allocated))
+	}
+}
+
+// DeleteDeviceConfigMetrics drops all the series of a deleted DeviceConfig.
+func DeleteDeviceConfigMetrics(deviceConfig string) {
+	for _, v := range deviceConfigVecs {
+		v.DeletePartialMatch(prometheus.Labels{"device_config": deviceConfig})
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func count(c prometheus.Collector) int {
+	ch := make(chan prometheus.Metric, 100)
+	c.Collect(ch)
+	close(ch)
+	return len(ch)
+}
+
+var _ = Describe("SetDriverInfo", func() {
+	AfterEach(func() {
+		DriverInfo.Reset()
+	})
+
+	It("should only keep the series of the current driver", func() {
+		SetDriverInfo("a-device-config", "an-image", "1.0.0")
+		SetDriverInfo("a-device-config", "an-image", "1.1.0")
+		SetDriverInfo("another-device-config", "an-image", "1.0.0")
+
+		Expect(count(DriverInfo)).To(Equal(2))
+	})
+})
+
+var _ = Describe("DeleteDeviceConfigMetrics", func() {
+	It("should drop all the series of the DeviceConfig", func() {
+		ReconciliationFailed.WithLabelValues("a-device-config").Set(1)
+		SetComponentNodes("a-device-config", "driver", 2, 1)
+		Conflicts.WithLabelValues("a-device-config", ConflictHostPort).Set(1)
+		SetDriverInfo("another-device-config", "an-image", "1.0.0")
+
+		DeleteDeviceConfigMetrics("a-device-config")
+
+		Expect(count(ReconciliationFailed)).To(BeZero())
+		Expect(count(ComponentNodesDesired)).To(BeZero())
+		Expect(count(ComponentNodesReady)).To(BeZero())
+		Expect(count(Conflicts)).To(BeZero())
+		Expect(count(DriverInfo)).To(Equal(1))
+	})
+})
@@ -20,16 +20,19 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/metrics"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
 	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 )
@@ -37,13 +40,25 @@ import (
 const (
 	moduleSuffix = "module"
 
-	driverServiceAccount = "driver-habana"
-
 	devicePluginLimitsCpu      = "200m"
 	devicePluginLimitsMemory   = "100Mi"
 	devicePluginRequestsCpu    = "100m"
 	devicePluginRequestsMemory = "50Mi"
-	devicePluginServiceAccount = "device-plugin"
+
+	// devicePluginCDISpecDirFlag makes the device plugin return the CDI names
+	// of the HPUs found in the CDI specs directory, instead of their device
+	// nodes.
+	devicePluginCDISpecDirFlag = "--cdi_spec_dir"
+	devicePluginCDIVolume      = "cdi"
+
+	// devicePluginPathFlag makes the device plugin register with the kubelet
+	// through the sockets directory mounted at devicePluginKubeletMountPath.
+	// KMM always mounts the default directory of the kubelet, hence a custom
+	// directory is mounted at another path.
+	devicePluginPathFlag          = "--device_plugin_path"
+	devicePluginKubeletVolume     = "kubelet-device-plugins-dir"
+	devicePluginKubeletMountPath  = "/var/lib/habana/kubelet/device-plugins"
+	defaultKubeletDevicePluginDir = hlaiv1alpha1.DefaultKubeletRootDir + "/device-plugins"
 )
 
 //go:generate mockgen -source=module.go -package=module -destination=mock_module.go
@@ -70,12 +85,29 @@ func GetModuleName(cr *hlaiv1alpha1.DeviceConfig) string {
 	return fmt.Sprintf("%s-%s", cr.Name, moduleSuffix)
 }
 
+// GetModuleNodeSelector returns the node selector of the Module, i.e. the
+// nodes of the DeviceConfig with HPUs.
+func GetModuleNodeSelector(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+	selector := make(map[string]string)
+	for k, v := range cr.GetNodeSelector() {
+		selector[k] = v
+	}
+	selector[constants.HPUPresentLabel] = "true"
+	return selector
+}
+
 func (r *moduleReconciler) ReconcileModule(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	// The simulated HPUs are advertised without driver, by the simulated
+	// device plugin, and KMM only loads the module of a driver image.
+	if cr.Spec.Simulation.Enabled || cr.GetDriverMode() != hlaiv1alpha1.DriverModeContainer {
+		return r.DeleteModule(ctx, cr)
+	}
+
 	logger := log.FromContext(ctx)
 
 	existingModule := &kmmv1beta1.Module{}
 	err := r.client.Get(ctx, types.NamespacedName{
-		Namespace: cr.Namespace,
+		Namespace: s.Settings.OperatorNamespace,
 		Name:      GetModuleName(cr),
 	}, existingModule)
 	exists := !apierrors.IsNotFound(err)
@@ -86,7 +118,7 @@ func (r *moduleReconciler) ReconcileModule(ctx context.Context, cr *hlaiv1alpha1
 	m := &kmmv1beta1.Module{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      GetModuleName(cr),
-			Namespace: cr.ObjectMeta.Namespace,
+			Namespace: s.Settings.OperatorNamespace,
 		},
 	}
 
@@ -104,6 +136,9 @@ func (r *moduleReconciler) ReconcileModule(ctx context.Context, cr *hlaiv1alpha1
 
 	logger.Info("Reconciled Module", "resource", m.Name, "result", res)
 
+	metrics.SetComponentNodes(cr.Name, rbac.ComponentDriver, m.Status.ModuleLoader.DesiredNumber, m.Status.ModuleLoader.AvailableNumber)
+	metrics.SetComponentNodes(cr.Name, rbac.ComponentDevicePlugin, m.Status.DevicePlugin.DesiredNumber, m.Status.DevicePlugin.AvailableNumber)
+
 	return nil
 }
 
@@ -111,12 +146,13 @@ func (r *moduleReconciler) DeleteModule(ctx context.Context, cr *hlaiv1alpha1.De
 	m := &kmmv1beta1.Module{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      GetModuleName(cr),
-			Namespace: cr.ObjectMeta.Namespace,
+			Namespace: s.Settings.OperatorNamespace,
 		},
 	}
 
+	// Nothing to delete when KMM is not installed.
 	err := r.client.Delete(ctx, m)
-	if err != nil && !apierrors.IsNotFound(err) {
+	if err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
 		return fmt.Errorf("failed to delete Module %s: %w", m.Name, err)
 	}
 
@@ -127,12 +163,16 @@ func (r *moduleReconciler) SetDesiredModule(m *kmmv1beta1.Module, cr *hlaiv1alph
 	if m == nil {
 		return errors.New("module cannot be nil")
 	}
+	if cr.Spec.DriverImage == "" || cr.Spec.DriverVersion == "" {
+		return errors.New("driverImage and driverVersion are required in the container driver mode")
+	}
 
-	deviceType := "gaudi"
+	deviceType := constants.HabanaDeviceType
 	devicePlugin := r.makeDevicePlugin(cr, deviceType)
 	ModuleLoader := r.makeModuleLoader(cr)
-	selector := cr.GetNodeSelector()
-	selector[fmt.Sprintf("habana.ai/hpu.%s.present", deviceType)] = "true"
+	selector := GetModuleNodeSelector(cr)
+
+	m.ObjectMeta.Labels = labelsForModule(cr)
 
 	m.Spec = kmmv1beta1.ModuleSpec{
 		DevicePlugin: &devicePlugin,
@@ -140,10 +180,6 @@ func (r *moduleReconciler) SetDesiredModule(m *kmmv1beta1.Module, cr *hlaiv1alph
 		Selector:     selector,
 	}
 
-	if err := ctrl.SetControllerReference(cr, m, r.scheme); err != nil {
-		return err
-	}
-
 	return nil
 }
 
@@ -157,13 +193,41 @@ func (r *moduleReconciler) makeModuleLoader(cr *hlaiv1alpha1.DeviceConfig) kmmv1
 				FirmwarePath: "/opt/lib/firmware/habanalabs",
 			},
 		},
-		ServiceAccountName: driverServiceAccount,
+		ServiceAccountName: rbac.GetServiceAccountName(cr, rbac.ComponentDriver),
 	}
 
 	return moduleLoader
 }
 
 func (r *moduleReconciler) makeDevicePlugin(cr *hlaiv1alpha1.DeviceConfig, deviceType string) kmmv1beta1.DevicePluginSpec {
+	devicePlugin := MakeDevicePlugin(cr, deviceType)
+
+	if dir := cr.GetKubeletDevicePluginsDir(); dir != defaultKubeletDevicePluginDir {
+		hostPathTypeDirectory := corev1.HostPathDirectory
+
+		devicePlugin.Container.Args = append(devicePlugin.Container.Args, devicePluginPathFlag, devicePluginKubeletMountPath+"/")
+		devicePlugin.Container.VolumeMounts = append(devicePlugin.Container.VolumeMounts, corev1.VolumeMount{
+			Name:      devicePluginKubeletVolume,
+			MountPath: devicePluginKubeletMountPath,
+		})
+		devicePlugin.Volumes = append(devicePlugin.Volumes, corev1.Volume{
+			Name: devicePluginKubeletVolume,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: dir,
+					Type: &hostPathTypeDirectory,
+				},
+			},
+		})
+	}
+
+	return devicePlugin
+}
+
+// MakeDevicePlugin returns the device plugin of the DeviceConfig, without the
+// kubelet device plugins directory, which is mounted by the DaemonSet running
+// it.
+func MakeDevicePlugin(cr *hlaiv1alpha1.DeviceConfig, deviceType string) kmmv1beta1.DevicePluginSpec {
 	devicePlugin := kmmv1beta1.DevicePluginSpec{
 		Container: kmmv1beta1.DevicePluginContainerSpec{
 			Args: []string{
@@ -186,7 +250,27 @@ func (r *moduleReconciler) makeDevicePlugin(cr *hlaiv1alpha1.DeviceConfig, devic
 				},
 			},
 		},
-		ServiceAccountName: devicePluginServiceAccount,
+		ServiceAccountName: rbac.GetServiceAccountName(cr, rbac.ComponentDevicePlugin),
+	}
+
+	if cr.Spec.CDI.Enabled {
+		hostPathTypeDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
+
+		devicePlugin.Container.Args = append(devicePlugin.Container.Args, devicePluginCDISpecDirFlag, hlaiv1alpha1.CDISpecDir)
+		devicePlugin.Container.VolumeMounts = append(devicePlugin.Container.VolumeMounts, corev1.VolumeMount{
+			Name:      devicePluginCDIVolume,
+			MountPath: hlaiv1alpha1.CDISpecDir,
+			ReadOnly:  true,
+		})
+		devicePlugin.Volumes = append(devicePlugin.Volumes, corev1.Volume{
+			Name: devicePluginCDIVolume,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: hlaiv1alpha1.CDISpecDir,
+					Type: &hostPathTypeDirectoryOrCreate,
+				},
+			},
+		})
 	}
 
 	return devicePlugin
@@ -202,3 +286,12 @@ func (r *moduleReconciler) makeKernelMappings(cr *hlaiv1alpha1.DeviceConfig) []k
 
 	return kernelMappings
 }
+
+// labelsForModule returns the labels for selecting the
+// resources belonging to the given DeviceConfig CR name.
+func labelsForModule(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":    constants.HabanaAIOperatorName,
+		constants.DeviceConfigLabel: cr.Name,
+	}
+}
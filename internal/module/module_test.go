@@ -21,6 +21,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -30,6 +31,7 @@ import (
 
 	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
 	mockClient "github.com/HabanaAI/habana-ai-operator/internal/client"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 )
 
@@ -55,6 +57,10 @@ var _ = Describe("ModuleReconciler", func() {
 				Name:      "a-device-config",
 				Namespace: "a-namespace",
 			},
+			Spec: hlaiv1alpha1.DeviceConfigSpec{
+				DriverImage:   testDriverImage,
+				DriverVersion: testDriverVersion,
+			},
 		}
 		c = mockClient.NewMockClient(gomock.NewController(GinkgoT()))
 
@@ -111,6 +117,32 @@ var _ = Describe("ModuleReconciler", func() {
 				Expect(r.ReconcileModule(ctx, dc)).To(HaveOccurred())
 			})
 		})
+
+		Context("with the simulation enabled", func() {
+			BeforeEach(func() {
+				dc.Spec.Simulation.Enabled = true
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(nil),
+				)
+			})
+
+			It("should delete the Module", func() {
+				Expect(r.ReconcileModule(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with the inTree driver mode", func() {
+			BeforeEach(func() {
+				dc.Spec.Driver.Mode = hlaiv1alpha1.DriverModeInTree
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(nil),
+				)
+			})
+
+			It("should delete the Module", func() {
+				Expect(r.ReconcileModule(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
 	})
 
 	Describe("DeleteModule", func() {
@@ -140,6 +172,20 @@ var _ = Describe("ModuleReconciler", func() {
 			})
 		})
 
+		Context("with a NoKindMatch client Delete error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().
+						Delete(ctx, gomock.Any()).
+						Return(&meta.NoKindMatchError{GroupKind: kmmv1beta1.GroupVersion.WithKind("Module").GroupKind()}),
+				)
+			})
+
+			It("should not return an error", func() {
+				Expect(r.DeleteModule(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
 		Context("with a generic client Delete error", func() {
 			BeforeEach(func() {
 				gomock.InOrder(
@@ -171,6 +217,20 @@ var _ = Describe("ModuleReconciler", func() {
 			})
 		})
 
+		Context("without a driver image", func() {
+			BeforeEach(func() {
+				dc.Spec.DriverImage = ""
+				m = &kmmv1beta1.Module{}
+			})
+
+			It("should return an error", func() {
+				err := r.SetDesiredModule(m, dc)
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("driverImage and driverVersion are required"))
+			})
+		})
+
 		Context("with a non-nil Module as input", func() {
 			BeforeEach(func() {
 				dc.Spec.NodeSelector = map[string]string{testLabelKey: testLabelValue}
@@ -215,16 +275,72 @@ var _ = Describe("ModuleReconciler", func() {
 					//					}
 					//					Expect(m.Spec.ModuleLoader.Container.Modprobe.Parameters).To(Equal(modprobeParameters))
 
-					Expect(m.Spec.ModuleLoader.ServiceAccountName).To(Equal(driverServiceAccount))
+					Expect(m.Spec.ModuleLoader.ServiceAccountName).To(Equal(rbac.GetServiceAccountName(dc, rbac.ComponentDriver)))
 				})
 
 				It("should have a correct DevicePlugin", func() {
 					Expect(m.Spec.DevicePlugin).ToNot(BeNil())
 					Expect(m.Spec.DevicePlugin.Container).ToNot(BeNil())
 					Expect(m.Spec.DevicePlugin.Container.Image).ToNot(BeNil())
-					Expect(m.Spec.DevicePlugin.ServiceAccountName).To(Equal(devicePluginServiceAccount))
+					Expect(m.Spec.DevicePlugin.ServiceAccountName).To(Equal(rbac.GetServiceAccountName(dc, rbac.ComponentDevicePlugin)))
+					Expect(m.Spec.DevicePlugin.Container.Args).ToNot(ContainElement(devicePluginCDISpecDirFlag))
+					Expect(m.Spec.DevicePlugin.Volumes).To(BeEmpty())
 				})
 			})
 		})
+
+		Context("with CDI enabled", func() {
+			BeforeEach(func() {
+				dc.Spec.CDI.Enabled = true
+				m = &kmmv1beta1.Module{}
+
+				Expect(r.SetDesiredModule(m, dc)).ToNot(HaveOccurred())
+			})
+
+			It("should configure the DevicePlugin to return CDI device names", func() {
+				Expect(m.Spec.DevicePlugin.Container.Args).To(ContainElements(devicePluginCDISpecDirFlag, hlaiv1alpha1.CDISpecDir))
+				Expect(m.Spec.DevicePlugin.Container.VolumeMounts).To(ConsistOf(corev1.VolumeMount{
+					Name:      devicePluginCDIVolume,
+					MountPath: hlaiv1alpha1.CDISpecDir,
+					ReadOnly:  true,
+				}))
+				Expect(m.Spec.DevicePlugin.Volumes).To(HaveLen(1))
+				Expect(m.Spec.DevicePlugin.Volumes[0].HostPath.Path).To(Equal(hlaiv1alpha1.CDISpecDir))
+			})
+		})
+
+		Context("with a custom kubelet root directory", func() {
+			BeforeEach(func() {
+				dc.Spec.Kubelet.RootDir = "/var/snap/microk8s/common/var/lib/kubelet"
+				m = &kmmv1beta1.Module{}
+
+				Expect(r.SetDesiredModule(m, dc)).ToNot(HaveOccurred())
+			})
+
+			It("should register the DevicePlugin through the device plugins directory of that root directory", func() {
+				Expect(m.Spec.DevicePlugin.Container.Args).To(ContainElements(devicePluginPathFlag, devicePluginKubeletMountPath+"/"))
+				Expect(m.Spec.DevicePlugin.Container.VolumeMounts).To(ConsistOf(corev1.VolumeMount{
+					Name:      devicePluginKubeletVolume,
+					MountPath: devicePluginKubeletMountPath,
+				}))
+				Expect(m.Spec.DevicePlugin.Volumes).To(HaveLen(1))
+				Expect(m.Spec.DevicePlugin.Volumes[0].HostPath.Path).To(Equal("/var/snap/microk8s/common/var/lib/kubelet/device-plugins"))
+			})
+		})
+
+		Context("with a custom device plugins directory and CDI enabled", func() {
+			BeforeEach(func() {
+				dc.Spec.CDI.Enabled = true
+				dc.Spec.Kubelet.DevicePluginsDir = "/opt/kubelet/device-plugins"
+				m = &kmmv1beta1.Module{}
+
+				Expect(r.SetDesiredModule(m, dc)).ToNot(HaveOccurred())
+			})
+
+			It("should mount both directories", func() {
+				Expect(m.Spec.DevicePlugin.Volumes).To(HaveLen(2))
+				Expect(m.Spec.DevicePlugin.Volumes[1].HostPath.Path).To(Equal("/opt/kubelet/device-plugins"))
+			})
+		})
 	})
 })
@@ -0,0 +1,121 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: monitoring.go
+
+// Package monitoring is a generated GoMock package.
+package monitoring
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// MockReconciler is a mock of Reconciler interface.
+type MockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcilerMockRecorder
+}
+
+// MockReconcilerMockRecorder is the mock recorder for MockReconciler.
+type MockReconcilerMockRecorder struct {
+	mock *MockReconciler
+}
+
+// NewMockReconciler creates a new mock instance.
+func NewMockReconciler(ctrl *gomock.Controller) *MockReconciler {
+	mock := &MockReconciler{ctrl: ctrl}
+	mock.recorder = &MockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// DeleteMonitoring mocks base method.
+func (m *MockReconciler) DeleteMonitoring(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMonitoring", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMonitoring indicates an expected call of DeleteMonitoring.
+func (mr *MockReconcilerMockRecorder) DeleteMonitoring(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMonitoring", reflect.TypeOf((*MockReconciler)(nil).DeleteMonitoring), ctx, dc)
+}
+
+// ReconcileMonitoring mocks base method.
+func (m *MockReconciler) ReconcileMonitoring(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileMonitoring", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileMonitoring indicates an expected call of ReconcileMonitoring.
+func (mr *MockReconcilerMockRecorder) ReconcileMonitoring(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileMonitoring", reflect.TypeOf((*MockReconciler)(nil).ReconcileMonitoring), ctx, dc)
+}
+
+// ReconcilePrometheusRule mocks base method.
+func (m *MockReconciler) ReconcilePrometheusRule(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcilePrometheusRule", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcilePrometheusRule indicates an expected call of ReconcilePrometheusRule.
+func (mr *MockReconcilerMockRecorder) ReconcilePrometheusRule(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcilePrometheusRule", reflect.TypeOf((*MockReconciler)(nil).ReconcilePrometheusRule), ctx, dc)
+}
+
+// ReconcileServiceMonitor mocks base method.
+func (m *MockReconciler) ReconcileServiceMonitor(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileServiceMonitor", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileServiceMonitor indicates an expected call of ReconcileServiceMonitor.
+func (mr *MockReconcilerMockRecorder) ReconcileServiceMonitor(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileServiceMonitor", reflect.TypeOf((*MockReconciler)(nil).ReconcileServiceMonitor), ctx, dc)
+}
+
+// SetDesiredPrometheusRule mocks base method.
+func (m *MockReconciler) SetDesiredPrometheusRule(pr *unstructured.Unstructured, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredPrometheusRule", pr, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredPrometheusRule indicates an expected call of SetDesiredPrometheusRule.
+func (mr *MockReconcilerMockRecorder) SetDesiredPrometheusRule(pr, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredPrometheusRule", reflect.TypeOf((*MockReconciler)(nil).SetDesiredPrometheusRule), pr, cr)
+}
+
+// SetDesiredServiceMonitor mocks base method.
+func (m *MockReconciler) SetDesiredServiceMonitor(sm *unstructured.Unstructured, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredServiceMonitor", sm, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredServiceMonitor indicates an expected call of SetDesiredServiceMonitor.
+func (mr *MockReconcilerMockRecorder) SetDesiredServiceMonitor(sm, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredServiceMonitor", reflect.TypeOf((*MockReconciler)(nil).SetDesiredServiceMonitor), sm, cr)
+}
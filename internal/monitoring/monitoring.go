@@ -0,0 +1,332 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	nodeMetrics "github.com/HabanaAI/habana-ai-operator/internal/node/metrics"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	monitoringSuffix     = "monitoring"
+	serviceMonitorSuffix = "node-metrics"
+	prometheusRuleSuffix = "alerts"
+
+	// temperatureMetric is the on-chip HPU temperature, in degrees Celsius,
+	// reported for each device by the node metrics exporter.
+	temperatureMetric = "habanalabs_temperature_onchip"
+
+	reconciliationFailedMetric = "habana_ai_operator_reconciliation_failed"
+)
+
+var (
+	// ServiceMonitorGVK and PrometheusRuleGVK are the GroupVersionKinds of the
+	// Prometheus Operator resources. The Prometheus Operator is not a Go
+	// dependency of the operator, so its resources are handled as unstructured
+	// objects.
+	ServiceMonitorGVK = schema.GroupVersionKind{
+		Group:   "monitoring.coreos.com",
+		Version: "v1",
+		Kind:    "ServiceMonitor",
+	}
+
+	PrometheusRuleGVK = schema.GroupVersionKind{
+		Group:   "monitoring.coreos.com",
+		Version: "v1",
+		Kind:    "PrometheusRule",
+	}
+)
+
+//go:generate mockgen -source=monitoring.go -package=monitoring -destination=mock_monitoring.go
+
+type Reconciler interface {
+	ReconcileMonitoring(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	DeleteMonitoring(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	ReconcileServiceMonitor(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredServiceMonitor(sm *unstructured.Unstructured, cr *hlaiv1alpha1.DeviceConfig) error
+	ReconcilePrometheusRule(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredPrometheusRule(pr *unstructured.Unstructured, cr *hlaiv1alpha1.DeviceConfig) error
+}
+
+type MonitoringReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *MonitoringReconciler {
+	return &MonitoringReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+func GetServiceMonitorName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-%s", cr.Name, serviceMonitorSuffix)
+}
+
+func GetPrometheusRuleName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-%s", cr.Name, prometheusRuleSuffix)
+}
+
+func newUnstructured(gvk schema.GroupVersionKind, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	u.SetName(name)
+	u.SetNamespace(s.Settings.OperatorNamespace)
+	return u
+}
+
+func (r *MonitoringReconciler) ReconcileMonitoring(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	err := r.ReconcileServiceMonitor(ctx, cr)
+	if err != nil {
+		return err
+	}
+
+	return r.ReconcilePrometheusRule(ctx, cr)
+}
+
+func (r *MonitoringReconciler) ReconcileServiceMonitor(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	return r.reconcileUnstructured(ctx, newUnstructured(ServiceMonitorGVK, GetServiceMonitorName(cr)), func(u *unstructured.Unstructured) error {
+		return r.SetDesiredServiceMonitor(u, cr)
+	})
+}
+
+func (r *MonitoringReconciler) ReconcilePrometheusRule(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	return r.reconcileUnstructured(ctx, newUnstructured(PrometheusRuleGVK, GetPrometheusRuleName(cr)), func(u *unstructured.Unstructured) error {
+		return r.SetDesiredPrometheusRule(u, cr)
+	})
+}
+
+func (r *MonitoringReconciler) reconcileUnstructured(ctx context.Context, u *unstructured.Unstructured, setDesired func(*unstructured.Unstructured) error) error {
+	logger := log.FromContext(ctx)
+
+	kind := u.GetKind()
+
+	existing := newUnstructured(u.GroupVersionKind(), u.GetName())
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: u.GetNamespace(), Name: u.GetName()}, existing)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if exists {
+		u = existing
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, u, func() error {
+		return setDesired(u)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch %s: %v", kind, err)
+	}
+
+	logger.Info("Reconciled "+kind, "resource", u.GetName(), "result", res)
+
+	return nil
+}
+
+func (r *MonitoringReconciler) DeleteMonitoring(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	objs := []*unstructured.Unstructured{
+		newUnstructured(ServiceMonitorGVK, GetServiceMonitorName(cr)),
+		newUnstructured(PrometheusRuleGVK, GetPrometheusRuleName(cr)),
+	}
+
+	for _, o := range objs {
+		err := r.client.Delete(ctx, o)
+		if err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("failed to delete %s %s: %w", o.GetKind(), o.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// SetDesiredServiceMonitor sets a ServiceMonitor scraping the node metrics
+// Service of the DeviceConfig. The name of the node is added to the metrics
+// as the node label.
+func (r *MonitoringReconciler) SetDesiredServiceMonitor(sm *unstructured.Unstructured, cr *hlaiv1alpha1.DeviceConfig) error {
+	if sm == nil {
+		return errors.New("servicemonitor cannot be nil")
+	}
+
+	sm.SetLabels(labelsForMonitoring(cr))
+
+	matchLabels := map[string]interface{}{}
+	for k, v := range nodeMetrics.GetNodeMetricsServiceLabels(cr) {
+		matchLabels[k] = v
+	}
+
+	endpoint := map[string]interface{}{
+		"port":     nodeMetrics.GetNodeMetricsPortName(cr),
+		"path":     "/metrics",
+		"scheme":   "http",
+		"interval": cr.GetMonitoringInterval(),
+		"relabelings": []interface{}{
+			map[string]interface{}{
+				"sourceLabels": []interface{}{"__meta_kubernetes_pod_node_name"},
+				"targetLabel":  "node",
+			},
+		},
+	}
+
+	// Secured node metrics are served by kube-rbac-proxy, which authorizes
+	// the token of the metrics reader ServiceAccount of the DeviceConfig. The
+	// serving certificate is verified with the CA bundle, whichever issued it.
+	if cr.Spec.NodeMetrics.Secure {
+		endpoint["scheme"] = "https"
+		endpoint["authorization"] = map[string]interface{}{
+			"type": "Bearer",
+			"credentials": map[string]interface{}{
+				"name": rbac.GetMetricsReaderTokenSecretName(cr),
+				"key":  rbac.MetricsReaderTokenKey,
+			},
+		}
+		endpoint["tlsConfig"] = map[string]interface{}{
+			"serverName": nodeMetrics.GetNodeMetricsServiceHostname(cr),
+			"ca": map[string]interface{}{
+				"configMap": map[string]interface{}{
+					"name": nodeMetrics.GetNodeMetricsCABundleName(cr),
+					"key":  nodeMetrics.CABundleKey,
+				},
+			},
+		}
+	}
+
+	sm.Object["spec"] = map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": matchLabels,
+		},
+		"namespaceSelector": map[string]interface{}{
+			"matchNames": []interface{}{s.Settings.OperatorNamespace},
+		},
+		"endpoints": []interface{}{endpoint},
+	}
+
+	return nil
+}
+
+// SetDesiredPrometheusRule sets the default alerts of the DeviceConfig, with
+// the thresholds set in the DeviceConfig spec.
+func (r *MonitoringReconciler) SetDesiredPrometheusRule(pr *unstructured.Unstructured, cr *hlaiv1alpha1.DeviceConfig) error {
+	if pr == nil {
+		return errors.New("prometheusrule cannot be nil")
+	}
+
+	pr.SetLabels(labelsForMonitoring(cr))
+
+	// The ServiceMonitor scrapes the node metrics Service, hence the job label
+	// of the node metrics is the name of the Service.
+	job := fmt.Sprintf(`job="%s"`, nodeMetrics.GetNodeMetricsName(cr))
+	daemonSet := fmt.Sprintf(`namespace="%s",daemonset="%s"`, s.Settings.OperatorNamespace, nodeMetrics.GetNodeMetricsName(cr))
+	alertFor := cr.GetAlertFor()
+
+	rules := []interface{}{
+		alertRule(
+			"HabanaHPUTemperatureHigh",
+			fmt.Sprintf("%s{%s} > %d", temperatureMetric, job, cr.GetAlertTemperatureThreshold()),
+			alertFor,
+			"critical",
+			"An HPU temperature is too high.",
+			fmt.Sprintf("The temperature of an HPU of node {{ $labels.node }} is {{ $value }}°C, above the %d°C threshold of DeviceConfig %s.",
+				cr.GetAlertTemperatureThreshold(), cr.Name),
+		),
+		alertRule(
+			"HabanaNodeMetricsExporterDown",
+			fmt.Sprintf("up{%s} == 0", job),
+			alertFor,
+			"warning",
+			"A Habana node metrics exporter is down.",
+			fmt.Sprintf("The node metrics exporter of node {{ $labels.node }} of DeviceConfig %s cannot be scraped.", cr.Name),
+		),
+		alertRule(
+			"HabanaNodeMetricsExporterMissing",
+			fmt.Sprintf("kube_daemonset_status_desired_number_scheduled{%[1]s} - kube_daemonset_status_number_available{%[1]s} > 0", daemonSet),
+			alertFor,
+			"warning",
+			"Habana node metrics exporters are missing.",
+			fmt.Sprintf("{{ $value }} node metrics exporters of DeviceConfig %s are not available.", cr.Name),
+		),
+		alertRule(
+			"HabanaHPUCountLow",
+			fmt.Sprintf("count by (node) (%s{%s}) < %d", temperatureMetric, job, cr.GetAlertMinDevicesPerNode()),
+			alertFor,
+			"warning",
+			"A node reports less HPUs than expected.",
+			fmt.Sprintf("Node {{ $labels.node }} reports {{ $value }} HPUs, less than the %d HPUs expected by DeviceConfig %s.",
+				cr.GetAlertMinDevicesPerNode(), cr.Name),
+		),
+		alertRule(
+			"HabanaAIOperatorReconciliationFailed",
+			fmt.Sprintf(`%s{device_config="%s"} > 0`, reconciliationFailedMetric, cr.Name),
+			alertFor,
+			"warning",
+			"The reconciliation of a DeviceConfig is failing.",
+			fmt.Sprintf("The reconciliation of DeviceConfig %s is failing, please check its conditions for more details.", cr.Name),
+		),
+	}
+
+	pr.Object["spec"] = map[string]interface{}{
+		"groups": []interface{}{
+			map[string]interface{}{
+				"name":  fmt.Sprintf("habana-ai-%s", cr.Name),
+				"rules": rules,
+			},
+		},
+	}
+
+	return nil
+}
+
+func alertRule(name, expr, alertFor, severity, summary, description string) map[string]interface{} {
+	return map[string]interface{}{
+		"alert": name,
+		"expr":  expr,
+		"for":   alertFor,
+		"labels": map[string]interface{}{
+			"severity": severity,
+		},
+		"annotations": map[string]interface{}{
+			"summary":     summary,
+			"description": description,
+		},
+	}
+}
+
+// labelsForMonitoring returns the labels for selecting the
+// resources belonging to the given DeviceConfig CR name.
+func labelsForMonitoring(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
+		"app.kubernetes.io/component": monitoringSuffix,
+		constants.DeviceConfigLabel:   cr.Name,
+	}
+}
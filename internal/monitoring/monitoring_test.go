@@ -0,0 +1,259 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"errors"
+
+	gomock "github.com/golang/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/client"
+	nodeMetrics "github.com/HabanaAI/habana-ai-operator/internal/node/metrics"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+func getRules(pr *unstructured.Unstructured) map[string]map[string]interface{} {
+	groups, found, err := unstructured.NestedSlice(pr.Object, "spec", "groups")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+	Expect(groups).To(HaveLen(1))
+
+	rules, found, err := unstructured.NestedSlice(groups[0].(map[string]interface{}), "rules")
+	Expect(err).ToNot(HaveOccurred())
+	Expect(found).To(BeTrue())
+
+	byName := make(map[string]map[string]interface{}, len(rules))
+	for _, rule := range rules {
+		r := rule.(map[string]interface{})
+		byName[r["alert"].(string)] = r
+	}
+	return byName
+}
+
+var _ = Describe("MonitoringReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		r   *MonitoringReconciler
+		c   *client.MockClient
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+		}
+		c = client.NewMockClient(gomock.NewController(GinkgoT()))
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+
+		r = NewReconciler(c, s)
+
+		ctx = context.TODO()
+	})
+
+	Describe("ReconcileMonitoring", func() {
+		Context("with no client Get error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().
+						Get(ctx, gomock.Any(), gomock.Any()).
+						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "servicemonitors"}, GetServiceMonitorName(dc))).
+						AnyTimes(),
+				)
+			})
+
+			Context("with no client Create error", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().Create(ctx, gomock.Any()).Return(nil).Times(2),
+					)
+				})
+
+				It("should not return an error", func() {
+					Expect(r.ReconcileMonitoring(ctx, dc)).ToNot(HaveOccurred())
+				})
+			})
+
+			Context("with client Create error", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().Create(ctx, gomock.Any()).Return(errors.New("some-error")),
+					)
+				})
+
+				It("should return an error", func() {
+					Expect(r.ReconcileMonitoring(ctx, dc)).To(HaveOccurred())
+				})
+			})
+		})
+
+		Context("with client Get error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some-other-that-not-found-error")),
+				)
+			})
+
+			It("should return an error", func() {
+				Expect(r.ReconcileMonitoring(ctx, dc)).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("DeleteMonitoring", func() {
+		Context("with the Prometheus Operator not installed", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(&meta.NoKindMatchError{GroupKind: ServiceMonitorGVK.GroupKind()}),
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(&meta.NoKindMatchError{GroupKind: PrometheusRuleGVK.GroupKind()}),
+				)
+			})
+
+			It("should not return an error", func() {
+				Expect(r.DeleteMonitoring(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with client Delete error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(errors.New("some-error")),
+				)
+			})
+
+			It("should return an error", func() {
+				Expect(r.DeleteMonitoring(ctx, dc)).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("SetDesiredServiceMonitor", func() {
+		It("should return an error if the ServiceMonitor is nil", func() {
+			Expect(r.SetDesiredServiceMonitor(nil, dc)).To(HaveOccurred())
+		})
+
+		It("should select the node metrics Service with the default interval", func() {
+			sm := newUnstructured(ServiceMonitorGVK, GetServiceMonitorName(dc))
+			Expect(r.SetDesiredServiceMonitor(sm, dc)).ToNot(HaveOccurred())
+
+			matchLabels, _, err := unstructured.NestedStringMap(sm.Object, "spec", "selector", "matchLabels")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matchLabels).To(Equal(nodeMetrics.GetNodeMetricsServiceLabels(dc)))
+
+			namespaces, _, err := unstructured.NestedStringSlice(sm.Object, "spec", "namespaceSelector", "matchNames")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(namespaces).To(ConsistOf(s.Settings.OperatorNamespace))
+
+			endpoints, _, err := unstructured.NestedSlice(sm.Object, "spec", "endpoints")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(endpoints).To(HaveLen(1))
+			Expect(endpoints[0]).To(HaveKeyWithValue("port", nodeMetrics.NodeMetricsPortName))
+			Expect(endpoints[0]).To(HaveKeyWithValue("interval", hlaiv1alpha1.DefaultMonitoringInterval))
+		})
+
+		It("should use the interval set in the DeviceConfig", func() {
+			dc.Spec.Monitoring.Interval = "1m"
+
+			sm := newUnstructured(ServiceMonitorGVK, GetServiceMonitorName(dc))
+			Expect(r.SetDesiredServiceMonitor(sm, dc)).ToNot(HaveOccurred())
+
+			endpoints, _, err := unstructured.NestedSlice(sm.Object, "spec", "endpoints")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(endpoints[0]).To(HaveKeyWithValue("interval", "1m"))
+		})
+	})
+
+	Describe("SetDesiredServiceMonitor with secured node metrics", func() {
+		It("should scrape the kube-rbac-proxy over TLS", func() {
+			dc.Spec.NodeMetrics.Secure = true
+
+			sm := newUnstructured(ServiceMonitorGVK, GetServiceMonitorName(dc))
+			Expect(r.SetDesiredServiceMonitor(sm, dc)).ToNot(HaveOccurred())
+
+			endpoints, _, err := unstructured.NestedSlice(sm.Object, "spec", "endpoints")
+			Expect(err).ToNot(HaveOccurred())
+
+			endpoint := endpoints[0].(map[string]interface{})
+			Expect(endpoint).To(HaveKeyWithValue("port", nodeMetrics.NodeMetricsSecurePortName))
+			Expect(endpoint).To(HaveKeyWithValue("scheme", "https"))
+			Expect(endpoint).ToNot(HaveKey("bearerTokenFile"))
+
+			secret, _, err := unstructured.NestedString(endpoint, "authorization", "credentials", "name")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secret).To(Equal(rbac.GetMetricsReaderTokenSecretName(dc)))
+
+			serverName, _, err := unstructured.NestedString(endpoint, "tlsConfig", "serverName")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(serverName).To(Equal(nodeMetrics.GetNodeMetricsServiceHostname(dc)))
+
+			ca, _, err := unstructured.NestedString(endpoint, "tlsConfig", "ca", "configMap", "name")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ca).To(Equal(nodeMetrics.GetNodeMetricsCABundleName(dc)))
+		})
+	})
+
+	Describe("SetDesiredPrometheusRule", func() {
+		It("should return an error if the PrometheusRule is nil", func() {
+			Expect(r.SetDesiredPrometheusRule(nil, dc)).To(HaveOccurred())
+		})
+
+		It("should set the default alerts with the default thresholds", func() {
+			pr := newUnstructured(PrometheusRuleGVK, GetPrometheusRuleName(dc))
+			Expect(r.SetDesiredPrometheusRule(pr, dc)).ToNot(HaveOccurred())
+
+			rules := getRules(pr)
+			Expect(rules).To(HaveLen(5))
+			Expect(rules).To(HaveKey("HabanaNodeMetricsExporterDown"))
+			Expect(rules).To(HaveKey("HabanaNodeMetricsExporterMissing"))
+			Expect(rules["HabanaHPUTemperatureHigh"]["expr"]).To(HaveSuffix("> 85"))
+			Expect(rules["HabanaHPUCountLow"]["expr"]).To(HaveSuffix("< 8"))
+			Expect(rules["HabanaAIOperatorReconciliationFailed"]["expr"]).To(ContainSubstring(`device_config="a-device-config"`))
+			Expect(rules["HabanaAIOperatorReconciliationFailed"]["for"]).To(Equal(hlaiv1alpha1.DefaultAlertFor))
+		})
+
+		It("should use the thresholds set in the DeviceConfig", func() {
+			dc.Spec.Monitoring.Alerts = hlaiv1alpha1.AlertsSpec{
+				TemperatureThreshold: 90,
+				MinDevicesPerNode:    4,
+				For:                  "10m",
+			}
+
+			pr := newUnstructured(PrometheusRuleGVK, GetPrometheusRuleName(dc))
+			Expect(r.SetDesiredPrometheusRule(pr, dc)).ToNot(HaveOccurred())
+
+			rules := getRules(pr)
+			Expect(rules["HabanaHPUTemperatureHigh"]["expr"]).To(HaveSuffix("> 90"))
+			Expect(rules["HabanaHPUCountLow"]["expr"]).To(HaveSuffix("< 4"))
+			Expect(rules["HabanaHPUCountLow"]["for"]).To(Equal("10m"))
+		})
+	})
+})
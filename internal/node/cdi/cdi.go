@@ -0,0 +1,272 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	operatorMetrics "github.com/HabanaAI/habana-ai-operator/internal/metrics"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	cdiSuffix = rbac.ComponentCDI
+
+	cdiVolume         = "cdi"
+	cdiLimitsCpu      = "50m"
+	cdiLimitsMemory   = "32Mi"
+	cdiRequestsCpu    = "10m"
+	cdiRequestsMemory = "16Mi"
+
+	// CDIVersion is the version of the CDI specs written on the nodes.
+	CDIVersion = "0.5.0"
+
+	// CDIKind is the kind of the HPUs in the CDI specs, so that the device
+	// names are habana.ai/gaudi=<index>.
+	CDIKind = constants.HabanaResourceName
+)
+
+// CDISpecPath is the path of the CDI spec of the HPUs on the nodes.
+var CDISpecPath = filepath.Join(hlaiv1alpha1.CDISpecDir, "habana.ai-gaudi.json")
+
+// cdiScript writes the CDI spec of the HPU device nodes of the node every 30
+// seconds, and removes it when the pod is deleted. The privileged container
+// sees the device nodes of the host. Both the /dev/accel/accel<index> and the
+// former /dev/hl<index> device nodes are handled, along with their control
+// device nodes.
+const cdiScript = `trap 'rm -f "$CDI_SPEC"; exit 0' TERM INT
+while true; do
+  devices=""
+  for d in /dev/accel/accel[0-9]* /dev/hl[0-9]*; do
+    [ -c "$d" ] || continue
+    base="${d%%[0-9]*}"
+    index="${d#"$base"}"
+    nodes="{\"path\":\"$d\"}"
+    if [ -c "${base}_controlD${index}" ]; then
+      nodes="$nodes,{\"path\":\"${base}_controlD${index}\"}"
+    fi
+    devices="$devices${devices:+,}{\"name\":\"$index\",\"containerEdits\":{\"deviceNodes\":[$nodes]}}"
+  done
+  if [ -n "$devices" ]; then
+    printf '{"cdiVersion":"%s","kind":"%s","devices":[%s]}\n' "$CDI_VERSION" "$CDI_KIND" "$devices" > "$CDI_SPEC.tmp"
+    mv -f "$CDI_SPEC.tmp" "$CDI_SPEC"
+  fi
+  sleep 30 &
+  wait $!
+done`
+
+//go:generate mockgen -source=cdi.go -package=cdi -destination=mock_cdi.go
+
+type Reconciler interface {
+	ReconcileCDI(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredCDIDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error
+	DeleteCDI(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+}
+
+type CDIReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *CDIReconciler {
+	return &CDIReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+func GetCDIName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-%s", cr.Name, cdiSuffix)
+}
+
+// ReconcileCDI deploys the DaemonSet writing the CDI specs of the HPUs on the
+// nodes of the DeviceConfig when CDI is enabled, and deletes it otherwise.
+func (r *CDIReconciler) ReconcileCDI(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	if !cr.Spec.CDI.Enabled {
+		return r.DeleteCDI(ctx, cr)
+	}
+
+	logger := log.FromContext(ctx)
+
+	existingDS := &appsv1.DaemonSet{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: GetCDIName(cr)}, existingDS)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetCDIName(cr),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	if exists {
+		ds = existingDS
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, ds, func() error {
+		return r.SetDesiredCDIDaemonSet(ds, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch DaemonSet: %v", err)
+	}
+
+	logger.Info("Reconciled DaemonSet", "resource", ds.Name, "result", res)
+
+	operatorMetrics.SetComponentNodes(cr.Name, rbac.ComponentCDI, ds.Status.DesiredNumberScheduled, ds.Status.NumberReady)
+
+	return nil
+}
+
+// DeleteCDI deletes the CDI DaemonSet. Its pods remove the CDI specs from the
+// nodes when they are terminated.
+func (r *CDIReconciler) DeleteCDI(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetCDIName(cr),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	err := r.client.Delete(ctx, ds)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete DaemonSet %s: %w", ds.Name, err)
+	}
+
+	return nil
+}
+
+func (r *CDIReconciler) SetDesiredCDIDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error {
+	if ds == nil {
+		return errors.New("daemonset cannot be nil")
+	}
+
+	labels := labelsForCDIDaemonSet(cr)
+
+	ds.ObjectMeta.Labels = labels
+
+	ds.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: labels,
+	}
+
+	ds.Spec.Template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: labels,
+		},
+	}
+
+	hostPathTypeDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
+
+	// The ubi-minimal node cleanup image provides the shell and coreutils
+	// the script needs.
+	container := corev1.Container{
+		Name:            cdiSuffix,
+		Image:           s.Settings.NodeCleanupImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", cdiScript},
+		Env: []corev1.EnvVar{
+			{Name: "CDI_SPEC", Value: CDISpecPath},
+			{Name: "CDI_KIND", Value: CDIKind},
+			{Name: "CDI_VERSION", Value: CDIVersion},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: pointer.Bool(true),
+			RunAsUser:  pointer.Int64(0),
+		},
+		// The node is ready for CDI once the spec of its HPUs is written.
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{"test", "-s", CDISpecPath},
+				},
+			},
+			PeriodSeconds: 10,
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				"cpu":    resource.MustParse(cdiLimitsCpu),
+				"memory": resource.MustParse(cdiLimitsMemory),
+			},
+			Requests: corev1.ResourceList{
+				"cpu":    resource.MustParse(cdiRequestsCpu),
+				"memory": resource.MustParse(cdiRequestsMemory),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      cdiVolume,
+				MountPath: hlaiv1alpha1.CDISpecDir,
+			},
+		},
+	}
+
+	ds.Spec.Template.Spec = corev1.PodSpec{
+		Containers: []corev1.Container{container},
+		// The CDI specs are only written on the nodes with HPUs.
+		NodeSelector:                  module.GetModuleNodeSelector(cr),
+		PriorityClassName:             "system-node-critical",
+		ServiceAccountName:            rbac.GetServiceAccountName(cr, rbac.ComponentCDI),
+		TerminationGracePeriodSeconds: pointer.Int64(10),
+		Tolerations:                   readiness.GetTolerations(),
+		Volumes: []corev1.Volume{
+			{
+				Name: cdiVolume,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: hlaiv1alpha1.CDISpecDir,
+						Type: &hostPathTypeDirectoryOrCreate,
+					},
+				},
+			},
+		},
+	}
+
+	return nil
+}
+
+// labelsForCDIDaemonSet returns the labels for selecting the
+// resources belonging to the given DeviceConfig CR name.
+func labelsForCDIDaemonSet(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
+		"app.kubernetes.io/component": cdiSuffix,
+		constants.DeviceConfigLabel:   cr.Name,
+	}
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdi
+
+import (
+	"context"
+	"errors"
+
+	gomock "github.com/golang/mock/gomock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/client"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+var _ = Describe("CDIReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		r   *CDIReconciler
+		c   *client.MockClient
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "a-device-config",
+				Namespace: "a-namespace",
+			},
+		}
+		c = client.NewMockClient(gomock.NewController(GinkgoT()))
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+
+		r = NewReconciler(c, s)
+
+		ctx = context.TODO()
+	})
+
+	Describe("ReconcileCDI", func() {
+		Context("with CDI disabled", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().
+						Delete(ctx, gomock.Any()).
+						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, GetCDIName(dc))),
+				)
+			})
+
+			It("should delete the DaemonSet", func() {
+				Expect(r.ReconcileCDI(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with CDI enabled", func() {
+			BeforeEach(func() {
+				dc.Spec.CDI.Enabled = true
+			})
+
+			Context("with no client Get error", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().
+							Get(ctx, gomock.Any(), gomock.Any()).
+							Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, GetCDIName(dc))).
+							AnyTimes(),
+					)
+				})
+
+				Context("with no client Create error", func() {
+					BeforeEach(func() {
+						gomock.InOrder(
+							c.EXPECT().Create(ctx, gomock.Any()).Return(nil),
+						)
+					})
+
+					It("should not return an error", func() {
+						Expect(r.ReconcileCDI(ctx, dc)).ToNot(HaveOccurred())
+					})
+				})
+
+				Context("with client Create error", func() {
+					BeforeEach(func() {
+						gomock.InOrder(
+							c.EXPECT().Create(ctx, gomock.Any()).Return(errors.New("some-error")),
+						)
+					})
+
+					It("should return an error", func() {
+						Expect(r.ReconcileCDI(ctx, dc)).To(HaveOccurred())
+					})
+				})
+			})
+
+			Context("with client Get error", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some-other-that-not-found-error")),
+					)
+				})
+
+				It("should return an error", func() {
+					Expect(r.ReconcileCDI(ctx, dc)).To(HaveOccurred())
+				})
+			})
+		})
+	})
+
+	Describe("DeleteCDI", func() {
+		Context("without a client Delete error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(nil),
+				)
+			})
+
+			It("should not return an error", func() {
+				Expect(r.DeleteCDI(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with a generic client Delete error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(errors.New("some-error")),
+				)
+			})
+
+			It("should return an error", func() {
+				Expect(r.DeleteCDI(ctx, dc)).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("SetDesiredCDIDaemonSet", func() {
+		var (
+			ds *appsv1.DaemonSet
+		)
+
+		Context("with a nil DaemonSet as input", func() {
+			BeforeEach(func() {
+				ds = nil
+			})
+
+			It("should return a DaemonSet cannot be nil error", func() {
+				err := r.SetDesiredCDIDaemonSet(ds, dc)
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("daemonset cannot be nil"))
+			})
+		})
+
+		Context("with a non-nil DaemonSet as input", func() {
+			BeforeEach(func() {
+				dc.Spec.CDI.Enabled = true
+
+				ds = &appsv1.DaemonSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "a-name",
+						Namespace: "a-namespace",
+					},
+				}
+
+				err := r.SetDesiredCDIDaemonSet(ds, dc)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			Context("it returns a DaemonSet which", func() {
+				It("should only select the nodes with HPUs", func() {
+					Expect(ds.Spec.Template.Spec.NodeSelector).To(Equal(module.GetModuleNodeSelector(dc)))
+				})
+
+				It("should have the correct ServiceAccountName", func() {
+					Expect(ds.Spec.Template.Spec.ServiceAccountName).To(Equal(rbac.GetServiceAccountName(dc, rbac.ComponentCDI)))
+				})
+
+				It("should tolerate the operator taints", func() {
+					Expect(ds.Spec.Template.Spec.Tolerations).To(Equal(readiness.GetTolerations()))
+				})
+
+				It("should mount the CDI spec directory of the host", func() {
+					Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(1))
+					Expect(ds.Spec.Template.Spec.Volumes[0].HostPath).ToNot(BeNil())
+					Expect(ds.Spec.Template.Spec.Volumes[0].HostPath.Path).To(Equal(hlaiv1alpha1.CDISpecDir))
+				})
+
+				Context("contains the CDI container, which", func() {
+					var (
+						container corev1.Container
+					)
+
+					BeforeEach(func() {
+						Expect(ds.Spec.Template.Spec.Containers).To(HaveLen(1))
+						container = ds.Spec.Template.Spec.Containers[0]
+					})
+
+					It("should have the correct image", func() {
+						Expect(container.Image).To(Equal(s.Settings.NodeCleanupImage))
+					})
+
+					It("should be privileged", func() {
+						Expect(container.SecurityContext.Privileged).ToNot(BeNil())
+						Expect(*container.SecurityContext.Privileged).To(BeTrue())
+					})
+
+					It("should write the CDI spec of the HPUs", func() {
+						Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "CDI_SPEC", Value: CDISpecPath}))
+						Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "CDI_KIND", Value: CDIKind}))
+					})
+
+					It("should only be ready once the CDI spec is written", func() {
+						Expect(container.ReadinessProbe).ToNot(BeNil())
+						Expect(container.ReadinessProbe.Exec.Command).To(ContainElement(CDISpecPath))
+					})
+				})
+			})
+		})
+	})
+})
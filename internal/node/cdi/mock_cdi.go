@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: cdi.go
+
+// Package cdi is a generated GoMock package.
+package cdi
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/api/apps/v1"
+)
+
+// MockReconciler is a mock of Reconciler interface.
+type MockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcilerMockRecorder
+}
+
+// MockReconcilerMockRecorder is the mock recorder for MockReconciler.
+type MockReconcilerMockRecorder struct {
+	mock *MockReconciler
+}
+
+// NewMockReconciler creates a new mock instance.
+func NewMockReconciler(ctrl *gomock.Controller) *MockReconciler {
+	mock := &MockReconciler{ctrl: ctrl}
+	mock.recorder = &MockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// DeleteCDI mocks base method.
+func (m *MockReconciler) DeleteCDI(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCDI", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCDI indicates an expected call of DeleteCDI.
+func (mr *MockReconcilerMockRecorder) DeleteCDI(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCDI", reflect.TypeOf((*MockReconciler)(nil).DeleteCDI), ctx, dc)
+}
+
+// ReconcileCDI mocks base method.
+func (m *MockReconciler) ReconcileCDI(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileCDI", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileCDI indicates an expected call of ReconcileCDI.
+func (mr *MockReconcilerMockRecorder) ReconcileCDI(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileCDI", reflect.TypeOf((*MockReconciler)(nil).ReconcileCDI), ctx, dc)
+}
+
+// SetDesiredCDIDaemonSet mocks base method.
+func (m *MockReconciler) SetDesiredCDIDaemonSet(ds *v1.DaemonSet, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredCDIDaemonSet", ds, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredCDIDaemonSet indicates an expected call of SetDesiredCDIDaemonSet.
+func (mr *MockReconcilerMockRecorder) SetDesiredCDIDaemonSet(ds, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredCDIDaemonSet", reflect.TypeOf((*MockReconciler)(nil).SetDesiredCDIDaemonSet), ds, cr)
+}
@@ -0,0 +1,555 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	nodeHealth "github.com/HabanaAI/habana-ai-operator/internal/node/health"
+	nodeLabeler "github.com/HabanaAI/habana-ai-operator/internal/node/labeler"
+	nodeNetwork "github.com/HabanaAI/habana-ai-operator/internal/node/network"
+	nodeSimulation "github.com/HabanaAI/habana-ai-operator/internal/node/simulation"
+	nodeTopology "github.com/HabanaAI/habana-ai-operator/internal/node/topology"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	nodeCleanupSuffix = "node-cleanup"
+
+	// nodeNameAnnotation is set on the cleanup Jobs, as node names do not
+	// always fit in a label value.
+	nodeNameAnnotation = "habana.ai/node-name"
+
+	// habanaLabelPrefix is the prefix of the labels of the feature files
+	// removed on cleanup.
+	habanaLabelPrefix = "habana.ai/"
+
+	nodeCleanupBackoffLimit          = 3
+	nodeCleanupActiveDeadlineSeconds = 300
+)
+
+var (
+	// managedNodeLabels are the node labels of a DeviceConfig removed on
+	// cleanup. The labels set by other tools in the habana.ai domain are kept.
+	managedNodeLabels = []string{
+		constants.HPUPresentLabel,
+		constants.DeviceConfigLabel,
+		nodeLabeler.HPUProductLabel,
+		nodeLabeler.HPUCountLabel,
+		nodeLabeler.DriverVersionLabel,
+		nodeHealth.DegradedLabel,
+		nodeSimulation.SimulatedLabel,
+	}
+
+	// managedNodeLabelPrefixes are the prefixes of the node labels of a
+	// DeviceConfig whose names depend on the node, e.g. its NUMA nodes.
+	managedNodeLabelPrefixes = []string{
+		nodeNetwork.LabelPrefix,
+		nodeTopology.LabelPrefix,
+	}
+)
+
+// nodeCleanupScript removes the feature files advertising Habana labels.
+var nodeCleanupScript = fmt.Sprintf(`for f in %[1]s/*; do
+  if [ -f "$f" ] && grep -qE '%[2]s|pci-%[3]s' "$f"; then
+    echo "Removing $f"
+    rm -f "$f"
+  fi
+done`, constants.FeaturesDir, habanaLabelPrefix, hlaiv1alpha1.HabanaPCIVendorID)
+
+//go:generate mockgen -source=cleanup.go -package=cleanup -destination=mock_cleanup.go
+
+type Reconciler interface {
+	ReconcileNodeCleanup(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	CleanupNodes(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) (bool, error)
+	ReconcileNodeCleanupJob(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig, nodeName string) (*batchv1.Job, error)
+	SetDesiredNodeCleanupJob(job *batchv1.Job, cr *hlaiv1alpha1.DeviceConfig, nodeName string) error
+	DeleteNodeCleanupJobs(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+}
+
+type NodeCleanupReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *NodeCleanupReconciler {
+	return &NodeCleanupReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+// GetNodeCleanupJobName returns the name of the cleanup Job of the given node.
+// The node name is hashed, as it may be too long for a Job name.
+func GetNodeCleanupJobName(cr *hlaiv1alpha1.DeviceConfig, nodeName string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(nodeName))
+	return fmt.Sprintf("%s-%s-%08x", cr.Name, nodeCleanupSuffix, h.Sum32())
+}
+
+// ReconcileNodeCleanup cleans up the nodes that left the DeviceConfig node
+// selector since the previous reconciliation, and updates the list of nodes
+// selected by the DeviceConfig in its status.
+func (r *NodeCleanupReconciler) ReconcileNodeCleanup(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	selected, err := r.getSelectedNodeNames(ctx, cr)
+	if err != nil {
+		return err
+	}
+
+	isSelected := make(map[string]bool, len(selected))
+	for _, n := range selected {
+		isSelected[n] = true
+	}
+
+	// A node which is selected again may need to be cleaned up again when it
+	// leaves the DeviceConfig later on.
+	for _, n := range selected {
+		if getNodeCleanupStatus(cr, n) == nil {
+			continue
+		}
+		if err := r.deleteNodeCleanupJob(ctx, cr, n); err != nil {
+			return err
+		}
+		removeNodeCleanupStatus(cr, n)
+	}
+
+	nodes := []string{}
+	for _, n := range cr.Status.Nodes {
+		if !isSelected[n] {
+			nodes = append(nodes, n)
+		}
+	}
+	for _, ncs := range cr.Status.NodeCleanup {
+		if ncs.State == hlaiv1alpha1.NodeCleanupPending {
+			nodes = append(nodes, ncs.NodeName)
+		}
+	}
+
+	for _, n := range uniqueSorted(nodes) {
+		if _, err := r.cleanupNode(ctx, cr, n); err != nil {
+			return err
+		}
+	}
+
+	if err := r.pruneNodeCleanupStatus(ctx, cr); err != nil {
+		return err
+	}
+
+	cr.Status.Nodes = selected
+
+	return nil
+}
+
+// pruneNodeCleanupStatus forgets the cleanups that succeeded, and the failed
+// ones of the nodes that have since been removed. The cleanups of the nodes
+// of a deleted DeviceConfig are tracked by CleanupNodes until its finalizer
+// is released, hence are not pruned.
+func (r *NodeCleanupReconciler) pruneNodeCleanupStatus(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	statuses := []hlaiv1alpha1.NodeCleanupStatus{}
+	for _, ncs := range cr.Status.NodeCleanup {
+		switch ncs.State {
+		case hlaiv1alpha1.NodeCleanupSucceeded:
+			continue
+
+		case hlaiv1alpha1.NodeCleanupFailed:
+			err := r.client.Get(ctx, types.NamespacedName{Name: ncs.NodeName}, &corev1.Node{})
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+		statuses = append(statuses, ncs)
+	}
+	cr.Status.NodeCleanup = statuses
+
+	return nil
+}
+
+// CleanupNodes cleans up all the nodes of the DeviceConfig, and returns
+// whether the cleanup of every node is over, either successfully or not.
+func (r *NodeCleanupReconciler) CleanupNodes(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) (bool, error) {
+	selected, err := r.getSelectedNodeNames(ctx, cr)
+	if err != nil {
+		return false, err
+	}
+
+	nodes := append([]string{}, selected...)
+	nodes = append(nodes, cr.Status.Nodes...)
+	for _, ncs := range cr.Status.NodeCleanup {
+		nodes = append(nodes, ncs.NodeName)
+	}
+
+	done := true
+	for _, n := range uniqueSorted(nodes) {
+		state, err := r.cleanupNode(ctx, cr, n)
+		if err != nil {
+			return false, err
+		}
+		if state == hlaiv1alpha1.NodeCleanupPending {
+			done = false
+		}
+	}
+
+	return done, nil
+}
+
+// cleanupNode runs the cleanup Job on the given node, removes the habana.ai
+// labels of the node once the Job succeeded and records the result in the
+// DeviceConfig status.
+func (r *NodeCleanupReconciler) cleanupNode(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, nodeName string) (hlaiv1alpha1.NodeCleanupState, error) {
+	logger := log.FromContext(ctx)
+
+	if ncs := getNodeCleanupStatus(cr, nodeName); ncs != nil && ncs.State != hlaiv1alpha1.NodeCleanupPending {
+		return ncs.State, nil
+	}
+
+	node := &corev1.Node{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: nodeName}, node)
+	if apierrors.IsNotFound(err) {
+		// Nothing is left to clean up on a node that has been removed.
+		if err := r.deleteNodeCleanupJob(ctx, cr, nodeName); err != nil {
+			return "", err
+		}
+		setNodeCleanupStatus(cr, nodeName, hlaiv1alpha1.NodeCleanupSucceeded, "Node not found")
+		return hlaiv1alpha1.NodeCleanupSucceeded, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	job, err := r.ReconcileNodeCleanupJob(ctx, cr, nodeName)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+		if err := r.removeHabanaNodeLabels(ctx, node); err != nil {
+			return "", err
+		}
+		if err := r.deleteNodeCleanupJob(ctx, cr, nodeName); err != nil {
+			return "", err
+		}
+		logger.Info("Cleaned up node", "node", nodeName)
+		setNodeCleanupStatus(cr, nodeName, hlaiv1alpha1.NodeCleanupSucceeded, "Feature files and labels removed")
+		return hlaiv1alpha1.NodeCleanupSucceeded, nil
+
+	case isJobFailed(job):
+		if err := r.deleteNodeCleanupJob(ctx, cr, nodeName); err != nil {
+			return "", err
+		}
+		msg := fmt.Sprintf("Job %s failed: %s", job.Name, getJobFailedMessage(job))
+		logger.Info("Failed to clean up node", "node", nodeName, "message", msg)
+		setNodeCleanupStatus(cr, nodeName, hlaiv1alpha1.NodeCleanupFailed, msg)
+		return hlaiv1alpha1.NodeCleanupFailed, nil
+	}
+
+	setNodeCleanupStatus(cr, nodeName, hlaiv1alpha1.NodeCleanupPending, fmt.Sprintf("Waiting for Job %s", job.Name))
+	return hlaiv1alpha1.NodeCleanupPending, nil
+}
+
+// ReconcileNodeCleanupJob creates the cleanup Job of the given node, if it does
+// not exist yet. The Job pod template is immutable, hence the Job is never patched.
+func (r *NodeCleanupReconciler) ReconcileNodeCleanupJob(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, nodeName string) (*batchv1.Job, error) {
+	logger := log.FromContext(ctx)
+
+	job := &batchv1.Job{}
+	err := r.client.Get(ctx, types.NamespacedName{
+		Namespace: s.Settings.OperatorNamespace,
+		Name:      GetNodeCleanupJobName(cr, nodeName),
+	}, job)
+	if err == nil {
+		return job, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	job = &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetNodeCleanupJobName(cr, nodeName),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	if err := r.SetDesiredNodeCleanupJob(job, cr, nodeName); err != nil {
+		return nil, err
+	}
+
+	if err := r.client.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("could not create Job: %v", err)
+	}
+
+	logger.Info("Reconciled Job", "resource", job.Name, "node", nodeName)
+
+	return job, nil
+}
+
+func (r *NodeCleanupReconciler) DeleteNodeCleanupJobs(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	err := r.client.DeleteAllOf(ctx, &batchv1.Job{},
+		client.InNamespace(s.Settings.OperatorNamespace),
+		client.MatchingLabels(labelsForNodeCleanupJob(cr)),
+		client.PropagationPolicy(metav1.DeletePropagationBackground),
+	)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the node cleanup Jobs: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NodeCleanupReconciler) deleteNodeCleanupJob(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, nodeName string) error {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetNodeCleanupJobName(cr, nodeName),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	err := r.client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete Job %s: %w", job.Name, err)
+	}
+
+	return nil
+}
+
+func (r *NodeCleanupReconciler) SetDesiredNodeCleanupJob(job *batchv1.Job, cr *hlaiv1alpha1.DeviceConfig, nodeName string) error {
+	if job == nil {
+		return errors.New("job cannot be nil")
+	}
+
+	labels := labelsForNodeCleanupJob(cr)
+
+	job.ObjectMeta.Labels = labels
+	job.ObjectMeta.Annotations = map[string]string{
+		nodeNameAnnotation: nodeName,
+	}
+
+	hostPathTypeDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
+	volumes := []corev1.Volume{
+		{
+			Name: "features-d",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: constants.FeaturesDir,
+					Type: &hostPathTypeDirectoryOrCreate,
+				},
+			},
+		},
+	}
+
+	job.Spec = batchv1.JobSpec{
+		BackoffLimit:          pointer.Int32(nodeCleanupBackoffLimit),
+		ActiveDeadlineSeconds: pointer.Int64(nodeCleanupActiveDeadlineSeconds),
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: labels,
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					r.makeNodeCleanupContainer(),
+				},
+				// The Job is bound to the node, bypassing the scheduler, and
+				// must run whatever the taints of the node.
+				NodeName:           nodeName,
+				PriorityClassName:  "system-node-critical",
+				RestartPolicy:      corev1.RestartPolicyNever,
+				ServiceAccountName: rbac.GetServiceAccountName(cr, rbac.ComponentNodeCleanup),
+				Tolerations: []corev1.Toleration{
+					{Operator: corev1.TolerationOpExists},
+				},
+				Volumes: volumes,
+			},
+		},
+	}
+
+	return nil
+}
+
+func (r *NodeCleanupReconciler) makeNodeCleanupContainer() corev1.Container {
+	return corev1.Container{
+		Name:            nodeCleanupSuffix,
+		Image:           s.Settings.NodeCleanupImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/sh", "-c", nodeCleanupScript},
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: pointer.Bool(true),
+			RunAsUser:  pointer.Int64(0),
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "features-d",
+				MountPath: constants.FeaturesDir,
+			},
+		},
+	}
+}
+
+// isManagedNodeLabel returns whether the given node label is managed by a
+// DeviceConfig.
+func isManagedNodeLabel(key string) bool {
+	for _, l := range managedNodeLabels {
+		if key == l {
+			return true
+		}
+	}
+	for _, p := range managedNodeLabelPrefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeHabanaNodeLabels removes the labels managed by the DeviceConfigs from
+// the given node. The labels still backed by a NodeFeatureRule are restored by
+// NFD.
+func (r *NodeCleanupReconciler) removeHabanaNodeLabels(ctx context.Context, node *corev1.Node) error {
+	patch := client.MergeFrom(node.DeepCopy())
+
+	changed := false
+	for k := range node.Labels {
+		if isManagedNodeLabel(k) {
+			delete(node.Labels, k)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := r.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to remove the labels of node %s: %w", node.Name, err)
+	}
+
+	return nil
+}
+
+func (r *NodeCleanupReconciler) getSelectedNodeNames(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) ([]string, error) {
+	nodeList := &corev1.NodeList{}
+
+	selector := labels.Set(cr.GetNodeSelector()).AsSelector()
+
+	err := r.client.List(ctx, nodeList, client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the DeviceConfig nodes: %w", err)
+	}
+
+	names := make([]string, 0, len(nodeList.Items))
+	for _, n := range nodeList.Items {
+		names = append(names, n.Name)
+	}
+
+	return uniqueSorted(names), nil
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func getJobFailedMessage(job *batchv1.Job) string {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed {
+			return c.Message
+		}
+	}
+	return ""
+}
+
+func getNodeCleanupStatus(cr *hlaiv1alpha1.DeviceConfig, nodeName string) *hlaiv1alpha1.NodeCleanupStatus {
+	for i := range cr.Status.NodeCleanup {
+		if cr.Status.NodeCleanup[i].NodeName == nodeName {
+			return &cr.Status.NodeCleanup[i]
+		}
+	}
+	return nil
+}
+
+func setNodeCleanupStatus(cr *hlaiv1alpha1.DeviceConfig, nodeName string, state hlaiv1alpha1.NodeCleanupState, message string) {
+	ncs := getNodeCleanupStatus(cr, nodeName)
+	if ncs == nil {
+		cr.Status.NodeCleanup = append(cr.Status.NodeCleanup, hlaiv1alpha1.NodeCleanupStatus{NodeName: nodeName})
+		ncs = &cr.Status.NodeCleanup[len(cr.Status.NodeCleanup)-1]
+	}
+
+	if ncs.State != state {
+		ncs.LastTransitionTime = metav1.Now()
+	}
+	ncs.State = state
+	ncs.Message = message
+}
+
+func removeNodeCleanupStatus(cr *hlaiv1alpha1.DeviceConfig, nodeName string) {
+	statuses := []hlaiv1alpha1.NodeCleanupStatus{}
+	for _, ncs := range cr.Status.NodeCleanup {
+		if ncs.NodeName != nodeName {
+			statuses = append(statuses, ncs)
+		}
+	}
+	cr.Status.NodeCleanup = statuses
+}
+
+func uniqueSorted(arr []string) []string {
+	seen := make(map[string]bool, len(arr))
+	res := []string{}
+	for _, e := range arr {
+		if !seen[e] {
+			seen[e] = true
+			res = append(res, e)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// labelsForNodeCleanupJob returns the labels for selecting the
+// resources belonging to the given DeviceConfig CR name.
+func labelsForNodeCleanupJob(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
+		"app.kubernetes.io/component": nodeCleanupSuffix,
+		constants.DeviceConfigLabel:   cr.Name,
+	}
+}
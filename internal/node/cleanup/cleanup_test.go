@@ -0,0 +1,431 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	gomock "github.com/golang/mock/gomock"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/client"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+)
+
+const (
+	testNodeName = "a-node"
+)
+
+var _ = Describe("NodeCleanupReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		r   *NodeCleanupReconciler
+		c   *client.MockClient
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+		}
+		c = client.NewMockClient(gomock.NewController(GinkgoT()))
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+
+		r = NewReconciler(c, s)
+
+		ctx = context.TODO()
+	})
+
+	listNodes := func(names ...string) *gomock.Call {
+		return c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, l *corev1.NodeList, _ ...ctrlclient.ListOption) error {
+				for _, n := range names {
+					l.Items = append(l.Items, corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: n}})
+				}
+				return nil
+			},
+		)
+	}
+
+	getNode := func(labels map[string]string) *gomock.Call {
+		return c.EXPECT().Get(ctx, types.NamespacedName{Name: testNodeName}, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ types.NamespacedName, n *corev1.Node) error {
+				n.Name = testNodeName
+				n.Labels = labels
+				return nil
+			},
+		)
+	}
+
+	getJob := func(status batchv1.JobStatus) *gomock.Call {
+		return c.EXPECT().Get(ctx, gomock.Any(), gomock.AssignableToTypeOf(&batchv1.Job{})).DoAndReturn(
+			func(_ context.Context, _ types.NamespacedName, j *batchv1.Job) error {
+				j.Name = GetNodeCleanupJobName(dc, testNodeName)
+				j.Status = status
+				return nil
+			},
+		)
+	}
+
+	Describe("CleanupNodes", func() {
+		Context("with a node without cleanup Job", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					listNodes(testNodeName),
+					getNode(nil),
+					c.EXPECT().
+						Get(ctx, gomock.Any(), gomock.AssignableToTypeOf(&batchv1.Job{})).
+						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "jobs"}, GetNodeCleanupJobName(dc, testNodeName))),
+					c.EXPECT().Create(ctx, gomock.AssignableToTypeOf(&batchv1.Job{})).Return(nil),
+				)
+			})
+
+			It("should create the Job and report the cleanup as pending", func() {
+				done, err := r.CleanupNodes(ctx, dc)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeFalse())
+
+				Expect(dc.Status.NodeCleanup).To(HaveLen(1))
+				Expect(dc.Status.NodeCleanup[0].NodeName).To(Equal(testNodeName))
+				Expect(dc.Status.NodeCleanup[0].State).To(Equal(hlaiv1alpha1.NodeCleanupPending))
+			})
+		})
+
+		Context("with a succeeded cleanup Job", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					listNodes(testNodeName),
+					getNode(map[string]string{
+						"habana.ai/hpu.gaudi.present":   "true",
+						"habana.ai/network.ports":       "2",
+						"habana.ai/topology.numa0.hpus": "4",
+						"habana.ai/workload-zone":       "a",
+						"other":                         "label",
+					}),
+					getJob(batchv1.JobStatus{Succeeded: 1}),
+					c.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+						func(_ context.Context, n *corev1.Node, _ ctrlclient.Patch, _ ...ctrlclient.PatchOption) error {
+							Expect(n.Labels).To(Equal(map[string]string{"habana.ai/workload-zone": "a", "other": "label"}))
+							return nil
+						},
+					),
+					c.EXPECT().Delete(ctx, gomock.AssignableToTypeOf(&batchv1.Job{}), gomock.Any()).Return(nil),
+				)
+			})
+
+			It("should only remove the managed labels and report the cleanup as succeeded", func() {
+				done, err := r.CleanupNodes(ctx, dc)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeTrue())
+
+				Expect(dc.Status.NodeCleanup).To(HaveLen(1))
+				Expect(dc.Status.NodeCleanup[0].State).To(Equal(hlaiv1alpha1.NodeCleanupSucceeded))
+			})
+		})
+
+		Context("with a failed cleanup Job", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					listNodes(testNodeName),
+					getNode(nil),
+					getJob(batchv1.JobStatus{
+						Conditions: []batchv1.JobCondition{
+							{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "some-message"},
+						},
+					}),
+					c.EXPECT().Delete(ctx, gomock.AssignableToTypeOf(&batchv1.Job{}), gomock.Any()).Return(nil),
+				)
+			})
+
+			It("should report the cleanup as failed", func() {
+				done, err := r.CleanupNodes(ctx, dc)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeTrue())
+
+				Expect(dc.Status.NodeCleanup).To(HaveLen(1))
+				Expect(dc.Status.NodeCleanup[0].State).To(Equal(hlaiv1alpha1.NodeCleanupFailed))
+				Expect(dc.Status.NodeCleanup[0].Message).To(ContainSubstring("some-message"))
+			})
+		})
+
+		Context("with a node that no longer exists", func() {
+			BeforeEach(func() {
+				dc.Status.Nodes = []string{testNodeName}
+
+				gomock.InOrder(
+					listNodes(),
+					c.EXPECT().
+						Get(ctx, types.NamespacedName{Name: testNodeName}, gomock.Any()).
+						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "nodes"}, testNodeName)),
+					c.EXPECT().Delete(ctx, gomock.AssignableToTypeOf(&batchv1.Job{}), gomock.Any()).Return(nil),
+				)
+			})
+
+			It("should report the cleanup as succeeded", func() {
+				done, err := r.CleanupNodes(ctx, dc)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeTrue())
+				Expect(dc.Status.NodeCleanup[0].State).To(Equal(hlaiv1alpha1.NodeCleanupSucceeded))
+			})
+		})
+
+		Context("with a node already cleaned up", func() {
+			BeforeEach(func() {
+				dc.Status.NodeCleanup = []hlaiv1alpha1.NodeCleanupStatus{
+					{NodeName: testNodeName, State: hlaiv1alpha1.NodeCleanupSucceeded},
+				}
+
+				gomock.InOrder(
+					listNodes(testNodeName),
+				)
+			})
+
+			It("should not clean up the node again", func() {
+				done, err := r.CleanupNodes(ctx, dc)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(done).To(BeTrue())
+			})
+		})
+
+		Context("with a client List error", func() {
+			BeforeEach(func() {
+				c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some-error"))
+			})
+
+			It("should return an error", func() {
+				_, err := r.CleanupNodes(ctx, dc)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("ReconcileNodeCleanup", func() {
+		Context("with a node that left the node selector", func() {
+			BeforeEach(func() {
+				dc.Status.Nodes = []string{testNodeName}
+
+				gomock.InOrder(
+					listNodes(),
+					getNode(nil),
+					getJob(batchv1.JobStatus{Active: 1}),
+				)
+			})
+
+			It("should clean up the node and update the selected nodes", func() {
+				Expect(r.ReconcileNodeCleanup(ctx, dc)).ToNot(HaveOccurred())
+
+				Expect(dc.Status.Nodes).To(BeEmpty())
+				Expect(dc.Status.NodeCleanup).To(HaveLen(1))
+				Expect(dc.Status.NodeCleanup[0].State).To(Equal(hlaiv1alpha1.NodeCleanupPending))
+			})
+		})
+
+		Context("with a node that left the node selector and was cleaned up", func() {
+			BeforeEach(func() {
+				dc.Status.Nodes = []string{testNodeName}
+
+				gomock.InOrder(
+					listNodes(),
+					getNode(nil),
+					getJob(batchv1.JobStatus{Succeeded: 1}),
+					c.EXPECT().Delete(ctx, gomock.AssignableToTypeOf(&batchv1.Job{}), gomock.Any()).Return(nil),
+				)
+			})
+
+			It("should forget the cleanup", func() {
+				Expect(r.ReconcileNodeCleanup(ctx, dc)).ToNot(HaveOccurred())
+
+				Expect(dc.Status.Nodes).To(BeEmpty())
+				Expect(dc.Status.NodeCleanup).To(BeEmpty())
+			})
+		})
+
+		Context("with a failed cleanup of a node that no longer exists", func() {
+			BeforeEach(func() {
+				dc.Status.NodeCleanup = []hlaiv1alpha1.NodeCleanupStatus{
+					{NodeName: testNodeName, State: hlaiv1alpha1.NodeCleanupFailed},
+				}
+
+				gomock.InOrder(
+					listNodes(),
+					c.EXPECT().
+						Get(ctx, types.NamespacedName{Name: testNodeName}, gomock.Any()).
+						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "nodes"}, testNodeName)),
+				)
+			})
+
+			It("should forget the cleanup", func() {
+				Expect(r.ReconcileNodeCleanup(ctx, dc)).ToNot(HaveOccurred())
+
+				Expect(dc.Status.NodeCleanup).To(BeEmpty())
+			})
+		})
+
+		Context("with a failed cleanup of an existing node", func() {
+			BeforeEach(func() {
+				dc.Status.NodeCleanup = []hlaiv1alpha1.NodeCleanupStatus{
+					{NodeName: testNodeName, State: hlaiv1alpha1.NodeCleanupFailed},
+				}
+
+				gomock.InOrder(
+					listNodes(),
+					getNode(nil),
+				)
+			})
+
+			It("should keep reporting the cleanup as failed", func() {
+				Expect(r.ReconcileNodeCleanup(ctx, dc)).ToNot(HaveOccurred())
+
+				Expect(dc.Status.NodeCleanup).To(HaveLen(1))
+				Expect(dc.Status.NodeCleanup[0].State).To(Equal(hlaiv1alpha1.NodeCleanupFailed))
+			})
+		})
+
+		Context("with a node Get error when pruning the cleanups", func() {
+			BeforeEach(func() {
+				dc.Status.NodeCleanup = []hlaiv1alpha1.NodeCleanupStatus{
+					{NodeName: testNodeName, State: hlaiv1alpha1.NodeCleanupFailed},
+				}
+
+				gomock.InOrder(
+					listNodes(),
+					c.EXPECT().
+						Get(ctx, types.NamespacedName{Name: testNodeName}, gomock.Any()).
+						Return(errors.New("some-error")),
+				)
+			})
+
+			It("should return an error", func() {
+				Expect(r.ReconcileNodeCleanup(ctx, dc)).To(HaveOccurred())
+			})
+		})
+
+		Context("with a cleaned up node selected again", func() {
+			BeforeEach(func() {
+				dc.Status.NodeCleanup = []hlaiv1alpha1.NodeCleanupStatus{
+					{NodeName: testNodeName, State: hlaiv1alpha1.NodeCleanupSucceeded},
+				}
+
+				gomock.InOrder(
+					listNodes(testNodeName),
+					c.EXPECT().Delete(ctx, gomock.AssignableToTypeOf(&batchv1.Job{}), gomock.Any()).Return(nil),
+				)
+			})
+
+			It("should forget the previous cleanup", func() {
+				Expect(r.ReconcileNodeCleanup(ctx, dc)).ToNot(HaveOccurred())
+
+				Expect(dc.Status.Nodes).To(Equal([]string{testNodeName}))
+				Expect(dc.Status.NodeCleanup).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("DeleteNodeCleanupJobs", func() {
+		Context("without a client DeleteAllOf error", func() {
+			BeforeEach(func() {
+				c.EXPECT().DeleteAllOf(ctx, gomock.Any(), gomock.Any()).Return(nil)
+			})
+
+			It("should not return an error", func() {
+				Expect(r.DeleteNodeCleanupJobs(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with a client DeleteAllOf error", func() {
+			BeforeEach(func() {
+				c.EXPECT().DeleteAllOf(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some-error"))
+			})
+
+			It("should return an error", func() {
+				Expect(r.DeleteNodeCleanupJobs(ctx, dc)).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("GetNodeCleanupJobName", func() {
+		It("should return a valid Job name for long node names", func() {
+			name := GetNodeCleanupJobName(dc, strings.Repeat("a", 253))
+			Expect(len(name)).To(BeNumerically("<=", 63))
+			Expect(name).To(Equal(GetNodeCleanupJobName(dc, strings.Repeat("a", 253))))
+			Expect(name).ToNot(Equal(GetNodeCleanupJobName(dc, testNodeName)))
+		})
+	})
+
+	Describe("SetDesiredNodeCleanupJob", func() {
+		var (
+			job *batchv1.Job
+		)
+
+		Context("with a nil Job as input", func() {
+			BeforeEach(func() {
+				job = nil
+			})
+
+			It("should return a job cannot be nil error", func() {
+				err := r.SetDesiredNodeCleanupJob(job, dc, testNodeName)
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("job cannot be nil"))
+			})
+		})
+
+		Context("with a non-nil Job as input", func() {
+			BeforeEach(func() {
+				job = &batchv1.Job{}
+
+				err := r.SetDesiredNodeCleanupJob(job, dc, testNodeName)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should run once on the given node", func() {
+				Expect(job.Spec.Template.Spec.NodeName).To(Equal(testNodeName))
+				Expect(job.Spec.Template.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+				Expect(job.Spec.ActiveDeadlineSeconds).ToNot(BeNil())
+				Expect(job.Annotations).To(HaveKeyWithValue(nodeNameAnnotation, testNodeName))
+			})
+
+			It("should mount the NFD features.d directory", func() {
+				Expect(job.Spec.Template.Spec.Volumes).To(HaveLen(1))
+				Expect(job.Spec.Template.Spec.Volumes[0].HostPath).ToNot(BeNil())
+				Expect(job.Spec.Template.Spec.Volumes[0].HostPath.Path).To(Equal(constants.FeaturesDir))
+			})
+
+			It("should use the node cleanup ServiceAccount", func() {
+				Expect(job.Spec.Template.Spec.ServiceAccountName).To(Equal(rbac.GetServiceAccountName(dc, rbac.ComponentNodeCleanup)))
+			})
+		})
+	})
+})
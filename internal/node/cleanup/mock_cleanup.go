@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: cleanup.go
+
+// Package cleanup is a generated GoMock package.
+package cleanup
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/api/batch/v1"
+)
+
+// MockReconciler is a mock of Reconciler interface.
+type MockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcilerMockRecorder
+}
+
+// MockReconcilerMockRecorder is the mock recorder for MockReconciler.
+type MockReconcilerMockRecorder struct {
+	mock *MockReconciler
+}
+
+// NewMockReconciler creates a new mock instance.
+func NewMockReconciler(ctrl *gomock.Controller) *MockReconciler {
+	mock := &MockReconciler{ctrl: ctrl}
+	mock.recorder = &MockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// CleanupNodes mocks base method.
+func (m *MockReconciler) CleanupNodes(ctx context.Context, dc *v1alpha1.DeviceConfig) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CleanupNodes", ctx, dc)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CleanupNodes indicates an expected call of CleanupNodes.
+func (mr *MockReconcilerMockRecorder) CleanupNodes(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanupNodes", reflect.TypeOf((*MockReconciler)(nil).CleanupNodes), ctx, dc)
+}
+
+// DeleteNodeCleanupJobs mocks base method.
+func (m *MockReconciler) DeleteNodeCleanupJobs(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNodeCleanupJobs", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNodeCleanupJobs indicates an expected call of DeleteNodeCleanupJobs.
+func (mr *MockReconcilerMockRecorder) DeleteNodeCleanupJobs(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNodeCleanupJobs", reflect.TypeOf((*MockReconciler)(nil).DeleteNodeCleanupJobs), ctx, dc)
+}
+
+// ReconcileNodeCleanup mocks base method.
+func (m *MockReconciler) ReconcileNodeCleanup(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileNodeCleanup", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileNodeCleanup indicates an expected call of ReconcileNodeCleanup.
+func (mr *MockReconcilerMockRecorder) ReconcileNodeCleanup(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileNodeCleanup", reflect.TypeOf((*MockReconciler)(nil).ReconcileNodeCleanup), ctx, dc)
+}
+
+// ReconcileNodeCleanupJob mocks base method.
+func (m *MockReconciler) ReconcileNodeCleanupJob(ctx context.Context, dc *v1alpha1.DeviceConfig, nodeName string) (*v1.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileNodeCleanupJob", ctx, dc, nodeName)
+	ret0, _ := ret[0].(*v1.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileNodeCleanupJob indicates an expected call of ReconcileNodeCleanupJob.
+func (mr *MockReconcilerMockRecorder) ReconcileNodeCleanupJob(ctx, dc, nodeName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileNodeCleanupJob", reflect.TypeOf((*MockReconciler)(nil).ReconcileNodeCleanupJob), ctx, dc, nodeName)
+}
+
+// SetDesiredNodeCleanupJob mocks base method.
+func (m *MockReconciler) SetDesiredNodeCleanupJob(job *v1.Job, cr *v1alpha1.DeviceConfig, nodeName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredNodeCleanupJob", job, cr, nodeName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredNodeCleanupJob indicates an expected call of SetDesiredNodeCleanupJob.
+func (mr *MockReconcilerMockRecorder) SetDesiredNodeCleanupJob(job, cr, nodeName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredNodeCleanupJob", reflect.TypeOf((*MockReconciler)(nil).SetDesiredNodeCleanupJob), job, cr, nodeName)
+}
@@ -0,0 +1,665 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package containerruntime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	operatorMetrics "github.com/HabanaAI/habana-ai-operator/internal/metrics"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	containerRuntimeSuffix = rbac.ComponentContainerRuntime
+
+	hostEtcVolume                  = "host-etc"
+	hostEtcPath                    = "/etc"
+	hostBinVolume                  = "habana-bin"
+	hostBinPath                    = "/usr/local/habana"
+	hostMountPath                  = "/host"
+	containerRuntimeLimitsCpu      = "100m"
+	containerRuntimeLimitsMemory   = "64Mi"
+	containerRuntimeRequestsCpu    = "10m"
+	containerRuntimeRequestsMemory = "16Mi"
+
+	// The engine is restarted when the runtime handler is removed, which may
+	// take a while.
+	containerRuntimeTerminationGracePeriodSeconds = 60
+
+	// RuntimeHandler is the name of the runtime handler registered in the
+	// container engine, to be used by the RuntimeClasses of the workloads.
+	RuntimeHandler = "habana"
+
+	// readyFile is created in the container once the runtime is installed.
+	readyFile = "/tmp/ready"
+
+	containerRuntimeRevertSuffix = "container-runtime-revert"
+
+	// nodeNameAnnotation is set on the revert Jobs, as node names do not
+	// always fit in a label value.
+	nodeNameAnnotation = "habana.ai/node-name"
+
+	revertBackoffLimit          = 3
+	revertActiveDeadlineSeconds = 300
+)
+
+// containerRuntimeFunctions detect the container engine of the node and
+// register or remove the runtime handler in its configuration, restarting the
+// engine when the configuration changed.
+//
+// The containerd handler is kept between markers in the containerd
+// configuration, so that it is replaced as a whole on every start. CRI-O loads
+// the handler from its own drop-in file.
+const containerRuntimeFunctions = `set -eu
+
+begin="# BEGIN habana-ai-operator"
+end="# END habana-ai-operator"
+bin_dir="$HOST_BIN_DIR/bin"
+binaries="habana-container-runtime habana-container-hook"
+
+engine="${CONTAINER_ENGINE:-}"
+if [ -z "$engine" ]; then
+  if [ -d "$HOST_ROOT/etc/crio" ]; then
+    engine=crio
+  else
+    engine=containerd
+  fi
+fi
+
+case "$engine" in
+  containerd)
+    config="$HOST_ROOT/etc/containerd/config.toml"
+    ;;
+  crio)
+    config="$HOST_ROOT/etc/crio/crio.conf.d/99-habana-ai-operator.conf"
+    ;;
+  *)
+    echo "Unsupported container engine $engine"
+    exit 1
+    ;;
+esac
+
+restart_engine() {
+  echo "Restarting $engine"
+  nsenter --target 1 --mount --uts --ipc --net --pid -- systemctl restart "$engine"
+}
+
+handler() {
+  echo "$begin"
+  case "$engine" in
+    containerd)
+      echo "[plugins.\"io.containerd.grpc.v1.cri\".containerd.runtimes.$RUNTIME_HANDLER]"
+      echo "  runtime_type = \"io.containerd.runc.v2\""
+      echo "  [plugins.\"io.containerd.grpc.v1.cri\".containerd.runtimes.$RUNTIME_HANDLER.options]"
+      echo "    BinaryName = \"$bin_dir/habana-container-runtime\""
+      ;;
+    crio)
+      echo "[crio.runtime.runtimes.$RUNTIME_HANDLER]"
+      echo "runtime_path = \"$bin_dir/habana-container-runtime\""
+      echo "runtime_type = \"oci\""
+      ;;
+  esac
+  echo "$end"
+}
+
+# strip_handler prints the configuration of the engine without the handler.
+strip_handler() {
+  if [ -f "$config" ]; then
+    sed "/^$begin\$/,/^$end\$/d" "$config"
+  fi
+}
+
+# update_config replaces the configuration of the engine with stdin, and
+# restarts the engine when it changed.
+update_config() {
+  new="$(cat)"
+  old=""
+  if [ -f "$config" ]; then
+    old="$(cat "$config")"
+  fi
+  if [ "$new" = "$old" ]; then
+    return 0
+  fi
+  mkdir -p "$(dirname "$config")"
+  printf '%s\n' "$new" > "$config.tmp"
+  mv -f "$config.tmp" "$config"
+  restart_engine
+}
+
+install() {
+  mkdir -p "$HOST_ROOT$bin_dir"
+  for b in $binaries; do
+    cp -f "/usr/bin/$b" "$HOST_ROOT$bin_dir/$b.tmp"
+    mv -f "$HOST_ROOT$bin_dir/$b.tmp" "$HOST_ROOT$bin_dir/$b"
+  done
+  if [ ! -d "$HOST_ROOT/etc/habana-container-runtime" ]; then
+    cp -r /etc/habana-container-runtime "$HOST_ROOT/etc/habana-container-runtime"
+  fi
+
+  case "$engine" in
+    containerd)
+      {
+        if [ ! -f "$config" ]; then
+          echo "version = 2"
+        fi
+        strip_handler
+        handler
+      } | update_config
+      ;;
+    crio)
+      handler | update_config
+      ;;
+  esac
+}
+
+uninstall() {
+  if [ ! -f "$config" ]; then
+    return 0
+  fi
+  case "$engine" in
+    containerd)
+      strip_handler | update_config
+      ;;
+    crio)
+      rm -f "$config"
+      restart_engine
+      ;;
+  esac
+}
+
+`
+
+// containerRuntimeScript installs the habana-container-runtime binaries of the
+// image on the node and registers the runtime handler. Terminating the pod
+// leaves the handler in place, as the pods are also restarted on updates and
+// node drains: the handler is only removed by the revert Jobs.
+const containerRuntimeScript = containerRuntimeFunctions + `trap 'rm -f "$READY_FILE"; exit 0' TERM INT
+install
+touch "$READY_FILE"
+echo "Registered the $RUNTIME_HANDLER runtime handler in $engine"
+sleep infinity &
+wait $!`
+
+// containerRuntimeRevertScript removes the runtime handler. The binaries are
+// left on the node, as the containers created with the runtime still rely on
+// them.
+const containerRuntimeRevertScript = containerRuntimeFunctions + `uninstall
+echo "Removed the $RUNTIME_HANDLER runtime handler from $engine"`
+
+//go:generate mockgen -source=containerruntime.go -package=containerruntime -destination=mock_containerruntime.go
+
+type Reconciler interface {
+	ReconcileContainerRuntime(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredContainerRuntimeDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error
+	RevertContainerRuntime(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) (bool, error)
+	ReconcileRevertJob(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig, nodeName string) (*batchv1.Job, error)
+	SetDesiredRevertJob(job *batchv1.Job, cr *hlaiv1alpha1.DeviceConfig, nodeName string) error
+	DeleteContainerRuntime(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+}
+
+type ContainerRuntimeReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *ContainerRuntimeReconciler {
+	return &ContainerRuntimeReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+func GetContainerRuntimeName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-%s", cr.Name, containerRuntimeSuffix)
+}
+
+// GetRevertJobName returns the name of the revert Job of the given node. The
+// node name is hashed, as it may be too long for a Job name.
+func GetRevertJobName(cr *hlaiv1alpha1.DeviceConfig, nodeName string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(nodeName))
+	return fmt.Sprintf("%s-%s-%08x", cr.Name, containerRuntimeRevertSuffix, h.Sum32())
+}
+
+// ReconcileContainerRuntime deploys the DaemonSet installing the Habana
+// container runtime on the nodes of the DeviceConfig when it is enabled, and
+// reverts it otherwise. The DaemonSet is only deployed once the pending revert
+// Jobs are over, so that they do not remove the handler it registers.
+func (r *ContainerRuntimeReconciler) ReconcileContainerRuntime(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	if !cr.Spec.ContainerRuntime.Enabled {
+		_, err := r.RevertContainerRuntime(ctx, cr)
+		return err
+	}
+
+	logger := log.FromContext(ctx)
+
+	done, err := r.reconcileRevertJobs(ctx, cr)
+	if err != nil {
+		return err
+	}
+	if !done {
+		logger.Info("Waiting for the container runtime revert Jobs", "resource", cr.Name)
+		return nil
+	}
+
+	existingDS := &appsv1.DaemonSet{}
+	err = r.client.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: GetContainerRuntimeName(cr)}, existingDS)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetContainerRuntimeName(cr),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	if exists {
+		ds = existingDS
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, ds, func() error {
+		return r.SetDesiredContainerRuntimeDaemonSet(ds, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch DaemonSet: %v", err)
+	}
+
+	logger.Info("Reconciled DaemonSet", "resource", ds.Name, "result", res)
+
+	operatorMetrics.SetComponentNodes(cr.Name, rbac.ComponentContainerRuntime, ds.Status.DesiredNumberScheduled, ds.Status.NumberReady)
+
+	return nil
+}
+
+// RevertContainerRuntime replaces the container runtime DaemonSet, if any, by
+// one-shot Jobs removing the runtime handler from the nodes of the DeviceConfig,
+// and returns whether the revert of every node is over, either successfully or
+// not. The finished Jobs are deleted.
+func (r *ContainerRuntimeReconciler) RevertContainerRuntime(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) (bool, error) {
+	ds := &appsv1.DaemonSet{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: GetContainerRuntimeName(cr)}, ds)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return false, err
+	}
+
+	if err == nil {
+		nodeList := &corev1.NodeList{}
+		selector := labels.Set(module.GetModuleNodeSelector(cr)).AsSelector()
+		if err := r.client.List(ctx, nodeList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return false, fmt.Errorf("failed to list the DeviceConfig nodes: %w", err)
+		}
+
+		for _, n := range nodeList.Items {
+			if _, err := r.ReconcileRevertJob(ctx, cr, n.Name); err != nil {
+				return false, err
+			}
+		}
+
+		if err := r.deleteContainerRuntimeDaemonSet(ctx, cr); err != nil {
+			return false, err
+		}
+	}
+
+	return r.reconcileRevertJobs(ctx, cr)
+}
+
+// ReconcileRevertJob creates the revert Job of the given node, if it does not
+// exist yet. The Job pod template is immutable, hence the Job is never patched.
+func (r *ContainerRuntimeReconciler) ReconcileRevertJob(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, nodeName string) (*batchv1.Job, error) {
+	logger := log.FromContext(ctx)
+
+	job := &batchv1.Job{}
+	err := r.client.Get(ctx, types.NamespacedName{
+		Namespace: s.Settings.OperatorNamespace,
+		Name:      GetRevertJobName(cr, nodeName),
+	}, job)
+	if err == nil {
+		return job, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	job = &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetRevertJobName(cr, nodeName),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	if err := r.SetDesiredRevertJob(job, cr, nodeName); err != nil {
+		return nil, err
+	}
+
+	if err := r.client.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("could not create Job: %v", err)
+	}
+
+	logger.Info("Reconciled Job", "resource", job.Name, "node", nodeName)
+
+	return job, nil
+}
+
+// reconcileRevertJobs deletes the finished revert Jobs, and returns whether
+// none is left running.
+func (r *ContainerRuntimeReconciler) reconcileRevertJobs(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	jobList := &batchv1.JobList{}
+	err := r.client.List(ctx, jobList,
+		client.InNamespace(s.Settings.OperatorNamespace),
+		client.MatchingLabels(labelsForRevertJob(cr)),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to list the container runtime revert Jobs: %w", err)
+	}
+
+	done := true
+	for i := range jobList.Items {
+		job := &jobList.Items[i]
+		nodeName := job.Annotations[nodeNameAnnotation]
+
+		switch {
+		case job.Status.Succeeded > 0:
+			logger.Info("Reverted the container runtime", "node", nodeName)
+		case isJobFailed(job):
+			logger.Info("Failed to revert the container runtime", "node", nodeName, "job", job.Name)
+		default:
+			done = false
+			continue
+		}
+
+		err := r.client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to delete Job %s: %w", job.Name, err)
+		}
+	}
+
+	return done, nil
+}
+
+// DeleteContainerRuntime deletes the container runtime DaemonSet and revert
+// Jobs. The container engine configuration is reverted beforehand by
+// RevertContainerRuntime.
+func (r *ContainerRuntimeReconciler) DeleteContainerRuntime(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	if err := r.deleteContainerRuntimeDaemonSet(ctx, cr); err != nil {
+		return err
+	}
+
+	err := r.client.DeleteAllOf(ctx, &batchv1.Job{},
+		client.InNamespace(s.Settings.OperatorNamespace),
+		client.MatchingLabels(labelsForRevertJob(cr)),
+		client.PropagationPolicy(metav1.DeletePropagationBackground),
+	)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the container runtime revert Jobs: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ContainerRuntimeReconciler) deleteContainerRuntimeDaemonSet(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetContainerRuntimeName(cr),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	err := r.client.Delete(ctx, ds)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete DaemonSet %s: %w", ds.Name, err)
+	}
+
+	return nil
+}
+
+func (r *ContainerRuntimeReconciler) SetDesiredContainerRuntimeDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error {
+	if ds == nil {
+		return errors.New("daemonset cannot be nil")
+	}
+
+	labels := labelsForContainerRuntimeDaemonSet(cr)
+
+	ds.ObjectMeta.Labels = labels
+
+	ds.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: labels,
+	}
+
+	ds.Spec.Template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: labels,
+		},
+	}
+
+	hostPathTypeDirectory := corev1.HostPathDirectory
+	hostPathTypeDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
+
+	container := corev1.Container{
+		Name:            containerRuntimeSuffix,
+		Image:           s.Settings.ContainerRuntimeImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", containerRuntimeScript},
+		Env: []corev1.EnvVar{
+			{Name: "CONTAINER_ENGINE", Value: cr.Spec.ContainerRuntime.Engine},
+			{Name: "RUNTIME_HANDLER", Value: RuntimeHandler},
+			{Name: "HOST_ROOT", Value: hostMountPath},
+			{Name: "HOST_BIN_DIR", Value: hostBinPath},
+			{Name: "READY_FILE", Value: readyFile},
+		},
+		// The engine is restarted from the host namespaces.
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: pointer.Bool(true),
+			RunAsUser:  pointer.Int64(0),
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{"test", "-f", readyFile},
+				},
+			},
+			PeriodSeconds: 10,
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				"cpu":    resource.MustParse(containerRuntimeLimitsCpu),
+				"memory": resource.MustParse(containerRuntimeLimitsMemory),
+			},
+			Requests: corev1.ResourceList{
+				"cpu":    resource.MustParse(containerRuntimeRequestsCpu),
+				"memory": resource.MustParse(containerRuntimeRequestsMemory),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      hostEtcVolume,
+				MountPath: hostMountPath + hostEtcPath,
+			},
+			{
+				Name:      hostBinVolume,
+				MountPath: hostMountPath + hostBinPath,
+			},
+		},
+	}
+
+	ds.Spec.Template.Spec = corev1.PodSpec{
+		Containers: []corev1.Container{container},
+		HostPID:    true,
+		// The runtime is only installed on the nodes with HPUs.
+		NodeSelector:                  module.GetModuleNodeSelector(cr),
+		PriorityClassName:             "system-node-critical",
+		ServiceAccountName:            rbac.GetServiceAccountName(cr, rbac.ComponentContainerRuntime),
+		TerminationGracePeriodSeconds: pointer.Int64(containerRuntimeTerminationGracePeriodSeconds),
+		Tolerations:                   readiness.GetTolerations(),
+		Volumes: []corev1.Volume{
+			{
+				Name: hostEtcVolume,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: hostEtcPath,
+						Type: &hostPathTypeDirectory,
+					},
+				},
+			},
+			{
+				Name: hostBinVolume,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: hostBinPath,
+						Type: &hostPathTypeDirectoryOrCreate,
+					},
+				},
+			},
+		},
+	}
+
+	return nil
+}
+
+// SetDesiredRevertJob sets the Job removing the runtime handler from the given
+// node. It runs with the node cleanup ServiceAccount, which is kept as long as
+// the DeviceConfig exists, unlike the container runtime one.
+func (r *ContainerRuntimeReconciler) SetDesiredRevertJob(job *batchv1.Job, cr *hlaiv1alpha1.DeviceConfig, nodeName string) error {
+	if job == nil {
+		return errors.New("job cannot be nil")
+	}
+
+	labels := labelsForRevertJob(cr)
+
+	job.ObjectMeta.Labels = labels
+	job.ObjectMeta.Annotations = map[string]string{
+		nodeNameAnnotation: nodeName,
+	}
+
+	hostPathTypeDirectory := corev1.HostPathDirectory
+
+	container := corev1.Container{
+		Name:            containerRuntimeRevertSuffix,
+		Image:           s.Settings.ContainerRuntimeImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", containerRuntimeRevertScript},
+		Env: []corev1.EnvVar{
+			{Name: "CONTAINER_ENGINE", Value: cr.Spec.ContainerRuntime.Engine},
+			{Name: "RUNTIME_HANDLER", Value: RuntimeHandler},
+			{Name: "HOST_ROOT", Value: hostMountPath},
+			{Name: "HOST_BIN_DIR", Value: hostBinPath},
+		},
+		// The engine is restarted from the host namespaces.
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: pointer.Bool(true),
+			RunAsUser:  pointer.Int64(0),
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      hostEtcVolume,
+				MountPath: hostMountPath + hostEtcPath,
+			},
+		},
+	}
+
+	job.Spec = batchv1.JobSpec{
+		BackoffLimit:          pointer.Int32(revertBackoffLimit),
+		ActiveDeadlineSeconds: pointer.Int64(revertActiveDeadlineSeconds),
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: labels,
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{container},
+				HostPID:    true,
+				// The Job is bound to the node, bypassing the scheduler, and
+				// must run whatever the taints of the node.
+				NodeName:           nodeName,
+				PriorityClassName:  "system-node-critical",
+				RestartPolicy:      corev1.RestartPolicyNever,
+				ServiceAccountName: rbac.GetServiceAccountName(cr, rbac.ComponentNodeCleanup),
+				Tolerations: []corev1.Toleration{
+					{Operator: corev1.TolerationOpExists},
+				},
+				Volumes: []corev1.Volume{
+					{
+						Name: hostEtcVolume,
+						VolumeSource: corev1.VolumeSource{
+							HostPath: &corev1.HostPathVolumeSource{
+								Path: hostEtcPath,
+								Type: &hostPathTypeDirectory,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return nil
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// labelsForContainerRuntimeDaemonSet returns the labels for selecting the
+// resources belonging to the given DeviceConfig CR name.
+func labelsForContainerRuntimeDaemonSet(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
+		"app.kubernetes.io/component": containerRuntimeSuffix,
+		constants.DeviceConfigLabel:   cr.Name,
+	}
+}
+
+// labelsForRevertJob returns the labels for selecting the revert Jobs
+// belonging to the given DeviceConfig CR name.
+func labelsForRevertJob(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
+		"app.kubernetes.io/component": containerRuntimeRevertSuffix,
+		constants.DeviceConfigLabel:   cr.Name,
+	}
+}
@@ -0,0 +1,383 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package containerruntime
+
+import (
+	"context"
+	"errors"
+
+	gomock "github.com/golang/mock/gomock"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/client"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+var _ = Describe("ContainerRuntimeReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		r   *ContainerRuntimeReconciler
+		c   *client.MockClient
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "a-device-config",
+				Namespace: "a-namespace",
+			},
+		}
+		c = client.NewMockClient(gomock.NewController(GinkgoT()))
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+
+		r = NewReconciler(c, s)
+
+		ctx = context.TODO()
+	})
+
+	Describe("ReconcileContainerRuntime", func() {
+		Context("with the container runtime disabled", func() {
+			Context("without a DaemonSet", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().
+							Get(ctx, gomock.Any(), gomock.Any()).
+							Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, GetContainerRuntimeName(dc))),
+						c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should not create any revert Job", func() {
+					Expect(r.ReconcileContainerRuntime(ctx, dc)).ToNot(HaveOccurred())
+				})
+			})
+
+			Context("with a DaemonSet", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(nil),
+						c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+							func(_ interface{}, list *corev1.NodeList, _ ...interface{}) error {
+								list.Items = []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}}}
+								return nil
+							},
+						),
+						c.EXPECT().
+							Get(ctx, gomock.Any(), gomock.Any()).
+							Return(apierrors.NewNotFound(schema.GroupResource{Resource: "jobs"}, GetRevertJobName(dc, "node-a"))),
+						c.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(
+							func(_ interface{}, job *batchv1.Job, _ ...interface{}) error {
+								Expect(job.Name).To(Equal(GetRevertJobName(dc, "node-a")))
+								Expect(job.Spec.Template.Spec.NodeName).To(Equal("node-a"))
+								return nil
+							},
+						),
+						c.EXPECT().Delete(ctx, gomock.Any()).Return(nil),
+						c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should replace the DaemonSet by a revert Job per node", func() {
+					Expect(r.ReconcileContainerRuntime(ctx, dc)).ToNot(HaveOccurred())
+				})
+			})
+		})
+
+		Context("with the container runtime enabled", func() {
+			BeforeEach(func() {
+				dc.Spec.ContainerRuntime.Enabled = true
+			})
+
+			Context("with a running revert Job", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+							func(_ interface{}, list *batchv1.JobList, _ ...interface{}) error {
+								list.Items = []batchv1.Job{{ObjectMeta: metav1.ObjectMeta{Name: GetRevertJobName(dc, "node-a")}}}
+								return nil
+							},
+						),
+					)
+				})
+
+				It("should wait for the Job before deploying the DaemonSet", func() {
+					Expect(r.ReconcileContainerRuntime(ctx, dc)).ToNot(HaveOccurred())
+				})
+			})
+
+			Context("without revert Jobs", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).Return(nil),
+					)
+				})
+
+				Context("with no client Get error", func() {
+					BeforeEach(func() {
+						gomock.InOrder(
+							c.EXPECT().
+								Get(ctx, gomock.Any(), gomock.Any()).
+								Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, GetContainerRuntimeName(dc))).
+								AnyTimes(),
+						)
+					})
+
+					Context("with no client Create error", func() {
+						BeforeEach(func() {
+							gomock.InOrder(
+								c.EXPECT().Create(ctx, gomock.Any()).Return(nil),
+							)
+						})
+
+						It("should not return an error", func() {
+							Expect(r.ReconcileContainerRuntime(ctx, dc)).ToNot(HaveOccurred())
+						})
+					})
+
+					Context("with client Create error", func() {
+						BeforeEach(func() {
+							gomock.InOrder(
+								c.EXPECT().Create(ctx, gomock.Any()).Return(errors.New("some-error")),
+							)
+						})
+
+						It("should return an error", func() {
+							Expect(r.ReconcileContainerRuntime(ctx, dc)).To(HaveOccurred())
+						})
+					})
+				})
+
+				Context("with client Get error", func() {
+					BeforeEach(func() {
+						gomock.InOrder(
+							c.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some-other-that-not-found-error")),
+						)
+					})
+
+					It("should return an error", func() {
+						Expect(r.ReconcileContainerRuntime(ctx, dc)).To(HaveOccurred())
+					})
+				})
+			})
+		})
+	})
+
+	Describe("RevertContainerRuntime", func() {
+		It("should delete the finished revert Jobs and wait for the running ones", func() {
+			succeeded := batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: GetRevertJobName(dc, "node-a")},
+				Status:     batchv1.JobStatus{Succeeded: 1},
+			}
+			failed := batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: GetRevertJobName(dc, "node-b")},
+				Status: batchv1.JobStatus{
+					Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+				},
+			}
+			running := batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: GetRevertJobName(dc, "node-c")}}
+
+			gomock.InOrder(
+				c.EXPECT().
+					Get(ctx, gomock.Any(), gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, GetContainerRuntimeName(dc))),
+				c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ interface{}, list *batchv1.JobList, _ ...interface{}) error {
+						list.Items = []batchv1.Job{succeeded, failed, running}
+						return nil
+					},
+				),
+				c.EXPECT().Delete(ctx, &succeeded, gomock.Any()).Return(nil),
+				c.EXPECT().Delete(ctx, &failed, gomock.Any()).Return(nil),
+			)
+
+			done, err := r.RevertContainerRuntime(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(done).To(BeFalse())
+		})
+
+		It("should be done without revert Jobs", func() {
+			gomock.InOrder(
+				c.EXPECT().
+					Get(ctx, gomock.Any(), gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, GetContainerRuntimeName(dc))),
+				c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).Return(nil),
+			)
+
+			done, err := r.RevertContainerRuntime(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(done).To(BeTrue())
+		})
+	})
+
+	Describe("DeleteContainerRuntime", func() {
+		Context("without a client Delete error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(nil),
+					c.EXPECT().DeleteAllOf(ctx, gomock.Any(), gomock.Any()).Return(nil),
+				)
+			})
+
+			It("should not return an error", func() {
+				Expect(r.DeleteContainerRuntime(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with a generic client Delete error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(errors.New("some-error")),
+				)
+			})
+
+			It("should return an error", func() {
+				Expect(r.DeleteContainerRuntime(ctx, dc)).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("SetDesiredRevertJob", func() {
+		It("should return a Job cannot be nil error with a nil Job as input", func() {
+			err := r.SetDesiredRevertJob(nil, dc, "node-a")
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("job cannot be nil"))
+		})
+
+		It("should run the revert script once on the node with the node cleanup ServiceAccount", func() {
+			job := &batchv1.Job{}
+			Expect(r.SetDesiredRevertJob(job, dc, "node-a")).ToNot(HaveOccurred())
+
+			Expect(job.Annotations).To(HaveKeyWithValue(nodeNameAnnotation, "node-a"))
+			Expect(job.Spec.Template.Spec.NodeName).To(Equal("node-a"))
+			Expect(job.Spec.Template.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+			Expect(job.Spec.Template.Spec.ServiceAccountName).To(Equal(rbac.GetServiceAccountName(dc, rbac.ComponentNodeCleanup)))
+			Expect(job.Spec.Template.Spec.Containers).To(HaveLen(1))
+			Expect(job.Spec.Template.Spec.Containers[0].Command).To(ContainElement(containerRuntimeRevertScript))
+		})
+	})
+
+	Describe("SetDesiredContainerRuntimeDaemonSet", func() {
+		var (
+			ds *appsv1.DaemonSet
+		)
+
+		Context("with a nil DaemonSet as input", func() {
+			BeforeEach(func() {
+				ds = nil
+			})
+
+			It("should return a DaemonSet cannot be nil error", func() {
+				err := r.SetDesiredContainerRuntimeDaemonSet(ds, dc)
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("daemonset cannot be nil"))
+			})
+		})
+
+		Context("with a non-nil DaemonSet as input", func() {
+			BeforeEach(func() {
+				dc.Spec.ContainerRuntime.Enabled = true
+				dc.Spec.ContainerRuntime.Engine = hlaiv1alpha1.ContainerEngineCRIO
+
+				ds = &appsv1.DaemonSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "a-name",
+						Namespace: "a-namespace",
+					},
+				}
+
+				err := r.SetDesiredContainerRuntimeDaemonSet(ds, dc)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			Context("it returns a DaemonSet which", func() {
+				It("should only select the nodes with HPUs", func() {
+					Expect(ds.Spec.Template.Spec.NodeSelector).To(Equal(module.GetModuleNodeSelector(dc)))
+				})
+
+				It("should have the HostPID enabled", func() {
+					Expect(ds.Spec.Template.Spec.HostPID).To(BeTrue())
+				})
+
+				It("should have the correct ServiceAccountName", func() {
+					Expect(ds.Spec.Template.Spec.ServiceAccountName).To(Equal(rbac.GetServiceAccountName(dc, rbac.ComponentContainerRuntime)))
+				})
+
+				It("should tolerate the operator taints", func() {
+					Expect(ds.Spec.Template.Spec.Tolerations).To(Equal(readiness.GetTolerations()))
+				})
+
+				It("should mount the host configuration and binaries directories", func() {
+					Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(2))
+					Expect(ds.Spec.Template.Spec.Volumes[0].HostPath.Path).To(Equal(hostEtcPath))
+					Expect(ds.Spec.Template.Spec.Volumes[1].HostPath.Path).To(Equal(hostBinPath))
+				})
+
+				Context("contains the container runtime container, which", func() {
+					var (
+						container corev1.Container
+					)
+
+					BeforeEach(func() {
+						Expect(ds.Spec.Template.Spec.Containers).To(HaveLen(1))
+						container = ds.Spec.Template.Spec.Containers[0]
+					})
+
+					It("should have the correct image", func() {
+						Expect(container.Image).To(Equal(s.Settings.ContainerRuntimeImage))
+					})
+
+					It("should be privileged", func() {
+						Expect(container.SecurityContext.Privileged).ToNot(BeNil())
+						Expect(*container.SecurityContext.Privileged).To(BeTrue())
+					})
+
+					It("should configure the container engine of the DeviceConfig", func() {
+						Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "CONTAINER_ENGINE", Value: hlaiv1alpha1.ContainerEngineCRIO}))
+						Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "RUNTIME_HANDLER", Value: RuntimeHandler}))
+					})
+
+					It("should not remove the runtime handler when terminated", func() {
+						Expect(container.Command).To(ContainElement(ContainSubstring(`trap 'rm -f "$READY_FILE"; exit 0' TERM INT`)))
+					})
+
+					It("should only be ready once the runtime is installed", func() {
+						Expect(container.ReadinessProbe).ToNot(BeNil())
+						Expect(container.ReadinessProbe.Exec.Command).To(ContainElement(readyFile))
+					})
+				})
+			})
+		})
+	})
+})
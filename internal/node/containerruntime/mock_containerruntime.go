@@ -0,0 +1,124 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: containerruntime.go
+
+// Package containerruntime is a generated GoMock package.
+package containerruntime
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/api/apps/v1"
+	v10 "k8s.io/api/batch/v1"
+)
+
+// MockReconciler is a mock of Reconciler interface.
+type MockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcilerMockRecorder
+}
+
+// MockReconcilerMockRecorder is the mock recorder for MockReconciler.
+type MockReconcilerMockRecorder struct {
+	mock *MockReconciler
+}
+
+// NewMockReconciler creates a new mock instance.
+func NewMockReconciler(ctrl *gomock.Controller) *MockReconciler {
+	mock := &MockReconciler{ctrl: ctrl}
+	mock.recorder = &MockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// DeleteContainerRuntime mocks base method.
+func (m *MockReconciler) DeleteContainerRuntime(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteContainerRuntime", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteContainerRuntime indicates an expected call of DeleteContainerRuntime.
+func (mr *MockReconcilerMockRecorder) DeleteContainerRuntime(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteContainerRuntime", reflect.TypeOf((*MockReconciler)(nil).DeleteContainerRuntime), ctx, dc)
+}
+
+// ReconcileContainerRuntime mocks base method.
+func (m *MockReconciler) ReconcileContainerRuntime(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileContainerRuntime", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileContainerRuntime indicates an expected call of ReconcileContainerRuntime.
+func (mr *MockReconcilerMockRecorder) ReconcileContainerRuntime(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileContainerRuntime", reflect.TypeOf((*MockReconciler)(nil).ReconcileContainerRuntime), ctx, dc)
+}
+
+// ReconcileRevertJob mocks base method.
+func (m *MockReconciler) ReconcileRevertJob(ctx context.Context, dc *v1alpha1.DeviceConfig, nodeName string) (*v10.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileRevertJob", ctx, dc, nodeName)
+	ret0, _ := ret[0].(*v10.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileRevertJob indicates an expected call of ReconcileRevertJob.
+func (mr *MockReconcilerMockRecorder) ReconcileRevertJob(ctx, dc, nodeName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileRevertJob", reflect.TypeOf((*MockReconciler)(nil).ReconcileRevertJob), ctx, dc, nodeName)
+}
+
+// RevertContainerRuntime mocks base method.
+func (m *MockReconciler) RevertContainerRuntime(ctx context.Context, dc *v1alpha1.DeviceConfig) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertContainerRuntime", ctx, dc)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertContainerRuntime indicates an expected call of RevertContainerRuntime.
+func (mr *MockReconcilerMockRecorder) RevertContainerRuntime(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertContainerRuntime", reflect.TypeOf((*MockReconciler)(nil).RevertContainerRuntime), ctx, dc)
+}
+
+// SetDesiredContainerRuntimeDaemonSet mocks base method.
+func (m *MockReconciler) SetDesiredContainerRuntimeDaemonSet(ds *v1.DaemonSet, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredContainerRuntimeDaemonSet", ds, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredContainerRuntimeDaemonSet indicates an expected call of SetDesiredContainerRuntimeDaemonSet.
+func (mr *MockReconcilerMockRecorder) SetDesiredContainerRuntimeDaemonSet(ds, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredContainerRuntimeDaemonSet", reflect.TypeOf((*MockReconciler)(nil).SetDesiredContainerRuntimeDaemonSet), ds, cr)
+}
+
+// SetDesiredRevertJob mocks base method.
+func (m *MockReconciler) SetDesiredRevertJob(job *v10.Job, cr *v1alpha1.DeviceConfig, nodeName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredRevertJob", job, cr, nodeName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredRevertJob indicates an expected call of SetDesiredRevertJob.
+func (mr *MockReconcilerMockRecorder) SetDesiredRevertJob(job, cr, nodeName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredRevertJob", reflect.TypeOf((*MockReconciler)(nil).SetDesiredRevertJob), job, cr, nodeName)
+}
@@ -0,0 +1,446 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	operatorMetrics "github.com/HabanaAI/habana-ai-operator/internal/metrics"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	// ModuleName is the name of the Habana kernel module.
+	ModuleName = "habanalabs"
+
+	// DriverContainerName is the name of the container verifying that the
+	// module is loaded, which exits when it is not.
+	DriverContainerName = "driver"
+	// FirmwareContainerName is the name of the init container installing the
+	// firmware on the node.
+	FirmwareContainerName = "firmware"
+
+	driverSuffix       = rbac.ComponentDriver
+	devicePluginSuffix = rbac.ComponentDevicePlugin
+
+	hostRootVolume = "host-root"
+	hostRootPath   = "/host"
+
+	// The firmware is copied from the directory used by the driver images to
+	// the firmware directory of the node, which is added to the firmware
+	// search path of the kernel when it is not set.
+	firmwareVolume     = "firmware"
+	firmwareImagePath  = "/opt/lib/firmware/" + ModuleName
+	firmwareHostPath   = "/var/lib/firmware/" + ModuleName
+	firmwareSearchPath = "/var/lib/firmware"
+
+	// The device plugin registers with the kubelet through the default
+	// device plugins directory, to which the directory of the nodes is
+	// mounted.
+	devicePluginKubeletVolume    = "kubelet-device-plugins-dir"
+	devicePluginKubeletMountPath = hlaiv1alpha1.DefaultKubeletRootDir + "/device-plugins"
+
+	driverLimitsCpu      = "50m"
+	driverLimitsMemory   = "32Mi"
+	driverRequestsCpu    = "10m"
+	driverRequestsMemory = "16Mi"
+
+	// readyFile is created in the container once the module is loaded.
+	readyFile = "/tmp/ready"
+)
+
+// driverScript loads the module of the node kernel in the inTree mode, and
+// verifies that the module is loaded in both the inTree and hostInstalled
+// modes. The container exits when the module is not loaded, so that the
+// nodes missing it are reported, and restarts to check it again.
+const driverScript = `set -u
+
+trap 'rm -f "$READY_FILE"; exit 0' TERM INT
+
+path_file=/sys/module/firmware_class/parameters/path
+if [ -n "$FIRMWARE_SEARCH_PATH" ] && [ -f "$path_file" ] && [ -z "$(cat "$path_file")" ]; then
+  echo -n "$FIRMWARE_SEARCH_PATH" > "$path_file" || echo "Failed to set the firmware search path"
+fi
+
+if [ "$DRIVER_MODE" = "inTree" ] && [ ! -d "/sys/module/$MODULE_NAME" ]; then
+  chroot "$HOST_ROOT" modprobe -v "$MODULE_NAME" || echo "Failed to load the $MODULE_NAME module"
+fi
+
+if [ ! -d "/sys/module/$MODULE_NAME" ]; then
+  message="The $MODULE_NAME module is not loaded on the node"
+  echo "$message"
+  echo -n "$message" > /dev/termination-log
+  exit 1
+fi
+
+echo "The $MODULE_NAME module is loaded"
+touch "$READY_FILE"
+sleep infinity &
+wait $!`
+
+// firmwareScript installs the firmware of the image on the node.
+const firmwareScript = `set -eu
+cp -rf "$FIRMWARE_IMAGE_PATH/." "$FIRMWARE_HOST_PATH/"
+echo "Installed the firmware in $FIRMWARE_HOST_PATH"`
+
+// waitForModuleScript delays the device plugin until the module is loaded, as
+// KMM does for the device plugin of the container mode.
+const waitForModuleScript = `until [ -d "/sys/module/$MODULE_NAME" ]; do
+  echo "Waiting for the $MODULE_NAME module"
+  sleep 5
+done`
+
+//go:generate mockgen -source=driver.go -package=driver -destination=mock_driver.go
+
+type Reconciler interface {
+	ReconcileDriver(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredDriverDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredDevicePluginDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error
+	DeleteDriver(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+}
+
+type DriverReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *DriverReconciler {
+	return &DriverReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+// GetDriverName returns the name of the driver DaemonSet.
+func GetDriverName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-%s", cr.Name, driverSuffix)
+}
+
+// GetDevicePluginName returns the name of the device plugin DaemonSet.
+func GetDevicePluginName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-%s", cr.Name, devicePluginSuffix)
+}
+
+// ReconcileDriver deploys the driver and the device plugin of the inTree and
+// hostInstalled driver modes, whose module is not loaded from a driver image
+// by KMM. The driver pods install the firmware, when its image is set, and
+// load or verify the module of the node.
+func (r *DriverReconciler) ReconcileDriver(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	if cr.Spec.Simulation.Enabled || cr.GetDriverMode() == hlaiv1alpha1.DriverModeContainer {
+		return r.DeleteDriver(ctx, cr)
+	}
+
+	if err := r.reconcileDaemonSet(ctx, cr, GetDriverName(cr), rbac.ComponentDriver, r.SetDesiredDriverDaemonSet); err != nil {
+		return err
+	}
+
+	return r.reconcileDaemonSet(ctx, cr, GetDevicePluginName(cr), rbac.ComponentDevicePlugin, r.SetDesiredDevicePluginDaemonSet)
+}
+
+func (r *DriverReconciler) reconcileDaemonSet(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, name, component string,
+	setDesired func(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error) error {
+	logger := log.FromContext(ctx)
+
+	existingDS := &appsv1.DaemonSet{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: name}, existingDS)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	if exists {
+		ds = existingDS
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, ds, func() error {
+		return setDesired(ds, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch DaemonSet: %v", err)
+	}
+
+	logger.Info("Reconciled DaemonSet", "resource", ds.Name, "result", res)
+
+	operatorMetrics.SetComponentNodes(cr.Name, component, ds.Status.DesiredNumberScheduled, ds.Status.NumberReady)
+
+	return nil
+}
+
+// DeleteDriver deletes the driver and device plugin DaemonSets. The module is
+// left loaded on the nodes.
+func (r *DriverReconciler) DeleteDriver(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	for _, name := range []string{GetDriverName(cr), GetDevicePluginName(cr)} {
+		ds := &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: s.Settings.OperatorNamespace,
+			},
+		}
+
+		err := r.client.Delete(ctx, ds)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete DaemonSet %s: %w", ds.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *DriverReconciler) SetDesiredDriverDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error {
+	if ds == nil {
+		return errors.New("daemonset cannot be nil")
+	}
+
+	labels := labelsForDriver(cr, driverSuffix)
+
+	ds.ObjectMeta.Labels = labels
+
+	ds.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: labels,
+	}
+
+	ds.Spec.Template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: labels,
+		},
+	}
+
+	hostPathTypeDirectory := corev1.HostPathDirectory
+	hostPathTypeDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
+
+	searchPath := ""
+	if cr.Spec.Driver.FirmwareImage != "" {
+		searchPath = firmwareSearchPath
+	}
+
+	container := corev1.Container{
+		Name:            DriverContainerName,
+		Image:           s.Settings.NodeCleanupImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", driverScript},
+		Env: []corev1.EnvVar{
+			{Name: "DRIVER_MODE", Value: string(cr.GetDriverMode())},
+			{Name: "MODULE_NAME", Value: ModuleName},
+			{Name: "FIRMWARE_SEARCH_PATH", Value: searchPath},
+			{Name: "HOST_ROOT", Value: hostRootPath},
+			{Name: "READY_FILE", Value: readyFile},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: pointer.Bool(true),
+			RunAsUser:  pointer.Int64(0),
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{"test", "-f", readyFile},
+				},
+			},
+			PeriodSeconds: 10,
+		},
+		Resources: resourceRequirements(),
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      hostRootVolume,
+				MountPath: hostRootPath,
+				ReadOnly:  true,
+			},
+		},
+	}
+
+	ds.Spec.Template.Spec = corev1.PodSpec{
+		Containers:                    []corev1.Container{container},
+		NodeSelector:                  module.GetModuleNodeSelector(cr),
+		PriorityClassName:             "system-node-critical",
+		ServiceAccountName:            rbac.GetServiceAccountName(cr, rbac.ComponentDriver),
+		TerminationGracePeriodSeconds: pointer.Int64(10),
+		Tolerations:                   readiness.GetTolerations(),
+		Volumes: []corev1.Volume{
+			{
+				// The in-tree module is loaded with the modprobe and the
+				// modules of the host.
+				Name: hostRootVolume,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: "/",
+						Type: &hostPathTypeDirectory,
+					},
+				},
+			},
+		},
+	}
+
+	if cr.Spec.Driver.FirmwareImage != "" {
+		ds.Spec.Template.Spec.InitContainers = []corev1.Container{
+			{
+				Name:            FirmwareContainerName,
+				Image:           cr.Spec.Driver.FirmwareImage,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"/bin/sh", "-c", firmwareScript},
+				Env: []corev1.EnvVar{
+					{Name: "FIRMWARE_IMAGE_PATH", Value: firmwareImagePath},
+					{Name: "FIRMWARE_HOST_PATH", Value: firmwareHostPath},
+				},
+				Resources: resourceRequirements(),
+				VolumeMounts: []corev1.VolumeMount{
+					{
+						Name:      firmwareVolume,
+						MountPath: firmwareHostPath,
+					},
+				},
+			},
+		}
+		ds.Spec.Template.Spec.Volumes = append(ds.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name: firmwareVolume,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: firmwareHostPath,
+					Type: &hostPathTypeDirectoryOrCreate,
+				},
+			},
+		})
+	}
+
+	return nil
+}
+
+// SetDesiredDevicePluginDaemonSet sets the device plugin of the Module of the
+// container mode, which only starts once the module is loaded.
+func (r *DriverReconciler) SetDesiredDevicePluginDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error {
+	if ds == nil {
+		return errors.New("daemonset cannot be nil")
+	}
+
+	labels := labelsForDriver(cr, devicePluginSuffix)
+
+	ds.ObjectMeta.Labels = labels
+
+	ds.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: labels,
+	}
+
+	ds.Spec.Template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: labels,
+		},
+	}
+
+	hostPathTypeDirectory := corev1.HostPathDirectory
+
+	devicePlugin := module.MakeDevicePlugin(cr, constants.HabanaDeviceType)
+
+	container := corev1.Container{
+		Name:            devicePluginSuffix,
+		Image:           devicePlugin.Container.Image,
+		ImagePullPolicy: devicePlugin.Container.ImagePullPolicy,
+		Command:         devicePlugin.Container.Command,
+		Args:            devicePlugin.Container.Args,
+		Env:             devicePlugin.Container.Env,
+		Resources:       devicePlugin.Container.Resources,
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: pointer.Bool(true),
+		},
+		VolumeMounts: append([]corev1.VolumeMount{
+			{
+				Name:      devicePluginKubeletVolume,
+				MountPath: devicePluginKubeletMountPath,
+			},
+		}, devicePlugin.Container.VolumeMounts...),
+	}
+
+	initContainer := corev1.Container{
+		Name:            "wait-for-module",
+		Image:           s.Settings.NodeCleanupImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", waitForModuleScript},
+		Env: []corev1.EnvVar{
+			{Name: "MODULE_NAME", Value: ModuleName},
+		},
+		Resources: resourceRequirements(),
+	}
+
+	ds.Spec.Template.Spec = corev1.PodSpec{
+		InitContainers:     []corev1.Container{initContainer},
+		Containers:         []corev1.Container{container},
+		NodeSelector:       module.GetModuleNodeSelector(cr),
+		PriorityClassName:  "system-node-critical",
+		ServiceAccountName: devicePlugin.ServiceAccountName,
+		Tolerations:        readiness.GetTolerations(),
+		Volumes: append([]corev1.Volume{
+			{
+				Name: devicePluginKubeletVolume,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: cr.GetKubeletDevicePluginsDir(),
+						Type: &hostPathTypeDirectory,
+					},
+				},
+			},
+		}, devicePlugin.Volumes...),
+	}
+
+	return nil
+}
+
+func resourceRequirements() corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			"cpu":    resource.MustParse(driverLimitsCpu),
+			"memory": resource.MustParse(driverLimitsMemory),
+		},
+		Requests: corev1.ResourceList{
+			"cpu":    resource.MustParse(driverRequestsCpu),
+			"memory": resource.MustParse(driverRequestsMemory),
+		},
+	}
+}
+
+// labelsForDriver returns the labels for selecting the resources of the given
+// component belonging to the given DeviceConfig CR name.
+func labelsForDriver(cr *hlaiv1alpha1.DeviceConfig, component string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
+		"app.kubernetes.io/component": component,
+		constants.DeviceConfigLabel:   cr.Name,
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+var _ = Describe("DriverReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+			Spec: hlaiv1alpha1.DeviceConfigSpec{
+				Driver: hlaiv1alpha1.DriverSpec{
+					Mode: hlaiv1alpha1.DriverModeInTree,
+				},
+			},
+		}
+		ctx = context.TODO()
+	})
+
+	build := func(objs ...client.Object) (client.Client, *DriverReconciler) {
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+		return c, NewReconciler(c, s)
+	}
+
+	getDaemonSet := func(c client.Client, name string) (*appsv1.DaemonSet, error) {
+		ds := &appsv1.DaemonSet{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: name}, ds)
+		return ds, err
+	}
+
+	Describe("ReconcileDriver", func() {
+		It("should deploy the driver and the device plugin in the inTree mode", func() {
+			c, r := build()
+
+			Expect(r.ReconcileDriver(ctx, dc)).To(Succeed())
+
+			_, err := getDaemonSet(c, GetDriverName(dc))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = getDaemonSet(c, GetDevicePluginName(dc))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should delete the DaemonSets in the container mode", func() {
+			c, r := build()
+			Expect(r.ReconcileDriver(ctx, dc)).To(Succeed())
+
+			dc.Spec.Driver.Mode = hlaiv1alpha1.DriverModeContainer
+			Expect(r.ReconcileDriver(ctx, dc)).To(Succeed())
+
+			_, err := getDaemonSet(c, GetDriverName(dc))
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			_, err = getDaemonSet(c, GetDevicePluginName(dc))
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should not deploy anything with the simulation enabled", func() {
+			dc.Spec.Simulation.Enabled = true
+			c, r := build()
+
+			Expect(r.ReconcileDriver(ctx, dc)).To(Succeed())
+
+			_, err := getDaemonSet(c, GetDriverName(dc))
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Describe("SetDesiredDriverDaemonSet", func() {
+		var (
+			ds *appsv1.DaemonSet
+			r  *DriverReconciler
+		)
+
+		BeforeEach(func() {
+			_, r = build()
+			ds = &appsv1.DaemonSet{}
+		})
+
+		It("should return an error with a nil DaemonSet", func() {
+			Expect(r.SetDesiredDriverDaemonSet(nil, dc)).To(MatchError("daemonset cannot be nil"))
+		})
+
+		It("should load the module on the nodes with HPUs", func() {
+			Expect(r.SetDesiredDriverDaemonSet(ds, dc)).To(Succeed())
+
+			spec := ds.Spec.Template.Spec
+			Expect(spec.NodeSelector).To(Equal(module.GetModuleNodeSelector(dc)))
+			Expect(spec.ServiceAccountName).To(Equal(rbac.GetServiceAccountName(dc, rbac.ComponentDriver)))
+			Expect(spec.Tolerations).To(Equal(readiness.GetTolerations()))
+			Expect(spec.InitContainers).To(BeEmpty())
+			Expect(spec.Containers).To(HaveLen(1))
+			Expect(spec.Containers[0].Name).To(Equal(DriverContainerName))
+			Expect(spec.Containers[0].Env).To(ContainElements(
+				corev1.EnvVar{Name: "DRIVER_MODE", Value: string(hlaiv1alpha1.DriverModeInTree)},
+				corev1.EnvVar{Name: "MODULE_NAME", Value: ModuleName},
+				corev1.EnvVar{Name: "FIRMWARE_SEARCH_PATH", Value: ""},
+			))
+		})
+
+		It("should install the firmware of the firmware image", func() {
+			dc.Spec.Driver.Mode = hlaiv1alpha1.DriverModeHostInstalled
+			dc.Spec.Driver.FirmwareImage = "registry/firmware:1.7.0"
+
+			Expect(r.SetDesiredDriverDaemonSet(ds, dc)).To(Succeed())
+
+			spec := ds.Spec.Template.Spec
+			Expect(spec.InitContainers).To(HaveLen(1))
+			Expect(spec.InitContainers[0].Name).To(Equal(FirmwareContainerName))
+			Expect(spec.InitContainers[0].Image).To(Equal("registry/firmware:1.7.0"))
+			Expect(spec.InitContainers[0].VolumeMounts).To(ConsistOf(corev1.VolumeMount{
+				Name:      firmwareVolume,
+				MountPath: firmwareHostPath,
+			}))
+			Expect(spec.Volumes).To(ContainElement(HaveField("VolumeSource.HostPath.Path", firmwareHostPath)))
+			Expect(spec.Containers[0].Env).To(ContainElements(
+				corev1.EnvVar{Name: "DRIVER_MODE", Value: string(hlaiv1alpha1.DriverModeHostInstalled)},
+				corev1.EnvVar{Name: "FIRMWARE_SEARCH_PATH", Value: firmwareSearchPath},
+			))
+		})
+	})
+
+	Describe("SetDesiredDevicePluginDaemonSet", func() {
+		var (
+			ds *appsv1.DaemonSet
+			r  *DriverReconciler
+		)
+
+		BeforeEach(func() {
+			_, r = build()
+			ds = &appsv1.DaemonSet{}
+		})
+
+		It("should run the device plugin once the module is loaded", func() {
+			Expect(r.SetDesiredDevicePluginDaemonSet(ds, dc)).To(Succeed())
+
+			devicePlugin := module.MakeDevicePlugin(dc, constants.HabanaDeviceType)
+			spec := ds.Spec.Template.Spec
+			Expect(spec.ServiceAccountName).To(Equal(rbac.GetServiceAccountName(dc, rbac.ComponentDevicePlugin)))
+			Expect(spec.InitContainers).To(HaveLen(1))
+			Expect(spec.Containers).To(HaveLen(1))
+			Expect(spec.Containers[0].Image).To(Equal(devicePlugin.Container.Image))
+			Expect(spec.Containers[0].Args).To(Equal(devicePlugin.Container.Args))
+			Expect(spec.Volumes[0].HostPath.Path).To(Equal(hlaiv1alpha1.DefaultKubeletRootDir + "/device-plugins"))
+		})
+
+		It("should mount the device plugins directory of the nodes at the default path", func() {
+			dc.Spec.Kubelet.DevicePluginsDir = "/opt/kubelet/device-plugins"
+			dc.Spec.CDI.Enabled = true
+
+			Expect(r.SetDesiredDevicePluginDaemonSet(ds, dc)).To(Succeed())
+
+			spec := ds.Spec.Template.Spec
+			Expect(spec.Volumes).To(HaveLen(2))
+			Expect(spec.Volumes[0].HostPath.Path).To(Equal("/opt/kubelet/device-plugins"))
+			Expect(spec.Containers[0].VolumeMounts[0].MountPath).To(Equal(devicePluginKubeletMountPath))
+			Expect(spec.Containers[0].Args).To(ContainElement(hlaiv1alpha1.CDISpecDir))
+		})
+	})
+})
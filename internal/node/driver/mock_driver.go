@@ -0,0 +1,93 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: driver.go
+
+// Package driver is a generated GoMock package.
+package driver
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/api/apps/v1"
+)
+
+// MockReconciler is a mock of Reconciler interface.
+type MockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcilerMockRecorder
+}
+
+// MockReconcilerMockRecorder is the mock recorder for MockReconciler.
+type MockReconcilerMockRecorder struct {
+	mock *MockReconciler
+}
+
+// NewMockReconciler creates a new mock instance.
+func NewMockReconciler(ctrl *gomock.Controller) *MockReconciler {
+	mock := &MockReconciler{ctrl: ctrl}
+	mock.recorder = &MockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// DeleteDriver mocks base method.
+func (m *MockReconciler) DeleteDriver(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDriver", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDriver indicates an expected call of DeleteDriver.
+func (mr *MockReconcilerMockRecorder) DeleteDriver(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDriver", reflect.TypeOf((*MockReconciler)(nil).DeleteDriver), ctx, dc)
+}
+
+// ReconcileDriver mocks base method.
+func (m *MockReconciler) ReconcileDriver(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileDriver", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileDriver indicates an expected call of ReconcileDriver.
+func (mr *MockReconcilerMockRecorder) ReconcileDriver(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileDriver", reflect.TypeOf((*MockReconciler)(nil).ReconcileDriver), ctx, dc)
+}
+
+// SetDesiredDevicePluginDaemonSet mocks base method.
+func (m *MockReconciler) SetDesiredDevicePluginDaemonSet(ds *v1.DaemonSet, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredDevicePluginDaemonSet", ds, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredDevicePluginDaemonSet indicates an expected call of SetDesiredDevicePluginDaemonSet.
+func (mr *MockReconcilerMockRecorder) SetDesiredDevicePluginDaemonSet(ds, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredDevicePluginDaemonSet", reflect.TypeOf((*MockReconciler)(nil).SetDesiredDevicePluginDaemonSet), ds, cr)
+}
+
+// SetDesiredDriverDaemonSet mocks base method.
+func (m *MockReconciler) SetDesiredDriverDaemonSet(ds *v1.DaemonSet, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredDriverDaemonSet", ds, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredDriverDaemonSet indicates an expected call of SetDesiredDriverDaemonSet.
+func (mr *MockReconcilerMockRecorder) SetDesiredDriverDaemonSet(ds, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredDriverDaemonSet", reflect.TypeOf((*MockReconciler)(nil).SetDesiredDriverDaemonSet), ds, cr)
+}
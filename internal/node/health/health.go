@@ -0,0 +1,212 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/metrics"
+)
+
+const (
+	// DegradedLabel is set on the degraded nodes, with the name of their
+	// DeviceConfig as value, when the DeviceConfig remediation is Label.
+	DegradedLabel = "habana.ai/hpu.degraded"
+
+	// DegradedTaintKey is the key of the NoSchedule taint set on the degraded
+	// nodes, with the name of their DeviceConfig as value, when the
+	// DeviceConfig remediation is Taint.
+	DegradedTaintKey = "habana.ai/hpu-degraded"
+)
+
+//go:generate mockgen -source=health.go -package=health -destination=mock_health.go
+
+type Reconciler interface {
+	ReconcileNodeHealth(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	DeleteNodeHealth(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+}
+
+type NodeHealthReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *NodeHealthReconciler {
+	return &NodeHealthReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+// ReconcileNodeHealth reports the nodes of the DeviceConfig advertising
+// another number of HPUs than the expected one in its status, and applies the
+// DeviceConfig remediation to them. The remediation is removed from the nodes
+// which are no longer degraded or selected by the DeviceConfig.
+func (r *NodeHealthReconciler) ReconcileNodeHealth(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(ctx, nodeList); err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	selector := labels.Set(cr.GetNodeSelector()).AsSelector()
+	remediation := cr.GetDegradedNodeRemediation()
+
+	degraded := []hlaiv1alpha1.DegradedNodeStatus{}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+
+		isDegraded := false
+		if selector.Matches(labels.Set(node.Labels)) {
+			var devices int64
+			devices, isDegraded = getDegradedDevices(cr, node)
+			if isDegraded {
+				degraded = append(degraded, hlaiv1alpha1.DegradedNodeStatus{NodeName: node.Name, Devices: devices})
+			}
+		}
+
+		err := r.remediateNode(ctx, cr, node,
+			isDegraded && remediation == hlaiv1alpha1.DegradedNodeRemediationLabel,
+			isDegraded && remediation == hlaiv1alpha1.DegradedNodeRemediationTaint,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(degraded, func(i, j int) bool { return degraded[i].NodeName < degraded[j].NodeName })
+
+	cr.Status.DegradedNodes = nil
+	if len(degraded) > 0 {
+		cr.Status.DegradedNodes = degraded
+	}
+	metrics.DegradedNodes.WithLabelValues(cr.Name).Set(float64(len(degraded)))
+
+	return nil
+}
+
+// DeleteNodeHealth removes the remediation of the DeviceConfig from all the
+// nodes.
+func (r *NodeHealthReconciler) DeleteNodeHealth(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(ctx, nodeList); err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	for i := range nodeList.Items {
+		if err := r.remediateNode(ctx, cr, &nodeList.Items[i], false, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getDegradedDevices returns the number of HPUs advertised by the node, and
+// whether it differs from the expected one. The nodes not advertising HPUs
+// yet, i.e. whose device plugin is not registered, are not degraded.
+func getDegradedDevices(cr *hlaiv1alpha1.DeviceConfig, node *corev1.Node) (int64, bool) {
+	if cr.Spec.ExpectedDevicesPerNode == 0 {
+		return 0, false
+	}
+
+	q, ok := node.Status.Allocatable[constants.HabanaResourceName]
+	if !ok {
+		return 0, false
+	}
+
+	return q.Value(), q.Value() != int64(cr.Spec.ExpectedDevicesPerNode)
+}
+
+// remediateNode sets or removes the degraded label and taint of the
+// DeviceConfig on the node. Only the label and taint carrying the name of the
+// DeviceConfig are removed, the ones of other DeviceConfigs are left as is.
+func (r *NodeHealthReconciler) remediateNode(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, node *corev1.Node, label, taint bool) error {
+	logger := log.FromContext(ctx)
+
+	patch := client.MergeFromWithOptions(node.DeepCopy(), client.MergeFromWithOptimisticLock{})
+
+	changed := false
+	if label && node.Labels[DegradedLabel] != cr.Name {
+		if node.Labels == nil {
+			node.Labels = map[string]string{}
+		}
+		node.Labels[DegradedLabel] = cr.Name
+		changed = true
+	}
+	if !label && node.Labels[DegradedLabel] == cr.Name {
+		delete(node.Labels, DegradedLabel)
+		changed = true
+	}
+
+	degradedTaint := corev1.Taint{
+		Key:    DegradedTaintKey,
+		Value:  cr.Name,
+		Effect: corev1.TaintEffectNoSchedule,
+	}
+	if taint {
+		changed = AddTaint(node, degradedTaint) || changed
+	} else {
+		changed = RemoveTaint(node, degradedTaint) || changed
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := r.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to update the degraded remediation of node %s: %w", node.Name, err)
+	}
+
+	logger.Info("Updated the degraded remediation", "node", node.Name, "label", label, "taint", taint)
+
+	return nil
+}
+
+// AddTaint adds the taint to the node, and returns whether the node changed.
+func AddTaint(node *corev1.Node, taint corev1.Taint) bool {
+	for _, t := range node.Spec.Taints {
+		if t.MatchTaint(&taint) && t.Value == taint.Value {
+			return false
+		}
+	}
+	node.Spec.Taints = append(node.Spec.Taints, taint)
+	return true
+}
+
+// RemoveTaint removes the taint from the node, only if its value matches, and
+// returns whether the node changed.
+func RemoveTaint(node *corev1.Node, taint corev1.Taint) bool {
+	taints := make([]corev1.Taint, 0, len(node.Spec.Taints))
+	for _, t := range node.Spec.Taints {
+		if t.MatchTaint(&taint) && t.Value == taint.Value {
+			continue
+		}
+		taints = append(taints, t)
+	}
+	if len(taints) == len(node.Spec.Taints) {
+		return false
+	}
+	node.Spec.Taints = taints
+	return true
+}
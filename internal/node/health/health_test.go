@@ -0,0 +1,146 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/testutil"
+)
+
+var selected = map[string]string{"hpu": "true"}
+
+var _ = Describe("NodeHealthReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		c   client.Client
+		r   *NodeHealthReconciler
+		ctx context.Context
+	)
+
+	getNode := func(name string) *corev1.Node {
+		n := &corev1.Node{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: name}, n)).To(Succeed())
+		return n
+	}
+
+	degradedTaint := func() corev1.Taint {
+		return corev1.Taint{Key: DegradedTaintKey, Value: dc.Name, Effect: corev1.TaintEffectNoSchedule}
+	}
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+			Spec: hlaiv1alpha1.DeviceConfigSpec{
+				NodeSelector:           selected,
+				ExpectedDevicesPerNode: 8,
+			},
+		}
+		ctx = context.TODO()
+
+		otherDCTaint := corev1.Taint{Key: DegradedTaintKey, Value: "another-device-config", Effect: corev1.TaintEffectNoSchedule}
+		formerlySelected := testutil.MakeNode("formerly-selected", testutil.AdvertisingHPUs(7))
+		formerlySelected.Spec.Taints = []corev1.Taint{otherDCTaint, {Key: DegradedTaintKey, Value: dc.Name, Effect: corev1.TaintEffectNoSchedule}}
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+		c = fake.NewClientBuilder().WithScheme(s).WithObjects(
+			testutil.MakeNode("healthy", testutil.Labelled(selected), testutil.AdvertisingHPUs(8)),
+			testutil.MakeNode("degraded", testutil.Labelled(selected), testutil.AdvertisingHPUs(7)),
+			testutil.MakeNode("not-advertising", testutil.Labelled(selected)),
+			formerlySelected,
+		).Build()
+		r = NewReconciler(c, s)
+	})
+
+	Describe("ReconcileNodeHealth", func() {
+		It("should report the degraded nodes", func() {
+			Expect(r.ReconcileNodeHealth(ctx, dc)).To(Succeed())
+			Expect(dc.Status.DegradedNodes).To(Equal([]hlaiv1alpha1.DegradedNodeStatus{{NodeName: "degraded", Devices: 7}}))
+		})
+
+		It("should not report any node without an expected device count", func() {
+			dc.Spec.ExpectedDevicesPerNode = 0
+			dc.Status.DegradedNodes = []hlaiv1alpha1.DegradedNodeStatus{{NodeName: "degraded", Devices: 7}}
+
+			Expect(r.ReconcileNodeHealth(ctx, dc)).To(Succeed())
+			Expect(dc.Status.DegradedNodes).To(BeNil())
+		})
+
+		It("should label the degraded nodes with the Label remediation", func() {
+			dc.Spec.DegradedNodeRemediation = hlaiv1alpha1.DegradedNodeRemediationLabel
+
+			Expect(r.ReconcileNodeHealth(ctx, dc)).To(Succeed())
+			Expect(getNode("degraded").Labels).To(HaveKeyWithValue(DegradedLabel, dc.Name))
+			Expect(getNode("healthy").Labels).ToNot(HaveKey(DegradedLabel))
+			Expect(getNode("degraded").Spec.Taints).To(BeEmpty())
+		})
+
+		It("should taint the degraded nodes with the Taint remediation", func() {
+			dc.Spec.DegradedNodeRemediation = hlaiv1alpha1.DegradedNodeRemediationTaint
+
+			Expect(r.ReconcileNodeHealth(ctx, dc)).To(Succeed())
+			Expect(getNode("degraded").Spec.Taints).To(ConsistOf(degradedTaint()))
+			Expect(getNode("healthy").Spec.Taints).To(BeEmpty())
+		})
+
+		It("should only remove its own taint from the nodes no longer selected", func() {
+			dc.Spec.DegradedNodeRemediation = hlaiv1alpha1.DegradedNodeRemediationTaint
+
+			Expect(r.ReconcileNodeHealth(ctx, dc)).To(Succeed())
+			Expect(getNode("formerly-selected").Spec.Taints).To(ConsistOf(
+				corev1.Taint{Key: DegradedTaintKey, Value: "another-device-config", Effect: corev1.TaintEffectNoSchedule},
+			))
+		})
+
+		It("should remove the remediation once the node is healthy again", func() {
+			dc.Spec.DegradedNodeRemediation = hlaiv1alpha1.DegradedNodeRemediationTaint
+			Expect(r.ReconcileNodeHealth(ctx, dc)).To(Succeed())
+
+			n := getNode("degraded")
+			n.Status.Allocatable[constants.HabanaResourceName] = *resource.NewQuantity(8, resource.DecimalSI)
+			Expect(c.Update(ctx, n)).To(Succeed())
+
+			Expect(r.ReconcileNodeHealth(ctx, dc)).To(Succeed())
+			Expect(getNode("degraded").Spec.Taints).To(BeEmpty())
+			Expect(dc.Status.DegradedNodes).To(BeNil())
+		})
+	})
+
+	Describe("DeleteNodeHealth", func() {
+		It("should remove the remediation from all the nodes", func() {
+			dc.Spec.DegradedNodeRemediation = hlaiv1alpha1.DegradedNodeRemediationLabel
+			Expect(r.ReconcileNodeHealth(ctx, dc)).To(Succeed())
+
+			Expect(r.DeleteNodeHealth(ctx, dc)).To(Succeed())
+			Expect(getNode("degraded").Labels).ToNot(HaveKey(DegradedLabel))
+			Expect(getNode("formerly-selected").Spec.Taints).To(HaveLen(1))
+		})
+	})
+})
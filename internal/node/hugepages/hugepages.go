@@ -0,0 +1,473 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hugepages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	operatorMetrics "github.com/HabanaAI/habana-ai-operator/internal/metrics"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	// ResourceName is the resource advertising the 2Mi huge pages of a node.
+	ResourceName = corev1.ResourceName(corev1.ResourceHugePagesPrefix + "2Mi")
+
+	// MachineConfigRoleLabel selects the MachineConfigPool of a MachineConfig.
+	MachineConfigRoleLabel = "machineconfiguration.openshift.io/role"
+
+	// pageSize is the size of a 2Mi huge page, in bytes.
+	pageSize = 2 * 1024 * 1024
+
+	hugePagesSuffix = rbac.ComponentHugePages
+
+	hostRootVolume          = "host-root"
+	hostRootPath            = "/host"
+	hostStateVolume         = "host-state"
+	hostStatePath           = "/var/lib/habana-ai-operator"
+	hugePagesFile           = "/sys/kernel/mm/hugepages/hugepages-2048kB/nr_hugepages"
+	hugePagesLimitsCpu      = "50m"
+	hugePagesLimitsMemory   = "32Mi"
+	hugePagesRequestsCpu    = "10m"
+	hugePagesRequestsMemory = "16Mi"
+
+	// readyFile is created in the container once the huge pages are
+	// allocated.
+	readyFile = "/tmp/ready"
+)
+
+// MachineConfigGVK is the GroupVersionKind of the OpenShift MachineConfigs,
+// which are only served on OpenShift. The Machine Config Operator is not a Go
+// dependency of the operator, so its resources are handled as unstructured
+// objects.
+var MachineConfigGVK = schema.GroupVersionKind{
+	Group:   "machineconfiguration.openshift.io",
+	Version: "v1",
+	Kind:    "MachineConfig",
+}
+
+// hugePagesScript allocates the 2Mi huge pages of the node every minute, as
+// the allocation may partially fail when the memory is fragmented. The kubelet
+// only discovers the huge pages when it starts, hence it is restarted once the
+// count is allocated. The count and the boot the kubelet was restarted for are
+// recorded in a marker file on the host, so that it is restarted at most once
+// per count and boot, whatever the restarts of the pod. The huge pages are left
+// allocated when the pod is deleted, so as not to disrupt the workloads.
+const hugePagesScript = `set -u
+
+trap 'rm -f "$READY_FILE"; exit 0' TERM INT
+
+target="$COUNT $(cat /proc/sys/kernel/random/boot_id)"
+
+while true; do
+  current="$(cat "$HUGEPAGES_FILE")"
+  if [ "$current" != "$COUNT" ]; then
+    echo "$COUNT" > "$HUGEPAGES_FILE" || echo "Failed to allocate $COUNT huge pages"
+    current="$(cat "$HUGEPAGES_FILE")"
+    echo "Allocated $current of $COUNT huge pages"
+  fi
+  if [ "$current" = "$COUNT" ]; then
+    if [ "$(cat "$MARKER_FILE" 2>/dev/null)" != "$target" ]; then
+      if chroot "$HOST_ROOT" systemctl restart kubelet; then
+        echo "$target" > "$MARKER_FILE"
+      else
+        echo "Failed to restart the kubelet"
+      fi
+    fi
+    touch "$READY_FILE"
+  else
+    rm -f "$READY_FILE"
+  fi
+  sleep 60 &
+  wait $!
+done`
+
+//go:generate mockgen -source=hugepages.go -package=hugepages -destination=mock_hugepages.go
+
+type Reconciler interface {
+	ReconcileHugePages(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig, machineConfig bool) error
+	SetDesiredHugePagesDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredHugePagesMachineConfig(mc *unstructured.Unstructured, cr *hlaiv1alpha1.DeviceConfig) error
+	DeleteHugePages(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+}
+
+type HugePagesReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *HugePagesReconciler {
+	return &HugePagesReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+// GetHugePagesName returns the name of the huge pages DaemonSet.
+func GetHugePagesName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-%s", cr.Name, hugePagesSuffix)
+}
+
+// GetMachineConfigName returns the name of the huge pages MachineConfig. The
+// MachineConfigs are merged in name order, so that it takes precedence over
+// the default ones.
+func GetMachineConfigName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("99-%s-%s", cr.Name, hugePagesSuffix)
+}
+
+func newMachineConfig(cr *hlaiv1alpha1.DeviceConfig) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(MachineConfigGVK)
+	u.SetName(GetMachineConfigName(cr))
+	return u
+}
+
+// ReconcileHugePages allocates the huge pages of the nodes with HPUs, when
+// their count is set, with a MachineConfig when machineConfig is true, i.e.
+// on OpenShift, or with a DaemonSet otherwise. The nodes advertising another
+// amount of huge pages are reported in the DeviceConfig status, which is
+// persisted along with its conditions.
+func (r *HugePagesReconciler) ReconcileHugePages(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, machineConfig bool) error {
+	if cr.Spec.HugePages.Count == nil {
+		cr.Status.HugePages = nil
+		return r.DeleteHugePages(ctx, cr)
+	}
+
+	if machineConfig {
+		// There is no default pool, as all the nodes of the pool are
+		// rebooted, including the ones without HPUs
+		if cr.Spec.HugePages.MachineConfigPool == "" {
+			return errors.New("the huge pages machineConfigPool must be set when the MachineConfig API is served")
+		}
+		if err := r.reconcileHugePagesMachineConfig(ctx, cr); err != nil {
+			return err
+		}
+		if err := r.deleteHugePagesDaemonSet(ctx, cr); err != nil {
+			return err
+		}
+	} else if err := r.reconcileHugePagesDaemonSet(ctx, cr); err != nil {
+		return err
+	}
+
+	return r.verifyHugePages(ctx, cr)
+}
+
+func (r *HugePagesReconciler) reconcileHugePagesDaemonSet(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	logger := log.FromContext(ctx)
+
+	existingDS := &appsv1.DaemonSet{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: GetHugePagesName(cr)}, existingDS)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetHugePagesName(cr),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	if exists {
+		ds = existingDS
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, ds, func() error {
+		return r.SetDesiredHugePagesDaemonSet(ds, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch DaemonSet: %v", err)
+	}
+
+	logger.Info("Reconciled DaemonSet", "resource", ds.Name, "result", res)
+
+	operatorMetrics.SetComponentNodes(cr.Name, rbac.ComponentHugePages, ds.Status.DesiredNumberScheduled, ds.Status.NumberReady)
+
+	return nil
+}
+
+func (r *HugePagesReconciler) reconcileHugePagesMachineConfig(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	logger := log.FromContext(ctx)
+
+	mc := newMachineConfig(cr)
+
+	existing := newMachineConfig(cr)
+	err := r.client.Get(ctx, types.NamespacedName{Name: mc.GetName()}, existing)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if exists {
+		mc = existing
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, mc, func() error {
+		return r.SetDesiredHugePagesMachineConfig(mc, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch MachineConfig: %v", err)
+	}
+
+	logger.Info("Reconciled MachineConfig", "resource", mc.GetName(), "result", res)
+
+	return nil
+}
+
+// verifyHugePages reports the nodes with HPUs advertising another amount of
+// huge pages than the expected one in the DeviceConfig status.
+func (r *HugePagesReconciler) verifyHugePages(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	nodeList := &corev1.NodeList{}
+	selector := labels.Set(module.GetModuleNodeSelector(cr)).AsSelector()
+	if err := r.client.List(ctx, nodeList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	status := &hlaiv1alpha1.HugePagesStatus{
+		Expected: *resource.NewQuantity(int64(*cr.Spec.HugePages.Count)*pageSize, resource.BinarySI),
+	}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+
+		allocatable := node.Status.Allocatable[ResourceName]
+		if allocatable.Cmp(status.Expected) == 0 {
+			status.NodesMatching++
+			continue
+		}
+
+		if allocatable.IsZero() {
+			allocatable = *resource.NewQuantity(0, resource.BinarySI)
+		}
+		status.MismatchedNodes = append(status.MismatchedNodes, hlaiv1alpha1.NodeHugePagesStatus{
+			NodeName:    node.Name,
+			Allocatable: allocatable,
+		})
+	}
+
+	sort.Slice(status.MismatchedNodes, func(i, j int) bool {
+		return status.MismatchedNodes[i].NodeName < status.MismatchedNodes[j].NodeName
+	})
+
+	cr.Status.HugePages = status
+
+	return nil
+}
+
+// DeleteHugePages deletes the huge pages DaemonSet and MachineConfig. The huge
+// pages are left allocated on the nodes.
+func (r *HugePagesReconciler) DeleteHugePages(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	if err := r.deleteHugePagesDaemonSet(ctx, cr); err != nil {
+		return err
+	}
+
+	mc := newMachineConfig(cr)
+	err := r.client.Delete(ctx, mc)
+	if err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return fmt.Errorf("failed to delete MachineConfig %s: %w", mc.GetName(), err)
+	}
+
+	return nil
+}
+
+func (r *HugePagesReconciler) deleteHugePagesDaemonSet(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetHugePagesName(cr),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	err := r.client.Delete(ctx, ds)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete DaemonSet %s: %w", ds.Name, err)
+	}
+
+	return nil
+}
+
+// SetDesiredHugePagesMachineConfig sets the kernel arguments allocating the
+// huge pages on the nodes of the MachineConfigPool of the DeviceConfig. The
+// Machine Config Operator reboots the nodes to apply them.
+func (r *HugePagesReconciler) SetDesiredHugePagesMachineConfig(mc *unstructured.Unstructured, cr *hlaiv1alpha1.DeviceConfig) error {
+	if mc == nil {
+		return errors.New("machineconfig cannot be nil")
+	}
+	if cr.Spec.HugePages.Count == nil {
+		return errors.New("huge pages count cannot be nil")
+	}
+	if cr.Spec.HugePages.MachineConfigPool == "" {
+		return errors.New("huge pages machineconfigpool cannot be empty")
+	}
+
+	mcLabels := labelsForHugePages(cr)
+	mcLabels[MachineConfigRoleLabel] = cr.Spec.HugePages.MachineConfigPool
+	mc.SetLabels(mcLabels)
+
+	mc.Object["spec"] = map[string]interface{}{
+		"kernelArguments": []interface{}{
+			"hugepagesz=2M",
+			fmt.Sprintf("hugepages=%d", *cr.Spec.HugePages.Count),
+		},
+	}
+
+	return nil
+}
+
+func (r *HugePagesReconciler) SetDesiredHugePagesDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error {
+	if ds == nil {
+		return errors.New("daemonset cannot be nil")
+	}
+	if cr.Spec.HugePages.Count == nil {
+		return errors.New("huge pages count cannot be nil")
+	}
+
+	labels := labelsForHugePages(cr)
+
+	ds.ObjectMeta.Labels = labels
+
+	ds.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: labels,
+	}
+
+	ds.Spec.Template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: labels,
+		},
+	}
+
+	hostPathTypeDirectory := corev1.HostPathDirectory
+	hostPathTypeDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
+
+	container := corev1.Container{
+		Name:            hugePagesSuffix,
+		Image:           s.Settings.NodeCleanupImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", hugePagesScript},
+		Env: []corev1.EnvVar{
+			{Name: "COUNT", Value: strconv.Itoa(int(*cr.Spec.HugePages.Count))},
+			{Name: "HUGEPAGES_FILE", Value: hugePagesFile},
+			{Name: "HOST_ROOT", Value: hostRootPath},
+			{Name: "READY_FILE", Value: readyFile},
+			{Name: "MARKER_FILE", Value: fmt.Sprintf("%s/%s", hostStatePath, GetHugePagesName(cr))},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: pointer.Bool(true),
+			RunAsUser:  pointer.Int64(0),
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{"test", "-f", readyFile},
+				},
+			},
+			PeriodSeconds: 10,
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				"cpu":    resource.MustParse(hugePagesLimitsCpu),
+				"memory": resource.MustParse(hugePagesLimitsMemory),
+			},
+			Requests: corev1.ResourceList{
+				"cpu":    resource.MustParse(hugePagesRequestsCpu),
+				"memory": resource.MustParse(hugePagesRequestsMemory),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      hostRootVolume,
+				MountPath: hostRootPath,
+				ReadOnly:  true,
+			},
+			{
+				Name:      hostStateVolume,
+				MountPath: hostStatePath,
+			},
+		},
+	}
+
+	ds.Spec.Template.Spec = corev1.PodSpec{
+		Containers: []corev1.Container{container},
+		// The huge pages are only allocated on the nodes with HPUs.
+		NodeSelector:                  module.GetModuleNodeSelector(cr),
+		PriorityClassName:             "system-node-critical",
+		ServiceAccountName:            rbac.GetServiceAccountName(cr, rbac.ComponentHugePages),
+		TerminationGracePeriodSeconds: pointer.Int64(10),
+		Tolerations:                   readiness.GetTolerations(),
+		Volumes: []corev1.Volume{
+			{
+				// The kubelet is restarted with the systemctl of the host.
+				Name: hostRootVolume,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: "/",
+						Type: &hostPathTypeDirectory,
+					},
+				},
+			},
+			{
+				// The marker file outlives the pods.
+				Name: hostStateVolume,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: hostStatePath,
+						Type: &hostPathTypeDirectoryOrCreate,
+					},
+				},
+			},
+		},
+	}
+
+	return nil
+}
+
+// labelsForHugePages returns the labels for selecting the
+// resources belonging to the given DeviceConfig CR name.
+func labelsForHugePages(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
+		"app.kubernetes.io/component": hugePagesSuffix,
+		constants.DeviceConfigLabel:   cr.Name,
+	}
+}
@@ -0,0 +1,331 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hugepages
+
+import (
+	"context"
+	"errors"
+
+	gomock "github.com/golang/mock/gomock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/client"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+var _ = Describe("HugePagesReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		r   *HugePagesReconciler
+		c   *client.MockClient
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "a-device-config",
+				Namespace: "a-namespace",
+			},
+		}
+		c = client.NewMockClient(gomock.NewController(GinkgoT()))
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+
+		r = NewReconciler(c, s)
+
+		ctx = context.TODO()
+	})
+
+	Describe("ReconcileHugePages", func() {
+		Context("without a huge pages count", func() {
+			BeforeEach(func() {
+				dc.Status.HugePages = &hlaiv1alpha1.HugePagesStatus{NodesMatching: 1}
+
+				gomock.InOrder(
+					c.EXPECT().
+						Delete(ctx, gomock.Any()).
+						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, GetHugePagesName(dc))),
+					c.EXPECT().
+						Delete(ctx, gomock.Any()).
+						Return(&meta.NoKindMatchError{GroupKind: MachineConfigGVK.GroupKind()}),
+				)
+			})
+
+			It("should delete the DaemonSet and the MachineConfig, and clear the status", func() {
+				Expect(r.ReconcileHugePages(ctx, dc, false)).ToNot(HaveOccurred())
+				Expect(dc.Status.HugePages).To(BeNil())
+			})
+		})
+
+		Context("with a huge pages count", func() {
+			var nodes []corev1.Node
+
+			BeforeEach(func() {
+				dc.Spec.HugePages.Count = pointer.Int32(512)
+
+				nodes = []corev1.Node{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "node-b"},
+						Status: corev1.NodeStatus{
+							Allocatable: corev1.ResourceList{ResourceName: resource.MustParse("1Gi")},
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+					},
+				}
+			})
+
+			listNodes := func(_ context.Context, list *corev1.NodeList, _ ...interface{}) error {
+				list.Items = nodes
+				return nil
+			}
+
+			Context("without the MachineConfig API", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().
+							Get(ctx, gomock.Any(), gomock.Any()).
+							Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, GetHugePagesName(dc))).
+							Times(2),
+						c.EXPECT().Create(ctx, gomock.Any()).Return(nil),
+						c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(listNodes),
+					)
+				})
+
+				It("should report the nodes advertising another amount of huge pages", func() {
+					Expect(r.ReconcileHugePages(ctx, dc, false)).ToNot(HaveOccurred())
+					Expect(dc.Status.HugePages).ToNot(BeNil())
+					Expect(dc.Status.HugePages.Expected.Cmp(resource.MustParse("1Gi"))).To(BeZero())
+					Expect(dc.Status.HugePages.NodesMatching).To(Equal(int32(1)))
+					Expect(dc.Status.HugePages.MismatchedNodes).To(HaveLen(1))
+					Expect(dc.Status.HugePages.MismatchedNodes[0].NodeName).To(Equal("node-a"))
+					Expect(dc.Status.HugePages.MismatchedNodes[0].Allocatable.IsZero()).To(BeTrue())
+				})
+			})
+
+			Context("with the MachineConfig API", func() {
+				BeforeEach(func() {
+					dc.Spec.HugePages.MachineConfigPool = "hpu"
+
+					gomock.InOrder(
+						c.EXPECT().
+							Get(ctx, gomock.Any(), gomock.Any()).
+							Return(apierrors.NewNotFound(schema.GroupResource{Resource: "machineconfigs"}, GetMachineConfigName(dc))).
+							Times(2),
+						c.EXPECT().Create(ctx, gomock.Any()).Return(nil),
+						c.EXPECT().
+							Delete(ctx, gomock.Any()).
+							Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, GetHugePagesName(dc))),
+						c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).DoAndReturn(listNodes),
+					)
+				})
+
+				It("should create the MachineConfig and delete the DaemonSet", func() {
+					Expect(r.ReconcileHugePages(ctx, dc, true)).ToNot(HaveOccurred())
+					Expect(dc.Status.HugePages).ToNot(BeNil())
+				})
+			})
+
+			Context("with the MachineConfig API and without a MachineConfigPool", func() {
+				It("should return an error without creating the MachineConfig", func() {
+					err := r.ReconcileHugePages(ctx, dc, true)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("machineConfigPool must be set"))
+				})
+			})
+
+			Context("with a client Get error", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some-other-that-not-found-error")),
+					)
+				})
+
+				It("should return an error", func() {
+					Expect(r.ReconcileHugePages(ctx, dc, false)).To(HaveOccurred())
+				})
+			})
+		})
+	})
+
+	Describe("DeleteHugePages", func() {
+		Context("without a client Delete error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(nil).Times(2),
+				)
+			})
+
+			It("should not return an error", func() {
+				Expect(r.DeleteHugePages(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with a generic client Delete error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(errors.New("some-error")),
+				)
+			})
+
+			It("should return an error", func() {
+				Expect(r.DeleteHugePages(ctx, dc)).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("SetDesiredHugePagesMachineConfig", func() {
+		It("should return an error with a nil MachineConfig", func() {
+			err := r.SetDesiredHugePagesMachineConfig(nil, dc)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("machineconfig cannot be nil"))
+		})
+
+		It("should return an error without a MachineConfigPool", func() {
+			dc.Spec.HugePages.Count = pointer.Int32(512)
+			err := r.SetDesiredHugePagesMachineConfig(newMachineConfig(dc), dc)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("huge pages machineconfigpool cannot be empty"))
+		})
+
+		It("should set the huge pages kernel arguments for the MachineConfigPool", func() {
+			dc.Spec.HugePages.Count = pointer.Int32(512)
+			dc.Spec.HugePages.MachineConfigPool = "hpu"
+			mc := newMachineConfig(dc)
+
+			Expect(r.SetDesiredHugePagesMachineConfig(mc, dc)).ToNot(HaveOccurred())
+			Expect(mc.GetLabels()).To(HaveKeyWithValue(MachineConfigRoleLabel, "hpu"))
+
+			args, found, err := unstructured.NestedStringSlice(mc.Object, "spec", "kernelArguments")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(args).To(Equal([]string{"hugepagesz=2M", "hugepages=512"}))
+		})
+	})
+
+	Describe("SetDesiredHugePagesDaemonSet", func() {
+		var (
+			ds *appsv1.DaemonSet
+		)
+
+		Context("with a nil DaemonSet as input", func() {
+			BeforeEach(func() {
+				ds = nil
+			})
+
+			It("should return a DaemonSet cannot be nil error", func() {
+				err := r.SetDesiredHugePagesDaemonSet(ds, dc)
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("daemonset cannot be nil"))
+			})
+		})
+
+		Context("with a non-nil DaemonSet as input", func() {
+			BeforeEach(func() {
+				dc.Spec.HugePages.Count = pointer.Int32(512)
+
+				ds = &appsv1.DaemonSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "a-name",
+						Namespace: "a-namespace",
+					},
+				}
+
+				err := r.SetDesiredHugePagesDaemonSet(ds, dc)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			Context("it returns a DaemonSet which", func() {
+				It("should only select the nodes with HPUs", func() {
+					Expect(ds.Spec.Template.Spec.NodeSelector).To(Equal(module.GetModuleNodeSelector(dc)))
+				})
+
+				It("should have the correct ServiceAccountName", func() {
+					Expect(ds.Spec.Template.Spec.ServiceAccountName).To(Equal(rbac.GetServiceAccountName(dc, rbac.ComponentHugePages)))
+				})
+
+				It("should tolerate the operator taints", func() {
+					Expect(ds.Spec.Template.Spec.Tolerations).To(Equal(readiness.GetTolerations()))
+				})
+
+				It("should mount the host root and state directories", func() {
+					Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(2))
+					Expect(ds.Spec.Template.Spec.Volumes[0].HostPath.Path).To(Equal("/"))
+					Expect(ds.Spec.Template.Spec.Volumes[1].HostPath.Path).To(Equal(hostStatePath))
+				})
+
+				Context("contains the huge pages container, which", func() {
+					var (
+						container corev1.Container
+					)
+
+					BeforeEach(func() {
+						Expect(ds.Spec.Template.Spec.Containers).To(HaveLen(1))
+						container = ds.Spec.Template.Spec.Containers[0]
+					})
+
+					It("should have the correct image", func() {
+						Expect(container.Image).To(Equal(s.Settings.NodeCleanupImage))
+					})
+
+					It("should be privileged", func() {
+						Expect(container.SecurityContext.Privileged).ToNot(BeNil())
+						Expect(*container.SecurityContext.Privileged).To(BeTrue())
+					})
+
+					It("should allocate the huge pages count of the DeviceConfig", func() {
+						Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "COUNT", Value: "512"}))
+					})
+
+					It("should record the kubelet restarts in a marker file on the host", func() {
+						Expect(container.Env).To(ContainElement(corev1.EnvVar{
+							Name:  "MARKER_FILE",
+							Value: hostStatePath + "/" + GetHugePagesName(dc),
+						}))
+					})
+
+					It("should only be ready once the huge pages are allocated", func() {
+						Expect(container.ReadinessProbe).ToNot(BeNil())
+						Expect(container.ReadinessProbe.Exec.Command).To(ContainElement(readyFile))
+					})
+				})
+			})
+		})
+	})
+})
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: hugepages.go
+
+// Package hugepages is a generated GoMock package.
+package hugepages
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/api/apps/v1"
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// MockReconciler is a mock of Reconciler interface.
+type MockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcilerMockRecorder
+}
+
+// MockReconcilerMockRecorder is the mock recorder for MockReconciler.
+type MockReconcilerMockRecorder struct {
+	mock *MockReconciler
+}
+
+// NewMockReconciler creates a new mock instance.
+func NewMockReconciler(ctrl *gomock.Controller) *MockReconciler {
+	mock := &MockReconciler{ctrl: ctrl}
+	mock.recorder = &MockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// DeleteHugePages mocks base method.
+func (m *MockReconciler) DeleteHugePages(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteHugePages", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHugePages indicates an expected call of DeleteHugePages.
+func (mr *MockReconcilerMockRecorder) DeleteHugePages(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHugePages", reflect.TypeOf((*MockReconciler)(nil).DeleteHugePages), ctx, dc)
+}
+
+// ReconcileHugePages mocks base method.
+func (m *MockReconciler) ReconcileHugePages(ctx context.Context, dc *v1alpha1.DeviceConfig, machineConfig bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileHugePages", ctx, dc, machineConfig)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileHugePages indicates an expected call of ReconcileHugePages.
+func (mr *MockReconcilerMockRecorder) ReconcileHugePages(ctx, dc, machineConfig interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileHugePages", reflect.TypeOf((*MockReconciler)(nil).ReconcileHugePages), ctx, dc, machineConfig)
+}
+
+// SetDesiredHugePagesDaemonSet mocks base method.
+func (m *MockReconciler) SetDesiredHugePagesDaemonSet(ds *v1.DaemonSet, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredHugePagesDaemonSet", ds, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredHugePagesDaemonSet indicates an expected call of SetDesiredHugePagesDaemonSet.
+func (mr *MockReconcilerMockRecorder) SetDesiredHugePagesDaemonSet(ds, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredHugePagesDaemonSet", reflect.TypeOf((*MockReconciler)(nil).SetDesiredHugePagesDaemonSet), ds, cr)
+}
+
+// SetDesiredHugePagesMachineConfig mocks base method.
+func (m *MockReconciler) SetDesiredHugePagesMachineConfig(mc *unstructured.Unstructured, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredHugePagesMachineConfig", mc, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredHugePagesMachineConfig indicates an expected call of SetDesiredHugePagesMachineConfig.
+func (mr *MockReconcilerMockRecorder) SetDesiredHugePagesMachineConfig(mc, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredHugePagesMachineConfig", reflect.TypeOf((*MockReconciler)(nil).SetDesiredHugePagesMachineConfig), mc, cr)
+}
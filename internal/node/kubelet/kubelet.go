@@ -0,0 +1,189 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubelet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+)
+
+const (
+	// RootDirAnnotation sets the kubelet root directory of a node explicitly,
+	// for the distributions which do not report it.
+	RootDirAnnotation = "habana.ai/kubelet-root-dir"
+
+	// K3sNodeArgsAnnotation and RKE2NodeArgsAnnotation are the JSON encoded
+	// arguments k3s and RKE2 were started with, which include the kubelet
+	// arguments, e.g. ["agent","--kubelet-arg","root-dir=/data/kubelet"].
+	K3sNodeArgsAnnotation  = "k3s.io/node-args"
+	RKE2NodeArgsAnnotation = "rke2.io/node-args"
+
+	// MicroK8sLabel is set on the nodes of the MicroK8s clusters, whose
+	// kubelet root directory is in the snap data directory.
+	MicroK8sLabel      = "microk8s.io/cluster"
+	MicroK8sRootDir    = "/var/snap/microk8s/common/var/lib/kubelet"
+	kubeletArgFlag     = "--kubelet-arg"
+	kubeletRootDirFlag = "root-dir"
+)
+
+// RootDirAnnotations are the node annotations the kubelet root directory is
+// detected from.
+var RootDirAnnotations = []string{RootDirAnnotation, K3sNodeArgsAnnotation, RKE2NodeArgsAnnotation}
+
+//go:generate mockgen -source=kubelet.go -package=kubelet -destination=mock_kubelet.go
+
+type Reconciler interface {
+	ReconcileKubelet(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+}
+
+type KubeletReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *KubeletReconciler {
+	return &KubeletReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+// ReconcileKubelet detects the kubelet root directory of the nodes of the
+// DeviceConfig when requested, and reports it in its status, which is
+// persisted along with its conditions. The directories mounted by the
+// components deployed on the nodes are derived from it, hence it has to be
+// reconciled first. As a DaemonSet mounts the same directory on every node,
+// the nodes must agree on it.
+func (r *KubeletReconciler) ReconcileKubelet(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	if !cr.Spec.Kubelet.DetectFromNodes || cr.Spec.Kubelet.RootDir != "" {
+		cr.Status.Kubelet = nil
+		return nil
+	}
+
+	nodeList := &corev1.NodeList{}
+	selector := labels.Set(module.GetModuleNodeSelector(cr)).AsSelector()
+	if err := r.client.List(ctx, nodeList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	status := &hlaiv1alpha1.KubeletStatus{RootDir: hlaiv1alpha1.DefaultKubeletRootDir}
+	rootDirNodes := make(map[string][]string)
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+
+		rootDir, source := detectRootDir(node)
+		if rootDir == "" {
+			rootDir = hlaiv1alpha1.DefaultKubeletRootDir
+		}
+		rootDirNodes[rootDir] = append(rootDirNodes[rootDir], node.Name)
+
+		if source != "" {
+			status.RootDir = rootDir
+			status.DetectedFrom = source
+		}
+	}
+
+	if len(rootDirNodes) > 1 {
+		return fmt.Errorf("the nodes use different kubelet root directories: %s", formatRootDirNodes(rootDirNodes))
+	}
+
+	cr.Status.Kubelet = status
+
+	return nil
+}
+
+// detectRootDir returns the kubelet root directory of the node and the
+// annotation or label it was read from, or empty strings when the node does
+// not report it.
+func detectRootDir(node *corev1.Node) (string, string) {
+	if dir := node.Annotations[RootDirAnnotation]; dir != "" {
+		return dir, RootDirAnnotation
+	}
+
+	for _, annotation := range []string{K3sNodeArgsAnnotation, RKE2NodeArgsAnnotation} {
+		if dir := getRootDirFromNodeArgs(node.Annotations[annotation]); dir != "" {
+			return dir, annotation
+		}
+	}
+
+	if _, ok := node.Labels[MicroK8sLabel]; ok {
+		return MicroK8sRootDir, MicroK8sLabel
+	}
+
+	return "", ""
+}
+
+// getRootDirFromNodeArgs returns the root-dir kubelet argument of the k3s or
+// RKE2 node arguments, which may be passed as "--kubelet-arg root-dir=<dir>"
+// or "--kubelet-arg=root-dir=<dir>".
+func getRootDirFromNodeArgs(nodeArgs string) string {
+	if nodeArgs == "" {
+		return ""
+	}
+
+	var args []string
+	if err := json.Unmarshal([]byte(nodeArgs), &args); err != nil {
+		return ""
+	}
+
+	for i, arg := range args {
+		var kubeletArg string
+		switch {
+		case arg == kubeletArgFlag && i+1 < len(args):
+			kubeletArg = args[i+1]
+		case strings.HasPrefix(arg, kubeletArgFlag+"="):
+			kubeletArg = strings.TrimPrefix(arg, kubeletArgFlag+"=")
+		default:
+			continue
+		}
+
+		name, value, found := strings.Cut(strings.TrimLeft(kubeletArg, "-"), "=")
+		if found && name == kubeletRootDirFlag && value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func formatRootDirNodes(rootDirNodes map[string][]string) string {
+	rootDirs := make([]string, 0, len(rootDirNodes))
+	for rootDir := range rootDirNodes {
+		rootDirs = append(rootDirs, rootDir)
+	}
+	sort.Strings(rootDirs)
+
+	s := make([]string, 0, len(rootDirs))
+	for _, rootDir := range rootDirs {
+		nodes := rootDirNodes[rootDir]
+		sort.Strings(nodes)
+		s = append(s, fmt.Sprintf("%s (%s)", rootDir, strings.Join(nodes, ", ")))
+	}
+
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubelet
+
+import (
+	"context"
+	"errors"
+
+	gomock "github.com/golang/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/client"
+)
+
+var _ = Describe("KubeletReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		r   *KubeletReconciler
+		c   *client.MockClient
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+		}
+		c = client.NewMockClient(gomock.NewController(GinkgoT()))
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+
+		r = NewReconciler(c, s)
+
+		ctx = context.TODO()
+	})
+
+	Describe("ReconcileKubelet", func() {
+		Context("with the detection disabled", func() {
+			It("should clear the status", func() {
+				dc.Status.Kubelet = &hlaiv1alpha1.KubeletStatus{RootDir: "/data/kubelet"}
+
+				Expect(r.ReconcileKubelet(ctx, dc)).ToNot(HaveOccurred())
+				Expect(dc.Status.Kubelet).To(BeNil())
+				Expect(dc.GetKubeletRootDir()).To(Equal(hlaiv1alpha1.DefaultKubeletRootDir))
+			})
+		})
+
+		Context("with the detection enabled and a root directory set", func() {
+			It("should not list the nodes", func() {
+				dc.Spec.Kubelet.DetectFromNodes = true
+				dc.Spec.Kubelet.RootDir = "/data/kubelet"
+
+				Expect(r.ReconcileKubelet(ctx, dc)).ToNot(HaveOccurred())
+				Expect(dc.Status.Kubelet).To(BeNil())
+				Expect(dc.GetKubeletRootDir()).To(Equal("/data/kubelet"))
+			})
+		})
+
+		Context("with the detection enabled", func() {
+			var nodes []corev1.Node
+
+			BeforeEach(func() {
+				dc.Spec.Kubelet.DetectFromNodes = true
+				nodes = nil
+			})
+
+			Context("with a client List error", func() {
+				It("should return an error", func() {
+					c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some-error"))
+
+					Expect(r.ReconcileKubelet(ctx, dc)).To(HaveOccurred())
+				})
+			})
+
+			Context("with no client List error", func() {
+				JustBeforeEach(func() {
+					c.EXPECT().
+						List(ctx, gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, list *corev1.NodeList, _ ...interface{}) error {
+							list.Items = nodes
+							return nil
+						})
+				})
+
+				Context("with nodes which do not report their root directory", func() {
+					BeforeEach(func() {
+						nodes = []corev1.Node{
+							{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}},
+						}
+					})
+
+					It("should report the default root directory", func() {
+						Expect(r.ReconcileKubelet(ctx, dc)).ToNot(HaveOccurred())
+						Expect(dc.Status.Kubelet).To(Equal(&hlaiv1alpha1.KubeletStatus{RootDir: hlaiv1alpha1.DefaultKubeletRootDir}))
+					})
+				})
+
+				Context("with k3s nodes started with a kubelet root directory", func() {
+					BeforeEach(func() {
+						nodes = []corev1.Node{
+							{
+								ObjectMeta: metav1.ObjectMeta{
+									Name: "node-a",
+									Annotations: map[string]string{
+										K3sNodeArgsAnnotation: `["agent","--kubelet-arg","root-dir=/data/kubelet"]`,
+									},
+								},
+							},
+							{
+								ObjectMeta: metav1.ObjectMeta{
+									Name: "node-b",
+									Annotations: map[string]string{
+										K3sNodeArgsAnnotation: `["agent","--kubelet-arg=--root-dir=/data/kubelet"]`,
+									},
+								},
+							},
+						}
+					})
+
+					It("should report and use that root directory", func() {
+						Expect(r.ReconcileKubelet(ctx, dc)).ToNot(HaveOccurred())
+						Expect(dc.Status.Kubelet).To(Equal(&hlaiv1alpha1.KubeletStatus{
+							RootDir:      "/data/kubelet",
+							DetectedFrom: K3sNodeArgsAnnotation,
+						}))
+						Expect(dc.GetKubeletDevicePluginsDir()).To(Equal("/data/kubelet/device-plugins"))
+						Expect(dc.GetKubeletPodResourcesDir()).To(Equal("/data/kubelet/pod-resources"))
+					})
+				})
+
+				Context("with MicroK8s nodes", func() {
+					BeforeEach(func() {
+						nodes = []corev1.Node{
+							{
+								ObjectMeta: metav1.ObjectMeta{
+									Name:   "node-a",
+									Labels: map[string]string{MicroK8sLabel: "true"},
+								},
+							},
+						}
+					})
+
+					It("should report the MicroK8s root directory", func() {
+						Expect(r.ReconcileKubelet(ctx, dc)).ToNot(HaveOccurred())
+						Expect(dc.Status.Kubelet.RootDir).To(Equal(MicroK8sRootDir))
+					})
+				})
+
+				Context("with nodes using different root directories", func() {
+					BeforeEach(func() {
+						nodes = []corev1.Node{
+							{
+								ObjectMeta: metav1.ObjectMeta{
+									Name:        "node-a",
+									Annotations: map[string]string{RootDirAnnotation: "/data/kubelet"},
+								},
+							},
+							{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}},
+						}
+					})
+
+					It("should return an error naming the nodes", func() {
+						err := r.ReconcileKubelet(ctx, dc)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("/data/kubelet (node-a), /var/lib/kubelet (node-b)"))
+					})
+				})
+			})
+		})
+	})
+
+	Describe("getRootDirFromNodeArgs", func() {
+		DescribeTable("should parse the kubelet root directory",
+			func(nodeArgs, expected string) {
+				Expect(getRootDirFromNodeArgs(nodeArgs)).To(Equal(expected))
+			},
+			Entry("without arguments", "", ""),
+			Entry("with invalid JSON", "--kubelet-arg", ""),
+			Entry("with other kubelet arguments", `["server","--kubelet-arg","max-pods=200"]`, ""),
+			Entry("with a separate kubelet argument", `["server","--kubelet-arg","root-dir=/data/kubelet"]`, "/data/kubelet"),
+			Entry("with an inline kubelet argument", `["server","--kubelet-arg=root-dir=/data/kubelet"]`, "/data/kubelet"),
+			Entry("with a trailing flag", `["server","--kubelet-arg"]`, ""),
+		)
+	})
+})
@@ -22,14 +22,13 @@ import (
 	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/utils/pointer"
-	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -40,22 +39,48 @@ import (
 )
 
 const (
-	nodeLabelerServiceAccount = "node-labeler"
-	nodeLabelerSuffix         = "node-labeler"
-	nodeLabelerLimitsCpu      = "1"
-	nodeLabelerLimitsMemory   = "200Mi"
-	nodeLabelerRequestsCpu    = "100m"
-	nodeLabelerRequestsMemory = "200Mi"
+	nodeLabelerSuffix     = "node-labeler"
+	nodeFeatureRuleSuffix = "node-feature-rule"
 )
 
+var (
+	// NodeFeatureRuleGVK is the GroupVersionKind of the Node Feature Discovery
+	// NodeFeatureRule. NFD is not a Go dependency of the operator, so the
+	// NodeFeatureRule is handled as an unstructured object.
+	NodeFeatureRuleGVK = schema.GroupVersionKind{
+		Group:   "nfd.k8s-sigs.io",
+		Version: "v1alpha1",
+		Kind:    "NodeFeatureRule",
+	}
+
+	// ErrNodeFeatureRuleAPINotFound is returned when the NodeFeatureRule API is
+	// not served by the cluster, i.e. Node Feature Discovery is not installed.
+	ErrNodeFeatureRuleAPINotFound = fmt.Errorf("%s API not found, Node Feature Discovery must be installed", NodeFeatureRuleGVK.GroupKind())
+)
+
+// hpuProduct is an HPU product and the PCI device IDs it is identified by.
+type hpuProduct struct {
+	name      string
+	deviceIDs []string
+}
+
+// hpuProducts are the HPU products labelled by the NodeFeatureRule, from the
+// PCI device IDs of the habanalabs driver.
+var hpuProducts = []hpuProduct{
+	{name: "gaudi", deviceIDs: []string{"1000", "1010"}},
+	{name: "gaudi2", deviceIDs: []string{"1020"}},
+}
+
 //go:generate mockgen -source=labeler.go -package=labeler -destination=mock_labeler.go
 
 type Reconciler interface {
 	ReconcileNodeLabeler(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
 	DeleteNodeLabeler(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
-	ReconcileNodeLabelerDaemonSet(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
-	SetDesiredNodeLabelerDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error
-	DeleteNodeLabelerDaemonSet(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	ReconcileNodeFeatureRule(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredNodeFeatureRule(nfr *unstructured.Unstructured, cr *hlaiv1alpha1.DeviceConfig) error
+	DeleteNodeFeatureRule(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	ReconcileNodeLabels(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	DeleteNodeLabels(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
 }
 
 type NodeLabelerReconciler struct {
@@ -74,58 +99,85 @@ func getNodeLabelerName(cr *hlaiv1alpha1.DeviceConfig) string {
 	return fmt.Sprintf("%s-%s", cr.Name, nodeLabelerSuffix)
 }
 
+func GetNodeFeatureRuleName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-%s", cr.Name, nodeFeatureRuleSuffix)
+}
+
+func newNodeFeatureRule(cr *hlaiv1alpha1.DeviceConfig) *unstructured.Unstructured {
+	nfr := &unstructured.Unstructured{}
+	nfr.SetGroupVersionKind(NodeFeatureRuleGVK)
+	nfr.SetName(GetNodeFeatureRuleName(cr))
+	return nfr
+}
+
 func (r *NodeLabelerReconciler) ReconcileNodeLabeler(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
-	err := r.ReconcileNodeLabelerDaemonSet(ctx, cr)
+	err := r.ReconcileNodeFeatureRule(ctx, cr)
 	if err != nil {
 		return err
 	}
 
-	return setNodeLabelerConditions(r)
+	// The node labeler used to be a DaemonSet writing NFD feature files on
+	// the hosts. Remove it, as it is superseded by the NodeFeatureRule.
+	return r.deleteLegacyNodeLabelerDaemonSet(ctx, cr)
 }
 
-func (r *NodeLabelerReconciler) ReconcileNodeLabelerDaemonSet(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+func (r *NodeLabelerReconciler) ReconcileNodeFeatureRule(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
 	logger := log.FromContext(ctx)
 
-	existingDS := &appsv1.DaemonSet{}
-	err := r.client.Get(ctx, types.NamespacedName{Namespace: cr.Namespace, Name: getNodeLabelerName(cr)}, existingDS)
+	existingNFR := newNodeFeatureRule(cr)
+	err := r.client.Get(ctx, types.NamespacedName{Name: GetNodeFeatureRuleName(cr)}, existingNFR)
+	if meta.IsNoMatchError(err) {
+		return ErrNodeFeatureRuleAPINotFound
+	}
 	exists := !apierrors.IsNotFound(err)
 	if err != nil && !apierrors.IsNotFound(err) {
 		return err
 	}
 
-	ds := &appsv1.DaemonSet{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      getNodeLabelerName(cr),
-			Namespace: cr.Namespace,
-		},
-	}
+	nfr := newNodeFeatureRule(cr)
 
 	if exists {
-		ds = existingDS
+		nfr = existingNFR
 	}
 
-	res, err := controllerutil.CreateOrPatch(ctx, r.client, ds, func() error {
-		return r.SetDesiredNodeLabelerDaemonSet(ds, cr)
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, nfr, func() error {
+		return r.SetDesiredNodeFeatureRule(nfr, cr)
 	})
 
 	if err != nil {
-		return fmt.Errorf("could not create or patch DaemonSet: %v", err)
+		return fmt.Errorf("could not create or patch NodeFeatureRule: %v", err)
 	}
 
-	logger.Info("Reconciled DaemonSet", "resource", ds.Name, "result", res)
+	logger.Info("Reconciled NodeFeatureRule", "resource", nfr.GetName(), "result", res)
 
 	return nil
 }
 
 func (r *NodeLabelerReconciler) DeleteNodeLabeler(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
-	return r.DeleteNodeLabelerDaemonSet(ctx, cr)
+	err := r.DeleteNodeFeatureRule(ctx, cr)
+	if err != nil {
+		return err
+	}
+
+	return r.deleteLegacyNodeLabelerDaemonSet(ctx, cr)
 }
 
-func (r *NodeLabelerReconciler) DeleteNodeLabelerDaemonSet(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+func (r *NodeLabelerReconciler) DeleteNodeFeatureRule(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	nfr := newNodeFeatureRule(cr)
+
+	err := r.client.Delete(ctx, nfr)
+	if err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return fmt.Errorf("failed to delete NodeFeatureRule %s: %w", nfr.GetName(), err)
+	}
+
+	return nil
+}
+
+func (r *NodeLabelerReconciler) deleteLegacyNodeLabelerDaemonSet(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
 	ds := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      getNodeLabelerName(cr),
-			Namespace: cr.Namespace,
+			Namespace: s.Settings.OperatorNamespace,
 		},
 	}
 
@@ -137,110 +189,90 @@ func (r *NodeLabelerReconciler) DeleteNodeLabelerDaemonSet(ctx context.Context,
 	return nil
 }
 
-func (r *NodeLabelerReconciler) SetDesiredNodeLabelerDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error {
-	if ds == nil {
-		return errors.New("daemonset cannot be nil")
+// SetDesiredNodeFeatureRule sets the rules producing the labels the operator
+// relies on: the PCI vendor label used by the default DeviceConfig node
+// selector, and the HPU label used by the Module selector.
+func (r *NodeLabelerReconciler) SetDesiredNodeFeatureRule(nfr *unstructured.Unstructured, cr *hlaiv1alpha1.DeviceConfig) error {
+	if nfr == nil {
+		return errors.New("nodefeaturerule cannot be nil")
 	}
 
-	labels := labelsForNodeLabelerDaemonSet(cr)
-
-	ds.Spec.Selector = &metav1.LabelSelector{
-		MatchLabels: labels,
-	}
+	nfr.SetLabels(labelsForNodeFeatureRule(cr))
 
-	ds.Spec.Template = corev1.PodTemplateSpec{
-		ObjectMeta: metav1.ObjectMeta{
-			Labels: labels,
+	matchHabanaPCIDevice := map[string]interface{}{
+		"feature": "pci.device",
+		"matchExpressions": map[string]interface{}{
+			"vendor": map[string]interface{}{
+				"op":    "In",
+				"value": []interface{}{hlaiv1alpha1.HabanaPCIVendorID},
+			},
+			"class": map[string]interface{}{
+				"op":    "In",
+				"value": []interface{}{hlaiv1alpha1.HabanaPCIClass},
+			},
 		},
 	}
 
-	hostPathTypeDirectory := corev1.HostPathDirectory
-	volumes := []corev1.Volume{
-		{
-			Name: "pod-resources",
-			VolumeSource: corev1.VolumeSource{
-				HostPath: &corev1.HostPathVolumeSource{
-					Path: "/etc/kubernetes/node-feature-discovery/features.d",
-					Type: &hostPathTypeDirectory,
-				},
+	rules := []interface{}{
+		map[string]interface{}{
+			"name": "habana.ai pci device",
+			"labels": map[string]interface{}{
+				fmt.Sprintf("feature.node.kubernetes.io/pci-%s.present", hlaiv1alpha1.HabanaPCIVendorID): "true",
 			},
+			"matchFeatures": []interface{}{matchHabanaPCIDevice},
+		},
+		map[string]interface{}{
+			"name": "habana.ai hpu",
+			"labels": map[string]interface{}{
+				constants.HPUPresentLabel: "true",
+			},
+			"matchFeatures": []interface{}{matchHabanaPCIDevice},
 		},
 	}
 
-	containers := []corev1.Container{
-		r.makeNodeLabelerContainer(cr),
-	}
-
-	nodeSelector := make(map[string]string)
-	for k, v := range cr.GetNodeSelector() {
-		nodeSelector[k] = v
-	}
-
-	ds.Spec.Template.Spec = corev1.PodSpec{
-		Containers:         containers,
-		HostPID:            true,
-		NodeSelector:       nodeSelector,
-		PriorityClassName:  "system-node-critical",
-		ServiceAccountName: nodeLabelerServiceAccount,
-		Volumes:            volumes,
-	}
-
-	return ctrl.SetControllerReference(cr, ds, r.scheme)
-}
-
-func (r *NodeLabelerReconciler) makeNodeLabelerContainer(cr *hlaiv1alpha1.DeviceConfig) corev1.Container {
-	nodeLabeler := corev1.Container{
-		Name: nodeLabelerSuffix,
-	}
-
-	nodeLabeler.Image = s.Settings.NodeLabelerImage
-	nodeLabeler.ImagePullPolicy = corev1.PullAlways
-
-	nodeLabeler.SecurityContext = &corev1.SecurityContext{
-		Privileged: pointer.Bool(true),
-		RunAsUser:  pointer.Int64(0),
-	}
-
-	nodeLabeler.Resources = corev1.ResourceRequirements{
-		Limits: corev1.ResourceList{
-			"cpu":    resource.MustParse(nodeLabelerLimitsCpu),
-			"memory": resource.MustParse(nodeLabelerLimitsMemory),
-		},
-		Requests: corev1.ResourceList{
-			"cpu":    resource.MustParse(nodeLabelerRequestsCpu),
-			"memory": resource.MustParse(nodeLabelerRequestsMemory),
-		},
+	// The product of the HPUs is identified by their PCI device ID.
+	for _, p := range hpuProducts {
+		deviceIDs := make([]interface{}, 0, len(p.deviceIDs))
+		for _, id := range p.deviceIDs {
+			deviceIDs = append(deviceIDs, id)
+		}
+
+		rules = append(rules, map[string]interface{}{
+			"name": "habana.ai hpu product " + p.name,
+			"labels": map[string]interface{}{
+				HPUProductLabel: p.name,
+			},
+			"matchFeatures": []interface{}{
+				map[string]interface{}{
+					"feature": "pci.device",
+					"matchExpressions": map[string]interface{}{
+						"vendor": map[string]interface{}{
+							"op":    "In",
+							"value": []interface{}{hlaiv1alpha1.HabanaPCIVendorID},
+						},
+						"device": map[string]interface{}{
+							"op":    "In",
+							"value": deviceIDs,
+						},
+					},
+				},
+			},
+		})
 	}
 
-	nodeLabeler.VolumeMounts = []corev1.VolumeMount{
-		{
-			Name:      "pod-resources",
-			MountPath: "/etc/kubernetes/node-feature-discovery/features.d",
-			ReadOnly:  false,
-		},
+	nfr.Object["spec"] = map[string]interface{}{
+		"rules": rules,
 	}
 
-	return nodeLabeler
+	return nil
 }
 
-// labelsForNodeLabelerDaemonSet returns the labels for selecting the
+// labelsForNodeFeatureRule returns the labels for selecting the
 // resources belonging to the given DeviceConfig CR name.
-func labelsForNodeLabelerDaemonSet(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+func labelsForNodeFeatureRule(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
 	return map[string]string{
 		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
 		"app.kubernetes.io/component": nodeLabelerSuffix,
+		constants.DeviceConfigLabel:   cr.Name,
 	}
 }
-
-// TODO: NodeLabelerConditions shoud reflect the conditions of the pods
-//
-//	on the nodes. For that, we should list all the nodes that match the
-//	DaemonSet.Spec.Template.Spec.NodeSelector and verify the conditions
-//	of the pods running on each of these nodes. We can then set the
-//	NodeLabeler* conditions accordingly.
-//
-// TODO: Define the NodeLabeler* conditions and what they mean.
-// setNodeLabelerConditions wraps the condition create/update
-func setNodeLabelerConditions(r *NodeLabelerReconciler) (err error) {
-	return
-}
@@ -21,10 +21,10 @@ import (
 	"errors"
 
 	gomock "github.com/golang/mock/gomock"
-	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
 
@@ -33,7 +33,7 @@ import (
 
 	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
 	"github.com/HabanaAI/habana-ai-operator/internal/client"
-	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
 )
 
 const (
@@ -52,8 +52,7 @@ var _ = Describe("NodeLabelerReconciler", func() {
 	BeforeEach(func() {
 		dc = &hlaiv1alpha1.DeviceConfig{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      "a-device-config",
-				Namespace: "a-namespace",
+				Name: "a-device-config",
 			},
 		}
 		c = client.NewMockClient(gomock.NewController(GinkgoT()))
@@ -67,15 +66,33 @@ var _ = Describe("NodeLabelerReconciler", func() {
 	})
 
 	Describe("ReconcileNodeLabeler", func() {
+		Context("with no client error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().
+						Get(ctx, gomock.Any(), gomock.Any()).
+						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "nodefeaturerules"}, GetNodeFeatureRuleName(dc))).
+						AnyTimes(),
+					c.EXPECT().Create(ctx, gomock.Any()).Return(nil),
+					c.EXPECT().
+						Delete(ctx, gomock.Any()).
+						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, getNodeLabelerName(dc))),
+				)
+			})
+
+			It("should not return an error", func() {
+				Expect(r.ReconcileNodeLabeler(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
 	})
 
-	Describe("ReconcileNodeLabelerDaemonSet", func() {
+	Describe("ReconcileNodeFeatureRule", func() {
 		Context("with no client Get error", func() {
 			BeforeEach(func() {
 				gomock.InOrder(
 					c.EXPECT().
 						Get(ctx, gomock.Any(), gomock.Any()).
-						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, getNodeLabelerName(dc))).
+						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "nodefeaturerules"}, GetNodeFeatureRuleName(dc))).
 						AnyTimes(),
 				)
 			})
@@ -88,7 +105,7 @@ var _ = Describe("NodeLabelerReconciler", func() {
 				})
 
 				It("should not return an error", func() {
-					Expect(r.ReconcileNodeLabelerDaemonSet(ctx, dc)).ToNot(HaveOccurred())
+					Expect(r.ReconcileNodeFeatureRule(ctx, dc)).ToNot(HaveOccurred())
 				})
 			})
 
@@ -100,7 +117,7 @@ var _ = Describe("NodeLabelerReconciler", func() {
 				})
 
 				It("should return an error", func() {
-					Expect(r.ReconcileNodeLabelerDaemonSet(ctx, dc)).To(HaveOccurred())
+					Expect(r.ReconcileNodeFeatureRule(ctx, dc)).To(HaveOccurred())
 				})
 			})
 		})
@@ -113,12 +130,27 @@ var _ = Describe("NodeLabelerReconciler", func() {
 			})
 
 			It("should return an error", func() {
-				Expect(r.ReconcileNodeLabelerDaemonSet(ctx, dc)).To(HaveOccurred())
+				Expect(r.ReconcileNodeFeatureRule(ctx, dc)).To(HaveOccurred())
+			})
+		})
+
+		Context("without the NodeFeatureRule API", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().
+						Get(ctx, gomock.Any(), gomock.Any()).
+						Return(&meta.NoKindMatchError{GroupKind: NodeFeatureRuleGVK.GroupKind()}),
+				)
+			})
+
+			It("should return a NodeFeatureRule API not found error", func() {
+				err := r.ReconcileNodeFeatureRule(ctx, dc)
+				Expect(errors.Is(err, ErrNodeFeatureRuleAPINotFound)).To(BeTrue())
 			})
 		})
 	})
 
-	Describe("DeleteNodeLabelerDaemonSet", func() {
+	Describe("DeleteNodeFeatureRule", func() {
 		Context("without a client Delete error", func() {
 			BeforeEach(func() {
 				gomock.InOrder(
@@ -127,21 +159,21 @@ var _ = Describe("NodeLabelerReconciler", func() {
 			})
 
 			It("should not return an error", func() {
-				Expect(r.DeleteNodeLabelerDaemonSet(ctx, dc)).ToNot(HaveOccurred())
+				Expect(r.DeleteNodeFeatureRule(ctx, dc)).ToNot(HaveOccurred())
 			})
 		})
 
-		Context("with a NotFound client Delete error", func() {
+		Context("without the NodeFeatureRule API", func() {
 			BeforeEach(func() {
 				gomock.InOrder(
 					c.EXPECT().
 						Delete(ctx, gomock.Any()).
-						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, getNodeLabelerName(dc))),
+						Return(&meta.NoKindMatchError{GroupKind: NodeFeatureRuleGVK.GroupKind()}),
 				)
 			})
 
 			It("should not return an error", func() {
-				Expect(r.DeleteNodeLabelerDaemonSet(ctx, dc)).ToNot(HaveOccurred())
+				Expect(r.DeleteNodeFeatureRule(ctx, dc)).ToNot(HaveOccurred())
 			})
 		})
 
@@ -153,105 +185,78 @@ var _ = Describe("NodeLabelerReconciler", func() {
 			})
 
 			It("should return an error", func() {
-				Expect(r.DeleteNodeLabelerDaemonSet(ctx, dc)).To(HaveOccurred())
+				Expect(r.DeleteNodeFeatureRule(ctx, dc)).To(HaveOccurred())
 			})
 		})
 	})
 
-	Describe("SetDesiredNodeLabelerDaemonSet", func() {
+	Describe("SetDesiredNodeFeatureRule", func() {
 		var (
-			ds *appsv1.DaemonSet
+			nfr *unstructured.Unstructured
 		)
 
-		Context("with a nil DaemonSet as input", func() {
-			BeforeEach(func() {
-				ds = nil
-			})
-
-			It("should return a DaemonSet cannot be nil error", func() {
-				err := r.SetDesiredNodeLabelerDaemonSet(ds, dc)
+		Context("with a nil NodeFeatureRule as input", func() {
+			It("should return a nodefeaturerule cannot be nil error", func() {
+				err := r.SetDesiredNodeFeatureRule(nil, dc)
 
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("daemonset cannot be nil"))
+				Expect(err.Error()).To(ContainSubstring("nodefeaturerule cannot be nil"))
 			})
 		})
 
-		Context("with a non-nil DaemonSet as input", func() {
+		Context("with a non-nil NodeFeatureRule as input", func() {
 			BeforeEach(func() {
-				dc.Spec.NodeSelector = map[string]string{testLabelKey: testLabelValue}
+				nfr = newNodeFeatureRule(dc)
 
-				ds = &appsv1.DaemonSet{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      "a-name",
-						Namespace: "a-namespace",
-					},
-				}
-
-				err := r.SetDesiredNodeLabelerDaemonSet(ds, dc)
+				err := r.SetDesiredNodeFeatureRule(nfr, dc)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
-			Context("it returns a DaemonSet which", func() {
-				It("should contain the correct node selector", func() {
-					Expect(ds.Spec.Template.Spec.NodeSelector).ToNot(BeNil())
-
-					v, contains := ds.Spec.Template.Spec.NodeSelector[testLabelKey]
-					Expect(contains).To(BeTrue())
-					Expect(v).To(Equal(testLabelValue))
+			Context("it returns a NodeFeatureRule which", func() {
+				It("should be labelled with the DeviceConfig name", func() {
+					Expect(nfr.GetLabels()).To(HaveKeyWithValue(constants.DeviceConfigLabel, dc.Name))
 				})
 
-				It("should have the HostPID enabled", func() {
-					Expect(ds.Spec.Template.Spec.HostPID).To(BeTrue())
+				It("should have a rule per label and HPU product", func() {
+					rules, found, err := unstructured.NestedSlice(nfr.Object, "spec", "rules")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(rules).To(HaveLen(2 + len(hpuProducts)))
 				})
 
-				It("should have the correct ServiceAccountName", func() {
-					Expect(ds.Spec.Template.Spec.ServiceAccountName).To(Equal(nodeLabelerServiceAccount))
-				})
-
-				It("should have one container", func() {
-					Expect(ds.Spec.Template.Spec.Containers).To(HaveLen(1))
-				})
-
-				Context("contains the nodeLabeler container, which", func() {
-					var (
-						nodeLabeler corev1.Container
-					)
+				It("should label the HPU product from the PCI device ID", func() {
+					rules, _, _ := unstructured.NestedSlice(nfr.Object, "spec", "rules")
 
-					BeforeEach(func() {
-						nodeLabeler = ds.Spec.Template.Spec.Containers[0]
-					})
+					products := map[string]interface{}{}
+					for _, rule := range rules[2:] {
+						product, _, err := unstructured.NestedString(rule.(map[string]interface{}), "labels", HPUProductLabel)
+						Expect(err).ToNot(HaveOccurred())
 
-					It("should have the correct name", func() {
-						Expect(nodeLabeler.Name).To(Equal(nodeLabelerSuffix))
-					})
+						matchFeatures, _, err := unstructured.NestedSlice(rule.(map[string]interface{}), "matchFeatures")
+						Expect(err).ToNot(HaveOccurred())
+						devices, _, err := unstructured.NestedSlice(matchFeatures[0].(map[string]interface{}), "matchExpressions", "device", "value")
+						Expect(err).ToNot(HaveOccurred())
+						products[product] = devices
+					}
 
-					It("should have the correct image", func() {
-						Expect(nodeLabeler.Image).To(Equal(s.Settings.NodeLabelerImage))
-					})
-
-					It("should have the image pull policy always", func() {
-						Expect(nodeLabeler.ImagePullPolicy).To(Equal(corev1.PullAlways))
-					})
-
-					It("should have the privileged SecurityContext", func() {
-						Expect(*nodeLabeler.SecurityContext.Privileged).To(BeTrue())
-					})
-
-					It("should run as user root (id: 0)", func() {
-						Expect(*nodeLabeler.SecurityContext.RunAsUser).To(Equal(int64(0)))
-					})
+					Expect(products).To(HaveKeyWithValue("gaudi", ConsistOf("1000", "1010")))
+					Expect(products).To(HaveKeyWithValue("gaudi2", ConsistOf("1020")))
+				})
 
-					It("should have resources", func() {
-						Expect(nodeLabeler.Resources).ToNot(BeNil())
-					})
+				It("should produce the PCI vendor and the HPU labels", func() {
+					rules, _, _ := unstructured.NestedSlice(nfr.Object, "spec", "rules")
 
-					It("should have one volume mount ", func() {
-						Expect(nodeLabeler.VolumeMounts).To(HaveLen(1))
-					})
-				})
+					labels := map[string]interface{}{}
+					for _, rule := range rules {
+						l, _, err := unstructured.NestedMap(rule.(map[string]interface{}), "labels")
+						Expect(err).ToNot(HaveOccurred())
+						for k, v := range l {
+							labels[k] = v
+						}
+					}
 
-				It("should have one volume", func() {
-					Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(1))
+					Expect(labels).To(HaveKeyWithValue("feature.node.kubernetes.io/pci-1da3.present", "true"))
+					Expect(labels).To(HaveKeyWithValue(testLabelKey, testLabelValue))
 				})
 			})
 		})
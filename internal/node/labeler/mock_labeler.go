@@ -10,7 +10,7 @@ import (
 
 	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
 	gomock "github.com/golang/mock/gomock"
-	v1 "k8s.io/api/apps/v1"
+	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // MockReconciler is a mock of Reconciler interface.
@@ -36,6 +36,20 @@ func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
 	return m.recorder
 }
 
+// DeleteNodeFeatureRule mocks base method.
+func (m *MockReconciler) DeleteNodeFeatureRule(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNodeFeatureRule", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNodeFeatureRule indicates an expected call of DeleteNodeFeatureRule.
+func (mr *MockReconcilerMockRecorder) DeleteNodeFeatureRule(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNodeFeatureRule", reflect.TypeOf((*MockReconciler)(nil).DeleteNodeFeatureRule), ctx, dc)
+}
+
 // DeleteNodeLabeler mocks base method.
 func (m *MockReconciler) DeleteNodeLabeler(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
 	m.ctrl.T.Helper()
@@ -50,18 +64,32 @@ func (mr *MockReconcilerMockRecorder) DeleteNodeLabeler(ctx, dc interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNodeLabeler", reflect.TypeOf((*MockReconciler)(nil).DeleteNodeLabeler), ctx, dc)
 }
 
-// DeleteNodeLabelerDaemonSet mocks base method.
-func (m *MockReconciler) DeleteNodeLabelerDaemonSet(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+// DeleteNodeLabels mocks base method.
+func (m *MockReconciler) DeleteNodeLabels(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNodeLabels", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNodeLabels indicates an expected call of DeleteNodeLabels.
+func (mr *MockReconcilerMockRecorder) DeleteNodeLabels(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNodeLabels", reflect.TypeOf((*MockReconciler)(nil).DeleteNodeLabels), ctx, dc)
+}
+
+// ReconcileNodeFeatureRule mocks base method.
+func (m *MockReconciler) ReconcileNodeFeatureRule(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteNodeLabelerDaemonSet", ctx, dc)
+	ret := m.ctrl.Call(m, "ReconcileNodeFeatureRule", ctx, dc)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteNodeLabelerDaemonSet indicates an expected call of DeleteNodeLabelerDaemonSet.
-func (mr *MockReconcilerMockRecorder) DeleteNodeLabelerDaemonSet(ctx, dc interface{}) *gomock.Call {
+// ReconcileNodeFeatureRule indicates an expected call of ReconcileNodeFeatureRule.
+func (mr *MockReconcilerMockRecorder) ReconcileNodeFeatureRule(ctx, dc interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNodeLabelerDaemonSet", reflect.TypeOf((*MockReconciler)(nil).DeleteNodeLabelerDaemonSet), ctx, dc)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileNodeFeatureRule", reflect.TypeOf((*MockReconciler)(nil).ReconcileNodeFeatureRule), ctx, dc)
 }
 
 // ReconcileNodeLabeler mocks base method.
@@ -78,30 +106,30 @@ func (mr *MockReconcilerMockRecorder) ReconcileNodeLabeler(ctx, dc interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileNodeLabeler", reflect.TypeOf((*MockReconciler)(nil).ReconcileNodeLabeler), ctx, dc)
 }
 
-// ReconcileNodeLabelerDaemonSet mocks base method.
-func (m *MockReconciler) ReconcileNodeLabelerDaemonSet(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+// ReconcileNodeLabels mocks base method.
+func (m *MockReconciler) ReconcileNodeLabels(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReconcileNodeLabelerDaemonSet", ctx, dc)
+	ret := m.ctrl.Call(m, "ReconcileNodeLabels", ctx, dc)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// ReconcileNodeLabelerDaemonSet indicates an expected call of ReconcileNodeLabelerDaemonSet.
-func (mr *MockReconcilerMockRecorder) ReconcileNodeLabelerDaemonSet(ctx, dc interface{}) *gomock.Call {
+// ReconcileNodeLabels indicates an expected call of ReconcileNodeLabels.
+func (mr *MockReconcilerMockRecorder) ReconcileNodeLabels(ctx, dc interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileNodeLabelerDaemonSet", reflect.TypeOf((*MockReconciler)(nil).ReconcileNodeLabelerDaemonSet), ctx, dc)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileNodeLabels", reflect.TypeOf((*MockReconciler)(nil).ReconcileNodeLabels), ctx, dc)
 }
 
-// SetDesiredNodeLabelerDaemonSet mocks base method.
-func (m *MockReconciler) SetDesiredNodeLabelerDaemonSet(ds *v1.DaemonSet, cr *v1alpha1.DeviceConfig) error {
+// SetDesiredNodeFeatureRule mocks base method.
+func (m *MockReconciler) SetDesiredNodeFeatureRule(nfr *unstructured.Unstructured, cr *v1alpha1.DeviceConfig) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetDesiredNodeLabelerDaemonSet", ds, cr)
+	ret := m.ctrl.Call(m, "SetDesiredNodeFeatureRule", nfr, cr)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetDesiredNodeLabelerDaemonSet indicates an expected call of SetDesiredNodeLabelerDaemonSet.
-func (mr *MockReconcilerMockRecorder) SetDesiredNodeLabelerDaemonSet(ds, cr interface{}) *gomock.Call {
+// SetDesiredNodeFeatureRule indicates an expected call of SetDesiredNodeFeatureRule.
+func (mr *MockReconcilerMockRecorder) SetDesiredNodeFeatureRule(nfr, cr interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredNodeLabelerDaemonSet", reflect.TypeOf((*MockReconciler)(nil).SetDesiredNodeLabelerDaemonSet), ds, cr)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredNodeFeatureRule", reflect.TypeOf((*MockReconciler)(nil).SetDesiredNodeFeatureRule), nfr, cr)
 }
@@ -0,0 +1,167 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+)
+
+const (
+	// HPUProductLabel is the product of the HPUs of the node, e.g. gaudi2,
+	// set by NFD from the PCI device ID of the HPUs.
+	HPUProductLabel = "habana.ai/hpu.product"
+
+	// HPUCountLabel is the number of HPUs advertised by the device plugin of
+	// the node.
+	HPUCountLabel = "habana.ai/hpu.count"
+
+	// DriverVersionLabel is the version of the driver loaded on the node.
+	DriverVersionLabel = "habana.ai/driver.version"
+)
+
+// nodeLabels are the labels managed on the nodes of a DeviceConfig, the
+// DeviceConfig label telling which DeviceConfig manages them.
+var nodeLabels = []string{
+	HPUCountLabel,
+	DriverVersionLabel,
+	constants.DeviceConfigLabel,
+}
+
+// ReconcileNodeLabels sets the HPU and driver labels on the nodes with HPUs
+// selected by the DeviceConfig, and removes them from the nodes which left it.
+func (r *NodeLabelerReconciler) ReconcileNodeLabels(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(ctx, nodeList); err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	selector := labels.Set(module.GetModuleNodeSelector(cr)).AsSelector()
+
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+
+		var err error
+		if selector.Matches(labels.Set(node.Labels)) {
+			err = r.patchNodeLabels(ctx, node, getDesiredNodeLabels(cr, node))
+		} else {
+			err = r.removeNodeLabels(ctx, cr, node)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteNodeLabels removes the labels of the DeviceConfig from all the nodes.
+func (r *NodeLabelerReconciler) DeleteNodeLabels(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(ctx, nodeList); err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	for i := range nodeList.Items {
+		if err := r.removeNodeLabels(ctx, cr, &nodeList.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getDesiredNodeLabels returns the labels of the node. The HPU count label is
+// set once the device plugin advertises the HPUs. The driver version is the one
+// of the Module once the node driver is ready, the previous version is kept
+// while the driver is being upgraded. It is left unset when the module is not
+// loaded from the driver image, as the version of the module of the node is
+// unknown.
+func getDesiredNodeLabels(cr *hlaiv1alpha1.DeviceConfig, node *corev1.Node) map[string]string {
+	desired := map[string]string{
+		constants.DeviceConfigLabel: cr.Name,
+	}
+
+	if q, ok := node.Status.Allocatable[constants.HabanaResourceName]; ok {
+		desired[HPUCountLabel] = strconv.FormatInt(q.Value(), 10)
+	}
+
+	if cr.GetDriverMode() != hlaiv1alpha1.DriverModeContainer {
+		return desired
+	}
+
+	desired[DriverVersionLabel] = node.Labels[DriverVersionLabel]
+	for _, c := range node.Status.Conditions {
+		if c.Type == readiness.DriverReadyCondition && c.Status == corev1.ConditionTrue {
+			desired[DriverVersionLabel] = cr.Spec.DriverVersion
+		}
+	}
+
+	return desired
+}
+
+// patchNodeLabels sets the managed labels of the node to the desired ones,
+// the labels with an empty desired value being removed.
+func (r *NodeLabelerReconciler) patchNodeLabels(ctx context.Context, node *corev1.Node, desired map[string]string) error {
+	patch := client.MergeFrom(node.DeepCopy())
+
+	changed := false
+	for _, k := range nodeLabels {
+		v, exists := node.Labels[k]
+		switch {
+		case desired[k] == "" && exists:
+			delete(node.Labels, k)
+			changed = true
+		case desired[k] != "" && v != desired[k]:
+			if node.Labels == nil {
+				node.Labels = map[string]string{}
+			}
+			node.Labels[k] = desired[k]
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := r.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to update the labels of node %s: %w", node.Name, err)
+	}
+
+	log.FromContext(ctx).Info("Updated the node labels", "node", node.Name)
+
+	return nil
+}
+
+// removeNodeLabels removes the managed labels of the node, provided that they
+// are managed by the DeviceConfig.
+func (r *NodeLabelerReconciler) removeNodeLabels(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, node *corev1.Node) error {
+	if node.Labels[constants.DeviceConfigLabel] != cr.Name {
+		return nil
+	}
+
+	return r.patchNodeLabels(ctx, node, map[string]string{})
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeler
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+)
+
+var _ = Describe("NodeLabels", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		c   client.Client
+		r   *NodeLabelerReconciler
+		ctx context.Context
+	)
+
+	hpuNode := func(name string, driverReady bool, extraLabels map[string]string) *corev1.Node {
+		status := corev1.ConditionFalse
+		if driverReady {
+			status = corev1.ConditionTrue
+		}
+		n := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"hpu": "true", testLabelKey: testLabelValue},
+			},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					constants.HabanaResourceName: *resource.NewQuantity(8, resource.DecimalSI),
+				},
+				Conditions: []corev1.NodeCondition{{Type: readiness.DriverReadyCondition, Status: status}},
+			},
+		}
+		for k, v := range extraLabels {
+			n.Labels[k] = v
+		}
+		return n
+	}
+
+	getLabels := func(name string) map[string]string {
+		n := &corev1.Node{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: name}, n)).To(Succeed())
+		return n.Labels
+	}
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+			Spec: hlaiv1alpha1.DeviceConfigSpec{
+				DriverVersion: "1.7.0",
+				NodeSelector:  map[string]string{"hpu": "true"},
+			},
+		}
+		ctx = context.TODO()
+
+		left := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "left",
+				Labels: map[string]string{
+					constants.DeviceConfigLabel: dc.Name,
+					HPUCountLabel:               "8",
+					"unrelated":                 "label",
+				},
+			},
+		}
+		otherDC := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "other-dc",
+				Labels: map[string]string{constants.DeviceConfigLabel: "another-device-config", HPUCountLabel: "8"},
+			},
+		}
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+		c = fake.NewClientBuilder().WithScheme(s).WithObjects(
+			hpuNode("ready", true, nil),
+			hpuNode("upgrading", false, map[string]string{DriverVersionLabel: "1.6.0"}),
+			left,
+			otherDC,
+		).Build()
+		r = NewReconciler(c, s)
+	})
+
+	Describe("ReconcileNodeLabels", func() {
+		It("should label the nodes of the DeviceConfig", func() {
+			Expect(r.ReconcileNodeLabels(ctx, dc)).To(Succeed())
+			Expect(getLabels("ready")).To(And(
+				HaveKeyWithValue(HPUCountLabel, "8"),
+				HaveKeyWithValue(DriverVersionLabel, "1.7.0"),
+				HaveKeyWithValue(constants.DeviceConfigLabel, dc.Name),
+			))
+		})
+
+		It("should keep the driver version while the driver is being upgraded", func() {
+			Expect(r.ReconcileNodeLabels(ctx, dc)).To(Succeed())
+			Expect(getLabels("upgrading")).To(HaveKeyWithValue(DriverVersionLabel, "1.6.0"))
+		})
+
+		It("should not set the driver version when the module is not loaded from the driver image", func() {
+			dc.Spec.Driver.Mode = hlaiv1alpha1.DriverModeHostInstalled
+
+			Expect(r.ReconcileNodeLabels(ctx, dc)).To(Succeed())
+			Expect(getLabels("ready")).To(HaveKeyWithValue(HPUCountLabel, "8"))
+			Expect(getLabels("ready")).ToNot(HaveKey(DriverVersionLabel))
+			Expect(getLabels("upgrading")).ToNot(HaveKey(DriverVersionLabel))
+		})
+
+		It("should only remove its labels from the nodes which left the DeviceConfig", func() {
+			Expect(r.ReconcileNodeLabels(ctx, dc)).To(Succeed())
+			Expect(getLabels("left")).To(Equal(map[string]string{"unrelated": "label"}))
+			Expect(getLabels("other-dc")).To(HaveKeyWithValue(HPUCountLabel, "8"))
+		})
+	})
+
+	Describe("DeleteNodeLabels", func() {
+		It("should remove the labels of the DeviceConfig", func() {
+			Expect(r.ReconcileNodeLabels(ctx, dc)).To(Succeed())
+			Expect(r.DeleteNodeLabels(ctx, dc)).To(Succeed())
+
+			Expect(getLabels("ready")).To(Equal(map[string]string{"hpu": "true", testLabelKey: testLabelValue}))
+			Expect(getLabels("other-dc")).To(HaveKey(constants.DeviceConfigLabel))
+		})
+	})
+})
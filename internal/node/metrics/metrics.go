@@ -30,24 +30,45 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
-	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
 	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	operatorMetrics "github.com/HabanaAI/habana-ai-operator/internal/metrics"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	nodeTopology "github.com/HabanaAI/habana-ai-operator/internal/node/topology"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
 	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
 )
 
 const (
-	nodeMetricsServiceAccount = "node-metrics"
 	nodeMetricsSuffix         = "node-metrics"
-	nodeMetricsPort           = 41611
+	NodeMetricsPortName       = nodeMetricsSuffix
+	NodeMetricsSecurePortName = "https"
 	nodeMetricsLimitsCpu      = "1"
 	nodeMetricsLimitsMemory   = "200Mi"
 	nodeMetricsRequestsCpu    = "100m"
 	nodeMetricsRequestsMemory = "200Mi"
+	podResourcesMountPath     = "/var/lib/kubelet/pod-resources"
+
+	kubeRBACProxyName           = "kube-rbac-proxy"
+	kubeRBACProxyLimitsCpu      = "500m"
+	kubeRBACProxyLimitsMemory   = "128Mi"
+	kubeRBACProxyRequestsCpu    = "5m"
+	kubeRBACProxyRequestsMemory = "64Mi"
+	kubeRBACProxyTLSVolume      = "tls"
+	kubeRBACProxyTLSMountPath   = "/etc/tls/private"
+
+	// CABundleKey is the key of the CA bundle in the node metrics CA bundle
+	// ConfigMap. It is the key the OpenShift service CA operator injects the
+	// bundle into.
+	CABundleKey = "service-ca.crt"
+
+	// ServingCertSecretAnnotation asks the OpenShift service CA operator to
+	// issue and rotate a serving certificate for a Service.
+	ServingCertSecretAnnotation = "service.beta.openshift.io/serving-cert-secret-name"
 )
 
 //go:generate mockgen -source=metrics.go -package=metrics -destination=mock_metrics.go
@@ -79,7 +100,40 @@ func GetNodeMetricsName(cr *hlaiv1alpha1.DeviceConfig) string {
 	return fmt.Sprintf("%s-%s", cr.Name, nodeMetricsSuffix)
 }
 
+// GetNodeMetricsTLSSecretName returns the name of the Secret holding the
+// serving certificate of the node metrics kube-rbac-proxy.
+func GetNodeMetricsTLSSecretName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-tls", GetNodeMetricsName(cr))
+}
+
+// GetNodeMetricsCABundleName returns the name of the ConfigMap holding the
+// CA bundle that verifies the serving certificate of the node metrics.
+func GetNodeMetricsCABundleName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-ca-bundle", GetNodeMetricsName(cr))
+}
+
+// GetNodeMetricsServiceHostname returns the in-cluster DNS name of the node
+// metrics Service, which the serving certificate is issued for.
+func GetNodeMetricsServiceHostname(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s.%s.svc", GetNodeMetricsName(cr), s.Settings.OperatorNamespace)
+}
+
+// GetNodeMetricsPortName returns the name of the node metrics Service port
+// to scrape, depending on whether the node metrics are secured.
+func GetNodeMetricsPortName(cr *hlaiv1alpha1.DeviceConfig) string {
+	if cr.Spec.NodeMetrics.Secure {
+		return NodeMetricsSecurePortName
+	}
+	return NodeMetricsPortName
+}
+
 func (r *NodeMetricsReconciler) ReconcileNodeMetrics(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	// The exporter reads the metrics of actual HPUs, the metrics of the
+	// simulated HPUs are synthesized by the telemetry collector instead.
+	if cr.Spec.Simulation.Enabled {
+		return r.DeleteNodeMetrics(ctx, cr)
+	}
+
 	err := r.ReconcileNodeMetricsDaemonSet(ctx, cr)
 	if err != nil {
 		return err
@@ -97,7 +151,7 @@ func (r *NodeMetricsReconciler) ReconcileNodeMetricsDaemonSet(ctx context.Contex
 	logger := log.FromContext(ctx)
 
 	existingDS := &appsv1.DaemonSet{}
-	err := r.client.Get(ctx, types.NamespacedName{Namespace: cr.Namespace, Name: GetNodeMetricsName(cr)}, existingDS)
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: GetNodeMetricsName(cr)}, existingDS)
 	exists := !apierrors.IsNotFound(err)
 	if err != nil && !apierrors.IsNotFound(err) {
 		return err
@@ -106,7 +160,7 @@ func (r *NodeMetricsReconciler) ReconcileNodeMetricsDaemonSet(ctx context.Contex
 	ds := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      GetNodeMetricsName(cr),
-			Namespace: cr.Namespace,
+			Namespace: s.Settings.OperatorNamespace,
 		},
 	}
 
@@ -124,6 +178,8 @@ func (r *NodeMetricsReconciler) ReconcileNodeMetricsDaemonSet(ctx context.Contex
 
 	logger.Info("Reconciled DaemonSet", "resource", ds.Name, "result", res)
 
+	operatorMetrics.SetComponentNodes(cr.Name, rbac.ComponentNodeMetrics, ds.Status.DesiredNumberScheduled, ds.Status.NumberReady)
+
 	return nil
 }
 
@@ -132,7 +188,7 @@ func (r *NodeMetricsReconciler) ReconcileNodeMetricsService(ctx context.Context,
 
 	existingService := &corev1.Service{}
 	err := r.client.Get(ctx, types.NamespacedName{
-		Namespace: cr.Namespace,
+		Namespace: s.Settings.OperatorNamespace,
 		Name:      GetNodeMetricsName(cr),
 	}, existingService)
 	exists := !apierrors.IsNotFound(err)
@@ -143,7 +199,7 @@ func (r *NodeMetricsReconciler) ReconcileNodeMetricsService(ctx context.Context,
 	s := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      GetNodeMetricsName(cr),
-			Namespace: cr.ObjectMeta.Namespace,
+			Namespace: s.Settings.OperatorNamespace,
 		},
 	}
 
@@ -182,7 +238,7 @@ func (r *NodeMetricsReconciler) DeleteNodeMetricsDaemonSet(ctx context.Context,
 	ds := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      GetNodeMetricsName(cr),
-			Namespace: cr.Namespace,
+			Namespace: s.Settings.OperatorNamespace,
 		},
 	}
 
@@ -198,7 +254,7 @@ func (r *NodeMetricsReconciler) DeleteNodeMetricsService(ctx context.Context, cr
 	s := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      GetNodeMetricsName(cr),
-			Namespace: cr.Namespace,
+			Namespace: s.Settings.OperatorNamespace,
 		},
 	}
 
@@ -214,9 +270,16 @@ func (r *NodeMetricsReconciler) SetDesiredNodeMetricsDaemonSet(ds *appsv1.Daemon
 	if ds == nil {
 		return errors.New("daemonset cannot be nil")
 	}
+	// The exporter would serve the plain HTTP port on the nodes, next to the
+	// kube-rbac-proxy one, on the clusters not validating the CEL rules.
+	if cr.Spec.NodeMetrics.HostNetwork && cr.Spec.NodeMetrics.Secure {
+		return errors.New("the node metrics cannot use the host network when secured")
+	}
 
 	labels := labelsForNodeMetricsDaemonSet(cr)
 
+	ds.ObjectMeta.Labels = labels
+
 	ds.Spec.Selector = &metav1.LabelSelector{
 		MatchLabels: labels,
 	}
@@ -233,7 +296,7 @@ func (r *NodeMetricsReconciler) SetDesiredNodeMetricsDaemonSet(ds *appsv1.Daemon
 			Name: "pod-resources",
 			VolumeSource: corev1.VolumeSource{
 				HostPath: &corev1.HostPathVolumeSource{
-					Path: "/var/lib/kubelet/pod-resources",
+					Path: cr.GetKubeletPodResourcesDir(),
 					Type: &hostPathTypeDirectory,
 				},
 			},
@@ -244,6 +307,25 @@ func (r *NodeMetricsReconciler) SetDesiredNodeMetricsDaemonSet(ds *appsv1.Daemon
 		r.makeNodeMetricsContainer(cr),
 	}
 
+	if cr.Spec.NodeMetrics.Secure {
+		containers = append(containers, r.makeKubeRBACProxyContainer(cr))
+		volumes = append(volumes, corev1.Volume{
+			Name: kubeRBACProxyTLSVolume,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: GetNodeMetricsTLSSecretName(cr),
+				},
+			},
+		})
+	}
+
+	// The topology of the node is exported as NFD labels, from the PCI
+	// devices.
+	if cr.Spec.Topology.Enabled {
+		containers = append(containers, nodeTopology.MakeTopologyContainer())
+		volumes = append(volumes, nodeTopology.MakeFeaturesVolume())
+	}
+
 	nodeSelector := make(map[string]string)
 	for k, v := range cr.GetNodeSelector() {
 		nodeSelector[k] = v
@@ -254,12 +336,14 @@ func (r *NodeMetricsReconciler) SetDesiredNodeMetricsDaemonSet(ds *appsv1.Daemon
 		HostPID:            true,
 		NodeSelector:       nodeSelector,
 		PriorityClassName:  "system-node-critical",
-		ServiceAccountName: nodeMetricsServiceAccount,
+		ServiceAccountName: rbac.GetServiceAccountName(cr, rbac.ComponentNodeMetrics),
+		Tolerations:        readiness.GetTolerations(),
 		Volumes:            volumes,
 	}
 
-	if err := ctrl.SetControllerReference(cr, ds, r.scheme); err != nil {
-		return err
+	if cr.Spec.NodeMetrics.HostNetwork {
+		ds.Spec.Template.Spec.HostNetwork = true
+		ds.Spec.Template.Spec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
 	}
 
 	return nil
@@ -275,20 +359,31 @@ func (r *NodeMetricsReconciler) SetDesiredNodeMetricsService(s *corev1.Service,
 		"prometheus.io/scrape": "true",
 	}
 
-	s.Spec = corev1.ServiceSpec{
-		Selector: labelsForNodeMetricsDaemonSet(cr),
-		Ports: []corev1.ServicePort{
-			{
-				Name:       nodeMetricsSuffix,
-				Port:       nodeMetricsPort,
-				TargetPort: intstr.FromInt(nodeMetricsPort),
-				Protocol:   corev1.ProtocolTCP,
-			},
-		},
+	port := corev1.ServicePort{
+		Name:       NodeMetricsPortName,
+		Port:       cr.GetNodeMetricsPort(),
+		TargetPort: intstr.FromInt(int(cr.GetNodeMetricsPort())),
+		Protocol:   corev1.ProtocolTCP,
 	}
 
-	if err := ctrl.SetControllerReference(cr, s, r.scheme); err != nil {
-		return err
+	// When secured, the plain HTTP port is not exposed by the Service. The
+	// serving certificate annotation is inert on clusters without the
+	// OpenShift service CA operator.
+	if cr.Spec.NodeMetrics.Secure {
+		s.ObjectMeta.Annotations["prometheus.io/scheme"] = "https"
+		s.ObjectMeta.Annotations[ServingCertSecretAnnotation] = GetNodeMetricsTLSSecretName(cr)
+
+		port = corev1.ServicePort{
+			Name:       NodeMetricsSecurePortName,
+			Port:       cr.GetNodeMetricsSecurePort(),
+			TargetPort: intstr.FromInt(int(cr.GetNodeMetricsSecurePort())),
+			Protocol:   corev1.ProtocolTCP,
+		}
+	}
+
+	s.Spec = corev1.ServiceSpec{
+		Selector: labelsForNodeMetricsDaemonSet(cr),
+		Ports:    []corev1.ServicePort{port},
 	}
 
 	return nil
@@ -307,15 +402,25 @@ func (r *NodeMetricsReconciler) makeNodeMetricsContainer(cr *hlaiv1alpha1.Device
 		RunAsUser:  pointer.Int64(0),
 	}
 
+	nodeMetrics.Args = []string{
+		fmt.Sprintf("--port=%d", cr.GetNodeMetricsPort()),
+	}
+
+	// When secured, the metrics are only reachable through kube-rbac-proxy,
+	// hence the plain HTTP port is not exposed on the node. On the host
+	// network, the container ports are the host ports.
 	nodeMetrics.Ports = []corev1.ContainerPort{
 		{
-			ContainerPort: nodeMetricsPort,
-			HostPort:      nodeMetricsPort,
+			ContainerPort: cr.GetNodeMetricsPort(),
 			Name:          nodeMetricsSuffix,
 			Protocol:      corev1.ProtocolTCP,
 		},
 	}
 
+	if cr.UsesNodeMetricsHostPort() {
+		nodeMetrics.Ports[0].HostPort = cr.GetNodeMetricsPort()
+	}
+
 	nodeMetrics.Resources = corev1.ResourceRequirements{
 		Limits: corev1.ResourceList{
 			"cpu":    resource.MustParse(nodeMetricsLimitsCpu),
@@ -329,8 +434,10 @@ func (r *NodeMetricsReconciler) makeNodeMetricsContainer(cr *hlaiv1alpha1.Device
 
 	nodeMetrics.VolumeMounts = []corev1.VolumeMount{
 		{
+			// The exporter reads the kubelet socket from its default path,
+			// whatever the kubelet root directory of the node.
 			Name:      "pod-resources",
-			MountPath: "/var/lib/kubelet/pod-resources",
+			MountPath: podResourcesMountPath,
 			ReadOnly:  true,
 		},
 	}
@@ -338,12 +445,85 @@ func (r *NodeMetricsReconciler) makeNodeMetricsContainer(cr *hlaiv1alpha1.Device
 	return nodeMetrics
 }
 
+// makeKubeRBACProxyContainer returns a sidecar terminating TLS in front of
+// the node metrics exporter, and authorizing the requests against the
+// Kubernetes API with TokenReviews and SubjectAccessReviews.
+func (r *NodeMetricsReconciler) makeKubeRBACProxyContainer(cr *hlaiv1alpha1.DeviceConfig) corev1.Container {
+	proxy := corev1.Container{
+		Name: kubeRBACProxyName,
+	}
+
+	proxy.Image = s.Settings.KubeRBACProxyImage
+	proxy.ImagePullPolicy = corev1.PullIfNotPresent
+
+	proxy.Args = []string{
+		fmt.Sprintf("--secure-listen-address=0.0.0.0:%d", cr.GetNodeMetricsSecurePort()),
+		fmt.Sprintf("--upstream=http://127.0.0.1:%d/", cr.GetNodeMetricsPort()),
+		fmt.Sprintf("--tls-cert-file=%s/%s", kubeRBACProxyTLSMountPath, corev1.TLSCertKey),
+		fmt.Sprintf("--tls-private-key-file=%s/%s", kubeRBACProxyTLSMountPath, corev1.TLSPrivateKeyKey),
+		"--logtostderr=true",
+		"--v=0",
+	}
+
+	proxy.SecurityContext = &corev1.SecurityContext{
+		AllowPrivilegeEscalation: pointer.Bool(false),
+		ReadOnlyRootFilesystem:   pointer.Bool(true),
+		RunAsNonRoot:             pointer.Bool(true),
+	}
+
+	proxy.Ports = []corev1.ContainerPort{
+		{
+			ContainerPort: cr.GetNodeMetricsSecurePort(),
+			Name:          NodeMetricsSecurePortName,
+			Protocol:      corev1.ProtocolTCP,
+		},
+	}
+
+	proxy.Resources = corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			"cpu":    resource.MustParse(kubeRBACProxyLimitsCpu),
+			"memory": resource.MustParse(kubeRBACProxyLimitsMemory),
+		},
+		Requests: corev1.ResourceList{
+			"cpu":    resource.MustParse(kubeRBACProxyRequestsCpu),
+			"memory": resource.MustParse(kubeRBACProxyRequestsMemory),
+		},
+	}
+
+	proxy.VolumeMounts = []corev1.VolumeMount{
+		{
+			Name:      kubeRBACProxyTLSVolume,
+			MountPath: kubeRBACProxyTLSMountPath,
+			ReadOnly:  true,
+		},
+	}
+
+	return proxy
+}
+
+// GetNodeMetricsHostPorts returns the ports the node metrics pods hold on the
+// nodes, either as hostPorts or on the host network.
+func GetNodeMetricsHostPorts(cr *hlaiv1alpha1.DeviceConfig) []int32 {
+	switch {
+	case cr.Spec.NodeMetrics.HostNetwork || cr.UsesNodeMetricsHostPort():
+		return []int32{cr.GetNodeMetricsPort()}
+	default:
+		return nil
+	}
+}
+
+// GetNodeMetricsServiceLabels returns the labels of the node metrics Service.
+func GetNodeMetricsServiceLabels(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+	return labelsForNodeMetricsDaemonSet(cr)
+}
+
 // labelsForNodeMetricsDaemonSet returns the labels for selecting the
 // resources belonging to the given DeviceConfig CR name.
 func labelsForNodeMetricsDaemonSet(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
 	return map[string]string{
 		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
 		"app.kubernetes.io/component": nodeMetricsSuffix,
+		constants.DeviceConfigLabel:   cr.Name,
 	}
 }
 
@@ -19,6 +19,7 @@ package metrics
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	gomock "github.com/golang/mock/gomock"
 	appsv1 "k8s.io/api/apps/v1"
@@ -28,12 +29,16 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
 	"github.com/HabanaAI/habana-ai-operator/internal/client"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	nodeTopology "github.com/HabanaAI/habana-ai-operator/internal/node/topology"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
 	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
 )
 
@@ -69,6 +74,19 @@ var _ = Describe("NodeMetricsReconciler", func() {
 	})
 
 	Describe("ReconcileNodeMetrics", func() {
+		Context("with the simulation enabled", func() {
+			BeforeEach(func() {
+				dc.Spec.Simulation.Enabled = true
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(nil),
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(nil),
+				)
+			})
+
+			It("should delete the node metrics DaemonSet and Service", func() {
+				Expect(r.ReconcileNodeMetrics(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
 	})
 
 	Describe("ReconcileNodeMetricsDaemonSet", func() {
@@ -294,7 +312,11 @@ var _ = Describe("NodeMetricsReconciler", func() {
 				})
 
 				It("should have the correct ServiceAccountName", func() {
-					Expect(ds.Spec.Template.Spec.ServiceAccountName).To(Equal(nodeMetricsServiceAccount))
+					Expect(ds.Spec.Template.Spec.ServiceAccountName).To(Equal(rbac.GetServiceAccountName(dc, rbac.ComponentNodeMetrics)))
+				})
+
+				It("should tolerate the operator taints", func() {
+					Expect(ds.Spec.Template.Spec.Tolerations).To(Equal(readiness.GetTolerations()))
 				})
 
 				It("should have one container", func() {
@@ -348,6 +370,139 @@ var _ = Describe("NodeMetricsReconciler", func() {
 				})
 			})
 		})
+
+		Context("with secured node metrics", func() {
+			BeforeEach(func() {
+				dc.Spec.NodeMetrics.Secure = true
+
+				ds = &appsv1.DaemonSet{}
+
+				err := r.SetDesiredNodeMetricsDaemonSet(ds, dc)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should not expose the node metrics on the node", func() {
+				Expect(ds.Spec.Template.Spec.Containers[0].Ports[0].HostPort).To(BeZero())
+			})
+
+			It("should have the kube-rbac-proxy container", func() {
+				Expect(ds.Spec.Template.Spec.Containers).To(HaveLen(2))
+
+				proxy := ds.Spec.Template.Spec.Containers[1]
+				Expect(proxy.Name).To(Equal(kubeRBACProxyName))
+				Expect(proxy.Image).To(Equal(s.Settings.KubeRBACProxyImage))
+				Expect(proxy.Args).To(ContainElement(fmt.Sprintf("--upstream=http://127.0.0.1:%d/", dc.GetNodeMetricsPort())))
+				Expect(proxy.Ports[0].ContainerPort).To(Equal(dc.GetNodeMetricsSecurePort()))
+			})
+
+			It("should mount the serving certificate", func() {
+				Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(2))
+				Expect(ds.Spec.Template.Spec.Volumes[1].Secret.SecretName).To(Equal(GetNodeMetricsTLSSecretName(dc)))
+			})
+		})
+
+		Context("with a custom kubelet root directory", func() {
+			BeforeEach(func() {
+				dc.Spec.Kubelet.RootDir = "/var/snap/microk8s/common/var/lib/kubelet"
+
+				ds = &appsv1.DaemonSet{}
+
+				err := r.SetDesiredNodeMetricsDaemonSet(ds, dc)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should mount the pod resources directory of that root directory", func() {
+				Expect(ds.Spec.Template.Spec.Volumes[0].HostPath.Path).To(Equal("/var/snap/microk8s/common/var/lib/kubelet/pod-resources"))
+				Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[0].MountPath).To(Equal("/var/lib/kubelet/pod-resources"))
+			})
+		})
+
+		Context("with a custom node metrics port", func() {
+			BeforeEach(func() {
+				dc.Spec.NodeMetrics.Port = 9400
+
+				ds = &appsv1.DaemonSet{}
+
+				err := r.SetDesiredNodeMetricsDaemonSet(ds, dc)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should serve and expose the node metrics on that port", func() {
+				nodeMetrics := ds.Spec.Template.Spec.Containers[0]
+				Expect(nodeMetrics.Args).To(ContainElement("--port=9400"))
+				Expect(nodeMetrics.Ports[0].ContainerPort).To(BeEquivalentTo(9400))
+				Expect(nodeMetrics.Ports[0].HostPort).To(BeEquivalentTo(9400))
+				Expect(GetNodeMetricsHostPorts(dc)).To(Equal([]int32{9400}))
+			})
+		})
+
+		Context("with the hostPort disabled", func() {
+			BeforeEach(func() {
+				dc.Spec.NodeMetrics.HostPort = pointer.Bool(false)
+
+				ds = &appsv1.DaemonSet{}
+
+				err := r.SetDesiredNodeMetricsDaemonSet(ds, dc)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should not expose the node metrics on the node", func() {
+				Expect(ds.Spec.Template.Spec.Containers[0].Ports[0].HostPort).To(BeZero())
+				Expect(GetNodeMetricsHostPorts(dc)).To(BeEmpty())
+			})
+		})
+
+		Context("with the host network", func() {
+			BeforeEach(func() {
+				dc.Spec.NodeMetrics.HostNetwork = true
+
+				ds = &appsv1.DaemonSet{}
+
+				err := r.SetDesiredNodeMetricsDaemonSet(ds, dc)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should run the pods in the host network", func() {
+				Expect(ds.Spec.Template.Spec.HostNetwork).To(BeTrue())
+				Expect(ds.Spec.Template.Spec.DNSPolicy).To(Equal(corev1.DNSClusterFirstWithHostNet))
+			})
+
+			It("should not use a hostPort", func() {
+				Expect(ds.Spec.Template.Spec.Containers[0].Ports[0].HostPort).To(BeZero())
+			})
+
+			It("should hold the node metrics port on the nodes", func() {
+				Expect(GetNodeMetricsHostPorts(dc)).To(Equal([]int32{hlaiv1alpha1.DefaultNodeMetricsPort}))
+			})
+
+			It("should return an error when secured", func() {
+				dc.Spec.NodeMetrics.Secure = true
+
+				err := r.SetDesiredNodeMetricsDaemonSet(&appsv1.DaemonSet{}, dc)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("cannot use the host network when secured"))
+			})
+		})
+
+		Context("with the topology export enabled", func() {
+			BeforeEach(func() {
+				dc.Spec.Topology.Enabled = true
+
+				ds = &appsv1.DaemonSet{}
+
+				err := r.SetDesiredNodeMetricsDaemonSet(ds, dc)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should add the topology container", func() {
+				Expect(ds.Spec.Template.Spec.Containers).To(HaveLen(2))
+				Expect(ds.Spec.Template.Spec.Containers[1].Name).To(Equal(nodeTopology.ContainerName))
+			})
+
+			It("should mount the NFD features directory", func() {
+				Expect(ds.Spec.Template.Spec.Volumes).To(ContainElement(nodeTopology.MakeFeaturesVolume()))
+			})
+		})
 	})
 
 	Describe("SetDesiredNodeMetricsService", func() {
@@ -393,11 +548,11 @@ var _ = Describe("NodeMetricsReconciler", func() {
 				})
 
 				It("should have the correct port", func() {
-					Expect(s.Spec.Ports[0].Port).To(BeEquivalentTo(nodeMetricsPort))
+					Expect(s.Spec.Ports[0].Port).To(Equal(dc.GetNodeMetricsPort()))
 				})
 
 				It("should have the correct target port", func() {
-					Expect(s.Spec.Ports[0].TargetPort).To(Equal(intstr.FromInt(nodeMetricsPort)))
+					Expect(s.Spec.Ports[0].TargetPort).To(Equal(intstr.FromInt(hlaiv1alpha1.DefaultNodeMetricsPort)))
 				})
 
 				It("should have TCP protocol", func() {
@@ -409,5 +564,26 @@ var _ = Describe("NodeMetricsReconciler", func() {
 				})
 			})
 		})
+
+		Context("with secured node metrics", func() {
+			BeforeEach(func() {
+				dc.Spec.NodeMetrics.Secure = true
+
+				s = &corev1.Service{}
+
+				err := r.SetDesiredNodeMetricsService(s, dc)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should only expose the kube-rbac-proxy port", func() {
+				Expect(s.Spec.Ports).To(HaveLen(1))
+				Expect(s.Spec.Ports[0].Name).To(Equal(NodeMetricsSecurePortName))
+				Expect(s.Spec.Ports[0].Port).To(Equal(dc.GetNodeMetricsSecurePort()))
+			})
+
+			It("should request a serving certificate from the OpenShift service CA", func() {
+				Expect(s.Annotations).To(HaveKeyWithValue(ServingCertSecretAnnotation, GetNodeMetricsTLSSecretName(dc)))
+			})
+		})
 	})
 })
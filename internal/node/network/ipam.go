@@ -0,0 +1,217 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+)
+
+// addressPool splits an IPv4 range into blocks of consecutive addresses, one
+// block per node. The network and broadcast addresses are never allocated.
+type addressPool struct {
+	first     uint32
+	prefixLen int
+	blockSize uint32
+	blocks    uint32
+	used      map[uint32]bool
+}
+
+func newAddressPool(cidr string, blockSize int32) (*addressPool, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IPAM range %s: %w", cidr, err)
+	}
+	ip := ipNet.IP.To4()
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IPAM range %s: only IPv4 ranges are supported", cidr)
+	}
+
+	ones, bits := ipNet.Mask.Size()
+	usable := int64(1)<<uint(bits-ones) - 2
+	if usable < 0 {
+		usable = 0
+	}
+
+	return &addressPool{
+		first:     binary.BigEndian.Uint32(ip) + 1,
+		prefixLen: ones,
+		blockSize: uint32(blockSize),
+		blocks:    uint32(usable / int64(blockSize)),
+		used:      make(map[uint32]bool),
+	}, nil
+}
+
+// addresses returns the addresses of the block, in CIDR notation.
+func (p *addressPool) addresses(block uint32) []string {
+	addresses := make([]string, 0, p.blockSize)
+	for i := uint32(0); i < p.blockSize; i++ {
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, p.first+block*p.blockSize+i)
+		addresses = append(addresses, fmt.Sprintf("%s/%d", ip, p.prefixLen))
+	}
+	return addresses
+}
+
+// reserve reserves the block holding exactly the given addresses, and returns
+// whether it was free.
+func (p *addressPool) reserve(addresses []string) (uint32, bool) {
+	if len(addresses) == 0 {
+		return 0, false
+	}
+	ip, _, err := net.ParseCIDR(addresses[0])
+	if err != nil || ip.To4() == nil {
+		return 0, false
+	}
+
+	offset := binary.BigEndian.Uint32(ip.To4()) - p.first
+	block := offset / p.blockSize
+	if offset%p.blockSize != 0 || block >= p.blocks || p.used[block] {
+		return 0, false
+	}
+
+	expected := p.addresses(block)
+	if len(expected) != len(addresses) {
+		return 0, false
+	}
+	for i := range expected {
+		if expected[i] != addresses[i] {
+			return 0, false
+		}
+	}
+
+	p.used[block] = true
+	return block, true
+}
+
+// exclude reserves the blocks holding any of the given addresses, so that
+// they are not allocated to other nodes. The addresses out of the range are
+// ignored.
+func (p *addressPool) exclude(addresses []string) {
+	for _, a := range addresses {
+		ip, _, err := net.ParseCIDR(a)
+		if err != nil || ip.To4() == nil {
+			continue
+		}
+		addr := binary.BigEndian.Uint32(ip.To4())
+		if addr < p.first {
+			continue
+		}
+		block := (addr - p.first) / p.blockSize
+		if block < p.blocks {
+			p.used[block] = true
+		}
+	}
+}
+
+// next reserves the first free block, and returns whether one was left.
+func (p *addressPool) next() (uint32, bool) {
+	for block := uint32(0); block < p.blocks; block++ {
+		if !p.used[block] {
+			p.used[block] = true
+			return block, true
+		}
+	}
+	return 0, false
+}
+
+// allocateAddresses returns the network status of the nodes, with their
+// addresses: the static addresses of the node if any, or else a block of the
+// IPAM range. The blocks holding static addresses are never allocated. The
+// nodes keep the block reported in the DeviceConfig status, so that their
+// addresses are stable, and the new nodes take the first free blocks.
+func allocateAddresses(cr *hlaiv1alpha1.DeviceConfig, nodes []corev1.Node) (*hlaiv1alpha1.NetworkStatus, error) {
+	static := make(map[string][]string, len(cr.Spec.Network.StaticAddresses))
+	for _, na := range cr.Spec.Network.StaticAddresses {
+		static[na.NodeName] = na.Addresses
+	}
+
+	previous := make(map[string][]string)
+	if cr.Status.Network != nil {
+		for _, ns := range cr.Status.Network.Nodes {
+			previous[ns.NodeName] = ns.Addresses
+		}
+	}
+
+	var pool *addressPool
+	if cr.Spec.Network.IPAM != nil {
+		var err error
+		pool, err = newAddressPool(cr.Spec.Network.IPAM.Range, cr.GetNetworkAddressesPerNode())
+		if err != nil {
+			return nil, err
+		}
+		for _, na := range cr.Spec.Network.StaticAddresses {
+			pool.exclude(na.Addresses)
+		}
+	}
+
+	status := &hlaiv1alpha1.NetworkStatus{}
+	allocated := make(map[string]bool)
+	for _, n := range sortedNodeNames(nodes) {
+		ns := hlaiv1alpha1.NodeNetworkStatus{NodeName: n}
+
+		if addresses, ok := static[n]; ok {
+			if err := validateAddresses(addresses); err != nil {
+				ns.Message = err.Error()
+			} else {
+				ns.Addresses = addresses
+			}
+			allocated[n] = true
+		} else if pool != nil {
+			if block, ok := pool.reserve(previous[n]); ok {
+				ns.Addresses = pool.addresses(block)
+				allocated[n] = true
+			}
+		}
+
+		status.Nodes = append(status.Nodes, ns)
+	}
+
+	if pool == nil {
+		return status, nil
+	}
+
+	for i := range status.Nodes {
+		ns := &status.Nodes[i]
+		if allocated[ns.NodeName] {
+			continue
+		}
+
+		block, ok := pool.next()
+		if !ok {
+			ns.Message = fmt.Sprintf("No addresses left in the IPAM range %s", cr.Spec.Network.IPAM.Range)
+			continue
+		}
+		ns.Addresses = pool.addresses(block)
+	}
+
+	return status, nil
+}
+
+func validateAddresses(addresses []string) error {
+	for _, a := range addresses {
+		if _, _, err := net.ParseCIDR(a); err != nil {
+			return fmt.Errorf("invalid static address %s: %w", a, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+)
+
+var _ = Describe("allocateAddresses", func() {
+	var (
+		dc    *hlaiv1alpha1.DeviceConfig
+		nodes []corev1.Node
+	)
+
+	newNode := func(name string) corev1.Node {
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "a-device-config"},
+		}
+		dc.Spec.Network.Enabled = true
+		dc.Spec.Network.IPAM = &hlaiv1alpha1.NetworkIPAMSpec{
+			Range:            "10.0.0.0/29",
+			AddressesPerNode: 2,
+		}
+		nodes = []corev1.Node{newNode("node-b"), newNode("node-a")}
+	})
+
+	It("should allocate consecutive blocks to the nodes in name order", func() {
+		status, err := allocateAddresses(dc, nodes)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Nodes).To(Equal([]hlaiv1alpha1.NodeNetworkStatus{
+			{NodeName: "node-a", Addresses: []string{"10.0.0.1/29", "10.0.0.2/29"}},
+			{NodeName: "node-b", Addresses: []string{"10.0.0.3/29", "10.0.0.4/29"}},
+		}))
+	})
+
+	It("should keep the blocks previously allocated to the nodes", func() {
+		dc.Status.Network = &hlaiv1alpha1.NetworkStatus{
+			Nodes: []hlaiv1alpha1.NodeNetworkStatus{
+				{NodeName: "node-b", Addresses: []string{"10.0.0.1/29", "10.0.0.2/29"}},
+			},
+		}
+
+		status, err := allocateAddresses(dc, nodes)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Nodes[0].Addresses).To(Equal([]string{"10.0.0.3/29", "10.0.0.4/29"}))
+		Expect(status.Nodes[1].Addresses).To(Equal([]string{"10.0.0.1/29", "10.0.0.2/29"}))
+	})
+
+	It("should use the static addresses of a node over the IPAM range", func() {
+		dc.Spec.Network.StaticAddresses = []hlaiv1alpha1.NodeNetworkAddresses{
+			{NodeName: "node-a", Addresses: []string{"192.168.0.1/24"}},
+		}
+
+		status, err := allocateAddresses(dc, nodes)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Nodes[0].Addresses).To(Equal([]string{"192.168.0.1/24"}))
+		Expect(status.Nodes[1].Addresses).To(Equal([]string{"10.0.0.1/29", "10.0.0.2/29"}))
+	})
+
+	It("should not allocate the blocks holding static addresses", func() {
+		dc.Spec.Network.StaticAddresses = []hlaiv1alpha1.NodeNetworkAddresses{
+			{NodeName: "node-a", Addresses: []string{"10.0.0.2/29"}},
+			{NodeName: "node-c", Addresses: []string{"10.0.0.3/29"}},
+		}
+		dc.Status.Network = &hlaiv1alpha1.NetworkStatus{
+			Nodes: []hlaiv1alpha1.NodeNetworkStatus{
+				{NodeName: "node-b", Addresses: []string{"10.0.0.3/29", "10.0.0.4/29"}},
+			},
+		}
+
+		status, err := allocateAddresses(dc, nodes)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Nodes[0].Addresses).To(Equal([]string{"10.0.0.2/29"}))
+		Expect(status.Nodes[1].Addresses).To(Equal([]string{"10.0.0.5/29", "10.0.0.6/29"}))
+	})
+
+	It("should report the invalid static addresses of a node", func() {
+		dc.Spec.Network.StaticAddresses = []hlaiv1alpha1.NodeNetworkAddresses{
+			{NodeName: "node-a", Addresses: []string{"192.168.0.1"}},
+		}
+
+		status, err := allocateAddresses(dc, nodes)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Nodes[0].Addresses).To(BeEmpty())
+		Expect(status.Nodes[0].Message).To(ContainSubstring("invalid static address 192.168.0.1"))
+	})
+
+	It("should report the nodes left without addresses when the range is exhausted", func() {
+		nodes = append(nodes, newNode("node-c"), newNode("node-d"))
+
+		status, err := allocateAddresses(dc, nodes)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Nodes[2].Addresses).To(HaveLen(2))
+		Expect(status.Nodes[3].Addresses).To(BeEmpty())
+		Expect(status.Nodes[3].Message).To(Equal("No addresses left in the IPAM range 10.0.0.0/29"))
+	})
+
+	It("should return an error with an invalid IPAM range", func() {
+		dc.Spec.Network.IPAM.Range = "fd00::/64"
+
+		_, err := allocateAddresses(dc, nodes)
+
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("setLinkState", func() {
+	It("should set the ports of each node from its labels", func() {
+		status := &hlaiv1alpha1.NetworkStatus{
+			Nodes: []hlaiv1alpha1.NodeNetworkStatus{{NodeName: "node-a"}, {NodeName: "node-b"}},
+		}
+		nodes := []corev1.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{PortsLabel: "24", PortsUpLabel: "23"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{PortsLabel: "not-a-count"}}},
+		}
+
+		setLinkState(status, nodes)
+
+		Expect(status.Ports).To(Equal(int32(24)))
+		Expect(status.PortsUp).To(Equal(int32(23)))
+		Expect(status.Nodes[1].Ports).To(BeZero())
+	})
+})
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: network.go
+
+// Package network is a generated GoMock package.
+package network
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/api/apps/v1"
+	v10 "k8s.io/api/core/v1"
+)
+
+// MockReconciler is a mock of Reconciler interface.
+type MockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcilerMockRecorder
+}
+
+// MockReconcilerMockRecorder is the mock recorder for MockReconciler.
+type MockReconcilerMockRecorder struct {
+	mock *MockReconciler
+}
+
+// NewMockReconciler creates a new mock instance.
+func NewMockReconciler(ctrl *gomock.Controller) *MockReconciler {
+	mock := &MockReconciler{ctrl: ctrl}
+	mock.recorder = &MockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// DeleteNetwork mocks base method.
+func (m *MockReconciler) DeleteNetwork(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNetwork", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNetwork indicates an expected call of DeleteNetwork.
+func (mr *MockReconcilerMockRecorder) DeleteNetwork(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNetwork", reflect.TypeOf((*MockReconciler)(nil).DeleteNetwork), ctx, dc)
+}
+
+// ReconcileNetwork mocks base method.
+func (m *MockReconciler) ReconcileNetwork(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileNetwork", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileNetwork indicates an expected call of ReconcileNetwork.
+func (mr *MockReconcilerMockRecorder) ReconcileNetwork(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileNetwork", reflect.TypeOf((*MockReconciler)(nil).ReconcileNetwork), ctx, dc)
+}
+
+// SetDesiredNetworkConfigMap mocks base method.
+func (m *MockReconciler) SetDesiredNetworkConfigMap(cm *v10.ConfigMap, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredNetworkConfigMap", cm, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredNetworkConfigMap indicates an expected call of SetDesiredNetworkConfigMap.
+func (mr *MockReconcilerMockRecorder) SetDesiredNetworkConfigMap(cm, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredNetworkConfigMap", reflect.TypeOf((*MockReconciler)(nil).SetDesiredNetworkConfigMap), cm, cr)
+}
+
+// SetDesiredNetworkDaemonSet mocks base method.
+func (m *MockReconciler) SetDesiredNetworkDaemonSet(ds *v1.DaemonSet, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredNetworkDaemonSet", ds, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredNetworkDaemonSet indicates an expected call of SetDesiredNetworkDaemonSet.
+func (mr *MockReconcilerMockRecorder) SetDesiredNetworkDaemonSet(ds, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredNetworkDaemonSet", reflect.TypeOf((*MockReconciler)(nil).SetDesiredNetworkDaemonSet), ds, cr)
+}
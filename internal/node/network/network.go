@@ -0,0 +1,455 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	operatorMetrics "github.com/HabanaAI/habana-ai-operator/internal/metrics"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	// LabelPrefix is the prefix of the network labels of the nodes.
+	LabelPrefix = "habana.ai/network."
+
+	// PortsLabel is the number of Habana network interfaces of the node.
+	PortsLabel = LabelPrefix + "ports"
+
+	// PortsUpLabel is the number of Habana network interfaces of the node
+	// whose link is up.
+	PortsUpLabel = LabelPrefix + "ports-up"
+
+	networkSuffix = rbac.ComponentNetwork
+
+	featuresVolume        = "features-d"
+	addressesVolume       = "addresses"
+	addressesPath         = "/etc/habana-network"
+	networkLimitsCpu      = "50m"
+	networkLimitsMemory   = "32Mi"
+	networkRequestsCpu    = "10m"
+	networkRequestsMemory = "16Mi"
+
+	// readyFile is created in the container once the interfaces are
+	// configured.
+	readyFile = "/tmp/ready"
+)
+
+// networkScript configures the MTU and the addresses of the Habana network
+// interfaces of the node every 30 seconds, the addresses of the node being
+// read from the ConfigMap of the DeviceConfig. The interfaces are ordered by
+// PCI address and port. The link state is written in an NFD feature file, so
+// that NFD labels the node, and the file is removed when the pod is deleted.
+// The interfaces are left configured, so as not to disrupt the workloads.
+const networkScript = `set -u
+
+features="$FEATURES_DIR/habana-network"
+trap 'rm -f "$features" "$READY_FILE"; exit 0' TERM INT
+
+habana_interfaces() {
+  for d in /sys/class/net/*; do
+    driver="$(readlink -f "$d/device/driver" 2>/dev/null || true)"
+    [ "${driver##*/}" = habanalabs ] || continue
+    device="$(readlink -f "$d/device")"
+    echo "${device##*/} $(cat "$d/dev_port" 2>/dev/null || echo 0) ${d##*/}"
+  done | sort -k1,1 -k2,2n | while read -r _ _ name; do
+    echo "$name"
+  done
+}
+
+configure_address() {
+  current="$(ip -4 -o addr show dev "$1" | while read -r _ _ _ a _; do echo "$a"; done)"
+  if [ "$current" != "$2" ]; then
+    ip -4 addr flush dev "$1"
+    ip addr add "$2" dev "$1"
+  fi
+}
+
+while true; do
+  addresses=""
+  if [ -f "$ADDRESSES_DIR/$NODE_NAME" ]; then
+    addresses="$(cat "$ADDRESSES_DIR/$NODE_NAME")"
+  fi
+  set -- $addresses
+  ports=0
+  up=0
+  for i in $(habana_interfaces); do
+    ports=$((ports + 1))
+    ip link set dev "$i" mtu "$MTU" up || echo "Failed to configure $i"
+    if [ $# -gt 0 ]; then
+      configure_address "$i" "$1" || echo "Failed to set the address $1 of $i"
+      shift
+    fi
+    if [ "$(cat "/sys/class/net/$i/carrier" 2>/dev/null)" = 1 ]; then
+      up=$((up + 1))
+    fi
+  done
+  printf '%s=%s\n%s=%s\n' "$PORTS_LABEL" "$ports" "$PORTS_UP_LABEL" "$up" > "$features.tmp"
+  mv -f "$features.tmp" "$features"
+  touch "$READY_FILE"
+  sleep 30 &
+  wait $!
+done`
+
+//go:generate mockgen -source=network.go -package=network -destination=mock_network.go
+
+type Reconciler interface {
+	ReconcileNetwork(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredNetworkConfigMap(cm *corev1.ConfigMap, cr *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredNetworkDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error
+	DeleteNetwork(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+}
+
+type NetworkReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *NetworkReconciler {
+	return &NetworkReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+// GetNetworkName returns the name of the network DaemonSet and ConfigMap.
+func GetNetworkName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-%s", cr.Name, networkSuffix)
+}
+
+// ReconcileNetwork allocates the addresses of the nodes with HPUs of the
+// DeviceConfig, and deploys the DaemonSet configuring their Habana network
+// interfaces when it is enabled. The addresses and the link state reported by
+// the node labels are set in the DeviceConfig status, which is persisted
+// along with its conditions.
+func (r *NetworkReconciler) ReconcileNetwork(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	if !cr.Spec.Network.Enabled {
+		cr.Status.Network = nil
+		return r.DeleteNetwork(ctx, cr)
+	}
+
+	nodeList := &corev1.NodeList{}
+	selector := labels.Set(module.GetModuleNodeSelector(cr)).AsSelector()
+	if err := r.client.List(ctx, nodeList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	status, err := allocateAddresses(cr, nodeList.Items)
+	if err != nil {
+		return err
+	}
+	setLinkState(status, nodeList.Items)
+	cr.Status.Network = status
+
+	if err := r.reconcileNetworkConfigMap(ctx, cr); err != nil {
+		return err
+	}
+
+	return r.reconcileNetworkDaemonSet(ctx, cr)
+}
+
+func (r *NetworkReconciler) reconcileNetworkConfigMap(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	logger := log.FromContext(ctx)
+
+	existingCM := &corev1.ConfigMap{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: GetNetworkName(cr)}, existingCM)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetNetworkName(cr),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	if exists {
+		cm = existingCM
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, cm, func() error {
+		return r.SetDesiredNetworkConfigMap(cm, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch ConfigMap: %v", err)
+	}
+
+	logger.Info("Reconciled ConfigMap", "resource", cm.Name, "result", res)
+
+	return nil
+}
+
+func (r *NetworkReconciler) reconcileNetworkDaemonSet(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	logger := log.FromContext(ctx)
+
+	existingDS := &appsv1.DaemonSet{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: GetNetworkName(cr)}, existingDS)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetNetworkName(cr),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	if exists {
+		ds = existingDS
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, ds, func() error {
+		return r.SetDesiredNetworkDaemonSet(ds, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch DaemonSet: %v", err)
+	}
+
+	logger.Info("Reconciled DaemonSet", "resource", ds.Name, "result", res)
+
+	operatorMetrics.SetComponentNodes(cr.Name, rbac.ComponentNetwork, ds.Status.DesiredNumberScheduled, ds.Status.NumberReady)
+
+	return nil
+}
+
+// DeleteNetwork deletes the network DaemonSet and ConfigMap. The link state
+// labels are removed from the nodes once the pods are terminated.
+func (r *NetworkReconciler) DeleteNetwork(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	objs := []client.Object{
+		&appsv1.DaemonSet{},
+		&corev1.ConfigMap{},
+	}
+
+	for _, o := range objs {
+		o.SetName(GetNetworkName(cr))
+		o.SetNamespace(s.Settings.OperatorNamespace)
+
+		err := r.client.Delete(ctx, o)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %T %s: %w", o, o.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// SetDesiredNetworkConfigMap sets the addresses of each node, one per line,
+// under the name of the node.
+func (r *NetworkReconciler) SetDesiredNetworkConfigMap(cm *corev1.ConfigMap, cr *hlaiv1alpha1.DeviceConfig) error {
+	if cm == nil {
+		return errors.New("configmap cannot be nil")
+	}
+
+	cm.ObjectMeta.Labels = labelsForNetwork(cr)
+
+	cm.Data = map[string]string{}
+	if cr.Status.Network == nil {
+		return nil
+	}
+	for _, ns := range cr.Status.Network.Nodes {
+		if len(ns.Addresses) > 0 {
+			cm.Data[ns.NodeName] = strings.Join(ns.Addresses, "\n") + "\n"
+		}
+	}
+
+	return nil
+}
+
+func (r *NetworkReconciler) SetDesiredNetworkDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error {
+	if ds == nil {
+		return errors.New("daemonset cannot be nil")
+	}
+
+	labels := labelsForNetwork(cr)
+
+	ds.ObjectMeta.Labels = labels
+
+	ds.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: labels,
+	}
+
+	ds.Spec.Template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: labels,
+		},
+	}
+
+	hostPathTypeDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
+
+	container := corev1.Container{
+		Name:            networkSuffix,
+		Image:           s.Settings.NetworkImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", networkScript},
+		Env: []corev1.EnvVar{
+			{
+				Name: "NODE_NAME",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"},
+				},
+			},
+			{Name: "MTU", Value: strconv.Itoa(int(cr.GetNetworkMTU()))},
+			{Name: "ADDRESSES_DIR", Value: addressesPath},
+			{Name: "FEATURES_DIR", Value: constants.FeaturesDir},
+			{Name: "PORTS_LABEL", Value: PortsLabel},
+			{Name: "PORTS_UP_LABEL", Value: PortsUpLabel},
+			{Name: "READY_FILE", Value: readyFile},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: pointer.Bool(true),
+			RunAsUser:  pointer.Int64(0),
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{"test", "-f", readyFile},
+				},
+			},
+			PeriodSeconds: 10,
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				"cpu":    resource.MustParse(networkLimitsCpu),
+				"memory": resource.MustParse(networkLimitsMemory),
+			},
+			Requests: corev1.ResourceList{
+				"cpu":    resource.MustParse(networkRequestsCpu),
+				"memory": resource.MustParse(networkRequestsMemory),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      featuresVolume,
+				MountPath: constants.FeaturesDir,
+			},
+			{
+				Name:      addressesVolume,
+				MountPath: addressesPath,
+				ReadOnly:  true,
+			},
+		},
+	}
+
+	ds.Spec.Template.Spec = corev1.PodSpec{
+		Containers: []corev1.Container{container},
+		// The Habana network interfaces are in the host network namespace.
+		HostNetwork: true,
+		// The interfaces are only configured on the nodes with HPUs.
+		NodeSelector:                  module.GetModuleNodeSelector(cr),
+		PriorityClassName:             "system-node-critical",
+		ServiceAccountName:            rbac.GetServiceAccountName(cr, rbac.ComponentNetwork),
+		TerminationGracePeriodSeconds: pointer.Int64(10),
+		Tolerations:                   readiness.GetTolerations(),
+		Volumes: []corev1.Volume{
+			{
+				Name: featuresVolume,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: constants.FeaturesDir,
+						Type: &hostPathTypeDirectoryOrCreate,
+					},
+				},
+			},
+			{
+				Name: addressesVolume,
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: GetNetworkName(cr)},
+					},
+				},
+			},
+		},
+	}
+
+	return nil
+}
+
+// setLinkState sets the number of interfaces and of interfaces up of each
+// node, as labeled by NFD from the feature file written by the network pods.
+func setLinkState(status *hlaiv1alpha1.NetworkStatus, nodes []corev1.Node) {
+	nodeLabels := make(map[string]map[string]string, len(nodes))
+	for i := range nodes {
+		nodeLabels[nodes[i].Name] = nodes[i].Labels
+	}
+
+	status.Ports = 0
+	status.PortsUp = 0
+	for i := range status.Nodes {
+		ns := &status.Nodes[i]
+		ns.Ports = getCountLabel(nodeLabels[ns.NodeName], PortsLabel)
+		ns.PortsUp = getCountLabel(nodeLabels[ns.NodeName], PortsUpLabel)
+		status.Ports += ns.Ports
+		status.PortsUp += ns.PortsUp
+	}
+}
+
+func getCountLabel(l map[string]string, key string) int32 {
+	v, err := strconv.ParseInt(l[key], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(v)
+}
+
+// sortedNodeNames returns the names of the nodes in alphabetical order.
+func sortedNodeNames(nodes []corev1.Node) []string {
+	names := make([]string, 0, len(nodes))
+	for i := range nodes {
+		names = append(names, nodes[i].Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// labelsForNetwork returns the labels for selecting the
+// resources belonging to the given DeviceConfig CR name.
+func labelsForNetwork(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
+		"app.kubernetes.io/component": networkSuffix,
+		constants.DeviceConfigLabel:   cr.Name,
+	}
+}
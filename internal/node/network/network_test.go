@@ -0,0 +1,298 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"context"
+	"errors"
+
+	gomock "github.com/golang/mock/gomock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/client"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+var _ = Describe("NetworkReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		r   *NetworkReconciler
+		c   *client.MockClient
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "a-device-config",
+				Namespace: "a-namespace",
+			},
+		}
+		c = client.NewMockClient(gomock.NewController(GinkgoT()))
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+
+		r = NewReconciler(c, s)
+
+		ctx = context.TODO()
+	})
+
+	Describe("ReconcileNetwork", func() {
+		Context("with the network disabled", func() {
+			BeforeEach(func() {
+				dc.Status.Network = &hlaiv1alpha1.NetworkStatus{Ports: 24}
+
+				gomock.InOrder(
+					c.EXPECT().
+						Delete(ctx, gomock.Any()).
+						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, GetNetworkName(dc))),
+					c.EXPECT().
+						Delete(ctx, gomock.Any()).
+						Return(apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, GetNetworkName(dc))),
+				)
+			})
+
+			It("should delete the DaemonSet and the ConfigMap, and clear the status", func() {
+				Expect(r.ReconcileNetwork(ctx, dc)).ToNot(HaveOccurred())
+				Expect(dc.Status.Network).To(BeNil())
+			})
+		})
+
+		Context("with the network enabled", func() {
+			BeforeEach(func() {
+				dc.Spec.Network.Enabled = true
+				dc.Spec.Network.IPAM = &hlaiv1alpha1.NetworkIPAMSpec{Range: "10.0.0.0/24"}
+			})
+
+			Context("with a client List error", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some-error")),
+					)
+				})
+
+				It("should return an error", func() {
+					Expect(r.ReconcileNetwork(ctx, dc)).To(HaveOccurred())
+				})
+			})
+
+			Context("with no client error", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						c.EXPECT().
+							List(ctx, gomock.Any(), gomock.Any()).
+							DoAndReturn(func(_ context.Context, list *corev1.NodeList, _ ...interface{}) error {
+								list.Items = []corev1.Node{
+									{
+										ObjectMeta: metav1.ObjectMeta{
+											Name:   "a-node",
+											Labels: map[string]string{PortsLabel: "10", PortsUpLabel: "8"},
+										},
+									},
+								}
+								return nil
+							}),
+						c.EXPECT().
+							Get(ctx, gomock.Any(), gomock.Any()).
+							Return(apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, GetNetworkName(dc))).
+							Times(2),
+						c.EXPECT().Create(ctx, gomock.Any()).Return(nil),
+						c.EXPECT().
+							Get(ctx, gomock.Any(), gomock.Any()).
+							Return(apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, GetNetworkName(dc))).
+							Times(2),
+						c.EXPECT().Create(ctx, gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should set the addresses and the link state in the status", func() {
+					Expect(r.ReconcileNetwork(ctx, dc)).ToNot(HaveOccurred())
+					Expect(dc.Status.Network).ToNot(BeNil())
+					Expect(dc.Status.Network.Ports).To(Equal(int32(10)))
+					Expect(dc.Status.Network.PortsUp).To(Equal(int32(8)))
+					Expect(dc.Status.Network.Nodes).To(HaveLen(1))
+					Expect(dc.Status.Network.Nodes[0].Addresses).To(HaveLen(int(hlaiv1alpha1.DefaultNetworkAddressesPerNode)))
+				})
+			})
+
+			Context("with an invalid IPAM range", func() {
+				BeforeEach(func() {
+					dc.Spec.Network.IPAM.Range = "not-a-range"
+
+					gomock.InOrder(
+						c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).Return(nil),
+					)
+				})
+
+				It("should return an error", func() {
+					Expect(r.ReconcileNetwork(ctx, dc)).To(HaveOccurred())
+				})
+			})
+		})
+	})
+
+	Describe("DeleteNetwork", func() {
+		Context("without a client Delete error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(nil).Times(2),
+				)
+			})
+
+			It("should not return an error", func() {
+				Expect(r.DeleteNetwork(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with a generic client Delete error", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					c.EXPECT().Delete(ctx, gomock.Any()).Return(errors.New("some-error")),
+				)
+			})
+
+			It("should return an error", func() {
+				Expect(r.DeleteNetwork(ctx, dc)).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("SetDesiredNetworkConfigMap", func() {
+		It("should return an error with a nil ConfigMap", func() {
+			err := r.SetDesiredNetworkConfigMap(nil, dc)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("configmap cannot be nil"))
+		})
+
+		It("should set the addresses of each node with addresses", func() {
+			dc.Status.Network = &hlaiv1alpha1.NetworkStatus{
+				Nodes: []hlaiv1alpha1.NodeNetworkStatus{
+					{NodeName: "a-node", Addresses: []string{"10.0.0.1/24", "10.0.0.2/24"}},
+					{NodeName: "another-node", Message: "No addresses left in the IPAM range 10.0.0.0/24"},
+				},
+			}
+			cm := &corev1.ConfigMap{}
+
+			Expect(r.SetDesiredNetworkConfigMap(cm, dc)).ToNot(HaveOccurred())
+			Expect(cm.Data).To(Equal(map[string]string{"a-node": "10.0.0.1/24\n10.0.0.2/24\n"}))
+		})
+	})
+
+	Describe("SetDesiredNetworkDaemonSet", func() {
+		var (
+			ds *appsv1.DaemonSet
+		)
+
+		Context("with a nil DaemonSet as input", func() {
+			BeforeEach(func() {
+				ds = nil
+			})
+
+			It("should return a DaemonSet cannot be nil error", func() {
+				err := r.SetDesiredNetworkDaemonSet(ds, dc)
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("daemonset cannot be nil"))
+			})
+		})
+
+		Context("with a non-nil DaemonSet as input", func() {
+			BeforeEach(func() {
+				dc.Spec.Network.Enabled = true
+				dc.Spec.Network.MTU = 9000
+
+				ds = &appsv1.DaemonSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "a-name",
+						Namespace: "a-namespace",
+					},
+				}
+
+				err := r.SetDesiredNetworkDaemonSet(ds, dc)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			Context("it returns a DaemonSet which", func() {
+				It("should only select the nodes with HPUs", func() {
+					Expect(ds.Spec.Template.Spec.NodeSelector).To(Equal(module.GetModuleNodeSelector(dc)))
+				})
+
+				It("should use the host network", func() {
+					Expect(ds.Spec.Template.Spec.HostNetwork).To(BeTrue())
+				})
+
+				It("should have the correct ServiceAccountName", func() {
+					Expect(ds.Spec.Template.Spec.ServiceAccountName).To(Equal(rbac.GetServiceAccountName(dc, rbac.ComponentNetwork)))
+				})
+
+				It("should tolerate the operator taints", func() {
+					Expect(ds.Spec.Template.Spec.Tolerations).To(Equal(readiness.GetTolerations()))
+				})
+
+				It("should mount the NFD features directory and the addresses ConfigMap", func() {
+					Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(2))
+					Expect(ds.Spec.Template.Spec.Volumes[0].HostPath.Path).To(Equal(constants.FeaturesDir))
+					Expect(ds.Spec.Template.Spec.Volumes[1].ConfigMap.Name).To(Equal(GetNetworkName(dc)))
+				})
+
+				Context("contains the network container, which", func() {
+					var (
+						container corev1.Container
+					)
+
+					BeforeEach(func() {
+						Expect(ds.Spec.Template.Spec.Containers).To(HaveLen(1))
+						container = ds.Spec.Template.Spec.Containers[0]
+					})
+
+					It("should have the correct image", func() {
+						Expect(container.Image).To(Equal(s.Settings.NetworkImage))
+					})
+
+					It("should be privileged", func() {
+						Expect(container.SecurityContext.Privileged).ToNot(BeNil())
+						Expect(*container.SecurityContext.Privileged).To(BeTrue())
+					})
+
+					It("should configure the MTU of the DeviceConfig", func() {
+						Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "MTU", Value: "9000"}))
+					})
+
+					It("should only be ready once the interfaces are configured", func() {
+						Expect(container.ReadinessProbe).ToNot(BeNil())
+						Expect(container.ReadinessProbe.Exec.Command).To(ContainElement(readyFile))
+					})
+				})
+			})
+		})
+	})
+})
@@ -0,0 +1,449 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+)
+
+const (
+	// DriverReadyCondition reports on the selected nodes whether the module
+	// loader pod of the DeviceConfig driver is ready.
+	DriverReadyCondition corev1.NodeConditionType = "HabanaDriverReady"
+
+	// DevicePluginReadyCondition reports on the selected nodes whether the
+	// device plugin pod of the DeviceConfig is ready.
+	DevicePluginReadyCondition corev1.NodeConditionType = "HabanaDevicePluginReady"
+
+	// CDIReadyCondition reports on the selected nodes whether the CDI spec of
+	// the HPUs is written, when CDI is enabled.
+	CDIReadyCondition corev1.NodeConditionType = "HabanaCDIReady"
+
+	// ContainerRuntimeReadyCondition reports on the selected nodes whether the
+	// Habana container runtime is installed, when its installation is enabled.
+	ContainerRuntimeReadyCondition corev1.NodeConditionType = "HabanaContainerRuntimeReady"
+
+	// NetworkReadyCondition reports on the selected nodes whether the Habana
+	// network interfaces are configured, when their configuration is enabled.
+	NetworkReadyCondition corev1.NodeConditionType = "HabanaNetworkReady"
+
+	ReasonPodReady               = "PodReady"
+	ReasonPodMissing             = "PodMissing"
+	ReasonPodNotReady            = "PodNotReady"
+	ReasonDriverUpgrading        = "DriverUpgrading"
+	ReasonModuleLoadFailed       = "ModuleLoadFailed"
+	ReasonDevicePluginFailed     = "DevicePluginFailed"
+	ReasonCDIFailed              = "CDIFailed"
+	ReasonContainerRuntimeFailed = "ContainerRuntimeFailed"
+	ReasonNetworkFailed          = "NetworkFailed"
+	ReasonSimulated              = "Simulated"
+	ReasonModuleMissing          = "ModuleMissing"
+	ReasonFirmwareFailed         = "FirmwareFailed"
+)
+
+// nodeComponent is an optional component run by the operator on the nodes
+// with HPUs, whose pods must be ready as well when it is enabled.
+type nodeComponent struct {
+	// name is the app.kubernetes.io/component label of the pods.
+	name         string
+	description  string
+	condition    corev1.NodeConditionType
+	failedReason string
+	enabled      func(cr *hlaiv1alpha1.DeviceConfig) bool
+}
+
+var nodeComponents = []nodeComponent{
+	{
+		name:         rbac.ComponentCDI,
+		description:  "CDI",
+		condition:    CDIReadyCondition,
+		failedReason: ReasonCDIFailed,
+		enabled: func(cr *hlaiv1alpha1.DeviceConfig) bool {
+			return cr.Spec.CDI.Enabled
+		},
+	},
+	{
+		name:         rbac.ComponentContainerRuntime,
+		description:  "container runtime",
+		condition:    ContainerRuntimeReadyCondition,
+		failedReason: ReasonContainerRuntimeFailed,
+		enabled: func(cr *hlaiv1alpha1.DeviceConfig) bool {
+			return cr.Spec.ContainerRuntime.Enabled
+		},
+	},
+	{
+		name:         rbac.ComponentNetwork,
+		description:  "network",
+		condition:    NetworkReadyCondition,
+		failedReason: ReasonNetworkFailed,
+		enabled: func(cr *hlaiv1alpha1.DeviceConfig) bool {
+			return cr.Spec.Network.Enabled
+		},
+	},
+}
+
+// waitingReasons are the waiting reasons of the pod containers reported as
+// is in the node conditions.
+var waitingReasons = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+}
+
+// componentState is the state of the KMM pod of a component on a node.
+type componentState struct {
+	ready   bool
+	reason  string
+	message string
+}
+
+// merge returns the state to report among the states of the pods of a
+// component on the same node, e.g. during a rolling update: a ready pod wins,
+// then a pod with a known issue, then the pod of the previous driver version.
+func (cs componentState) merge(other componentState) componentState {
+	if other.priority() > cs.priority() {
+		return other
+	}
+	return cs
+}
+
+func (cs componentState) priority() int {
+	switch {
+	case cs.ready:
+		return 4
+	case cs.reason == ReasonPodMissing:
+		return 0
+	case cs.reason == ReasonPodNotReady:
+		return 1
+	case cs.reason == ReasonDriverUpgrading:
+		return 2
+	default:
+		return 3
+	}
+}
+
+type nodeState struct {
+	driver       componentState
+	devicePlugin componentState
+	// loaderPod and pluginPod are whether the KMM module loader and device
+	// plugin pods exist on the node.
+	loaderPod bool
+	pluginPod bool
+	// components holds the states of the enabled node components, by
+	// condition type.
+	components map[corev1.NodeConditionType]componentState
+}
+
+func newNodeState() *nodeState {
+	return &nodeState{
+		driver: componentState{
+			reason:  ReasonPodMissing,
+			message: "No module loader pod is running on the node",
+		},
+		devicePlugin: componentState{
+			reason:  ReasonPodMissing,
+			message: "No device plugin pod is running on the node",
+		},
+		components: make(map[corev1.NodeConditionType]componentState),
+	}
+}
+
+// mergeComponentState merges the state of a pod of the node component.
+func (ns *nodeState) mergeComponentState(nc nodeComponent, pod *corev1.Pod) {
+	cs, ok := ns.components[nc.condition]
+	if !ok {
+		cs = newComponentState(nc)
+	}
+	ns.components[nc.condition] = cs.merge(getPodState(pod, nc.failedReason))
+}
+
+// setMissingComponents reports the enabled node components of the DeviceConfig
+// without any pod on the node.
+func (ns *nodeState) setMissingComponents(cr *hlaiv1alpha1.DeviceConfig) {
+	for _, nc := range nodeComponents {
+		if _, ok := ns.components[nc.condition]; !ok && nc.enabled(cr) {
+			ns.components[nc.condition] = newComponentState(nc)
+		}
+	}
+}
+
+// setSimulated reports the driver of a node with simulated HPUs as ready, and
+// its device plugin once the simulated HPUs are advertised, as no KMM pod runs
+// on the node. The kubelet keeps advertising no HPU once the simulated device
+// plugin left the node.
+func (ns *nodeState) setSimulated(node *corev1.Node) {
+	ns.driver = componentState{
+		ready:   true,
+		reason:  ReasonSimulated,
+		message: "The HPUs of the node are simulated",
+	}
+
+	ns.devicePlugin = componentState{
+		reason:  ReasonSimulated,
+		message: "The simulated HPUs are not advertised yet",
+	}
+	if q, ok := node.Status.Allocatable[constants.HabanaResourceName]; ok && !q.IsZero() {
+		ns.devicePlugin = componentState{
+			ready:   true,
+			reason:  ReasonSimulated,
+			message: "The simulated HPUs are advertised",
+		}
+	}
+}
+
+// isReady returns whether all the pods of the node are ready.
+func (ns *nodeState) isReady() bool {
+	if !ns.driver.ready || !ns.devicePlugin.ready {
+		return false
+	}
+	for _, cs := range ns.components {
+		if !cs.ready {
+			return false
+		}
+	}
+	return true
+}
+
+// getDriverState returns the state of a module loader pod. The module loader
+// pods of another driver version are not ready, as the driver is being
+// upgraded.
+func getDriverState(cr *hlaiv1alpha1.DeviceConfig, pod *corev1.Pod) componentState {
+	if !runsDriverVersion(cr, pod) {
+		return componentState{
+			reason:  ReasonDriverUpgrading,
+			message: fmt.Sprintf("Pod %s does not run the driver version %s", pod.Name, cr.Spec.DriverVersion),
+		}
+	}
+	return getPodState(pod, ReasonModuleLoadFailed)
+}
+
+// getModuleState returns the state of a driver pod of the inTree and
+// hostInstalled driver modes, whose driver container exits when the module is
+// not loaded. The crashes before the firmware is installed are reported as
+// such.
+func getModuleState(pod *corev1.Pod) componentState {
+	failedReason := ReasonModuleMissing
+	for _, cs := range pod.Status.InitContainerStatuses {
+		if !cs.Ready {
+			failedReason = ReasonFirmwareFailed
+		}
+	}
+	return getPodState(pod, failedReason)
+}
+
+func newComponentState(nc nodeComponent) componentState {
+	return componentState{
+		reason:  ReasonPodMissing,
+		message: fmt.Sprintf("No %s pod is running on the node", nc.description),
+	}
+}
+
+func getDevicePluginState(pod *corev1.Pod) componentState {
+	return getPodState(pod, ReasonDevicePluginFailed)
+}
+
+// getPodState returns the state of a pod, using the given reason for its
+// crashing containers.
+func getPodState(pod *corev1.Pod, failedReason string) componentState {
+	if isPodReady(pod) {
+		return componentState{
+			ready:   true,
+			reason:  ReasonPodReady,
+			message: fmt.Sprintf("Pod %s is ready", pod.Name),
+		}
+	}
+
+	statuses := make([]corev1.ContainerStatus, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses))
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+
+	for _, cs := range statuses {
+		if w := cs.State.Waiting; w != nil && waitingReasons[w.Reason] {
+			return componentState{
+				reason:  w.Reason,
+				message: fmt.Sprintf("Pod %s: %s", pod.Name, w.Message),
+			}
+		}
+
+		if t := cs.LastTerminationState.Terminated; t != nil && t.ExitCode != 0 {
+			return componentState{
+				reason:  failedReason,
+				message: fmt.Sprintf("Pod %s: container %s exited with code %d: %s", pod.Name, cs.Name, t.ExitCode, t.Message),
+			}
+		}
+		if t := cs.State.Terminated; t != nil && t.ExitCode != 0 {
+			return componentState{
+				reason:  failedReason,
+				message: fmt.Sprintf("Pod %s: container %s exited with code %d: %s", pod.Name, cs.Name, t.ExitCode, t.Message),
+			}
+		}
+	}
+
+	return componentState{
+		reason:  ReasonPodNotReady,
+		message: fmt.Sprintf("Pod %s is not ready", pod.Name),
+	}
+}
+
+// setConditions sets the readiness conditions on the node, with a strategic
+// merge patch leaving the conditions of the kubelet as is.
+func (r *NodeReadinessReconciler) setConditions(ctx context.Context, node *corev1.Node, ns *nodeState) error {
+	patch := client.StrategicMergeFrom(node.DeepCopy())
+
+	changed := setNodeCondition(node, DriverReadyCondition, ns.driver)
+	changed = setNodeCondition(node, DevicePluginReadyCondition, ns.devicePlugin) || changed
+	for _, nc := range nodeComponents {
+		if cs, ok := ns.components[nc.condition]; ok {
+			changed = setNodeCondition(node, nc.condition, cs) || changed
+		} else {
+			changed = removeNodeConditions(node, nc.condition) || changed
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	if err := r.client.Status().Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to update the readiness conditions of node %s: %w", node.Name, err)
+	}
+
+	log.FromContext(ctx).Info("Updated the readiness conditions", "node", node.Name,
+		"driver", ns.driver.reason, "devicePlugin", ns.devicePlugin.reason)
+
+	return nil
+}
+
+// removeConditions removes the readiness conditions from the node, unless it
+// is selected by one of the given selectors of the other DeviceConfigs.
+func (r *NodeReadinessReconciler) removeConditions(ctx context.Context, node *corev1.Node, others []labels.Selector) error {
+	for _, selector := range others {
+		if selector.Matches(labels.Set(node.Labels)) {
+			return nil
+		}
+	}
+
+	patch := client.StrategicMergeFrom(node.DeepCopy())
+
+	conditionTypes := []corev1.NodeConditionType{DriverReadyCondition, DevicePluginReadyCondition}
+	for _, nc := range nodeComponents {
+		conditionTypes = append(conditionTypes, nc.condition)
+	}
+
+	if !removeNodeConditions(node, conditionTypes...) {
+		return nil
+	}
+
+	if err := r.client.Status().Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to remove the readiness conditions of node %s: %w", node.Name, err)
+	}
+
+	return nil
+}
+
+// setNodeCondition sets the condition of the given type on the node, and
+// returns whether the node changed. The heartbeat is only updated along with
+// the condition, so that the node is not patched at every reconciliation.
+func setNodeCondition(node *corev1.Node, conditionType corev1.NodeConditionType, cs componentState) bool {
+	status := corev1.ConditionFalse
+	if cs.ready {
+		status = corev1.ConditionTrue
+	}
+
+	now := metav1.Now()
+	for i := range node.Status.Conditions {
+		c := &node.Status.Conditions[i]
+		if c.Type != conditionType {
+			continue
+		}
+		if c.Status == status && c.Reason == cs.reason && c.Message == cs.message {
+			return false
+		}
+		if c.Status != status {
+			c.LastTransitionTime = now
+		}
+		c.Status = status
+		c.Reason = cs.reason
+		c.Message = cs.message
+		c.LastHeartbeatTime = now
+		return true
+	}
+
+	node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             cs.reason,
+		Message:            cs.message,
+		LastHeartbeatTime:  now,
+		LastTransitionTime: now,
+	})
+	return true
+}
+
+// removeNodeConditions removes the conditions of the given types from the
+// node, and returns whether the node changed.
+func removeNodeConditions(node *corev1.Node, conditionTypes ...corev1.NodeConditionType) bool {
+	conditions := make([]corev1.NodeCondition, 0, len(node.Status.Conditions))
+	for _, c := range node.Status.Conditions {
+		remove := false
+		for _, t := range conditionTypes {
+			if c.Type == t {
+				remove = true
+				break
+			}
+		}
+		if !remove {
+			conditions = append(conditions, c)
+		}
+	}
+	if len(conditions) == len(node.Status.Conditions) {
+		return false
+	}
+	node.Status.Conditions = conditions
+	return true
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// runsDriverVersion returns true if the module loader pod runs the driver
+// image of the DeviceConfig, which is tagged with the driver and kernel
+// versions.
+func runsDriverVersion(cr *hlaiv1alpha1.DeviceConfig, pod *corev1.Pod) bool {
+	prefix := fmt.Sprintf("%s:%s-", cr.Spec.DriverImage, cr.Spec.DriverVersion)
+	for _, c := range pod.Spec.Containers {
+		if strings.HasPrefix(c.Image, prefix) {
+			return true
+		}
+	}
+	return false
+}
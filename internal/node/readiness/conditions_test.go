@@ -0,0 +1,124 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readiness
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+)
+
+var _ = Describe("getPodState", func() {
+	var pod *corev1.Pod
+
+	BeforeEach(func() {
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod"},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+			},
+		}
+	})
+
+	It("should report the ready pods", func() {
+		pod.Status.Conditions[0].Status = corev1.ConditionTrue
+
+		state := getPodState(pod, ReasonModuleLoadFailed)
+		Expect(state.ready).To(BeTrue())
+		Expect(state.reason).To(Equal(ReasonPodReady))
+	})
+
+	It("should report the image pull errors", func() {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name: "main",
+			State: corev1.ContainerState{
+				Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"},
+			},
+		}}
+
+		state := getPodState(pod, ReasonModuleLoadFailed)
+		Expect(state.ready).To(BeFalse())
+		Expect(state.reason).To(Equal("ImagePullBackOff"))
+		Expect(state.message).To(ContainSubstring("Back-off pulling image"))
+	})
+
+	It("should report the crashing containers with the given reason", func() {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name: "main",
+			State: corev1.ContainerState{
+				Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+			},
+			LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "modprobe: ERROR"},
+			},
+		}}
+
+		state := getPodState(pod, ReasonModuleLoadFailed)
+		Expect(state.reason).To(Equal(ReasonModuleLoadFailed))
+		Expect(state.message).To(ContainSubstring("modprobe: ERROR"))
+	})
+
+	It("should report the pods not ready yet", func() {
+		Expect(getPodState(pod, ReasonModuleLoadFailed).reason).To(Equal(ReasonPodNotReady))
+	})
+})
+
+var _ = Describe("getDriverState", func() {
+	It("should report the pods of another driver version as upgrading", func() {
+		dc := &hlaiv1alpha1.DeviceConfig{
+			Spec: hlaiv1alpha1.DeviceConfigSpec{DriverImage: "registry/driver", DriverVersion: "1.7.0"},
+		}
+		pod := &corev1.Pod{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Image: "registry/driver:1.6.0-5.14.0"}}},
+		}
+
+		Expect(getDriverState(dc, pod).reason).To(Equal(ReasonDriverUpgrading))
+	})
+})
+
+var _ = Describe("componentState", func() {
+	It("should prefer the ready pods, then the pods with known issues", func() {
+		missing := newNodeState().driver
+		upgrading := componentState{reason: ReasonDriverUpgrading}
+		failed := componentState{reason: ReasonModuleLoadFailed}
+		ready := componentState{ready: true, reason: ReasonPodReady}
+
+		Expect(missing.merge(upgrading)).To(Equal(upgrading))
+		Expect(upgrading.merge(failed)).To(Equal(failed))
+		Expect(failed.merge(upgrading)).To(Equal(failed))
+		Expect(failed.merge(ready)).To(Equal(ready))
+	})
+})
+
+var _ = Describe("setNodeCondition", func() {
+	It("should only change the node along with the condition", func() {
+		node := &corev1.Node{}
+		state := componentState{reason: ReasonPodMissing, message: "missing"}
+
+		Expect(setNodeCondition(node, DriverReadyCondition, state)).To(BeTrue())
+		Expect(node.Status.Conditions).To(HaveLen(1))
+		Expect(node.Status.Conditions[0].Status).To(Equal(corev1.ConditionFalse))
+
+		Expect(setNodeCondition(node, DriverReadyCondition, state)).To(BeFalse())
+
+		Expect(setNodeCondition(node, DriverReadyCondition, componentState{ready: true, reason: ReasonPodReady})).To(BeTrue())
+		Expect(node.Status.Conditions).To(HaveLen(1))
+		Expect(node.Status.Conditions[0].Status).To(Equal(corev1.ConditionTrue))
+		Expect(node.Status.Conditions[0].Reason).To(Equal(ReasonPodReady))
+	})
+})
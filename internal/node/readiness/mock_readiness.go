@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: readiness.go
+
+// Package readiness is a generated GoMock package.
+package readiness
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockReconciler is a mock of Reconciler interface.
+type MockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcilerMockRecorder
+}
+
+// MockReconcilerMockRecorder is the mock recorder for MockReconciler.
+type MockReconcilerMockRecorder struct {
+	mock *MockReconciler
+}
+
+// NewMockReconciler creates a new mock instance.
+func NewMockReconciler(ctrl *gomock.Controller) *MockReconciler {
+	mock := &MockReconciler{ctrl: ctrl}
+	mock.recorder = &MockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// DeleteNodeReadiness mocks base method.
+func (m *MockReconciler) DeleteNodeReadiness(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNodeReadiness", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNodeReadiness indicates an expected call of DeleteNodeReadiness.
+func (mr *MockReconcilerMockRecorder) DeleteNodeReadiness(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNodeReadiness", reflect.TypeOf((*MockReconciler)(nil).DeleteNodeReadiness), ctx, dc)
+}
+
+// ReconcileNodeReadiness mocks base method.
+func (m *MockReconciler) ReconcileNodeReadiness(ctx context.Context, dc *v1alpha1.DeviceConfig) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileNodeReadiness", ctx, dc)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileNodeReadiness indicates an expected call of ReconcileNodeReadiness.
+func (mr *MockReconcilerMockRecorder) ReconcileNodeReadiness(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileNodeReadiness", reflect.TypeOf((*MockReconciler)(nil).ReconcileNodeReadiness), ctx, dc)
+}
@@ -0,0 +1,407 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	nodeHealth "github.com/HabanaAI/habana-ai-operator/internal/node/health"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	// DriverNotReadyTaintKey is the key of the NoSchedule taint set on the
+	// nodes of a DeviceConfig, with the name of the DeviceConfig as value,
+	// until their module loader and device plugin pods are ready.
+	DriverNotReadyTaintKey = "habana.ai/driver-not-ready"
+
+	// The labels set by KMM on the pods of the Module DaemonSets.
+	kmmModuleNameLabel  = "kmm.node.kubernetes.io/module.name"
+	kmmRoleLabel        = "kmm.node.kubernetes.io/role"
+	kmmRoleModuleLoader = "module-loader"
+	kmmRoleDevicePlugin = "device-plugin"
+)
+
+//go:generate mockgen -source=readiness.go -package=readiness -destination=mock_readiness.go
+
+type Reconciler interface {
+	ReconcileNodeReadiness(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) (bool, error)
+	DeleteNodeReadiness(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+}
+
+type NodeReadinessReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *NodeReadinessReconciler {
+	return &NodeReadinessReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+// GetTolerations returns the tolerations of the taints managed by the
+// operator, which the pods of the DeviceConfig components need to run on the
+// nodes they prepare.
+func GetTolerations() []corev1.Toleration {
+	return []corev1.Toleration{
+		{
+			Key:      DriverNotReadyTaintKey,
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		},
+		{
+			Key:      nodeHealth.DegradedTaintKey,
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		},
+	}
+}
+
+// ReconcileNodeReadiness taints the nodes of the DeviceConfig whose module
+// loader, device plugin or enabled node component pods are not ready, e.g.
+// nodes which just joined the node selector or whose driver is being
+// upgraded, and removes the taint once the pods are ready. The state of the
+// pods is reported in the conditions of the nodes. It returns whether all the
+// nodes are ready. The nodes with simulated HPUs are ready once the HPUs are
+// advertised, as they do not run the KMM pods. In the inTree and
+// hostInstalled driver modes, the nodes missing the module are reported in
+// the DeviceConfig status, which is persisted along with its conditions.
+//
+// The Module API does not allow to set tolerations on the KMM pods, so the
+// tolerations of the operator taints are added to the existing pods. As the
+// KMM DaemonSets do not create pods on the tainted nodes, a node is only
+// tainted once both its KMM pods exist. Until then, the node does not
+// advertise HPUs, so no workload requesting HPUs can be scheduled on it.
+func (r *NodeReadinessReconciler) ReconcileNodeReadiness(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) (bool, error) {
+	podList := &corev1.PodList{}
+	err := r.client.List(ctx, podList,
+		client.InNamespace(s.Settings.OperatorNamespace),
+		client.MatchingLabels{kmmModuleNameLabel: module.GetModuleName(cr)},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to list the Module pods: %w", err)
+	}
+
+	states := make(map[string]*nodeState)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		if err := r.tolerateOperatorTaints(ctx, pod); err != nil {
+			return false, err
+		}
+
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+
+		ns, ok := states[pod.Spec.NodeName]
+		if !ok {
+			ns = newNodeState()
+			states[pod.Spec.NodeName] = ns
+		}
+
+		switch pod.Labels[kmmRoleLabel] {
+		case kmmRoleModuleLoader:
+			ns.loaderPod = true
+			ns.driver = ns.driver.merge(getDriverState(cr, pod))
+		case kmmRoleDevicePlugin:
+			ns.pluginPod = true
+			ns.devicePlugin = ns.devicePlugin.merge(getDevicePluginState(pod))
+		}
+	}
+
+	if cr.GetDriverMode() != hlaiv1alpha1.DriverModeContainer {
+		if err := r.getDriverStates(ctx, cr, states); err != nil {
+			return false, err
+		}
+	}
+
+	for _, nc := range nodeComponents {
+		if !nc.enabled(cr) {
+			continue
+		}
+		if err := r.getComponentStates(ctx, cr, nc, states); err != nil {
+			return false, err
+		}
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(ctx, nodeList); err != nil {
+		return false, fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	// Only the nodes with HPUs run the KMM pods.
+	selector := labels.Set(module.GetModuleNodeSelector(cr)).AsSelector()
+	others, err := r.getOtherSelectors(ctx, cr)
+	if err != nil {
+		return false, err
+	}
+
+	ready := true
+	missingModule := []string{}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+
+		if !selector.Matches(labels.Set(node.Labels)) {
+			if err := r.taintNode(ctx, cr, node, false); err != nil {
+				return false, err
+			}
+			if err := r.removeConditions(ctx, node, others); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		ns, ok := states[node.Name]
+		if !ok {
+			ns = newNodeState()
+		}
+		ns.setMissingComponents(cr)
+		if cr.Spec.Simulation.Enabled {
+			ns.setSimulated(node)
+		}
+		notReady := !ns.isReady()
+		if ns.driver.reason == ReasonModuleMissing {
+			missingModule = append(missingModule, node.Name)
+		}
+
+		if err := r.taintNode(ctx, cr, node, notReady && canTaint(cr, ns)); err != nil {
+			return false, err
+		}
+		if err := r.setConditions(ctx, node, ns); err != nil {
+			return false, err
+		}
+
+		ready = ready && !notReady
+	}
+
+	cr.Status.Driver = nil
+	if mode := cr.GetDriverMode(); mode != hlaiv1alpha1.DriverModeContainer {
+		sort.Strings(missingModule)
+		cr.Status.Driver = &hlaiv1alpha1.DriverStatus{
+			Mode:               mode,
+			NodesMissingModule: missingModule,
+		}
+	}
+
+	return ready, nil
+}
+
+// canTaint returns whether the not ready node with the given state can be
+// tainted without preventing the creation of the pods preparing it. The
+// pods deployed by the operator tolerate the taint, unlike the KMM ones.
+func canTaint(cr *hlaiv1alpha1.DeviceConfig, ns *nodeState) bool {
+	if cr.Spec.Simulation.Enabled || cr.GetDriverMode() != hlaiv1alpha1.DriverModeContainer {
+		return true
+	}
+	return ns.loaderPod && ns.pluginPod
+}
+
+// getDriverStates merges the state of the driver and device plugin pods of
+// the inTree and hostInstalled driver modes, which are deployed by the
+// operator instead of KMM, into the states of their nodes.
+func (r *NodeReadinessReconciler) getDriverStates(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, states map[string]*nodeState) error {
+	for _, component := range []string{rbac.ComponentDriver, rbac.ComponentDevicePlugin} {
+		podList := &corev1.PodList{}
+		err := r.client.List(ctx, podList,
+			client.InNamespace(s.Settings.OperatorNamespace),
+			client.MatchingLabels{
+				"app.kubernetes.io/component": component,
+				constants.DeviceConfigLabel:   cr.Name,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to list the %s pods: %w", component, err)
+		}
+
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			if pod.DeletionTimestamp != nil || pod.Spec.NodeName == "" {
+				continue
+			}
+
+			ns, ok := states[pod.Spec.NodeName]
+			if !ok {
+				ns = newNodeState()
+				states[pod.Spec.NodeName] = ns
+			}
+
+			if component == rbac.ComponentDriver {
+				ns.driver = ns.driver.merge(getModuleState(pod))
+			} else {
+				ns.devicePlugin = ns.devicePlugin.merge(getDevicePluginState(pod))
+			}
+		}
+	}
+
+	return nil
+}
+
+// getComponentStates merges the state of the pods of the node component of
+// the DeviceConfig into the states of their nodes.
+func (r *NodeReadinessReconciler) getComponentStates(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, nc nodeComponent, states map[string]*nodeState) error {
+	podList := &corev1.PodList{}
+	err := r.client.List(ctx, podList,
+		client.InNamespace(s.Settings.OperatorNamespace),
+		client.MatchingLabels{
+			"app.kubernetes.io/component": nc.name,
+			constants.DeviceConfigLabel:   cr.Name,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list the %s pods: %w", nc.description, err)
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp != nil || pod.Spec.NodeName == "" {
+			continue
+		}
+
+		ns, ok := states[pod.Spec.NodeName]
+		if !ok {
+			ns = newNodeState()
+			states[pod.Spec.NodeName] = ns
+		}
+		ns.mergeComponentState(nc, pod)
+	}
+
+	return nil
+}
+
+// DeleteNodeReadiness removes the taint of the DeviceConfig from all the
+// nodes, and the conditions from the nodes not selected by another
+// DeviceConfig.
+func (r *NodeReadinessReconciler) DeleteNodeReadiness(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(ctx, nodeList); err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	others, err := r.getOtherSelectors(ctx, cr)
+	if err != nil {
+		return err
+	}
+
+	for i := range nodeList.Items {
+		if err := r.taintNode(ctx, cr, &nodeList.Items[i], false); err != nil {
+			return err
+		}
+		if err := r.removeConditions(ctx, &nodeList.Items[i], others); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tolerateOperatorTaints adds the missing tolerations of the operator taints
+// to the pod. Tolerations can be added to existing pods.
+func (r *NodeReadinessReconciler) tolerateOperatorTaints(ctx context.Context, pod *corev1.Pod) error {
+	missing := []corev1.Toleration{}
+	for _, t := range GetTolerations() {
+		if !hasToleration(pod, t) {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	pod.Spec.Tolerations = append(pod.Spec.Tolerations, missing...)
+	if err := r.client.Patch(ctx, pod, patch); err != nil {
+		return fmt.Errorf("failed to add the operator tolerations to pod %s: %w", pod.Name, err)
+	}
+
+	return nil
+}
+
+// taintNode sets or removes the driver not ready taint of the DeviceConfig on
+// the node. The taints of other DeviceConfigs are left as is.
+func (r *NodeReadinessReconciler) taintNode(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, node *corev1.Node, taint bool) error {
+	logger := log.FromContext(ctx)
+
+	patch := client.MergeFromWithOptions(node.DeepCopy(), client.MergeFromWithOptimisticLock{})
+
+	notReadyTaint := corev1.Taint{
+		Key:    DriverNotReadyTaintKey,
+		Value:  cr.Name,
+		Effect: corev1.TaintEffectNoSchedule,
+	}
+
+	var changed bool
+	if taint {
+		changed = nodeHealth.AddTaint(node, notReadyTaint)
+	} else {
+		changed = nodeHealth.RemoveTaint(node, notReadyTaint)
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := r.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to update the driver not ready taint of node %s: %w", node.Name, err)
+	}
+
+	logger.Info("Updated the driver not ready taint", "node", node.Name, "taint", taint)
+
+	return nil
+}
+
+// getOtherSelectors returns the node selectors of the other DeviceConfigs,
+// whose nodes conditions must be left as is.
+func (r *NodeReadinessReconciler) getOtherSelectors(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) ([]labels.Selector, error) {
+	list := &hlaiv1alpha1.DeviceConfigList{}
+	if err := r.client.List(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to list the DeviceConfigs: %w", err)
+	}
+
+	selectors := make([]labels.Selector, 0, len(list.Items))
+	for i := range list.Items {
+		if list.Items[i].Name == cr.Name {
+			continue
+		}
+		selectors = append(selectors, labels.Set(list.Items[i].GetNodeSelector()).AsSelector())
+	}
+
+	return selectors, nil
+}
+
+func hasToleration(pod *corev1.Pod, toleration corev1.Toleration) bool {
+	for _, t := range pod.Spec.Tolerations {
+		if t.MatchToleration(&toleration) {
+			return true
+		}
+	}
+	return false
+}
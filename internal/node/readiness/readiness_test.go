@@ -0,0 +1,388 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readiness
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+	"github.com/HabanaAI/habana-ai-operator/internal/testutil"
+)
+
+var (
+	selected = map[string]string{"hpu": "true"}
+	hpuNode  = map[string]string{"hpu": "true", "habana.ai/hpu.gaudi.present": "true"}
+)
+
+// makeKMMPod returns a pod of the KMM Module of the DeviceConfig with the
+// given role.
+func makeKMMPod(dc *hlaiv1alpha1.DeviceConfig, name, nodeName, role, image string, ready bool) *corev1.Pod {
+	return testutil.MakePod(s.Settings.OperatorNamespace, name,
+		testutil.WithPodLabels(map[string]string{
+			kmmModuleNameLabel: module.GetModuleName(dc),
+			kmmRoleLabel:       role,
+		}),
+		testutil.OnNode(nodeName),
+		testutil.WithImage(image),
+		testutil.Ready(ready),
+	)
+}
+
+var _ = Describe("NodeReadinessReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		ctx context.Context
+	)
+
+	const (
+		driverImage  = "registry/driver:1.7.0-5.14.0"
+		pluginImage  = "registry/plugin:1.7.0"
+		oldImage     = "registry/driver:1.6.0-5.14.0"
+		otherDCValue = "another-device-config"
+	)
+
+	notReadyTaint := func(value string) corev1.Taint {
+		return corev1.Taint{Key: DriverNotReadyTaintKey, Value: value, Effect: corev1.TaintEffectNoSchedule}
+	}
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+			Spec: hlaiv1alpha1.DeviceConfigSpec{
+				DriverImage:   "registry/driver",
+				DriverVersion: "1.7.0",
+				NodeSelector:  selected,
+			},
+		}
+		ctx = context.TODO()
+	})
+
+	build := func(objs ...client.Object) (client.Client, *NodeReadinessReconciler) {
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+		return c, NewReconciler(c, s)
+	}
+
+	getNode := func(c client.Client, name string) *corev1.Node {
+		n := &corev1.Node{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: name}, n)).To(Succeed())
+		return n
+	}
+
+	Describe("ReconcileNodeReadiness", func() {
+		It("should taint the nodes without ready pods", func() {
+			c, r := build(
+				testutil.MakeNode("not-ready", testutil.Labelled(hpuNode)),
+				makeKMMPod(dc, "loader", "not-ready", kmmRoleModuleLoader, driverImage, true),
+				makeKMMPod(dc, "plugin", "not-ready", kmmRoleDevicePlugin, pluginImage, false),
+			)
+
+			ready, err := r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(BeFalse())
+			Expect(getNode(c, "not-ready").Spec.Taints).To(ConsistOf(notReadyTaint(dc.Name)))
+		})
+
+		It("should not taint the nodes until their KMM pods exist", func() {
+			c, r := build(
+				testutil.MakeNode("new-node", testutil.Labelled(hpuNode)),
+				testutil.MakeNode("tainted", testutil.Labelled(hpuNode), testutil.Tainted(notReadyTaint(dc.Name))),
+				testutil.MakeNode("loading", testutil.Labelled(hpuNode)),
+				makeKMMPod(dc, "loader", "loading", kmmRoleModuleLoader, driverImage, false),
+			)
+
+			ready, err := r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(BeFalse())
+			Expect(getNode(c, "new-node").Spec.Taints).To(BeEmpty())
+			Expect(getNode(c, "tainted").Spec.Taints).To(BeEmpty())
+			Expect(getNode(c, "loading").Spec.Taints).To(BeEmpty())
+
+			Expect(getNode(c, "new-node").Status.Conditions).To(ContainElement(And(
+				HaveField("Type", DriverReadyCondition),
+				HaveField("Status", corev1.ConditionFalse),
+				HaveField("Reason", ReasonPodMissing),
+			)))
+		})
+
+		It("should taint the nodes whose driver is being upgraded", func() {
+			c, r := build(
+				testutil.MakeNode("upgrading", testutil.Labelled(hpuNode)),
+				makeKMMPod(dc, "loader", "upgrading", kmmRoleModuleLoader, oldImage, true),
+				makeKMMPod(dc, "plugin", "upgrading", kmmRoleDevicePlugin, pluginImage, true),
+			)
+
+			ready, err := r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(BeFalse())
+			Expect(getNode(c, "upgrading").Spec.Taints).To(ConsistOf(notReadyTaint(dc.Name)))
+		})
+
+		It("should not taint the nodes without HPUs", func() {
+			c, r := build(testutil.MakeNode("no-hpu", testutil.Labelled(selected)))
+
+			ready, err := r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(BeTrue())
+			Expect(getNode(c, "no-hpu").Spec.Taints).To(BeEmpty())
+		})
+
+		It("should remove the taint once the pods are ready", func() {
+			c, r := build(
+				testutil.MakeNode("ready", testutil.Labelled(hpuNode), testutil.Tainted(notReadyTaint(dc.Name), notReadyTaint(otherDCValue))),
+				testutil.MakeNode("not-selected", testutil.Tainted(notReadyTaint(dc.Name))),
+				makeKMMPod(dc, "loader", "ready", kmmRoleModuleLoader, driverImage, true),
+				makeKMMPod(dc, "plugin", "ready", kmmRoleDevicePlugin, pluginImage, true),
+			)
+
+			ready, err := r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(BeTrue())
+			Expect(getNode(c, "ready").Spec.Taints).To(ConsistOf(notReadyTaint(otherDCValue)))
+			Expect(getNode(c, "not-selected").Spec.Taints).To(BeEmpty())
+		})
+
+		It("should remove the taint once the simulated HPUs are advertised", func() {
+			dc.Spec.Simulation.Enabled = true
+			advertised := testutil.MakeNode("advertised", testutil.Labelled(hpuNode), testutil.Tainted(notReadyTaint(dc.Name)))
+			advertised.Status.Allocatable = corev1.ResourceList{constants.HabanaResourceName: resource.MustParse("8")}
+			c, r := build(
+				advertised,
+				testutil.MakeNode("not-advertised", testutil.Labelled(hpuNode)),
+				testutil.MakeNode("unregistered", testutil.Labelled(hpuNode), testutil.AdvertisingHPUs(0)),
+			)
+
+			ready, err := r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(BeFalse())
+
+			node := getNode(c, "advertised")
+			Expect(node.Spec.Taints).To(BeEmpty())
+			Expect(node.Status.Conditions).To(ContainElement(And(
+				HaveField("Type", DevicePluginReadyCondition),
+				HaveField("Status", corev1.ConditionTrue),
+				HaveField("Reason", ReasonSimulated),
+			)))
+
+			node = getNode(c, "not-advertised")
+			Expect(node.Spec.Taints).To(ConsistOf(notReadyTaint(dc.Name)))
+			Expect(node.Status.Conditions).To(ContainElement(And(
+				HaveField("Type", DriverReadyCondition),
+				HaveField("Status", corev1.ConditionTrue),
+			)))
+
+			node = getNode(c, "unregistered")
+			Expect(node.Spec.Taints).To(ConsistOf(notReadyTaint(dc.Name)))
+		})
+
+		It("should report the nodes missing the module in the inTree driver mode", func() {
+			dc.Spec.Driver.Mode = hlaiv1alpha1.DriverModeInTree
+			operatorPod := func(name, nodeName, component string, ready bool) *corev1.Pod {
+				pod := makeKMMPod(dc, name, nodeName, "", "registry/ubi-minimal", ready)
+				pod.Labels = map[string]string{
+					"app.kubernetes.io/component": component,
+					constants.DeviceConfigLabel:   dc.Name,
+				}
+				return pod
+			}
+			missing := operatorPod("driver-2", "missing", rbac.ComponentDriver, false)
+			missing.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name: "main",
+				LastTerminationState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "The habanalabs module is not loaded on the node"},
+				},
+			}}
+			c, r := build(
+				testutil.MakeNode("loaded", testutil.Labelled(hpuNode)),
+				testutil.MakeNode("missing", testutil.Labelled(hpuNode)),
+				operatorPod("driver-1", "loaded", rbac.ComponentDriver, true),
+				operatorPod("plugin-1", "loaded", rbac.ComponentDevicePlugin, true),
+				missing,
+				operatorPod("plugin-2", "missing", rbac.ComponentDevicePlugin, false),
+			)
+
+			ready, err := r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(BeFalse())
+			Expect(getNode(c, "loaded").Spec.Taints).To(BeEmpty())
+
+			node := getNode(c, "missing")
+			Expect(node.Spec.Taints).To(ConsistOf(notReadyTaint(dc.Name)))
+			Expect(node.Status.Conditions).To(ContainElement(And(
+				HaveField("Type", DriverReadyCondition),
+				HaveField("Status", corev1.ConditionFalse),
+				HaveField("Reason", ReasonModuleMissing),
+			)))
+
+			Expect(dc.Status.Driver).To(Equal(&hlaiv1alpha1.DriverStatus{
+				Mode:               hlaiv1alpha1.DriverModeInTree,
+				NodesMissingModule: []string{"missing"},
+			}))
+		})
+
+		It("should taint the nodes whose CDI spec is not written when CDI is enabled", func() {
+			dc.Spec.CDI.Enabled = true
+			cdiPod := func(name, nodeName string, ready bool) *corev1.Pod {
+				pod := makeKMMPod(dc, name, nodeName, "", "registry/ubi-minimal", ready)
+				pod.Labels = map[string]string{
+					"app.kubernetes.io/component": rbac.ComponentCDI,
+					constants.DeviceConfigLabel:   dc.Name,
+				}
+				return pod
+			}
+			c, r := build(
+				testutil.MakeNode("ready", testutil.Labelled(hpuNode)),
+				testutil.MakeNode("no-cdi", testutil.Labelled(hpuNode)),
+				makeKMMPod(dc, "loader-1", "ready", kmmRoleModuleLoader, driverImage, true),
+				makeKMMPod(dc, "plugin-1", "ready", kmmRoleDevicePlugin, pluginImage, true),
+				cdiPod("cdi-1", "ready", true),
+				makeKMMPod(dc, "loader-2", "no-cdi", kmmRoleModuleLoader, driverImage, true),
+				makeKMMPod(dc, "plugin-2", "no-cdi", kmmRoleDevicePlugin, pluginImage, true),
+				cdiPod("cdi-2", "no-cdi", false),
+			)
+
+			ready, err := r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(BeFalse())
+
+			node := getNode(c, "ready")
+			Expect(node.Spec.Taints).To(BeEmpty())
+			Expect(node.Status.Conditions).To(ContainElement(And(
+				HaveField("Type", CDIReadyCondition),
+				HaveField("Status", corev1.ConditionTrue),
+			)))
+
+			node = getNode(c, "no-cdi")
+			Expect(node.Spec.Taints).To(ContainElement(notReadyTaint(dc.Name)))
+			Expect(node.Status.Conditions).To(ContainElement(And(
+				HaveField("Type", CDIReadyCondition),
+				HaveField("Status", corev1.ConditionFalse),
+				HaveField("Reason", ReasonPodNotReady),
+			)))
+
+			dc.Spec.CDI.Enabled = false
+			_, err = r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getNode(c, "ready").Status.Conditions).ToNot(ContainElement(HaveField("Type", CDIReadyCondition)))
+		})
+
+		It("should taint the nodes without the container runtime when its installation is enabled", func() {
+			dc.Spec.ContainerRuntime.Enabled = true
+			c, r := build(
+				testutil.MakeNode("node", testutil.Labelled(hpuNode)),
+				makeKMMPod(dc, "loader", "node", kmmRoleModuleLoader, driverImage, true),
+				makeKMMPod(dc, "plugin", "node", kmmRoleDevicePlugin, pluginImage, true),
+			)
+
+			ready, err := r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(BeFalse())
+
+			node := getNode(c, "node")
+			Expect(node.Spec.Taints).To(ContainElement(notReadyTaint(dc.Name)))
+			Expect(node.Status.Conditions).To(ContainElement(And(
+				HaveField("Type", ContainerRuntimeReadyCondition),
+				HaveField("Status", corev1.ConditionFalse),
+				HaveField("Reason", ReasonPodMissing),
+			)))
+			Expect(node.Status.Conditions).ToNot(ContainElement(HaveField("Type", CDIReadyCondition)))
+		})
+
+		It("should report the pods state in the node conditions", func() {
+			loader := makeKMMPod(dc, "loader", "node", kmmRoleModuleLoader, driverImage, false)
+			loader.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name: "main",
+				State: corev1.ContainerState{
+					Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"},
+				},
+			}}
+			kubeletCondition := corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue}
+			node := testutil.MakeNode("node", testutil.Labelled(hpuNode))
+			node.Status.Conditions = []corev1.NodeCondition{kubeletCondition}
+			c, r := build(node, loader, makeKMMPod(dc, "plugin", "node", kmmRoleDevicePlugin, pluginImage, true))
+
+			_, err := r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+
+			conditions := getNode(c, "node").Status.Conditions
+			Expect(conditions).To(HaveLen(3))
+			Expect(conditions[0]).To(Equal(kubeletCondition))
+			Expect(conditions[1].Type).To(Equal(DriverReadyCondition))
+			Expect(conditions[1].Status).To(Equal(corev1.ConditionFalse))
+			Expect(conditions[1].Reason).To(Equal("ImagePullBackOff"))
+			Expect(conditions[2].Type).To(Equal(DevicePluginReadyCondition))
+			Expect(conditions[2].Status).To(Equal(corev1.ConditionTrue))
+		})
+
+		It("should only remove the conditions of the nodes not selected by another DeviceConfig", func() {
+			conditions := []corev1.NodeCondition{
+				{Type: DriverReadyCondition, Status: corev1.ConditionTrue},
+				{Type: DevicePluginReadyCondition, Status: corev1.ConditionTrue},
+			}
+			notSelected := testutil.MakeNode("not-selected")
+			notSelected.Status.Conditions = conditions
+			otherDCNode := testutil.MakeNode("other-dc-node", testutil.Labelled(map[string]string{"other": "true"}))
+			otherDCNode.Status.Conditions = conditions
+			otherDC := &hlaiv1alpha1.DeviceConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: otherDCValue},
+				Spec:       hlaiv1alpha1.DeviceConfigSpec{NodeSelector: map[string]string{"other": "true"}},
+			}
+			c, r := build(notSelected, otherDCNode, otherDC)
+
+			_, err := r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getNode(c, "not-selected").Status.Conditions).To(BeEmpty())
+			Expect(getNode(c, "other-dc-node").Status.Conditions).To(HaveLen(2))
+		})
+
+		It("should add the operator tolerations to the Module pods", func() {
+			pending := makeKMMPod(dc, "pending", "", kmmRoleModuleLoader, driverImage, false)
+			c, r := build(testutil.MakeNode("new-node", testutil.Labelled(hpuNode)), pending)
+
+			_, err := r.ReconcileNodeReadiness(ctx, dc)
+			Expect(err).ToNot(HaveOccurred())
+
+			pod := &corev1.Pod{}
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(pending), pod)).To(Succeed())
+			Expect(pod.Spec.Tolerations).To(Equal(GetTolerations()))
+		})
+	})
+
+	Describe("DeleteNodeReadiness", func() {
+		It("should only remove the taint of the DeviceConfig", func() {
+			c, r := build(testutil.MakeNode("node", testutil.Labelled(hpuNode), testutil.Tainted(notReadyTaint(dc.Name), notReadyTaint(otherDCValue))))
+
+			Expect(r.DeleteNodeReadiness(ctx, dc)).To(Succeed())
+			Expect(getNode(c, "node").Spec.Taints).To(ConsistOf(notReadyTaint(otherDCValue)))
+		})
+	})
+})
@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: simulation.go
+
+// Package simulation is a generated GoMock package.
+package simulation
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/api/apps/v1"
+)
+
+// MockReconciler is a mock of Reconciler interface.
+type MockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcilerMockRecorder
+}
+
+// MockReconcilerMockRecorder is the mock recorder for MockReconciler.
+type MockReconcilerMockRecorder struct {
+	mock *MockReconciler
+}
+
+// NewMockReconciler creates a new mock instance.
+func NewMockReconciler(ctrl *gomock.Controller) *MockReconciler {
+	mock := &MockReconciler{ctrl: ctrl}
+	mock.recorder = &MockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// DeleteSimulation mocks base method.
+func (m *MockReconciler) DeleteSimulation(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSimulation", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSimulation indicates an expected call of DeleteSimulation.
+func (mr *MockReconcilerMockRecorder) DeleteSimulation(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSimulation", reflect.TypeOf((*MockReconciler)(nil).DeleteSimulation), ctx, dc)
+}
+
+// ReconcileSimulation mocks base method.
+func (m *MockReconciler) ReconcileSimulation(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileSimulation", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileSimulation indicates an expected call of ReconcileSimulation.
+func (mr *MockReconcilerMockRecorder) ReconcileSimulation(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileSimulation", reflect.TypeOf((*MockReconciler)(nil).ReconcileSimulation), ctx, dc)
+}
+
+// SetDesiredDevicePluginDaemonSet mocks base method.
+func (m *MockReconciler) SetDesiredDevicePluginDaemonSet(ds *v1.DaemonSet, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredDevicePluginDaemonSet", ds, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredDevicePluginDaemonSet indicates an expected call of SetDesiredDevicePluginDaemonSet.
+func (mr *MockReconcilerMockRecorder) SetDesiredDevicePluginDaemonSet(ds, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredDevicePluginDaemonSet", reflect.TypeOf((*MockReconciler)(nil).SetDesiredDevicePluginDaemonSet), ds, cr)
+}
@@ -0,0 +1,364 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	operatorMetrics "github.com/HabanaAI/habana-ai-operator/internal/metrics"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	// SimulatedLabel is set on the nodes advertising simulated HPUs, with the
+	// name of their DeviceConfig as value.
+	SimulatedLabel = "habana.ai/simulated"
+
+	devicePluginSuffix = "simulated-" + rbac.ComponentDevicePlugin
+
+	// The device plugin registers with the kubelet through the default
+	// device plugins directory, to which the directory of the nodes is
+	// mounted.
+	devicePluginKubeletVolume    = "kubelet-device-plugins-dir"
+	devicePluginKubeletMountPath = hlaiv1alpha1.DefaultKubeletRootDir + "/device-plugins"
+
+	// simulatedDevicePath backs each simulated HPU, so that no actual device
+	// is mounted in the containers.
+	simulatedDevicePath = "/dev/null"
+
+	devicePluginLimitsCpu      = "50m"
+	devicePluginLimitsMemory   = "32Mi"
+	devicePluginRequestsCpu    = "10m"
+	devicePluginRequestsMemory = "16Mi"
+)
+
+//go:generate mockgen -source=simulation.go -package=simulation -destination=mock_simulation.go
+
+type Reconciler interface {
+	ReconcileSimulation(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredDevicePluginDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error
+	DeleteSimulation(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+}
+
+type SimulationReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *SimulationReconciler {
+	return &SimulationReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+// GetDevicePluginName returns the name of the simulated device plugin
+// DaemonSet.
+func GetDevicePluginName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-%s", cr.Name, devicePluginSuffix)
+}
+
+// ReconcileSimulation advertises the simulated HPUs on the nodes selected by
+// the DeviceConfig when the simulation is enabled, and removes them from the
+// other nodes. The nodes are labeled as nodes with HPUs, so that they are
+// selected by the node components, and a simulated device plugin registers
+// the HPUs with their kubelet.
+func (r *SimulationReconciler) ReconcileSimulation(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(ctx, nodeList); err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	selector := labels.Set(cr.GetNodeSelector()).AsSelector()
+
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+
+		var err error
+		if cr.Spec.Simulation.Enabled && selector.Matches(labels.Set(node.Labels)) {
+			err = r.simulateNode(ctx, cr, node)
+		} else {
+			err = r.removeSimulation(ctx, cr, node)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if !cr.Spec.Simulation.Enabled {
+		return r.deleteDevicePluginDaemonSet(ctx, cr)
+	}
+
+	return r.reconcileDevicePluginDaemonSet(ctx, cr)
+}
+
+// DeleteSimulation removes the simulated HPUs of the DeviceConfig from all the
+// nodes.
+func (r *SimulationReconciler) DeleteSimulation(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	if err := r.deleteDevicePluginDaemonSet(ctx, cr); err != nil {
+		return err
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(ctx, nodeList); err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	for i := range nodeList.Items {
+		if err := r.removeSimulation(ctx, cr, &nodeList.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SimulationReconciler) reconcileDevicePluginDaemonSet(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	logger := log.FromContext(ctx)
+
+	existingDS := &appsv1.DaemonSet{}
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: GetDevicePluginName(cr)}, existingDS)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetDevicePluginName(cr),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	if exists {
+		ds = existingDS
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, ds, func() error {
+		return r.SetDesiredDevicePluginDaemonSet(ds, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch DaemonSet: %v", err)
+	}
+
+	logger.Info("Reconciled DaemonSet", "resource", ds.Name, "result", res)
+
+	operatorMetrics.SetComponentNodes(cr.Name, rbac.ComponentDevicePlugin, ds.Status.DesiredNumberScheduled, ds.Status.NumberReady)
+
+	return nil
+}
+
+func (r *SimulationReconciler) deleteDevicePluginDaemonSet(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetDevicePluginName(cr),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	err := r.client.Delete(ctx, ds)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete DaemonSet %s: %w", ds.Name, err)
+	}
+
+	return nil
+}
+
+// simulatedDevice is the device configuration of the generic device plugin,
+// which advertises count devices of the group, backed by its paths.
+type simulatedDevice struct {
+	Name   string                 `json:"name"`
+	Groups []simulatedDeviceGroup `json:"groups"`
+}
+
+type simulatedDeviceGroup struct {
+	Paths []simulatedDevicePathSpec `json:"paths"`
+	Count int32                     `json:"count"`
+}
+
+type simulatedDevicePathSpec struct {
+	Path string `json:"path"`
+}
+
+// SetDesiredDevicePluginDaemonSet sets the simulated device plugin, which
+// registers the simulated HPUs of the DeviceConfig with the kubelet of the
+// simulated nodes. Each HPU is backed by /dev/null, so that the pods are
+// admitted like with actual devices, without any HPU being mounted.
+func (r *SimulationReconciler) SetDesiredDevicePluginDaemonSet(ds *appsv1.DaemonSet, cr *hlaiv1alpha1.DeviceConfig) error {
+	if ds == nil {
+		return errors.New("daemonset cannot be nil")
+	}
+
+	domain, name, found := strings.Cut(constants.HabanaResourceName, "/")
+	if !found {
+		return fmt.Errorf("invalid resource name %s", constants.HabanaResourceName)
+	}
+
+	device, err := json.Marshal(simulatedDevice{
+		Name: name,
+		Groups: []simulatedDeviceGroup{
+			{
+				Paths: []simulatedDevicePathSpec{{Path: simulatedDevicePath}},
+				Count: cr.GetSimulationDevicesPerNode(),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode the simulated devices: %w", err)
+	}
+
+	labels := labelsForSimulation(cr)
+
+	ds.ObjectMeta.Labels = labels
+
+	ds.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: labels,
+	}
+
+	ds.Spec.Template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: labels,
+		},
+	}
+
+	hostPathTypeDirectory := corev1.HostPathDirectory
+
+	container := corev1.Container{
+		Name:            rbac.ComponentDevicePlugin,
+		Image:           s.Settings.SimulationDevicePluginImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Args: []string{
+			"--domain", domain,
+			"--device", string(device),
+			"--plugin-directory", devicePluginKubeletMountPath,
+		},
+		SecurityContext: &corev1.SecurityContext{
+			Privileged: pointer.Bool(true),
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				"cpu":    resource.MustParse(devicePluginLimitsCpu),
+				"memory": resource.MustParse(devicePluginLimitsMemory),
+			},
+			Requests: corev1.ResourceList{
+				"cpu":    resource.MustParse(devicePluginRequestsCpu),
+				"memory": resource.MustParse(devicePluginRequestsMemory),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      devicePluginKubeletVolume,
+				MountPath: devicePluginKubeletMountPath,
+			},
+		},
+	}
+
+	ds.Spec.Template.Spec = corev1.PodSpec{
+		Containers:         []corev1.Container{container},
+		NodeSelector:       map[string]string{SimulatedLabel: cr.Name},
+		PriorityClassName:  "system-node-critical",
+		ServiceAccountName: rbac.GetServiceAccountName(cr, rbac.ComponentDevicePlugin),
+		Tolerations:        readiness.GetTolerations(),
+		Volumes: []corev1.Volume{
+			{
+				Name: devicePluginKubeletVolume,
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: cr.GetKubeletDevicePluginsDir(),
+						Type: &hostPathTypeDirectory,
+					},
+				},
+			},
+		},
+	}
+
+	return nil
+}
+
+// simulateNode labels the node, so that the simulated device plugin runs on
+// it.
+func (r *SimulationReconciler) simulateNode(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, node *corev1.Node) error {
+	if node.Labels[SimulatedLabel] == cr.Name && node.Labels[constants.HPUPresentLabel] == "true" {
+		return nil
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	node.Labels[SimulatedLabel] = cr.Name
+	node.Labels[constants.HPUPresentLabel] = "true"
+	if err := r.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to label node %s as simulated: %w", node.Name, err)
+	}
+
+	log.FromContext(ctx).Info("Labeled the node as simulated", "node", node.Name)
+
+	return nil
+}
+
+// removeSimulation removes the labels of the node, provided that they are
+// managed by the DeviceConfig. The simulated device plugin then leaves the
+// node, and the kubelet stops advertising the simulated HPUs.
+func (r *SimulationReconciler) removeSimulation(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, node *corev1.Node) error {
+	if node.Labels[SimulatedLabel] != cr.Name {
+		return nil
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	delete(node.Labels, SimulatedLabel)
+	delete(node.Labels, constants.HPUPresentLabel)
+	if err := r.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to remove the simulated label of node %s: %w", node.Name, err)
+	}
+
+	log.FromContext(ctx).Info("Removed the simulated HPUs", "node", node.Name)
+
+	return nil
+}
+
+// labelsForSimulation returns the labels for selecting the simulated device
+// plugin of the given DeviceConfig CR name.
+func labelsForSimulation(cr *hlaiv1alpha1.DeviceConfig) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
+		"app.kubernetes.io/component": devicePluginSuffix,
+		constants.DeviceConfigLabel:   cr.Name,
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulation
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+	"github.com/HabanaAI/habana-ai-operator/internal/testutil"
+)
+
+var selected = map[string]string{"ci": "true"}
+
+var _ = Describe("SimulationReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+			Spec: hlaiv1alpha1.DeviceConfigSpec{
+				NodeSelector: selected,
+			},
+		}
+		ctx = context.TODO()
+	})
+
+	build := func(objs ...client.Object) (client.Client, *SimulationReconciler) {
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+		return c, NewReconciler(c, s)
+	}
+
+	getNode := func(c client.Client, name string) *corev1.Node {
+		n := &corev1.Node{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: name}, n)).To(Succeed())
+		return n
+	}
+
+	simulatedLabels := func(value string) map[string]string {
+		return map[string]string{
+			"ci":                      "true",
+			SimulatedLabel:            value,
+			constants.HPUPresentLabel: "true",
+		}
+	}
+
+	getDevicePlugin := func(c client.Client) (*appsv1.DaemonSet, error) {
+		ds := &appsv1.DaemonSet{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: s.Settings.OperatorNamespace, Name: GetDevicePluginName(dc)}, ds)
+		return ds, err
+	}
+
+	Describe("ReconcileSimulation", func() {
+		Context("with the simulation enabled", func() {
+			BeforeEach(func() {
+				dc.Spec.Simulation.Enabled = true
+				dc.Spec.Simulation.DevicesPerNode = 4
+			})
+
+			It("should label the selected nodes and deploy the simulated device plugin", func() {
+				c, r := build(
+					testutil.MakeNode("selected", testutil.Labelled(selected)),
+					testutil.MakeNode("not-selected", testutil.Labelled(map[string]string{"other": "true"})),
+				)
+
+				Expect(r.ReconcileSimulation(ctx, dc)).To(Succeed())
+
+				Expect(getNode(c, "selected").Labels).To(Equal(simulatedLabels(dc.Name)))
+				Expect(getNode(c, "not-selected").Labels).To(Equal(map[string]string{"other": "true"}))
+
+				ds, err := getDevicePlugin(c)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ds.Spec.Template.Spec.NodeSelector).To(Equal(map[string]string{SimulatedLabel: dc.Name}))
+			})
+
+			It("should remove the simulated HPUs from the nodes which left the node selector", func() {
+				c, r := build(
+					testutil.MakeNode("left", testutil.Labelled(map[string]string{SimulatedLabel: dc.Name, constants.HPUPresentLabel: "true"})),
+					testutil.MakeNode("another", testutil.Labelled(map[string]string{SimulatedLabel: "another-device-config", constants.HPUPresentLabel: "true"})),
+				)
+
+				Expect(r.ReconcileSimulation(ctx, dc)).To(Succeed())
+
+				Expect(getNode(c, "left").Labels).To(BeEmpty())
+				Expect(getNode(c, "another").Labels).To(HaveKeyWithValue(SimulatedLabel, "another-device-config"))
+			})
+		})
+
+		Context("with the simulation disabled", func() {
+			It("should remove the simulated HPUs from the nodes", func() {
+				ds := &appsv1.DaemonSet{
+					ObjectMeta: metav1.ObjectMeta{Name: GetDevicePluginName(dc), Namespace: s.Settings.OperatorNamespace},
+				}
+				c, r := build(testutil.MakeNode("simulated", testutil.Labelled(simulatedLabels(dc.Name))), ds)
+
+				Expect(r.ReconcileSimulation(ctx, dc)).To(Succeed())
+
+				Expect(getNode(c, "simulated").Labels).To(Equal(selected))
+
+				_, err := getDevicePlugin(c)
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("SetDesiredDevicePluginDaemonSet", func() {
+		It("should return an error with a nil DaemonSet", func() {
+			_, r := build()
+			err := r.SetDesiredDevicePluginDaemonSet(nil, dc)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("daemonset cannot be nil"))
+		})
+
+		It("should register the simulated HPUs as habana.ai/gaudi devices", func() {
+			dc.Spec.Simulation.DevicesPerNode = 4
+			_, r := build()
+			ds := &appsv1.DaemonSet{}
+
+			Expect(r.SetDesiredDevicePluginDaemonSet(ds, dc)).To(Succeed())
+
+			Expect(ds.Spec.Template.Spec.ServiceAccountName).To(Equal(rbac.GetServiceAccountName(dc, rbac.ComponentDevicePlugin)))
+			Expect(ds.Spec.Template.Spec.Volumes[0].HostPath.Path).To(Equal(dc.GetKubeletDevicePluginsDir()))
+
+			container := ds.Spec.Template.Spec.Containers[0]
+			Expect(container.Image).To(Equal(s.Settings.SimulationDevicePluginImage))
+			Expect(container.Args).To(Equal([]string{
+				"--domain", "habana.ai",
+				"--device", `{"name":"gaudi","groups":[{"paths":[{"path":"/dev/null"}],"count":4}]}`,
+				"--plugin-directory", "/var/lib/kubelet/device-plugins",
+			}))
+		})
+	})
+
+	Describe("DeleteSimulation", func() {
+		It("should remove the simulated HPUs from all the nodes", func() {
+			dc.Spec.Simulation.Enabled = true
+			ds := &appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: GetDevicePluginName(dc), Namespace: s.Settings.OperatorNamespace},
+			}
+			c, r := build(testutil.MakeNode("simulated", testutil.Labelled(simulatedLabels(dc.Name))), ds)
+
+			Expect(r.DeleteSimulation(ctx, dc)).To(Succeed())
+
+			Expect(getNode(c, "simulated").Labels).To(Equal(selected))
+
+			_, err := getDevicePlugin(c)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
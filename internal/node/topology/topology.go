@@ -0,0 +1,278 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	// LabelPrefix is the prefix of the topology labels of the nodes, e.g.
+	// habana.ai/topology.numa0.hpus=4 and habana.ai/topology.numa0.nics=2.
+	LabelPrefix = "habana.ai/topology."
+
+	// ContainerName is the name of the node metrics container exporting the
+	// topology of the node.
+	ContainerName = "topology"
+
+	// FeaturesVolume is the name of the volume of the NFD local feature files
+	// directory.
+	FeaturesVolume = "features-d"
+
+	numaLabelPrefix = LabelPrefix + "numa"
+	hpusSuffix      = ".hpus"
+	nicsSuffix      = ".nics"
+
+	topologyLimitsCpu      = "50m"
+	topologyLimitsMemory   = "32Mi"
+	topologyRequestsCpu    = "10m"
+	topologyRequestsMemory = "16Mi"
+)
+
+// topologyScript counts the HPUs and the other network interfaces of each
+// NUMA node of the node every 5 minutes, from the PCI devices. The counts of
+// the NUMA nodes with HPUs are written in an NFD feature file, so that NFD
+// labels the node, and the file is removed when the pod is deleted. The
+// devices of the machines without NUMA are reported on NUMA node 0.
+const topologyScript = `set -u
+
+features="$FEATURES_DIR/habana-topology"
+trap 'rm -f "$features"; exit 0' TERM INT
+
+while true; do
+  unset hpus nics
+  declare -A hpus=() nics=()
+  for d in /sys/bus/pci/devices/*; do
+    vendor="$(cat "$d/vendor")"
+    class="$(cat "$d/class")"
+    numa="$(cat "$d/numa_node" 2>/dev/null || echo -1)"
+    [ "$numa" -lt 0 ] && numa=0
+    if [ "$vendor" = "0x$HABANA_VENDOR_ID" ]; then
+      [ "${class:2:4}" = "$HABANA_CLASS" ] && hpus[$numa]=$((${hpus[$numa]:-0} + 1))
+    elif [ "${class:2:2}" = 02 ]; then
+      nics[$numa]=$((${nics[$numa]:-0} + 1))
+    fi
+  done
+  for numa in "${!hpus[@]}"; do
+    printf '%snuma%s.hpus=%s\n%snuma%s.nics=%s\n' \
+      "$LABEL_PREFIX" "$numa" "${hpus[$numa]}" "$LABEL_PREFIX" "$numa" "${nics[$numa]:-0}"
+  done > "$features.tmp"
+  mv -f "$features.tmp" "$features"
+  sleep 300 &
+  wait $!
+done`
+
+//go:generate mockgen -source=topology.go -package=topology -destination=mock_topology.go
+
+type Reconciler interface {
+	ReconcileTopology(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+}
+
+type TopologyReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *TopologyReconciler {
+	return &TopologyReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+// ReconcileTopology reports the NUMA topology labeled on the nodes with HPUs
+// of the DeviceConfig in its status, which is persisted along with its
+// conditions, along with the recommended Topology Manager policy. The labels
+// are written by the topology container of the node metrics pods.
+func (r *TopologyReconciler) ReconcileTopology(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	if !cr.Spec.Topology.Enabled {
+		cr.Status.Topology = nil
+		return nil
+	}
+
+	nodeList := &corev1.NodeList{}
+	selector := labels.Set(module.GetModuleNodeSelector(cr)).AsSelector()
+	if err := r.client.List(ctx, nodeList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	status := &hlaiv1alpha1.TopologyStatus{}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+
+		numaNodes := getNUMANodes(node.Labels)
+		if len(numaNodes) == 0 {
+			continue
+		}
+
+		status.Nodes = append(status.Nodes, hlaiv1alpha1.NodeTopology{
+			NodeName:    node.Name,
+			NUMANodes:   numaNodes,
+			NICsAligned: nicsAligned(numaNodes),
+		})
+	}
+
+	sort.Slice(status.Nodes, func(i, j int) bool { return status.Nodes[i].NodeName < status.Nodes[j].NodeName })
+	status.RecommendedPolicy = recommendPolicy(status.Nodes)
+
+	cr.Status.Topology = status
+
+	return nil
+}
+
+// MakeTopologyContainer returns the node metrics container exporting the
+// topology of the node, which mounts the FeaturesVolume.
+func MakeTopologyContainer() corev1.Container {
+	return corev1.Container{
+		Name:            ContainerName,
+		Image:           s.Settings.NodeCleanupImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/bin/bash", "-c", topologyScript},
+		Env: []corev1.EnvVar{
+			{Name: "FEATURES_DIR", Value: constants.FeaturesDir},
+			{Name: "HABANA_VENDOR_ID", Value: hlaiv1alpha1.HabanaPCIVendorID},
+			{Name: "HABANA_CLASS", Value: hlaiv1alpha1.HabanaPCIClass},
+			{Name: "LABEL_PREFIX", Value: LabelPrefix},
+		},
+		SecurityContext: &corev1.SecurityContext{
+			RunAsUser: pointer.Int64(0),
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				"cpu":    resource.MustParse(topologyLimitsCpu),
+				"memory": resource.MustParse(topologyLimitsMemory),
+			},
+			Requests: corev1.ResourceList{
+				"cpu":    resource.MustParse(topologyRequestsCpu),
+				"memory": resource.MustParse(topologyRequestsMemory),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      FeaturesVolume,
+				MountPath: constants.FeaturesDir,
+			},
+		},
+	}
+}
+
+// MakeFeaturesVolume returns the FeaturesVolume, i.e. the NFD local feature
+// files directory of the node.
+func MakeFeaturesVolume() corev1.Volume {
+	hostPathTypeDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
+
+	return corev1.Volume{
+		Name: FeaturesVolume,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: constants.FeaturesDir,
+				Type: &hostPathTypeDirectoryOrCreate,
+			},
+		},
+	}
+}
+
+// getNUMANodes returns the NUMA nodes labeled on a node, in ID order.
+func getNUMANodes(l map[string]string) []hlaiv1alpha1.NUMANodeTopology {
+	byID := make(map[int32]*hlaiv1alpha1.NUMANodeTopology)
+	for k, v := range l {
+		if !strings.HasPrefix(k, numaLabelPrefix) {
+			continue
+		}
+
+		id, suffix, found := strings.Cut(strings.TrimPrefix(k, numaLabelPrefix), ".")
+		if !found {
+			continue
+		}
+		numaID, err := strconv.ParseInt(id, 10, 32)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			continue
+		}
+
+		n, ok := byID[int32(numaID)]
+		if !ok {
+			n = &hlaiv1alpha1.NUMANodeTopology{ID: int32(numaID)}
+			byID[int32(numaID)] = n
+		}
+		switch "." + suffix {
+		case hpusSuffix:
+			n.HPUs = int32(count)
+		case nicsSuffix:
+			n.NICs = int32(count)
+		}
+	}
+
+	numaNodes := make([]hlaiv1alpha1.NUMANodeTopology, 0, len(byID))
+	for _, n := range byID {
+		if n.HPUs > 0 {
+			numaNodes = append(numaNodes, *n)
+		}
+	}
+	sort.Slice(numaNodes, func(i, j int) bool { return numaNodes[i].ID < numaNodes[j].ID })
+
+	return numaNodes
+}
+
+// nicsAligned returns true if every NUMA node with HPUs has network
+// interfaces.
+func nicsAligned(numaNodes []hlaiv1alpha1.NUMANodeTopology) bool {
+	for _, n := range numaNodes {
+		if n.NICs == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// recommendPolicy returns single-numa-node when the HPUs of every node are
+// attached to a single NUMA node, so that the pods get CPUs and HPUs of the
+// same NUMA node. Otherwise, the pods requesting the HPUs of several NUMA
+// nodes would be rejected, hence best-effort is recommended. No policy is
+// recommended until a node reports its topology.
+func recommendPolicy(nodes []hlaiv1alpha1.NodeTopology) hlaiv1alpha1.TopologyManagerPolicy {
+	if len(nodes) == 0 {
+		return ""
+	}
+
+	for _, n := range nodes {
+		if len(n.NUMANodes) > 1 {
+			return hlaiv1alpha1.TopologyManagerPolicyBestEffort
+		}
+	}
+
+	return hlaiv1alpha1.TopologyManagerPolicySingleNUMANode
+}
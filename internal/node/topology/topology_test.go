@@ -0,0 +1,194 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"context"
+	"errors"
+
+	gomock "github.com/golang/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/client"
+)
+
+var _ = Describe("TopologyReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		r   *TopologyReconciler
+		c   *client.MockClient
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+		}
+		c = client.NewMockClient(gomock.NewController(GinkgoT()))
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+
+		r = NewReconciler(c, s)
+
+		ctx = context.TODO()
+	})
+
+	Describe("ReconcileTopology", func() {
+		Context("with the topology export disabled", func() {
+			It("should clear the status", func() {
+				dc.Status.Topology = &hlaiv1alpha1.TopologyStatus{}
+
+				Expect(r.ReconcileTopology(ctx, dc)).ToNot(HaveOccurred())
+				Expect(dc.Status.Topology).To(BeNil())
+			})
+		})
+
+		Context("with the topology export enabled", func() {
+			var nodes []corev1.Node
+
+			BeforeEach(func() {
+				dc.Spec.Topology.Enabled = true
+				nodes = nil
+			})
+
+			JustBeforeEach(func() {
+				c.EXPECT().
+					List(ctx, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, list *corev1.NodeList, _ ...interface{}) error {
+						list.Items = nodes
+						return nil
+					})
+			})
+
+			Context("with nodes whose HPUs are attached to a single NUMA node", func() {
+				BeforeEach(func() {
+					nodes = []corev1.Node{
+						{
+							ObjectMeta: metav1.ObjectMeta{
+								Name: "node-b",
+								Labels: map[string]string{
+									"habana.ai/topology.numa1.hpus": "8",
+									"habana.ai/topology.numa1.nics": "2",
+								},
+							},
+						},
+						{
+							ObjectMeta: metav1.ObjectMeta{
+								Name: "node-a",
+								Labels: map[string]string{
+									"habana.ai/topology.numa0.hpus": "8",
+									"habana.ai/topology.numa0.nics": "0",
+								},
+							},
+						},
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "node-without-topology"},
+						},
+					}
+				})
+
+				It("should report the topology of the nodes and recommend single-numa-node", func() {
+					Expect(r.ReconcileTopology(ctx, dc)).ToNot(HaveOccurred())
+					Expect(dc.Status.Topology).To(Equal(&hlaiv1alpha1.TopologyStatus{
+						RecommendedPolicy: hlaiv1alpha1.TopologyManagerPolicySingleNUMANode,
+						Nodes: []hlaiv1alpha1.NodeTopology{
+							{
+								NodeName:    "node-a",
+								NUMANodes:   []hlaiv1alpha1.NUMANodeTopology{{ID: 0, HPUs: 8, NICs: 0}},
+								NICsAligned: false,
+							},
+							{
+								NodeName:    "node-b",
+								NUMANodes:   []hlaiv1alpha1.NUMANodeTopology{{ID: 1, HPUs: 8, NICs: 2}},
+								NICsAligned: true,
+							},
+						},
+					}))
+				})
+			})
+
+			Context("with a node whose HPUs are attached to several NUMA nodes", func() {
+				BeforeEach(func() {
+					nodes = []corev1.Node{
+						{
+							ObjectMeta: metav1.ObjectMeta{
+								Name: "node-a",
+								Labels: map[string]string{
+									"habana.ai/topology.numa1.hpus":  "4",
+									"habana.ai/topology.numa1.nics":  "1",
+									"habana.ai/topology.numa0.hpus":  "4",
+									"habana.ai/topology.numa0.nics":  "1",
+									"habana.ai/topology.numaX.hpus":  "4",
+									"habana.ai/topology.numa2.hpus":  "not-a-count",
+									"habana.ai/topology.unsupported": "true",
+								},
+							},
+						},
+					}
+				})
+
+				It("should recommend best-effort", func() {
+					Expect(r.ReconcileTopology(ctx, dc)).ToNot(HaveOccurred())
+					Expect(dc.Status.Topology.RecommendedPolicy).To(Equal(hlaiv1alpha1.TopologyManagerPolicyBestEffort))
+					Expect(dc.Status.Topology.Nodes).To(HaveLen(1))
+					Expect(dc.Status.Topology.Nodes[0].NUMANodes).To(Equal([]hlaiv1alpha1.NUMANodeTopology{
+						{ID: 0, HPUs: 4, NICs: 1},
+						{ID: 1, HPUs: 4, NICs: 1},
+					}))
+					Expect(dc.Status.Topology.Nodes[0].NICsAligned).To(BeTrue())
+				})
+			})
+
+			Context("without a node reporting its topology", func() {
+				It("should not recommend a policy", func() {
+					Expect(r.ReconcileTopology(ctx, dc)).ToNot(HaveOccurred())
+					Expect(dc.Status.Topology.RecommendedPolicy).To(BeEmpty())
+				})
+			})
+		})
+
+		Context("with a client List error", func() {
+			BeforeEach(func() {
+				dc.Spec.Topology.Enabled = true
+
+				c.EXPECT().List(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some-error"))
+			})
+
+			It("should return an error", func() {
+				Expect(r.ReconcileTopology(ctx, dc)).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("MakeTopologyContainer", func() {
+		It("should mount the features volume", func() {
+			container := MakeTopologyContainer()
+
+			Expect(container.VolumeMounts).To(HaveLen(1))
+			Expect(container.VolumeMounts[0].Name).To(Equal(MakeFeaturesVolume().Name))
+		})
+	})
+})
@@ -0,0 +1,276 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: rbac.go
+
+// Package rbac is a generated GoMock package.
+package rbac
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+	v1 "k8s.io/api/core/v1"
+	v10 "k8s.io/api/rbac/v1"
+)
+
+// MockReconciler is a mock of Reconciler interface.
+type MockReconciler struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconcilerMockRecorder
+}
+
+// MockReconcilerMockRecorder is the mock recorder for MockReconciler.
+type MockReconcilerMockRecorder struct {
+	mock *MockReconciler
+}
+
+// NewMockReconciler creates a new mock instance.
+func NewMockReconciler(ctrl *gomock.Controller) *MockReconciler {
+	mock := &MockReconciler{ctrl: ctrl}
+	mock.recorder = &MockReconcilerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciler) EXPECT() *MockReconcilerMockRecorder {
+	return m.recorder
+}
+
+// DeleteMetricsReaderRBAC mocks base method.
+func (m *MockReconciler) DeleteMetricsReaderRBAC(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMetricsReaderRBAC", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMetricsReaderRBAC indicates an expected call of DeleteMetricsReaderRBAC.
+func (mr *MockReconcilerMockRecorder) DeleteMetricsReaderRBAC(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMetricsReaderRBAC", reflect.TypeOf((*MockReconciler)(nil).DeleteMetricsReaderRBAC), ctx, dc)
+}
+
+// DeleteNodeMetricsClusterRBAC mocks base method.
+func (m *MockReconciler) DeleteNodeMetricsClusterRBAC(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNodeMetricsClusterRBAC", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNodeMetricsClusterRBAC indicates an expected call of DeleteNodeMetricsClusterRBAC.
+func (mr *MockReconcilerMockRecorder) DeleteNodeMetricsClusterRBAC(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNodeMetricsClusterRBAC", reflect.TypeOf((*MockReconciler)(nil).DeleteNodeMetricsClusterRBAC), ctx, dc)
+}
+
+// DeleteRBAC mocks base method.
+func (m *MockReconciler) DeleteRBAC(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRBAC", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRBAC indicates an expected call of DeleteRBAC.
+func (mr *MockReconcilerMockRecorder) DeleteRBAC(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRBAC", reflect.TypeOf((*MockReconciler)(nil).DeleteRBAC), ctx, dc)
+}
+
+// ReconcileMetricsReaderRBAC mocks base method.
+func (m *MockReconciler) ReconcileMetricsReaderRBAC(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileMetricsReaderRBAC", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileMetricsReaderRBAC indicates an expected call of ReconcileMetricsReaderRBAC.
+func (mr *MockReconcilerMockRecorder) ReconcileMetricsReaderRBAC(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileMetricsReaderRBAC", reflect.TypeOf((*MockReconciler)(nil).ReconcileMetricsReaderRBAC), ctx, dc)
+}
+
+// ReconcileNodeMetricsClusterRBAC mocks base method.
+func (m *MockReconciler) ReconcileNodeMetricsClusterRBAC(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileNodeMetricsClusterRBAC", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileNodeMetricsClusterRBAC indicates an expected call of ReconcileNodeMetricsClusterRBAC.
+func (mr *MockReconcilerMockRecorder) ReconcileNodeMetricsClusterRBAC(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileNodeMetricsClusterRBAC", reflect.TypeOf((*MockReconciler)(nil).ReconcileNodeMetricsClusterRBAC), ctx, dc)
+}
+
+// ReconcileRBAC mocks base method.
+func (m *MockReconciler) ReconcileRBAC(ctx context.Context, dc *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileRBAC", ctx, dc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileRBAC indicates an expected call of ReconcileRBAC.
+func (mr *MockReconcilerMockRecorder) ReconcileRBAC(ctx, dc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileRBAC", reflect.TypeOf((*MockReconciler)(nil).ReconcileRBAC), ctx, dc)
+}
+
+// ReconcileRole mocks base method.
+func (m *MockReconciler) ReconcileRole(ctx context.Context, dc *v1alpha1.DeviceConfig, component string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileRole", ctx, dc, component)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileRole indicates an expected call of ReconcileRole.
+func (mr *MockReconcilerMockRecorder) ReconcileRole(ctx, dc, component interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileRole", reflect.TypeOf((*MockReconciler)(nil).ReconcileRole), ctx, dc, component)
+}
+
+// ReconcileRoleBinding mocks base method.
+func (m *MockReconciler) ReconcileRoleBinding(ctx context.Context, dc *v1alpha1.DeviceConfig, component string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileRoleBinding", ctx, dc, component)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileRoleBinding indicates an expected call of ReconcileRoleBinding.
+func (mr *MockReconcilerMockRecorder) ReconcileRoleBinding(ctx, dc, component interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileRoleBinding", reflect.TypeOf((*MockReconciler)(nil).ReconcileRoleBinding), ctx, dc, component)
+}
+
+// ReconcileServiceAccount mocks base method.
+func (m *MockReconciler) ReconcileServiceAccount(ctx context.Context, dc *v1alpha1.DeviceConfig, component string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileServiceAccount", ctx, dc, component)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileServiceAccount indicates an expected call of ReconcileServiceAccount.
+func (mr *MockReconcilerMockRecorder) ReconcileServiceAccount(ctx, dc, component interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileServiceAccount", reflect.TypeOf((*MockReconciler)(nil).ReconcileServiceAccount), ctx, dc, component)
+}
+
+// SetDesiredMetricsReaderClusterRole mocks base method.
+func (m *MockReconciler) SetDesiredMetricsReaderClusterRole(role *v10.ClusterRole, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredMetricsReaderClusterRole", role, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredMetricsReaderClusterRole indicates an expected call of SetDesiredMetricsReaderClusterRole.
+func (mr *MockReconcilerMockRecorder) SetDesiredMetricsReaderClusterRole(role, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredMetricsReaderClusterRole", reflect.TypeOf((*MockReconciler)(nil).SetDesiredMetricsReaderClusterRole), role, cr)
+}
+
+// SetDesiredMetricsReaderClusterRoleBinding mocks base method.
+func (m *MockReconciler) SetDesiredMetricsReaderClusterRoleBinding(crb *v10.ClusterRoleBinding, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredMetricsReaderClusterRoleBinding", crb, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredMetricsReaderClusterRoleBinding indicates an expected call of SetDesiredMetricsReaderClusterRoleBinding.
+func (mr *MockReconcilerMockRecorder) SetDesiredMetricsReaderClusterRoleBinding(crb, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredMetricsReaderClusterRoleBinding", reflect.TypeOf((*MockReconciler)(nil).SetDesiredMetricsReaderClusterRoleBinding), crb, cr)
+}
+
+// SetDesiredMetricsReaderTokenSecret mocks base method.
+func (m *MockReconciler) SetDesiredMetricsReaderTokenSecret(secret *v1.Secret, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredMetricsReaderTokenSecret", secret, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredMetricsReaderTokenSecret indicates an expected call of SetDesiredMetricsReaderTokenSecret.
+func (mr *MockReconcilerMockRecorder) SetDesiredMetricsReaderTokenSecret(secret, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredMetricsReaderTokenSecret", reflect.TypeOf((*MockReconciler)(nil).SetDesiredMetricsReaderTokenSecret), secret, cr)
+}
+
+// SetDesiredNodeMetricsClusterRole mocks base method.
+func (m *MockReconciler) SetDesiredNodeMetricsClusterRole(role *v10.ClusterRole, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredNodeMetricsClusterRole", role, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredNodeMetricsClusterRole indicates an expected call of SetDesiredNodeMetricsClusterRole.
+func (mr *MockReconcilerMockRecorder) SetDesiredNodeMetricsClusterRole(role, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredNodeMetricsClusterRole", reflect.TypeOf((*MockReconciler)(nil).SetDesiredNodeMetricsClusterRole), role, cr)
+}
+
+// SetDesiredNodeMetricsClusterRoleBinding mocks base method.
+func (m *MockReconciler) SetDesiredNodeMetricsClusterRoleBinding(crb *v10.ClusterRoleBinding, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredNodeMetricsClusterRoleBinding", crb, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredNodeMetricsClusterRoleBinding indicates an expected call of SetDesiredNodeMetricsClusterRoleBinding.
+func (mr *MockReconcilerMockRecorder) SetDesiredNodeMetricsClusterRoleBinding(crb, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredNodeMetricsClusterRoleBinding", reflect.TypeOf((*MockReconciler)(nil).SetDesiredNodeMetricsClusterRoleBinding), crb, cr)
+}
+
+// SetDesiredRole mocks base method.
+func (m *MockReconciler) SetDesiredRole(role *v10.Role, cr *v1alpha1.DeviceConfig, component string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredRole", role, cr, component)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredRole indicates an expected call of SetDesiredRole.
+func (mr *MockReconcilerMockRecorder) SetDesiredRole(role, cr, component interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredRole", reflect.TypeOf((*MockReconciler)(nil).SetDesiredRole), role, cr, component)
+}
+
+// SetDesiredRoleBinding mocks base method.
+func (m *MockReconciler) SetDesiredRoleBinding(rb *v10.RoleBinding, cr *v1alpha1.DeviceConfig, component string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredRoleBinding", rb, cr, component)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredRoleBinding indicates an expected call of SetDesiredRoleBinding.
+func (mr *MockReconcilerMockRecorder) SetDesiredRoleBinding(rb, cr, component interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredRoleBinding", reflect.TypeOf((*MockReconciler)(nil).SetDesiredRoleBinding), rb, cr, component)
+}
+
+// SetDesiredServiceAccount mocks base method.
+func (m *MockReconciler) SetDesiredServiceAccount(sa *v1.ServiceAccount, cr *v1alpha1.DeviceConfig, component string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDesiredServiceAccount", sa, cr, component)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDesiredServiceAccount indicates an expected call of SetDesiredServiceAccount.
+func (mr *MockReconcilerMockRecorder) SetDesiredServiceAccount(sa, cr, component interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDesiredServiceAccount", reflect.TypeOf((*MockReconciler)(nil).SetDesiredServiceAccount), sa, cr, component)
+}
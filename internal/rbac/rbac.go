@@ -0,0 +1,671 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	ComponentDriver           = "driver"
+	ComponentDevicePlugin     = "device-plugin"
+	ComponentNodeMetrics      = "node-metrics"
+	ComponentNodeCleanup      = "node-cleanup"
+	ComponentCDI              = "cdi"
+	ComponentContainerRuntime = "container-runtime"
+	ComponentNetwork          = "network"
+	ComponentHugePages        = "hugepages"
+
+	// ComponentMetricsReader is the identity Prometheus authenticates with
+	// to the node metrics kube-rbac-proxy. It runs no pod, hence it is not
+	// part of the Components.
+	ComponentMetricsReader = "metrics-reader"
+
+	// MetricsReaderTokenKey is the key of the token in the metrics reader
+	// token Secret.
+	MetricsReaderTokenKey = corev1.ServiceAccountTokenKey
+
+	privilegedSCC = "privileged"
+)
+
+// Components lists the components that run with a dedicated ServiceAccount.
+var Components = []string{
+	ComponentDriver,
+	ComponentDevicePlugin,
+	ComponentNodeMetrics,
+	ComponentNodeCleanup,
+	ComponentCDI,
+	ComponentContainerRuntime,
+	ComponentNetwork,
+	ComponentHugePages,
+}
+
+//go:generate mockgen -source=rbac.go -package=rbac -destination=mock_rbac.go
+
+type Reconciler interface {
+	ReconcileRBAC(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	DeleteRBAC(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	ReconcileServiceAccount(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig, component string) error
+	SetDesiredServiceAccount(sa *corev1.ServiceAccount, cr *hlaiv1alpha1.DeviceConfig, component string) error
+	ReconcileRole(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig, component string) error
+	SetDesiredRole(role *rbacv1.Role, cr *hlaiv1alpha1.DeviceConfig, component string) error
+	ReconcileRoleBinding(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig, component string) error
+	SetDesiredRoleBinding(rb *rbacv1.RoleBinding, cr *hlaiv1alpha1.DeviceConfig, component string) error
+	ReconcileNodeMetricsClusterRBAC(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	DeleteNodeMetricsClusterRBAC(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredNodeMetricsClusterRole(role *rbacv1.ClusterRole, cr *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredNodeMetricsClusterRoleBinding(crb *rbacv1.ClusterRoleBinding, cr *hlaiv1alpha1.DeviceConfig) error
+	ReconcileMetricsReaderRBAC(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	DeleteMetricsReaderRBAC(ctx context.Context, dc *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredMetricsReaderTokenSecret(secret *corev1.Secret, cr *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredMetricsReaderClusterRole(role *rbacv1.ClusterRole, cr *hlaiv1alpha1.DeviceConfig) error
+	SetDesiredMetricsReaderClusterRoleBinding(crb *rbacv1.ClusterRoleBinding, cr *hlaiv1alpha1.DeviceConfig) error
+}
+
+type RBACReconciler struct {
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+func NewReconciler(c client.Client, s *runtime.Scheme) *RBACReconciler {
+	return &RBACReconciler{
+		client: c,
+		scheme: s,
+	}
+}
+
+// GetServiceAccountName returns the name of the ServiceAccount, Role and
+// RoleBinding used by the given component of the given DeviceConfig.
+func GetServiceAccountName(cr *hlaiv1alpha1.DeviceConfig, component string) string {
+	return fmt.Sprintf("%s-%s", cr.Name, component)
+}
+
+// GetClusterRoleName returns the name of the ClusterRole and ClusterRoleBinding
+// used by the given component of the given DeviceConfig. As they are cluster-scoped,
+// the name is prefixed with the operator name.
+func GetClusterRoleName(cr *hlaiv1alpha1.DeviceConfig, component string) string {
+	return fmt.Sprintf("%s-%s-%s", constants.HabanaAIOperatorName, cr.Name, component)
+}
+
+// GetMetricsReaderTokenSecretName returns the name of the Secret holding the
+// token of the metrics reader ServiceAccount of the given DeviceConfig.
+func GetMetricsReaderTokenSecretName(cr *hlaiv1alpha1.DeviceConfig) string {
+	return fmt.Sprintf("%s-token", GetServiceAccountName(cr, ComponentMetricsReader))
+}
+
+// IsComponentEnabled returns whether the given component runs on the nodes of
+// the DeviceConfig. The node cleanup Jobs run whenever nodes leave the
+// DeviceConfig, and the driver unless the HPUs are simulated. The device
+// plugin always runs, the simulated one registering the simulated HPUs.
+func IsComponentEnabled(cr *hlaiv1alpha1.DeviceConfig, component string) bool {
+	switch component {
+	case ComponentDriver, ComponentNodeMetrics:
+		return !cr.Spec.Simulation.Enabled
+	case ComponentCDI:
+		return cr.Spec.CDI.Enabled
+	case ComponentContainerRuntime:
+		return cr.Spec.ContainerRuntime.Enabled
+	case ComponentNetwork:
+		return cr.Spec.Network.Enabled
+	case ComponentHugePages:
+		return cr.Spec.HugePages.Count != nil
+	default:
+		return true
+	}
+}
+
+// ReconcileRBAC grants the permissions of the enabled components only, and
+// revokes the ones of the disabled components.
+func (r *RBACReconciler) ReconcileRBAC(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	for _, component := range Components {
+		if !IsComponentEnabled(cr, component) {
+			if err := r.deleteComponentRBAC(ctx, cr, component); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := r.ReconcileServiceAccount(ctx, cr, component); err != nil {
+			return err
+		}
+
+		if err := r.ReconcileRole(ctx, cr, component); err != nil {
+			return err
+		}
+
+		if err := r.ReconcileRoleBinding(ctx, cr, component); err != nil {
+			return err
+		}
+	}
+
+	if cr.Spec.NodeMetrics.Secure && IsComponentEnabled(cr, ComponentNodeMetrics) {
+		if err := r.ReconcileNodeMetricsClusterRBAC(ctx, cr); err != nil {
+			return err
+		}
+		return r.ReconcileMetricsReaderRBAC(ctx, cr)
+	}
+
+	if err := r.DeleteNodeMetricsClusterRBAC(ctx, cr); err != nil {
+		return err
+	}
+	return r.DeleteMetricsReaderRBAC(ctx, cr)
+}
+
+// ReconcileNodeMetricsClusterRBAC grants the node metrics kube-rbac-proxy the
+// permissions to authenticate and authorize the requests. TokenReviews and
+// SubjectAccessReviews are cluster-scoped, hence a ClusterRole is needed.
+func (r *RBACReconciler) ReconcileNodeMetricsClusterRBAC(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	logger := log.FromContext(ctx)
+
+	name := GetClusterRoleName(cr, ComponentNodeMetrics)
+
+	existingRole := &rbacv1.ClusterRole{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: name}, existingRole)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+
+	if exists {
+		role = existingRole
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, role, func() error {
+		return r.SetDesiredNodeMetricsClusterRole(role, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch ClusterRole: %v", err)
+	}
+
+	logger.Info("Reconciled ClusterRole", "resource", role.Name, "result", res)
+
+	existingCRB := &rbacv1.ClusterRoleBinding{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: name}, existingCRB)
+	exists = !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	crb := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+
+	if exists {
+		crb = existingCRB
+	}
+
+	res, err = controllerutil.CreateOrPatch(ctx, r.client, crb, func() error {
+		return r.SetDesiredNodeMetricsClusterRoleBinding(crb, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch ClusterRoleBinding: %v", err)
+	}
+
+	logger.Info("Reconciled ClusterRoleBinding", "resource", crb.Name, "result", res)
+
+	return nil
+}
+
+func (r *RBACReconciler) DeleteNodeMetricsClusterRBAC(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	objs := []client.Object{
+		&rbacv1.ClusterRoleBinding{},
+		&rbacv1.ClusterRole{},
+	}
+
+	for _, o := range objs {
+		o.SetName(GetClusterRoleName(cr, ComponentNodeMetrics))
+
+		err := r.client.Delete(ctx, o)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %T %s: %w", o, o.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// ReconcileMetricsReaderRBAC creates the ServiceAccount Prometheus scrapes the
+// secured node metrics with, along with a long-lived token Secret referenced
+// by the ServiceMonitor, and grants it the read of the metrics.
+func (r *RBACReconciler) ReconcileMetricsReaderRBAC(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	logger := log.FromContext(ctx)
+
+	if err := r.ReconcileServiceAccount(ctx, cr, ComponentMetricsReader); err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetMetricsReaderTokenSecretName(cr),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, secret, func() error {
+		return r.SetDesiredMetricsReaderTokenSecret(secret, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch Secret: %v", err)
+	}
+
+	logger.Info("Reconciled Secret", "resource", secret.Name, "result", res)
+
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: GetClusterRoleName(cr, ComponentMetricsReader),
+		},
+	}
+
+	res, err = controllerutil.CreateOrPatch(ctx, r.client, role, func() error {
+		return r.SetDesiredMetricsReaderClusterRole(role, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch ClusterRole: %v", err)
+	}
+
+	logger.Info("Reconciled ClusterRole", "resource", role.Name, "result", res)
+
+	crb := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: GetClusterRoleName(cr, ComponentMetricsReader),
+		},
+	}
+
+	res, err = controllerutil.CreateOrPatch(ctx, r.client, crb, func() error {
+		return r.SetDesiredMetricsReaderClusterRoleBinding(crb, cr)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch ClusterRoleBinding: %v", err)
+	}
+
+	logger.Info("Reconciled ClusterRoleBinding", "resource", crb.Name, "result", res)
+
+	return nil
+}
+
+func (r *RBACReconciler) DeleteMetricsReaderRBAC(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	objs := []client.Object{
+		&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: GetClusterRoleName(cr, ComponentMetricsReader)}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: GetClusterRoleName(cr, ComponentMetricsReader)}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      GetMetricsReaderTokenSecretName(cr),
+			Namespace: s.Settings.OperatorNamespace,
+		}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+			Name:      GetServiceAccountName(cr, ComponentMetricsReader),
+			Namespace: s.Settings.OperatorNamespace,
+		}},
+	}
+
+	for _, o := range objs {
+		err := r.client.Delete(ctx, o)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %T %s: %w", o, o.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+func (r *RBACReconciler) ReconcileServiceAccount(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, component string) error {
+	logger := log.FromContext(ctx)
+
+	existingSA := &corev1.ServiceAccount{}
+	err := r.client.Get(ctx, types.NamespacedName{
+		Namespace: s.Settings.OperatorNamespace,
+		Name:      GetServiceAccountName(cr, component),
+	}, existingSA)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetServiceAccountName(cr, component),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	if exists {
+		sa = existingSA
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, sa, func() error {
+		return r.SetDesiredServiceAccount(sa, cr, component)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch ServiceAccount: %v", err)
+	}
+
+	logger.Info("Reconciled ServiceAccount", "resource", sa.Name, "result", res)
+
+	return nil
+}
+
+func (r *RBACReconciler) ReconcileRole(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, component string) error {
+	logger := log.FromContext(ctx)
+
+	existingRole := &rbacv1.Role{}
+	err := r.client.Get(ctx, types.NamespacedName{
+		Namespace: s.Settings.OperatorNamespace,
+		Name:      GetServiceAccountName(cr, component),
+	}, existingRole)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetServiceAccountName(cr, component),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	if exists {
+		role = existingRole
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, role, func() error {
+		return r.SetDesiredRole(role, cr, component)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch Role: %v", err)
+	}
+
+	logger.Info("Reconciled Role", "resource", role.Name, "result", res)
+
+	return nil
+}
+
+func (r *RBACReconciler) ReconcileRoleBinding(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, component string) error {
+	logger := log.FromContext(ctx)
+
+	existingRB := &rbacv1.RoleBinding{}
+	err := r.client.Get(ctx, types.NamespacedName{
+		Namespace: s.Settings.OperatorNamespace,
+		Name:      GetServiceAccountName(cr, component),
+	}, existingRB)
+	exists := !apierrors.IsNotFound(err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	rb := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetServiceAccountName(cr, component),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	if exists {
+		rb = existingRB
+	}
+
+	res, err := controllerutil.CreateOrPatch(ctx, r.client, rb, func() error {
+		return r.SetDesiredRoleBinding(rb, cr, component)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not create or patch RoleBinding: %v", err)
+	}
+
+	logger.Info("Reconciled RoleBinding", "resource", rb.Name, "result", res)
+
+	return nil
+}
+
+func (r *RBACReconciler) DeleteRBAC(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	for _, component := range Components {
+		if err := r.deleteComponentRBAC(ctx, cr, component); err != nil {
+			return err
+		}
+	}
+
+	if err := r.DeleteNodeMetricsClusterRBAC(ctx, cr); err != nil {
+		return err
+	}
+
+	return r.DeleteMetricsReaderRBAC(ctx, cr)
+}
+
+// deleteComponentRBAC deletes the ServiceAccount, Role and RoleBinding of the
+// given component.
+func (r *RBACReconciler) deleteComponentRBAC(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig, component string) error {
+	objs := []client.Object{
+		&rbacv1.RoleBinding{},
+		&rbacv1.Role{},
+		&corev1.ServiceAccount{},
+	}
+
+	for _, o := range objs {
+		o.SetName(GetServiceAccountName(cr, component))
+		o.SetNamespace(s.Settings.OperatorNamespace)
+
+		err := r.client.Delete(ctx, o)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %T %s: %w", o, o.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+func (r *RBACReconciler) SetDesiredServiceAccount(sa *corev1.ServiceAccount, cr *hlaiv1alpha1.DeviceConfig, component string) error {
+	if sa == nil {
+		return errors.New("serviceaccount cannot be nil")
+	}
+
+	sa.ObjectMeta.Labels = labelsForRBAC(cr, component)
+
+	return nil
+}
+
+// SetDesiredRole grants the component the permissions it needs in the
+// operator namespace. All the Habana components run privileged pods, which
+// on OpenShift requires the use of the privileged SecurityContextConstraints.
+// The rule is inert on clusters that do not serve the security.openshift.io API.
+func (r *RBACReconciler) SetDesiredRole(role *rbacv1.Role, cr *hlaiv1alpha1.DeviceConfig, component string) error {
+	if role == nil {
+		return errors.New("role cannot be nil")
+	}
+
+	role.ObjectMeta.Labels = labelsForRBAC(cr, component)
+
+	role.Rules = []rbacv1.PolicyRule{
+		{
+			APIGroups:     []string{"security.openshift.io"},
+			Resources:     []string{"securitycontextconstraints"},
+			ResourceNames: []string{privilegedSCC},
+			Verbs:         []string{"use"},
+		},
+	}
+
+	return nil
+}
+
+func (r *RBACReconciler) SetDesiredRoleBinding(rb *rbacv1.RoleBinding, cr *hlaiv1alpha1.DeviceConfig, component string) error {
+	if rb == nil {
+		return errors.New("rolebinding cannot be nil")
+	}
+
+	rb.ObjectMeta.Labels = labelsForRBAC(cr, component)
+
+	rb.RoleRef = rbacv1.RoleRef{
+		APIGroup: rbacv1.GroupName,
+		Kind:     "Role",
+		Name:     GetServiceAccountName(cr, component),
+	}
+
+	rb.Subjects = []rbacv1.Subject{
+		{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      GetServiceAccountName(cr, component),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	return nil
+}
+
+func (r *RBACReconciler) SetDesiredNodeMetricsClusterRole(role *rbacv1.ClusterRole, cr *hlaiv1alpha1.DeviceConfig) error {
+	if role == nil {
+		return errors.New("clusterrole cannot be nil")
+	}
+
+	role.ObjectMeta.Labels = labelsForRBAC(cr, ComponentNodeMetrics)
+
+	role.Rules = []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{"authentication.k8s.io"},
+			Resources: []string{"tokenreviews"},
+			Verbs:     []string{"create"},
+		},
+		{
+			APIGroups: []string{"authorization.k8s.io"},
+			Resources: []string{"subjectaccessreviews"},
+			Verbs:     []string{"create"},
+		},
+	}
+
+	return nil
+}
+
+func (r *RBACReconciler) SetDesiredNodeMetricsClusterRoleBinding(crb *rbacv1.ClusterRoleBinding, cr *hlaiv1alpha1.DeviceConfig) error {
+	if crb == nil {
+		return errors.New("clusterrolebinding cannot be nil")
+	}
+
+	crb.ObjectMeta.Labels = labelsForRBAC(cr, ComponentNodeMetrics)
+
+	crb.RoleRef = rbacv1.RoleRef{
+		APIGroup: rbacv1.GroupName,
+		Kind:     "ClusterRole",
+		Name:     GetClusterRoleName(cr, ComponentNodeMetrics),
+	}
+
+	crb.Subjects = []rbacv1.Subject{
+		{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      GetServiceAccountName(cr, ComponentNodeMetrics),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	return nil
+}
+
+// SetDesiredMetricsReaderTokenSecret requests a token of the metrics reader
+// ServiceAccount, which the token controller stores in the Secret.
+func (r *RBACReconciler) SetDesiredMetricsReaderTokenSecret(secret *corev1.Secret, cr *hlaiv1alpha1.DeviceConfig) error {
+	if secret == nil {
+		return errors.New("secret cannot be nil")
+	}
+
+	secret.ObjectMeta.Labels = labelsForRBAC(cr, ComponentMetricsReader)
+
+	if secret.ObjectMeta.Annotations == nil {
+		secret.ObjectMeta.Annotations = map[string]string{}
+	}
+	secret.ObjectMeta.Annotations[corev1.ServiceAccountNameKey] = GetServiceAccountName(cr, ComponentMetricsReader)
+
+	secret.Type = corev1.SecretTypeServiceAccountToken
+
+	return nil
+}
+
+// SetDesiredMetricsReaderClusterRole grants the read of the metrics, which
+// kube-rbac-proxy authorizes as a non-resource URL.
+func (r *RBACReconciler) SetDesiredMetricsReaderClusterRole(role *rbacv1.ClusterRole, cr *hlaiv1alpha1.DeviceConfig) error {
+	if role == nil {
+		return errors.New("clusterrole cannot be nil")
+	}
+
+	role.ObjectMeta.Labels = labelsForRBAC(cr, ComponentMetricsReader)
+
+	role.Rules = []rbacv1.PolicyRule{
+		{
+			NonResourceURLs: []string{"/metrics"},
+			Verbs:           []string{"get"},
+		},
+	}
+
+	return nil
+}
+
+func (r *RBACReconciler) SetDesiredMetricsReaderClusterRoleBinding(crb *rbacv1.ClusterRoleBinding, cr *hlaiv1alpha1.DeviceConfig) error {
+	if crb == nil {
+		return errors.New("clusterrolebinding cannot be nil")
+	}
+
+	crb.ObjectMeta.Labels = labelsForRBAC(cr, ComponentMetricsReader)
+
+	crb.RoleRef = rbacv1.RoleRef{
+		APIGroup: rbacv1.GroupName,
+		Kind:     "ClusterRole",
+		Name:     GetClusterRoleName(cr, ComponentMetricsReader),
+	}
+
+	crb.Subjects = []rbacv1.Subject{
+		{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      GetServiceAccountName(cr, ComponentMetricsReader),
+			Namespace: s.Settings.OperatorNamespace,
+		},
+	}
+
+	return nil
+}
+
+// labelsForRBAC returns the labels for selecting the
+// resources belonging to the given DeviceConfig CR name.
+func labelsForRBAC(cr *hlaiv1alpha1.DeviceConfig, component string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      constants.HabanaAIOperatorName,
+		"app.kubernetes.io/component": component,
+		constants.DeviceConfigLabel:   cr.Name,
+	}
+}
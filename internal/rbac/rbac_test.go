@@ -0,0 +1,310 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"context"
+	"errors"
+
+	gomock "github.com/golang/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/client"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+)
+
+var _ = Describe("RBACReconciler", func() {
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		r   *RBACReconciler
+		c   *client.MockClient
+		ctx context.Context
+	)
+
+	BeforeEach(func() {
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+		}
+		c = client.NewMockClient(gomock.NewController(GinkgoT()))
+
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+
+		r = NewReconciler(c, s)
+
+		ctx = context.TODO()
+	})
+
+	Describe("ReconcileRBAC", func() {
+		Context("with no client error", func() {
+			BeforeEach(func() {
+				c.EXPECT().
+					Get(ctx, gomock.Any(), gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{}, "")).
+					AnyTimes()
+				// The driver, device plugin, node metrics and node cleanup
+				// components are enabled by default.
+				c.EXPECT().Create(ctx, gomock.Any()).Return(nil).Times(3 * 4)
+				c.EXPECT().
+					Delete(ctx, gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{}, "")).
+					Times(3*(len(Components)-4) + 6)
+			})
+
+			It("should not return an error", func() {
+				Expect(r.ReconcileRBAC(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with the CDI enabled", func() {
+			var created []string
+
+			BeforeEach(func() {
+				dc.Spec.CDI.Enabled = true
+				created = nil
+
+				c.EXPECT().
+					Get(ctx, gomock.Any(), gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{}, "")).
+					AnyTimes()
+				c.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, o ctrlclient.Object, _ ...ctrlclient.CreateOption) error {
+						created = append(created, o.GetName())
+						return nil
+					},
+				).Times(3 * 5)
+				c.EXPECT().
+					Delete(ctx, gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{}, "")).
+					Times(3*(len(Components)-5) + 6)
+			})
+
+			It("should only grant the permissions of the enabled components", func() {
+				Expect(r.ReconcileRBAC(ctx, dc)).ToNot(HaveOccurred())
+				Expect(created).To(ContainElement(GetServiceAccountName(dc, ComponentCDI)))
+				Expect(created).ToNot(ContainElement(GetServiceAccountName(dc, ComponentNetwork)))
+			})
+		})
+
+		Context("with the simulation enabled", func() {
+			BeforeEach(func() {
+				dc.Spec.Simulation.Enabled = true
+				dc.Spec.NodeMetrics.Secure = true
+
+				c.EXPECT().
+					Get(ctx, gomock.Any(), gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{}, "")).
+					AnyTimes()
+				c.EXPECT().Create(ctx, gomock.Any()).Return(nil).Times(6)
+				c.EXPECT().
+					Delete(ctx, gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{}, "")).
+					Times(3*(len(Components)-2) + 6)
+			})
+
+			It("should only grant the permissions of the node cleanup and the simulated device plugin", func() {
+				Expect(r.ReconcileRBAC(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with secured node metrics", func() {
+			BeforeEach(func() {
+				dc.Spec.NodeMetrics.Secure = true
+
+				c.EXPECT().
+					Get(ctx, gomock.Any(), gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{}, "")).
+					AnyTimes()
+				c.EXPECT().Create(ctx, gomock.Any()).Return(nil).Times(3*4 + 6)
+				c.EXPECT().
+					Delete(ctx, gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{}, "")).
+					Times(3 * (len(Components) - 4))
+			})
+
+			It("should create the node metrics and metrics reader cluster RBAC", func() {
+				Expect(r.ReconcileRBAC(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with client Create error", func() {
+			BeforeEach(func() {
+				c.EXPECT().
+					Get(ctx, gomock.Any(), gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{}, "")).
+					AnyTimes()
+				c.EXPECT().Create(ctx, gomock.Any()).Return(errors.New("some-error"))
+			})
+
+			It("should return an error", func() {
+				Expect(r.ReconcileRBAC(ctx, dc)).To(HaveOccurred())
+			})
+		})
+
+		Context("with client Get error", func() {
+			BeforeEach(func() {
+				c.EXPECT().Get(ctx, gomock.Any(), gomock.Any()).Return(errors.New("some-other-that-not-found-error"))
+			})
+
+			It("should return an error", func() {
+				Expect(r.ReconcileRBAC(ctx, dc)).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("DeleteRBAC", func() {
+		Context("with NotFound client Delete errors", func() {
+			BeforeEach(func() {
+				c.EXPECT().
+					Delete(ctx, gomock.Any()).
+					Return(apierrors.NewNotFound(schema.GroupResource{}, "")).
+					Times(3*len(Components) + 6)
+			})
+
+			It("should not return an error", func() {
+				Expect(r.DeleteRBAC(ctx, dc)).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("with a generic client Delete error", func() {
+			BeforeEach(func() {
+				c.EXPECT().Delete(ctx, gomock.Any()).Return(errors.New("some-error"))
+			})
+
+			It("should return an error", func() {
+				Expect(r.DeleteRBAC(ctx, dc)).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("SetDesiredServiceAccount", func() {
+		It("should return an error with a nil ServiceAccount as input", func() {
+			err := r.SetDesiredServiceAccount(nil, dc, ComponentDriver)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("serviceaccount cannot be nil"))
+		})
+
+		It("should label the ServiceAccount with the DeviceConfig name", func() {
+			sa := &corev1.ServiceAccount{}
+			Expect(r.SetDesiredServiceAccount(sa, dc, ComponentDriver)).ToNot(HaveOccurred())
+			Expect(sa.Labels).To(HaveKeyWithValue(constants.DeviceConfigLabel, dc.Name))
+		})
+	})
+
+	Describe("SetDesiredRole", func() {
+		It("should return an error with a nil Role as input", func() {
+			err := r.SetDesiredRole(nil, dc, ComponentDriver)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("role cannot be nil"))
+		})
+
+		It("should only grant the use of the privileged SCC", func() {
+			role := &rbacv1.Role{}
+			Expect(r.SetDesiredRole(role, dc, ComponentDriver)).ToNot(HaveOccurred())
+			Expect(role.Rules).To(HaveLen(1))
+			Expect(role.Rules[0].Resources).To(Equal([]string{"securitycontextconstraints"}))
+			Expect(role.Rules[0].ResourceNames).To(Equal([]string{privilegedSCC}))
+			Expect(role.Rules[0].Verbs).To(Equal([]string{"use"}))
+		})
+	})
+
+	Describe("SetDesiredRoleBinding", func() {
+		It("should return an error with a nil RoleBinding as input", func() {
+			err := r.SetDesiredRoleBinding(nil, dc, ComponentDriver)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("rolebinding cannot be nil"))
+		})
+
+		It("should bind the component Role to the component ServiceAccount", func() {
+			rb := &rbacv1.RoleBinding{}
+			Expect(r.SetDesiredRoleBinding(rb, dc, ComponentNodeMetrics)).ToNot(HaveOccurred())
+			Expect(rb.RoleRef.Kind).To(Equal("Role"))
+			Expect(rb.RoleRef.Name).To(Equal(GetServiceAccountName(dc, ComponentNodeMetrics)))
+			Expect(rb.Subjects).To(HaveLen(1))
+			Expect(rb.Subjects[0].Name).To(Equal(GetServiceAccountName(dc, ComponentNodeMetrics)))
+		})
+	})
+
+	Describe("SetDesiredMetricsReaderTokenSecret", func() {
+		It("should return an error with a nil Secret as input", func() {
+			err := r.SetDesiredMetricsReaderTokenSecret(nil, dc)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("secret cannot be nil"))
+		})
+
+		It("should request a token of the metrics reader ServiceAccount", func() {
+			secret := &corev1.Secret{}
+			Expect(r.SetDesiredMetricsReaderTokenSecret(secret, dc)).ToNot(HaveOccurred())
+			Expect(secret.Type).To(Equal(corev1.SecretTypeServiceAccountToken))
+			Expect(secret.Annotations).To(HaveKeyWithValue(corev1.ServiceAccountNameKey, GetServiceAccountName(dc, ComponentMetricsReader)))
+		})
+	})
+
+	Describe("SetDesiredMetricsReaderClusterRole", func() {
+		It("should only grant the read of the metrics", func() {
+			role := &rbacv1.ClusterRole{}
+			Expect(r.SetDesiredMetricsReaderClusterRole(role, dc)).ToNot(HaveOccurred())
+			Expect(role.Rules).To(HaveLen(1))
+			Expect(role.Rules[0].NonResourceURLs).To(Equal([]string{"/metrics"}))
+			Expect(role.Rules[0].Verbs).To(Equal([]string{"get"}))
+		})
+	})
+
+	Describe("SetDesiredNodeMetricsClusterRole", func() {
+		It("should return an error with a nil ClusterRole as input", func() {
+			err := r.SetDesiredNodeMetricsClusterRole(nil, dc)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("clusterrole cannot be nil"))
+		})
+
+		It("should only grant the creation of TokenReviews and SubjectAccessReviews", func() {
+			role := &rbacv1.ClusterRole{}
+			Expect(r.SetDesiredNodeMetricsClusterRole(role, dc)).ToNot(HaveOccurred())
+			Expect(role.Rules).To(HaveLen(2))
+			Expect(role.Rules[0].Resources).To(Equal([]string{"tokenreviews"}))
+			Expect(role.Rules[1].Resources).To(Equal([]string{"subjectaccessreviews"}))
+			Expect(role.Rules[0].Verbs).To(Equal([]string{"create"}))
+			Expect(role.Rules[1].Verbs).To(Equal([]string{"create"}))
+		})
+	})
+
+	Describe("SetDesiredNodeMetricsClusterRoleBinding", func() {
+		It("should return an error with a nil ClusterRoleBinding as input", func() {
+			err := r.SetDesiredNodeMetricsClusterRoleBinding(nil, dc)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("clusterrolebinding cannot be nil"))
+		})
+
+		It("should bind the ClusterRole to the node metrics ServiceAccount", func() {
+			crb := &rbacv1.ClusterRoleBinding{}
+			Expect(r.SetDesiredNodeMetricsClusterRoleBinding(crb, dc)).ToNot(HaveOccurred())
+			Expect(crb.RoleRef.Kind).To(Equal("ClusterRole"))
+			Expect(crb.RoleRef.Name).To(Equal(GetClusterRoleName(dc, ComponentNodeMetrics)))
+			Expect(crb.Subjects).To(HaveLen(1))
+			Expect(crb.Subjects[0].Name).To(Equal(GetServiceAccountName(dc, ComponentNodeMetrics)))
+		})
+	})
+})
@@ -20,10 +20,15 @@ import (
 )
 
 const (
-	DevicePluginImageEnvVar         = "DEVICE_PLUGIN_IMAGE"
-	DriverHabanaImageBasenameEnvVar = "DRIVER_HABANA_IMAGE_BASENAME"
-	NodeMetricsImageEnvVar          = "NODE_METRICS_IMAGE"
-	NodeLabelerImageEnvVar          = "NODE_LABELER_IMAGE"
+	ContainerRuntimeImageEnvVar       = "CONTAINER_RUNTIME_IMAGE"
+	DevicePluginImageEnvVar           = "DEVICE_PLUGIN_IMAGE"
+	DriverHabanaImageBasenameEnvVar   = "DRIVER_HABANA_IMAGE_BASENAME"
+	KubeRBACProxyImageEnvVar          = "KUBE_RBAC_PROXY_IMAGE"
+	NetworkImageEnvVar                = "NETWORK_IMAGE"
+	NodeCleanupImageEnvVar            = "NODE_CLEANUP_IMAGE"
+	NodeMetricsImageEnvVar            = "NODE_METRICS_IMAGE"
+	OperatorNamespaceEnvVar           = "OPERATOR_NAMESPACE"
+	SimulationDevicePluginImageEnvVar = "SIMULATION_DEVICE_PLUGIN_IMAGE"
 )
 
 var (
@@ -33,16 +38,26 @@ var (
 var Settings = ControllerSettings{}
 
 type ControllerSettings struct {
-	DevicePluginImage         string
-	DriverHabanaImageBasename string
-	NodeMetricsImage          string
-	NodeLabelerImage          string
+	ContainerRuntimeImage       string
+	DevicePluginImage           string
+	DriverHabanaImageBasename   string
+	KubeRBACProxyImage          string
+	NetworkImage                string
+	NodeCleanupImage            string
+	NodeMetricsImage            string
+	OperatorNamespace           string
+	SimulationDevicePluginImage string
 }
 
 func (r *ControllerSettings) Load() error {
 	errs := []error{}
 	var found bool
 
+	r.ContainerRuntimeImage, found = os.LookupEnv(ContainerRuntimeImageEnvVar)
+	if !found {
+		errs = append(errs, fmt.Errorf("%v: %w", ContainerRuntimeImageEnvVar, errEnvVarNotSet))
+	}
+
 	r.DevicePluginImage, found = os.LookupEnv(DevicePluginImageEnvVar)
 	if !found {
 		errs = append(errs, fmt.Errorf("%v: %w", DevicePluginImageEnvVar, errEnvVarNotSet))
@@ -53,14 +68,34 @@ func (r *ControllerSettings) Load() error {
 		errs = append(errs, fmt.Errorf("%v: %w", DriverHabanaImageBasenameEnvVar, errEnvVarNotSet))
 	}
 
+	r.KubeRBACProxyImage, found = os.LookupEnv(KubeRBACProxyImageEnvVar)
+	if !found {
+		errs = append(errs, fmt.Errorf("%v: %w", KubeRBACProxyImageEnvVar, errEnvVarNotSet))
+	}
+
+	r.NetworkImage, found = os.LookupEnv(NetworkImageEnvVar)
+	if !found {
+		errs = append(errs, fmt.Errorf("%v: %w", NetworkImageEnvVar, errEnvVarNotSet))
+	}
+
+	r.NodeCleanupImage, found = os.LookupEnv(NodeCleanupImageEnvVar)
+	if !found {
+		errs = append(errs, fmt.Errorf("%v: %w", NodeCleanupImageEnvVar, errEnvVarNotSet))
+	}
+
 	r.NodeMetricsImage, found = os.LookupEnv(NodeMetricsImageEnvVar)
 	if !found {
 		errs = append(errs, fmt.Errorf("%v: %w", NodeMetricsImageEnvVar, errEnvVarNotSet))
 	}
 
-	r.NodeLabelerImage, found = os.LookupEnv(NodeLabelerImageEnvVar)
+	r.OperatorNamespace, found = os.LookupEnv(OperatorNamespaceEnvVar)
+	if !found {
+		errs = append(errs, fmt.Errorf("%v: %w", OperatorNamespaceEnvVar, errEnvVarNotSet))
+	}
+
+	r.SimulationDevicePluginImage, found = os.LookupEnv(SimulationDevicePluginImageEnvVar)
 	if !found {
-		errs = append(errs, fmt.Errorf("%v: %w", NodeLabelerImageEnvVar, errEnvVarNotSet))
+		errs = append(errs, fmt.Errorf("%v: %w", SimulationDevicePluginImageEnvVar, errEnvVarNotSet))
 	}
 
 	if len(errs) > 0 {
@@ -27,10 +27,15 @@ func TestControllerSettings_Load(t *testing.T) {
 	defer cleanupTestEnv(env)
 
 	expectedCS := &ControllerSettings{
-		DevicePluginImage:         env["DEVICE_PLUGIN_IMAGE"],
-		DriverHabanaImageBasename: env["DRIVER_HABANA_IMAGE_BASENAME"],
-		NodeMetricsImage:          env["NODE_METRICS_IMAGE"],
-		NodeLabelerImage:          env["NODE_LABELER_IMAGE"],
+		ContainerRuntimeImage:       env["CONTAINER_RUNTIME_IMAGE"],
+		DevicePluginImage:           env["DEVICE_PLUGIN_IMAGE"],
+		DriverHabanaImageBasename:   env["DRIVER_HABANA_IMAGE_BASENAME"],
+		KubeRBACProxyImage:          env["KUBE_RBAC_PROXY_IMAGE"],
+		NetworkImage:                env["NETWORK_IMAGE"],
+		NodeCleanupImage:            env["NODE_CLEANUP_IMAGE"],
+		NodeMetricsImage:            env["NODE_METRICS_IMAGE"],
+		OperatorNamespace:           env["OPERATOR_NAMESPACE"],
+		SimulationDevicePluginImage: env["SIMULATION_DEVICE_PLUGIN_IMAGE"],
 	}
 
 	cs := &ControllerSettings{}
@@ -69,16 +74,26 @@ func TestControllerSettings_Load_withMultipleEnvVarsMissing(t *testing.T) {
 	tests := []struct {
 		missingEnvVars []string
 	}{
+		{missingEnvVars: []string{"CONTAINER_RUNTIME_IMAGE"}},
 		{missingEnvVars: []string{"DEVICE_PLUGIN_IMAGE"}},
 		{missingEnvVars: []string{"DRIVER_HABANA_IMAGE_BASENAME"}},
+		{missingEnvVars: []string{"KUBE_RBAC_PROXY_IMAGE"}},
+		{missingEnvVars: []string{"NETWORK_IMAGE"}},
+		{missingEnvVars: []string{"NODE_CLEANUP_IMAGE"}},
 		{missingEnvVars: []string{"NODE_METRICS_IMAGE"}},
-		{missingEnvVars: []string{"NODE_LABELER_IMAGE"}},
+		{missingEnvVars: []string{"OPERATOR_NAMESPACE"}},
+		{missingEnvVars: []string{"SIMULATION_DEVICE_PLUGIN_IMAGE"}},
 		{
 			missingEnvVars: []string{
+				"CONTAINER_RUNTIME_IMAGE",
 				"DEVICE_PLUGIN_IMAGE",
 				"DRIVER_HABANA_IMAGE_BASENAME",
+				"KUBE_RBAC_PROXY_IMAGE",
+				"NETWORK_IMAGE",
+				"NODE_CLEANUP_IMAGE",
 				"NODE_METRICS_IMAGE",
-				"NODE_LABELER_IMAGE",
+				"OPERATOR_NAMESPACE",
+				"SIMULATION_DEVICE_PLUGIN_IMAGE",
 			},
 		},
 	}
@@ -108,10 +123,15 @@ func TestControllerSettings_Load_withMultipleEnvVarsMissing(t *testing.T) {
 
 func getCompleteEnv() map[string]string {
 	return map[string]string{
-		"DEVICE_PLUGIN_IMAGE":          "device plugin image",
-		"DRIVER_HABANA_IMAGE_BASENAME": "driver habana image basename",
-		"NODE_METRICS_IMAGE":           "node metrics image",
-		"NODE_LABELER_IMAGE":           "node labeler image",
+		"CONTAINER_RUNTIME_IMAGE":        "container runtime image",
+		"DEVICE_PLUGIN_IMAGE":            "device plugin image",
+		"DRIVER_HABANA_IMAGE_BASENAME":   "driver habana image basename",
+		"KUBE_RBAC_PROXY_IMAGE":          "kube rbac proxy image",
+		"NETWORK_IMAGE":                  "network image",
+		"NODE_CLEANUP_IMAGE":             "node cleanup image",
+		"NODE_METRICS_IMAGE":             "node metrics image",
+		"OPERATOR_NAMESPACE":             "operator namespace",
+		"SIMULATION_DEVICE_PLUGIN_IMAGE": "simulation device plugin image",
 	}
 }
 
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: telemetry.go
+
+// Package telemetry is a generated GoMock package.
+package telemetry
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCollector is a mock of Collector interface.
+type MockCollector struct {
+	ctrl     *gomock.Controller
+	recorder *MockCollectorMockRecorder
+}
+
+// MockCollectorMockRecorder is the mock recorder for MockCollector.
+type MockCollectorMockRecorder struct {
+	mock *MockCollector
+}
+
+// NewMockCollector creates a new mock instance.
+func NewMockCollector(ctrl *gomock.Controller) *MockCollector {
+	mock := &MockCollector{ctrl: ctrl}
+	mock.recorder = &MockCollectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCollector) EXPECT() *MockCollectorMockRecorder {
+	return m.recorder
+}
+
+// CollectTelemetry mocks base method.
+func (m *MockCollector) CollectTelemetry(ctx context.Context, cr *v1alpha1.DeviceConfig) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CollectTelemetry", ctx, cr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CollectTelemetry indicates an expected call of CollectTelemetry.
+func (mr *MockCollectorMockRecorder) CollectTelemetry(ctx, cr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CollectTelemetry", reflect.TypeOf((*MockCollector)(nil).CollectTelemetry), ctx, cr)
+}
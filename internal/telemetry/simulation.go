@@ -0,0 +1,106 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/simulation"
+)
+
+const (
+	// simulatedMemoryTotalBytes is the memory of a simulated HPU, the 96GB
+	// of a Gaudi2.
+	simulatedMemoryTotalBytes = 96 << 30
+
+	simulatedMinTemperature   = 35
+	simulatedTemperatureRange = 30
+)
+
+// simulateNodes returns the telemetry of the nodes with the simulated HPUs of
+// the DeviceConfig, summarized from synthetic metrics.
+func (c *collector) simulateNodes(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) ([]hlaiv1alpha1.NodeTelemetry, error) {
+	nodeList := &corev1.NodeList{}
+	if err := c.client.List(ctx, nodeList, client.MatchingLabels{simulation.SimulatedLabel: cr.Name}); err != nil {
+		return nil, fmt.Errorf("could not list the simulated nodes: %w", err)
+	}
+
+	nodes := make([]hlaiv1alpha1.NodeTelemetry, 0, len(nodeList.Items))
+	for i := range nodeList.Items {
+		name := nodeList.Items[i].Name
+
+		nt := Summarize(name, simulatedMetricFamilies(name, cr.GetSimulationDevicesPerNode()))
+		nt.ExceededThresholds = exceededThresholds(cr, &nt)
+		nodes = append(nodes, nt)
+	}
+
+	return nodes, nil
+}
+
+// simulatedMetricFamilies returns the synthetic metrics of the simulated HPUs
+// of a node, in the format of the node metrics exporter. The values are
+// derived from the name of the node and the index of the HPU, so that they
+// differ between the HPUs but are stable across the collections.
+func simulatedMetricFamilies(nodeName string, devices int32) map[string]*dto.MetricFamily {
+	gauge := func(name string) *dto.MetricFamily {
+		return &dto.MetricFamily{
+			Name: pointer.String(name),
+			Type: dto.MetricType_GAUGE.Enum(),
+		}
+	}
+
+	families := map[string]*dto.MetricFamily{
+		temperatureMetric: gauge(temperatureMetric),
+		utilizationMetric: gauge(utilizationMetric),
+		memoryUsedMetric:  gauge(memoryUsedMetric),
+		memoryTotalMetric: gauge(memoryTotalMetric),
+	}
+	for _, name := range errorMetrics {
+		families[name] = gauge(name)
+	}
+
+	for i := int32(0); i < devices; i++ {
+		h := fnv.New32a()
+		fmt.Fprintf(h, "%s/%d", nodeName, i)
+		seed := h.Sum32()
+
+		temperature := float64(simulatedMinTemperature + seed%simulatedTemperatureRange)
+		utilization := float64((seed >> 8) % 101)
+		memoryUsed := float64(simulatedMemoryTotalBytes) * utilization / 100
+
+		add := func(name string, value float64) {
+			families[name].Metric = append(families[name].Metric, &dto.Metric{
+				Label: []*dto.LabelPair{{Name: pointer.String("UUID"), Value: pointer.String(fmt.Sprintf("simulated-%02d", i))}},
+				Gauge: &dto.Gauge{Value: pointer.Float64(value)},
+			})
+		}
+		add(temperatureMetric, temperature)
+		add(utilizationMetric, utilization)
+		add(memoryUsedMetric, memoryUsed)
+		add(memoryTotalMetric, simulatedMemoryTotalBytes)
+		for _, name := range errorMetrics {
+			add(name, 0)
+		}
+	}
+
+	return families
+}
@@ -0,0 +1,424 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	nodeMetrics "github.com/HabanaAI/habana-ai-operator/internal/node/metrics"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const (
+	// Metrics reported for each HPU by the node metrics exporter.
+	temperatureMetric = "habanalabs_temperature_onchip"
+	utilizationMetric = "habanalabs_utilization"
+	memoryUsedMetric  = "habanalabs_memory_used_bytes"
+	memoryTotalMetric = "habanalabs_memory_total_bytes"
+
+	// Exceeded thresholds reported in the node telemetry.
+	ThresholdTemperature = "temperature"
+	ThresholdMemoryUsage = "memoryUsage"
+	ThresholdErrors      = "errors"
+
+	scrapeTimeout     = 10 * time.Second
+	scrapeConcurrency = 10
+
+	// idleConnTimeout closes the connections of the transports of the
+	// DeviceConfigs that are no longer collected.
+	idleConnTimeout = 90 * time.Second
+)
+
+// errorMetrics are the error counters reported for each HPU by the node
+// metrics exporter, summed up in the node telemetry.
+var errorMetrics = []string{
+	"habanalabs_pending_rows_with_single_bit_ecc_errors",
+	"habanalabs_pending_rows_with_double_bit_ecc_errors",
+	"habanalabs_pcie_replay_count",
+}
+
+//go:generate mockgen -source=telemetry.go -package=telemetry -destination=mock_telemetry.go
+
+type Collector interface {
+	CollectTelemetry(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error
+}
+
+type collector struct {
+	client client.Client
+	config *rest.Config
+	now    func() time.Time
+
+	mu         sync.Mutex
+	transports map[string]*caTransport
+}
+
+// caTransport is the transport scraping the secured node metrics of a
+// DeviceConfig, kept across collections as long as its CA bundle does not
+// change, so that its connections are reused.
+type caTransport struct {
+	caBundle   string
+	serverName string
+	transport  *http.Transport
+}
+
+// NewCollector returns a Collector scraping the node metrics exporters. The
+// credentials of the given config authenticate the operator to kube-rbac-proxy
+// when the node metrics are secured.
+func NewCollector(c client.Client, cfg *rest.Config) *collector {
+	return &collector{
+		client:     c,
+		config:     cfg,
+		now:        time.Now,
+		transports: map[string]*caTransport{},
+	}
+}
+
+// CollectTelemetry scrapes the node metrics exporter of each node of the
+// DeviceConfig and summarizes the metrics in its status. The nodes whose node
+// metrics cannot be collected are reported in the status, only the errors
+// preventing the collection as a whole are returned. The metrics of the
+// simulated HPUs are synthesized instead.
+func (c *collector) CollectTelemetry(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) error {
+	var (
+		nodes []hlaiv1alpha1.NodeTelemetry
+		err   error
+	)
+	if cr.Spec.Simulation.Enabled {
+		nodes, err = c.simulateNodes(ctx, cr)
+	} else {
+		nodes, err = c.scrapeNodes(ctx, cr)
+	}
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeName < nodes[j].NodeName })
+
+	status := &hlaiv1alpha1.TelemetryStatus{
+		LastCollectionTime: metav1.NewTime(c.now()),
+		Nodes:              nodes,
+	}
+	for _, nt := range nodes {
+		if len(nt.ExceededThresholds) > 0 || nt.Message != "" {
+			status.NodesExceedingThresholds++
+		}
+		if nt.MaxTemperature > status.MaxTemperature {
+			status.MaxTemperature = nt.MaxTemperature
+		}
+	}
+	cr.Status.Telemetry = status
+
+	return nil
+}
+
+// scrapeNodes returns the telemetry of the nodes running a node metrics pod.
+func (c *collector) scrapeNodes(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) ([]hlaiv1alpha1.NodeTelemetry, error) {
+	logger := log.FromContext(ctx)
+
+	pods := &corev1.PodList{}
+	err := c.client.List(ctx, pods,
+		client.InNamespace(s.Settings.OperatorNamespace),
+		client.MatchingLabels(nodeMetrics.GetNodeMetricsServiceLabels(cr)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not list the node metrics pods: %w", err)
+	}
+
+	httpClient, err := c.httpClient(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := c.token(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, scrapeConcurrency)
+		nodes = []hlaiv1alpha1.NodeTelemetry{}
+	)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		// The pods not scheduled or not started yet cannot be scraped.
+		if pod.Spec.NodeName == "" || pod.Status.PodIP == "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			nt := hlaiv1alpha1.NodeTelemetry{NodeName: pod.Spec.NodeName}
+			families, err := scrape(ctx, httpClient, scrapeURL(cr, pod), token)
+			if err != nil {
+				logger.Info("Could not collect the node metrics", "node", pod.Spec.NodeName, "error", err.Error())
+				nt.Message = err.Error()
+			} else {
+				nt = Summarize(pod.Spec.NodeName, families)
+				nt.ExceededThresholds = exceededThresholds(cr, &nt)
+			}
+
+			mu.Lock()
+			nodes = append(nodes, nt)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return nodes, nil
+}
+
+// GetFlaggedNodes returns the names of the nodes exceeding a threshold or
+// whose node metrics could not be collected.
+func GetFlaggedNodes(cr *hlaiv1alpha1.DeviceConfig) []string {
+	names := []string{}
+	if cr.Status.Telemetry == nil {
+		return names
+	}
+	for _, nt := range cr.Status.Telemetry.Nodes {
+		if len(nt.ExceededThresholds) > 0 || nt.Message != "" {
+			names = append(names, nt.NodeName)
+		}
+	}
+	return names
+}
+
+// httpClient returns the HTTP client scraping the node metrics exporters,
+// trusting the node metrics CA bundle when the node metrics are secured.
+func (c *collector) httpClient(ctx context.Context, cr *hlaiv1alpha1.DeviceConfig) (*http.Client, error) {
+	httpClient := &http.Client{Timeout: scrapeTimeout}
+	if !cr.Spec.NodeMetrics.Secure {
+		c.closeTransport(cr.Name)
+		return httpClient, nil
+	}
+
+	cm := &corev1.ConfigMap{}
+	err := c.client.Get(ctx, types.NamespacedName{
+		Namespace: s.Settings.OperatorNamespace,
+		Name:      nodeMetrics.GetNodeMetricsCABundleName(cr),
+	}, cm)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the node metrics CA bundle: %w", err)
+	}
+
+	transport, err := c.transport(cr.Name, cm.Data[nodeMetrics.CABundleKey], nodeMetrics.GetNodeMetricsServiceHostname(cr))
+	if err != nil {
+		return nil, err
+	}
+	httpClient.Transport = transport
+
+	return httpClient, nil
+}
+
+// transport returns the transport trusting the given CA bundle for the given
+// DeviceConfig. The transport is only replaced when the CA bundle or the
+// server name change, e.g. on CA rotation, and the connections of the former
+// one are closed.
+func (c *collector) transport(name, caBundle, serverName string) (*http.Transport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t, ok := c.transports[name]; ok {
+		if t.caBundle == caBundle && t.serverName == serverName {
+			return t.transport, nil
+		}
+		t.transport.CloseIdleConnections()
+		delete(c.transports, name)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(caBundle)) {
+		return nil, errors.New("node metrics CA bundle does not contain any certificate")
+	}
+
+	t := &http.Transport{
+		IdleConnTimeout: idleConnTimeout,
+		TLSClientConfig: &tls.Config{
+			RootCAs:    pool,
+			ServerName: serverName,
+			MinVersion: tls.VersionTLS12,
+		},
+	}
+	c.transports[name] = &caTransport{
+		caBundle:   caBundle,
+		serverName: serverName,
+		transport:  t,
+	}
+
+	return t, nil
+}
+
+// closeTransport closes the connections of the transport of the given
+// DeviceConfig, once its node metrics are no longer secured.
+func (c *collector) closeTransport(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t, ok := c.transports[name]; ok {
+		t.transport.CloseIdleConnections()
+		delete(c.transports, name)
+	}
+}
+
+// token returns the bearer token of the operator when the node metrics are
+// secured. The token file is read on each collection, as it is rotated.
+func (c *collector) token(cr *hlaiv1alpha1.DeviceConfig) (string, error) {
+	if !cr.Spec.NodeMetrics.Secure || c.config == nil {
+		return "", nil
+	}
+	if c.config.BearerTokenFile != "" {
+		token, err := os.ReadFile(c.config.BearerTokenFile)
+		if err != nil {
+			return "", fmt.Errorf("could not read the operator token: %w", err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+	return c.config.BearerToken, nil
+}
+
+func scrapeURL(cr *hlaiv1alpha1.DeviceConfig, pod *corev1.Pod) string {
+	if cr.Spec.NodeMetrics.Secure {
+		return fmt.Sprintf("https://%s/metrics", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(cr.GetNodeMetricsSecurePort()))))
+	}
+	return fmt.Sprintf("http://%s/metrics", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(cr.GetNodeMetricsPort()))))
+}
+
+func scrape(ctx context.Context, httpClient *http.Client, url, token string) (map[string]*dto.MetricFamily, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", string(expfmt.FmtText))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the node metrics: %w", err)
+	}
+
+	return families, nil
+}
+
+// Summarize returns the telemetry of a node from the metric families scraped
+// from its node metrics exporter.
+func Summarize(nodeName string, families map[string]*dto.MetricFamily) hlaiv1alpha1.NodeTelemetry {
+	nt := hlaiv1alpha1.NodeTelemetry{NodeName: nodeName}
+
+	for _, v := range values(families[temperatureMetric]) {
+		nt.Devices++
+		if int32(v) > nt.MaxTemperature {
+			nt.MaxTemperature = int32(v)
+		}
+	}
+
+	if utilization := values(families[utilizationMetric]); len(utilization) > 0 {
+		nt.Utilization = int32(sum(utilization) / float64(len(utilization)))
+	}
+
+	nt.MemoryUsedBytes = int64(sum(values(families[memoryUsedMetric])))
+	nt.MemoryTotalBytes = int64(sum(values(families[memoryTotalMetric])))
+
+	for _, name := range errorMetrics {
+		nt.Errors += int64(sum(values(families[name])))
+	}
+
+	return nt
+}
+
+func exceededThresholds(cr *hlaiv1alpha1.DeviceConfig, nt *hlaiv1alpha1.NodeTelemetry) []string {
+	exceeded := []string{}
+	if nt.MaxTemperature > cr.GetTelemetryTemperatureThreshold() {
+		exceeded = append(exceeded, ThresholdTemperature)
+	}
+	if nt.MemoryTotalBytes > 0 && nt.MemoryUsedBytes*100/nt.MemoryTotalBytes > int64(cr.GetTelemetryMemoryUsagePercentThreshold()) {
+		exceeded = append(exceeded, ThresholdMemoryUsage)
+	}
+	if nt.Errors > cr.Spec.Telemetry.Thresholds.Errors {
+		exceeded = append(exceeded, ThresholdErrors)
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+	return exceeded
+}
+
+// values returns the values of the gauge, counter or untyped metrics of a
+// metric family.
+func values(mf *dto.MetricFamily) []float64 {
+	if mf == nil {
+		return nil
+	}
+	vs := make([]float64, 0, len(mf.GetMetric()))
+	for _, m := range mf.GetMetric() {
+		switch {
+		case m.Gauge != nil:
+			vs = append(vs, m.GetGauge().GetValue())
+		case m.Counter != nil:
+			vs = append(vs, m.GetCounter().GetValue())
+		case m.Untyped != nil:
+			vs = append(vs, m.GetUntyped().GetValue())
+		}
+	}
+	return vs
+}
+
+func sum(vs []float64) float64 {
+	total := 0.0
+	for _, v := range vs {
+		total += v
+	}
+	return total
+}
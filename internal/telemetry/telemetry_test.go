@@ -0,0 +1,271 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	nodeMetrics "github.com/HabanaAI/habana-ai-operator/internal/node/metrics"
+	"github.com/HabanaAI/habana-ai-operator/internal/node/simulation"
+	s "github.com/HabanaAI/habana-ai-operator/internal/settings"
+)
+
+const testMetrics = `# TYPE habanalabs_temperature_onchip gauge
+habanalabs_temperature_onchip{UUID="01"} 45
+habanalabs_temperature_onchip{UUID="02"} 91
+# TYPE habanalabs_utilization gauge
+habanalabs_utilization{UUID="01"} 20
+habanalabs_utilization{UUID="02"} 60
+# TYPE habanalabs_memory_used_bytes gauge
+habanalabs_memory_used_bytes{UUID="01"} 10
+habanalabs_memory_used_bytes{UUID="02"} 30
+# TYPE habanalabs_memory_total_bytes gauge
+habanalabs_memory_total_bytes{UUID="01"} 100
+habanalabs_memory_total_bytes{UUID="02"} 100
+# TYPE habanalabs_pending_rows_with_double_bit_ecc_errors gauge
+habanalabs_pending_rows_with_double_bit_ecc_errors{UUID="01"} 0
+habanalabs_pending_rows_with_double_bit_ecc_errors{UUID="02"} 2
+`
+
+var _ = Describe("Summarize", func() {
+	It("should summarize the metrics of the HPUs of the node", func() {
+		var parser expfmt.TextParser
+		families, err := parser.TextToMetricFamilies(strings.NewReader(testMetrics))
+		Expect(err).ToNot(HaveOccurred())
+
+		nt := Summarize("a-node", families)
+		Expect(nt).To(Equal(hlaiv1alpha1.NodeTelemetry{
+			NodeName:         "a-node",
+			Devices:          2,
+			MaxTemperature:   91,
+			Utilization:      40,
+			MemoryUsedBytes:  40,
+			MemoryTotalBytes: 200,
+			Errors:           2,
+		}))
+	})
+})
+
+var _ = Describe("CollectTelemetry", func() {
+	var (
+		dc     *hlaiv1alpha1.DeviceConfig
+		server *httptest.Server
+		port   int
+		now    time.Time
+		ctx    context.Context
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, testMetrics)
+		}))
+
+		_, p, err := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+		Expect(err).ToNot(HaveOccurred())
+		port, err = strconv.Atoi(p)
+		Expect(err).ToNot(HaveOccurred())
+
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "a-device-config",
+			},
+			Spec: hlaiv1alpha1.DeviceConfigSpec{
+				NodeMetrics: hlaiv1alpha1.NodeMetricsSpec{
+					Port: int32(port),
+				},
+				Telemetry: hlaiv1alpha1.TelemetrySpec{
+					Enabled: true,
+				},
+			},
+		}
+		now = time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+		ctx = context.TODO()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	makePod := func(name, nodeName, ip string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: s.Settings.OperatorNamespace,
+				Labels:    nodeMetrics.GetNodeMetricsServiceLabels(dc),
+			},
+			Spec: corev1.PodSpec{
+				NodeName: nodeName,
+			},
+			Status: corev1.PodStatus{
+				PodIP: ip,
+			},
+		}
+	}
+
+	collect := func(objs ...*corev1.Pod) {
+		sch := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(sch)).ToNot(HaveOccurred())
+
+		b := fake.NewClientBuilder().WithScheme(sch)
+		for _, o := range objs {
+			b = b.WithObjects(o)
+		}
+
+		c := NewCollector(b.Build(), nil)
+		c.now = func() time.Time { return now }
+
+		Expect(c.CollectTelemetry(ctx, dc)).To(Succeed())
+	}
+
+	It("should summarize the node metrics in the status", func() {
+		collect(makePod("node-metrics-a", "node-a", "127.0.0.1"))
+
+		Expect(dc.Status.Telemetry).ToNot(BeNil())
+		Expect(dc.Status.Telemetry.LastCollectionTime.Time).To(Equal(now))
+		Expect(dc.Status.Telemetry.MaxTemperature).To(BeEquivalentTo(91))
+		Expect(dc.Status.Telemetry.NodesExceedingThresholds).To(BeEquivalentTo(1))
+		Expect(dc.Status.Telemetry.Nodes).To(HaveLen(1))
+		Expect(dc.Status.Telemetry.Nodes[0].Devices).To(BeEquivalentTo(2))
+		Expect(dc.Status.Telemetry.Nodes[0].ExceededThresholds).To(Equal([]string{ThresholdTemperature, ThresholdErrors}))
+		Expect(GetFlaggedNodes(dc)).To(Equal([]string{"node-a"}))
+	})
+
+	It("should not flag the nodes below the thresholds", func() {
+		dc.Spec.Telemetry.Thresholds = hlaiv1alpha1.TelemetryThresholdsSpec{
+			Temperature: 95,
+			Errors:      2,
+		}
+
+		collect(makePod("node-metrics-a", "node-a", "127.0.0.1"))
+
+		Expect(dc.Status.Telemetry.NodesExceedingThresholds).To(BeZero())
+		Expect(dc.Status.Telemetry.Nodes[0].ExceededThresholds).To(BeEmpty())
+		Expect(GetFlaggedNodes(dc)).To(BeEmpty())
+	})
+
+	It("should report the nodes whose node metrics cannot be collected", func() {
+		server.Close()
+
+		collect(
+			makePod("node-metrics-b", "node-b", "127.0.0.1"),
+			makePod("pending", "", ""),
+			makePod("starting", "node-c", ""),
+		)
+
+		Expect(dc.Status.Telemetry.Nodes).To(HaveLen(1))
+		Expect(dc.Status.Telemetry.Nodes[0].NodeName).To(Equal("node-b"))
+		Expect(dc.Status.Telemetry.Nodes[0].Message).ToNot(BeEmpty())
+		Expect(dc.Status.Telemetry.NodesExceedingThresholds).To(BeEquivalentTo(1))
+	})
+
+	It("should synthesize the metrics of the simulated HPUs", func() {
+		dc.Spec.Simulation = hlaiv1alpha1.SimulationSpec{Enabled: true, DevicesPerNode: 4}
+
+		sch := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(sch)).ToNot(HaveOccurred())
+		c := NewCollector(fake.NewClientBuilder().WithScheme(sch).WithObjects(
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{simulation.SimulatedLabel: dc.Name}}},
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{simulation.SimulatedLabel: dc.Name}}},
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "other", Labels: map[string]string{simulation.SimulatedLabel: "another"}}},
+		).Build(), nil)
+		c.now = func() time.Time { return now }
+
+		Expect(c.CollectTelemetry(ctx, dc)).To(Succeed())
+		Expect(dc.Status.Telemetry.Nodes).To(HaveLen(2))
+		for i, name := range []string{"node-a", "node-b"} {
+			nt := dc.Status.Telemetry.Nodes[i]
+			Expect(nt.NodeName).To(Equal(name))
+			Expect(nt.Devices).To(BeEquivalentTo(4))
+			Expect(nt.MaxTemperature).To(BeNumerically(">=", simulatedMinTemperature))
+			Expect(nt.MaxTemperature).To(BeNumerically("<", simulatedMinTemperature+simulatedTemperatureRange))
+			Expect(nt.MemoryTotalBytes).To(BeEquivalentTo(4 * simulatedMemoryTotalBytes))
+			Expect(nt.Errors).To(BeZero())
+		}
+
+		first := dc.Status.Telemetry.Nodes
+		Expect(c.CollectTelemetry(ctx, dc)).To(Succeed())
+		Expect(dc.Status.Telemetry.Nodes).To(Equal(first))
+	})
+
+	It("should fail without the CA bundle when the node metrics are secured", func() {
+		dc.Spec.NodeMetrics.Secure = true
+
+		sch := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(sch)).ToNot(HaveOccurred())
+		c := NewCollector(fake.NewClientBuilder().WithScheme(sch).Build(), nil)
+
+		err := c.CollectTelemetry(ctx, dc)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("CA bundle"))
+	})
+
+	It("should reuse the transport until the CA bundle changes", func() {
+		tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, testMetrics)
+		}))
+		defer tlsServer.Close()
+
+		caBundle := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw}))
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      nodeMetrics.GetNodeMetricsCABundleName(dc),
+				Namespace: s.Settings.OperatorNamespace,
+			},
+			Data: map[string]string{nodeMetrics.CABundleKey: caBundle},
+		}
+
+		dc.Spec.NodeMetrics.Secure = true
+
+		sch := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(sch)).ToNot(HaveOccurred())
+		cl := fake.NewClientBuilder().WithScheme(sch).WithObjects(cm).Build()
+		c := NewCollector(cl, nil)
+
+		first, err := c.httpClient(ctx, dc)
+		Expect(err).ToNot(HaveOccurred())
+		second, err := c.httpClient(ctx, dc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(second.Transport).To(BeIdenticalTo(first.Transport))
+
+		cm.Data[nodeMetrics.CABundleKey] = caBundle + caBundle
+		Expect(cl.Update(ctx, cm)).To(Succeed())
+
+		third, err := c.httpClient(ctx, dc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(third.Transport).ToNot(BeIdenticalTo(first.Transport))
+
+		dc.Spec.NodeMetrics.Secure = false
+		_, err = c.httpClient(ctx, dc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.transports).To(BeEmpty())
+	})
+})
@@ -0,0 +1,150 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testutil builds the nodes and pods shared by the tests of the
+// node reconcilers.
+package testutil
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+)
+
+type NodeOption func(*corev1.Node)
+
+// MakeNode returns a node with the given name and options.
+func MakeNode(name string, opts ...NodeOption) *corev1.Node {
+	n := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+	for _, o := range opts {
+		o(n)
+	}
+	return n
+}
+
+// Labelled sets the given labels on the node.
+func Labelled(labels map[string]string) NodeOption {
+	return func(n *corev1.Node) {
+		if n.Labels == nil {
+			n.Labels = map[string]string{}
+		}
+		for k, v := range labels {
+			n.Labels[k] = v
+		}
+	}
+}
+
+// Tainted sets the given taints on the node.
+func Tainted(taints ...corev1.Taint) NodeOption {
+	return func(n *corev1.Node) {
+		n.Spec.Taints = append(n.Spec.Taints, taints...)
+	}
+}
+
+// AdvertisingHPUs sets the given number of HPUs as the capacity and the
+// allocatable of the node, as the device plugin does.
+func AdvertisingHPUs(devices int64) NodeOption {
+	return func(n *corev1.Node) {
+		if n.Status.Capacity == nil {
+			n.Status.Capacity = corev1.ResourceList{}
+		}
+		if n.Status.Allocatable == nil {
+			n.Status.Allocatable = corev1.ResourceList{}
+		}
+		n.Status.Capacity[constants.HabanaResourceName] = *resource.NewQuantity(devices, resource.DecimalSI)
+		n.Status.Allocatable[constants.HabanaResourceName] = *resource.NewQuantity(devices, resource.DecimalSI)
+	}
+}
+
+type PodOption func(*corev1.Pod)
+
+// MakePod returns a running pod with a single container, with the given
+// namespace, name and options.
+func MakePod(namespace, name string, opts ...PodOption) *corev1.Pod {
+	p := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "main"}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+// OnNode binds the pod to the given node.
+func OnNode(nodeName string) PodOption {
+	return func(p *corev1.Pod) {
+		p.Spec.NodeName = nodeName
+	}
+}
+
+// WithPodLabels sets the given labels on the pod.
+func WithPodLabels(labels map[string]string) PodOption {
+	return func(p *corev1.Pod) {
+		if p.Labels == nil {
+			p.Labels = map[string]string{}
+		}
+		for k, v := range labels {
+			p.Labels[k] = v
+		}
+	}
+}
+
+// WithImage sets the image of the container of the pod.
+func WithImage(image string) PodOption {
+	return func(p *corev1.Pod) {
+		p.Spec.Containers[0].Image = image
+	}
+}
+
+// RequestingHPUs sets the given number of HPUs as the limit of the container
+// of the pod, which the requests of extended resources default to.
+func RequestingHPUs(devices int64) PodOption {
+	return func(p *corev1.Pod) {
+		p.Spec.Containers[0].Resources.Limits = corev1.ResourceList{
+			constants.HabanaResourceName: *resource.NewQuantity(devices, resource.DecimalSI),
+		}
+	}
+}
+
+// WithPhase sets the phase of the pod.
+func WithPhase(phase corev1.PodPhase) PodOption {
+	return func(p *corev1.Pod) {
+		p.Status.Phase = phase
+	}
+}
+
+// Ready sets the Ready condition of the pod.
+func Ready(ready bool) PodOption {
+	return func(p *corev1.Pod) {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		p.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+	}
+}
@@ -0,0 +1,343 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+)
+
+const (
+	// MutatePodPath is the path the pod mutating webhook is served on.
+	MutatePodPath = "/mutate-v1-pod"
+
+	// WorkloadInjectionLabel opts a namespace in or out the injection of the
+	// Habana runtime requirements, with the enabled or disabled value.
+	WorkloadInjectionLabel    = "habana.ai/workload-injection"
+	WorkloadInjectionEnabled  = "enabled"
+	WorkloadInjectionDisabled = "disabled"
+
+	habanaResourcePrefix = "habana.ai/"
+	hugePages2Mi         = corev1.ResourceName(corev1.ResourceHugePagesPrefix + "2Mi")
+)
+
+//+kubebuilder:webhook:path=/mutate-v1-pod,mutating=true,failurePolicy=ignore,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=mpod.habana.ai,admissionReviewVersions=v1
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+// PodMutator injects the Habana runtime requirements, configured by the
+// WorkloadInjection of a DeviceConfig, in the pods requesting HPUs.
+type PodMutator struct {
+	client  client.Reader
+	decoder *admission.Decoder
+}
+
+func NewPodMutator(c client.Reader) *PodMutator {
+	return &PodMutator{client: c}
+}
+
+// InjectDecoder injects the decoder of the admission requests.
+func (m *PodMutator) InjectDecoder(d *admission.Decoder) error {
+	m.decoder = d
+	return nil
+}
+
+func (m *PodMutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := log.FromContext(ctx)
+
+	pod := &corev1.Pod{}
+	if err := m.decoder.Decode(req, pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if len(GetHPUResources(pod)) == 0 {
+		return admission.Allowed("the pod does not request HPUs")
+	}
+
+	dc, err := m.getDeviceConfig(ctx, pod)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if dc == nil {
+		return admission.Allowed("no DeviceConfig injects the pod")
+	}
+
+	injected, err := m.isNamespaceInjected(ctx, req.Namespace, dc)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if !injected {
+		return admission.Allowed(fmt.Sprintf("the namespace %s is not injected", req.Namespace))
+	}
+
+	InjectWorkload(dc, pod)
+
+	marshaled, err := json.Marshal(pod)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	logger.Info("Injected the Habana runtime requirements", "namespace", req.Namespace, "pod", req.Name, "resource", dc.Name)
+
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// getDeviceConfig returns the DeviceConfig injecting the pod: the one with the
+// injection enabled whose node selector is part of the pod node selector or,
+// when the pod does not target any, the only one with the injection enabled.
+func (m *PodMutator) getDeviceConfig(ctx context.Context, pod *corev1.Pod) (*hlaiv1alpha1.DeviceConfig, error) {
+	list := &hlaiv1alpha1.DeviceConfigList{}
+	if err := m.client.List(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to list the DeviceConfigs: %w", err)
+	}
+
+	enabled := []*hlaiv1alpha1.DeviceConfig{}
+	matching := []*hlaiv1alpha1.DeviceConfig{}
+	for i := range list.Items {
+		dc := &list.Items[i]
+		if !dc.Spec.WorkloadInjection.Enabled {
+			continue
+		}
+		enabled = append(enabled, dc)
+
+		if labels.SelectorFromSet(dc.GetNodeSelector()).Matches(labels.Set(pod.Spec.NodeSelector)) {
+			matching = append(matching, dc)
+		}
+	}
+
+	switch {
+	case len(matching) == 1:
+		return matching[0], nil
+	case len(matching) == 0 && len(enabled) == 1:
+		return enabled[0], nil
+	}
+
+	return nil, nil
+}
+
+// isNamespaceInjected returns whether the namespace opted in the injection,
+// when the DeviceConfig requires it, or did not opt out otherwise.
+func (m *PodMutator) isNamespaceInjected(ctx context.Context, name string, dc *hlaiv1alpha1.DeviceConfig) (bool, error) {
+	ns := &corev1.Namespace{}
+	if err := m.client.Get(ctx, types.NamespacedName{Name: name}, ns); err != nil {
+		return false, fmt.Errorf("failed to get namespace %s: %w", name, err)
+	}
+
+	if dc.Spec.WorkloadInjection.NamespaceOptIn {
+		return ns.Labels[WorkloadInjectionLabel] == WorkloadInjectionEnabled, nil
+	}
+	return ns.Labels[WorkloadInjectionLabel] != WorkloadInjectionDisabled, nil
+}
+
+// GetHPUResources returns the habana.ai resources requested by the containers
+// of the pod.
+func GetHPUResources(pod *corev1.Pod) []corev1.ResourceName {
+	seen := map[corev1.ResourceName]bool{}
+	names := []corev1.ResourceName{}
+	for _, c := range getContainers(pod) {
+		for _, name := range getContainerHPUResources(c) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// InjectWorkload injects the Habana runtime requirements configured by the
+// DeviceConfig in the containers requesting HPUs, and tolerates the taints of
+// the requested habana.ai resources.
+func InjectWorkload(dc *hlaiv1alpha1.DeviceConfig, pod *corev1.Pod) {
+	for _, c := range getContainers(pod) {
+		if len(getContainerHPUResources(c)) == 0 {
+			continue
+		}
+		injectCapabilities(c, dc.GetWorkloadInjectionCapabilities())
+		injectEnv(c, dc.GetWorkloadInjectionEnv())
+		injectVisibleModules(c, getNodeHPUs(dc))
+		if dc.Spec.WorkloadInjection.HugePages2Mi != nil {
+			injectHugePages(c, *dc.Spec.WorkloadInjection.HugePages2Mi)
+		}
+	}
+
+	for _, name := range GetHPUResources(pod) {
+		injectToleration(pod, name)
+	}
+}
+
+func getContainers(pod *corev1.Pod) []*corev1.Container {
+	containers := make([]*corev1.Container, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	for i := range pod.Spec.InitContainers {
+		containers = append(containers, &pod.Spec.InitContainers[i])
+	}
+	for i := range pod.Spec.Containers {
+		containers = append(containers, &pod.Spec.Containers[i])
+	}
+	return containers
+}
+
+func getContainerHPUResources(c *corev1.Container) []corev1.ResourceName {
+	names := []corev1.ResourceName{}
+	for _, list := range []corev1.ResourceList{c.Resources.Limits, c.Resources.Requests} {
+		for name := range list {
+			if strings.HasPrefix(string(name), habanaResourcePrefix) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func injectCapabilities(c *corev1.Container, capabilities []corev1.Capability) {
+	if c.SecurityContext == nil {
+		c.SecurityContext = &corev1.SecurityContext{}
+	}
+	if c.SecurityContext.Capabilities == nil {
+		c.SecurityContext.Capabilities = &corev1.Capabilities{}
+	}
+
+	for _, capability := range capabilities {
+		found := false
+		for _, added := range c.SecurityContext.Capabilities.Add {
+			if added == capability {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.SecurityContext.Capabilities.Add = append(c.SecurityContext.Capabilities.Add, capability)
+		}
+	}
+}
+
+func injectEnv(c *corev1.Container, env []corev1.EnvVar) {
+	for _, e := range env {
+		found := false
+		for _, existing := range c.Env {
+			if existing.Name == e.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.Env = append(c.Env, e)
+		}
+	}
+}
+
+// getNodeHPUs returns the largest number of HPUs allocatable on a node of the
+// DeviceConfig, or 0 when the capacity is not reported yet.
+func getNodeHPUs(dc *hlaiv1alpha1.DeviceConfig) int64 {
+	if dc.Status.Capacity == nil {
+		return 0
+	}
+	var devices int64
+	for _, n := range dc.Status.Capacity.Nodes {
+		if n.Allocatable > devices {
+			devices = n.Allocatable
+		}
+	}
+	return devices
+}
+
+// getContainerHPUs returns the number of HPUs requested by the container.
+func getContainerHPUs(c *corev1.Container) int64 {
+	seen := map[corev1.ResourceName]bool{}
+	var devices int64
+	for _, name := range getContainerHPUResources(c) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		q, ok := c.Resources.Limits[name]
+		if !ok {
+			q = c.Resources.Requests[name]
+		}
+		devices += q.Value()
+	}
+	return devices
+}
+
+// injectVisibleModules sets HABANA_VISIBLE_MODULES to all the modules of the
+// node in the containers requesting all its HPUs, unless already set. The
+// modules allocated to the containers requesting fewer HPUs are only known by
+// the device plugin, so they are left to the Habana runtime.
+func injectVisibleModules(c *corev1.Container, nodeHPUs int64) {
+	if nodeHPUs == 0 || getContainerHPUs(c) != nodeHPUs {
+		return
+	}
+
+	modules := make([]string, 0, nodeHPUs)
+	for i := int64(0); i < nodeHPUs; i++ {
+		modules = append(modules, strconv.FormatInt(i, 10))
+	}
+	injectEnv(c, []corev1.EnvVar{{Name: hlaiv1alpha1.HabanaVisibleModulesEnvVar, Value: strings.Join(modules, ",")}})
+}
+
+// injectHugePages requests the huge pages, which must be requested along with
+// memory or CPU, unless the container already requests huge pages.
+func injectHugePages(c *corev1.Container, q resource.Quantity) {
+	requestsMemory := false
+	for _, list := range []corev1.ResourceList{c.Resources.Limits, c.Resources.Requests} {
+		for name := range list {
+			if strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix) {
+				return
+			}
+			if name == corev1.ResourceMemory || name == corev1.ResourceCPU {
+				requestsMemory = true
+			}
+		}
+	}
+	if !requestsMemory {
+		return
+	}
+
+	if c.Resources.Limits == nil {
+		c.Resources.Limits = corev1.ResourceList{}
+	}
+	c.Resources.Limits[hugePages2Mi] = q
+	if c.Resources.Requests != nil {
+		c.Resources.Requests[hugePages2Mi] = q
+	}
+}
+
+// injectToleration tolerates the NoSchedule taint of the resource, unless the
+// pod already tolerates it.
+func injectToleration(pod *corev1.Pod, name corev1.ResourceName) {
+	taint := &corev1.Taint{Key: string(name), Effect: corev1.TaintEffectNoSchedule}
+	for _, t := range pod.Spec.Tolerations {
+		if t.ToleratesTaint(taint) {
+			return
+		}
+	}
+	pod.Spec.Tolerations = append(pod.Spec.Tolerations, corev1.Toleration{
+		Key:      taint.Key,
+		Operator: corev1.TolerationOpExists,
+		Effect:   taint.Effect,
+	})
+}
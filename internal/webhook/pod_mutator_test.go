@@ -0,0 +1,282 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+)
+
+var _ = Describe("PodMutator", func() {
+	const testNamespace = "workloads"
+
+	var (
+		dc  *hlaiv1alpha1.DeviceConfig
+		ns  *corev1.Namespace
+		m   *PodMutator
+		ctx context.Context
+	)
+
+	hpuPod := func() *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "training", Namespace: testNamespace},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name: "train",
+						Env:  []corev1.EnvVar{{Name: hlaiv1alpha1.HabanaLogsEnvVar, Value: "/tmp/logs"}},
+						Resources: corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{
+								constants.HabanaResourceName: *resource.NewQuantity(8, resource.DecimalSI),
+								corev1.ResourceMemory:        resource.MustParse("64Gi"),
+							},
+						},
+					},
+					{Name: "sidecar"},
+				},
+			},
+		}
+	}
+
+	newMutator := func(objs ...client.Object) *PodMutator {
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+
+		m := NewPodMutator(c)
+		d, err := admission.NewDecoder(s)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m.InjectDecoder(d)).ToNot(HaveOccurred())
+		return m
+	}
+
+	handle := func(pod *corev1.Pod) admission.Response {
+		raw, err := json.Marshal(pod)
+		Expect(err).ToNot(HaveOccurred())
+
+		return m.Handle(ctx, admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		})
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		hugePages := resource.MustParse("1Gi")
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "a-device-config"},
+			Spec: hlaiv1alpha1.DeviceConfigSpec{
+				WorkloadInjection: hlaiv1alpha1.WorkloadInjectionSpec{
+					Enabled:      true,
+					HugePages2Mi: &hugePages,
+				},
+			},
+		}
+		ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}
+	})
+
+	Describe("Handle", func() {
+		It("should inject the runtime requirements in the containers requesting HPUs", func() {
+			m = newMutator(dc, ns)
+
+			res := handle(hpuPod())
+			Expect(res.Allowed).To(BeTrue())
+			Expect(res.Patches).ToNot(BeEmpty())
+		})
+
+		It("should not mutate the pods not requesting HPUs", func() {
+			m = newMutator(dc, ns)
+
+			pod := hpuPod()
+			pod.Spec.Containers = pod.Spec.Containers[1:]
+
+			res := handle(pod)
+			Expect(res.Allowed).To(BeTrue())
+			Expect(res.Patches).To(BeEmpty())
+		})
+
+		It("should not mutate the pods when the injection is disabled", func() {
+			dc.Spec.WorkloadInjection.Enabled = false
+			m = newMutator(dc, ns)
+
+			res := handle(hpuPod())
+			Expect(res.Allowed).To(BeTrue())
+			Expect(res.Patches).To(BeEmpty())
+		})
+
+		It("should not mutate the pods of the namespaces opting out", func() {
+			ns.Labels = map[string]string{WorkloadInjectionLabel: WorkloadInjectionDisabled}
+			m = newMutator(dc, ns)
+
+			res := handle(hpuPod())
+			Expect(res.Allowed).To(BeTrue())
+			Expect(res.Patches).To(BeEmpty())
+		})
+
+		It("should only mutate the pods of the namespaces opting in when required", func() {
+			dc.Spec.WorkloadInjection.NamespaceOptIn = true
+			m = newMutator(dc, ns)
+
+			res := handle(hpuPod())
+			Expect(res.Allowed).To(BeTrue())
+			Expect(res.Patches).To(BeEmpty())
+
+			ns.Labels = map[string]string{WorkloadInjectionLabel: WorkloadInjectionEnabled}
+			m = newMutator(dc, ns)
+
+			res = handle(hpuPod())
+			Expect(res.Allowed).To(BeTrue())
+			Expect(res.Patches).ToNot(BeEmpty())
+		})
+
+		It("should use the DeviceConfig matching the pod node selector", func() {
+			dc.Spec.NodeSelector = map[string]string{"pool": "a"}
+			other := dc.DeepCopy()
+			other.Name = "b-device-config"
+			other.Spec.NodeSelector = map[string]string{"pool": "b"}
+			other.Spec.WorkloadInjection.NamespaceOptIn = true
+			m = newMutator(dc, other, ns)
+
+			pod := hpuPod()
+			pod.Spec.NodeSelector = map[string]string{"pool": "b"}
+			res := handle(pod)
+			Expect(res.Allowed).To(BeTrue())
+			Expect(res.Patches).To(BeEmpty())
+
+			pod.Spec.NodeSelector = map[string]string{"pool": "a"}
+			res = handle(pod)
+			Expect(res.Allowed).To(BeTrue())
+			Expect(res.Patches).ToNot(BeEmpty())
+		})
+
+		It("should not mutate the pods matching several DeviceConfigs", func() {
+			other := dc.DeepCopy()
+			other.Name = "b-device-config"
+			m = newMutator(dc, other, ns)
+
+			res := handle(hpuPod())
+			Expect(res.Allowed).To(BeTrue())
+			Expect(res.Patches).To(BeEmpty())
+		})
+	})
+
+	Describe("InjectWorkload", func() {
+		It("should inject the defaults in the containers requesting HPUs", func() {
+			pod := hpuPod()
+			InjectWorkload(dc, pod)
+
+			train := pod.Spec.Containers[0]
+			Expect(train.SecurityContext.Capabilities.Add).To(ConsistOf(corev1.Capability(hlaiv1alpha1.DefaultWorkloadCapability)))
+			Expect(train.Env).To(ConsistOf(corev1.EnvVar{Name: hlaiv1alpha1.HabanaLogsEnvVar, Value: "/tmp/logs"}))
+			Expect(train.Resources.Limits).To(HaveKeyWithValue(hugePages2Mi, resource.MustParse("1Gi")))
+
+			Expect(pod.Spec.Containers[1]).To(Equal(corev1.Container{Name: "sidecar"}))
+
+			Expect(pod.Spec.Tolerations).To(ConsistOf(corev1.Toleration{
+				Key:      constants.HabanaResourceName,
+				Operator: corev1.TolerationOpExists,
+				Effect:   corev1.TaintEffectNoSchedule,
+			}))
+		})
+
+		It("should inject the configured capabilities and environment", func() {
+			dc.Spec.WorkloadInjection.Capabilities = []corev1.Capability{"SYS_RAWIO", "IPC_LOCK"}
+			dc.Spec.WorkloadInjection.Env = []corev1.EnvVar{{Name: "HABANA_VISIBLE_MODULES", Value: "0,1"}}
+
+			pod := hpuPod()
+			pod.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{
+				Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"IPC_LOCK"}},
+			}
+			InjectWorkload(dc, pod)
+
+			train := pod.Spec.Containers[0]
+			Expect(train.SecurityContext.Capabilities.Add).To(Equal([]corev1.Capability{"IPC_LOCK", "SYS_RAWIO"}))
+			Expect(train.Env).To(ContainElement(corev1.EnvVar{Name: "HABANA_VISIBLE_MODULES", Value: "0,1"}))
+		})
+
+		It("should set the visible modules in the containers requesting all the HPUs of the nodes", func() {
+			dc.Status.Capacity = &hlaiv1alpha1.CapacityStatus{
+				Nodes: []hlaiv1alpha1.NodeCapacity{
+					{NodeName: "node-a", Allocatable: 8},
+					{NodeName: "node-b", Allocatable: 7},
+				},
+			}
+
+			pod := hpuPod()
+			InjectWorkload(dc, pod)
+
+			Expect(pod.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+				Name:  hlaiv1alpha1.HabanaVisibleModulesEnvVar,
+				Value: "0,1,2,3,4,5,6,7",
+			}))
+		})
+
+		It("should not set the visible modules in the containers requesting some HPUs of the nodes", func() {
+			dc.Status.Capacity = &hlaiv1alpha1.CapacityStatus{
+				Nodes: []hlaiv1alpha1.NodeCapacity{{NodeName: "node-a", Allocatable: 8}},
+			}
+
+			pod := hpuPod()
+			pod.Spec.Containers[0].Resources.Limits[constants.HabanaResourceName] = *resource.NewQuantity(2, resource.DecimalSI)
+			InjectWorkload(dc, pod)
+
+			Expect(pod.Spec.Containers[0].Env).ToNot(ContainElement(HaveField("Name", hlaiv1alpha1.HabanaVisibleModulesEnvVar)))
+		})
+
+		It("should not override the huge pages requested by the containers", func() {
+			pod := hpuPod()
+			pod.Spec.Containers[0].Resources.Limits[hugePages2Mi] = resource.MustParse("2Gi")
+			InjectWorkload(dc, pod)
+
+			Expect(pod.Spec.Containers[0].Resources.Limits).To(HaveKeyWithValue(hugePages2Mi, resource.MustParse("2Gi")))
+		})
+
+		It("should not request huge pages without memory or CPU", func() {
+			pod := hpuPod()
+			delete(pod.Spec.Containers[0].Resources.Limits, corev1.ResourceMemory)
+			InjectWorkload(dc, pod)
+
+			Expect(pod.Spec.Containers[0].Resources.Limits).ToNot(HaveKey(hugePages2Mi))
+		})
+
+		It("should not duplicate the tolerations", func() {
+			pod := hpuPod()
+			pod.Spec.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+			InjectWorkload(dc, pod)
+
+			Expect(pod.Spec.Tolerations).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,245 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/capacity"
+	"github.com/HabanaAI/habana-ai-operator/internal/metrics"
+)
+
+const (
+	// ValidatePodPath is the path the pod validating webhook is served on.
+	ValidatePodPath = "/validate-v1-pod"
+
+	ReasonHPUAccessDenied = "HPUAccessDenied"
+)
+
+//+kubebuilder:webhook:path=/validate-v1-pod,mutating=false,failurePolicy=fail,sideEffects=NoneOnDryRun,groups="",resources=pods,verbs=create,versions=v1,name=vpod.habana.ai,admissionReviewVersions=v1
+//+kubebuilder:rbac:groups=habana.ai,resources=hpuaccesspolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// PodValidator denies the pods requesting HPUs on the nodes restricted by an
+// HPUAccessPolicy, unless their namespace is allowed and stays within its
+// maximum number of HPUs.
+type PodValidator struct {
+	client   client.Reader
+	recorder record.EventRecorder
+	decoder  *admission.Decoder
+}
+
+// NewPodValidator returns a PodValidator. The pods of all the namespaces are
+// listed, hence an uncached reader should be used, as the cache of the manager
+// may be restricted to the operator namespace.
+func NewPodValidator(c client.Reader, recorder record.EventRecorder) *PodValidator {
+	return &PodValidator{client: c, recorder: recorder}
+}
+
+// InjectDecoder injects the decoder of the admission requests.
+func (v *PodValidator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+func (v *PodValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := log.FromContext(ctx)
+
+	pod := &corev1.Pod{}
+	if err := v.decoder.Decode(req, pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	pod.Namespace = req.Namespace
+
+	devices := capacity.GetPodRequest(pod)
+	if devices == 0 {
+		return admission.Allowed("the pod does not request HPUs")
+	}
+
+	policies := &hlaiv1alpha1.HPUAccessPolicyList{}
+	if err := v.client.List(ctx, policies); err != nil {
+		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("failed to list the HPUAccessPolicies: %w", err))
+	}
+
+	nodes := map[string]labels.Set{}
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+
+		violation, err := v.checkPolicy(ctx, policy, pod, devices, nodes)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if violation == "" {
+			continue
+		}
+
+		if req.DryRun == nil || !*req.DryRun {
+			v.recorder.Eventf(policy, corev1.EventTypeWarning, ReasonHPUAccessDenied, "Denied pod %s/%s: %s", pod.Namespace, pod.Name, violation)
+			metrics.HPUAccessViolations.WithLabelValues(policy.Name, pod.Namespace).Inc()
+		}
+		logger.Info("Denied the pod", "namespace", pod.Namespace, "pod", pod.Name, "policy", policy.Name, "violation", violation)
+
+		return admission.Denied(fmt.Sprintf("HPUAccessPolicy %s: %s", policy.Name, violation))
+	}
+
+	return admission.Allowed("")
+}
+
+// checkPolicy returns why the pod requesting the devices violates the policy,
+// or an empty string when it does not.
+func (v *PodValidator) checkPolicy(ctx context.Context, policy *hlaiv1alpha1.HPUAccessPolicy, pod *corev1.Pod, devices int64, nodes map[string]labels.Set) (string, error) {
+	pools, err := v.getPools(ctx, policy)
+	if err != nil {
+		return "", err
+	}
+
+	restricted, err := v.isRestricted(ctx, pools, pod, nodes)
+	if err != nil || !restricted {
+		return "", err
+	}
+
+	access := policy.GetNamespaceAccess(pod.Namespace)
+	if access == nil {
+		return fmt.Sprintf("namespace %s is not allowed to request HPUs", pod.Namespace), nil
+	}
+	if access.MaxHPUs == nil {
+		return "", nil
+	}
+
+	used, err := v.getNamespaceRequest(ctx, pools, pod.Namespace, nodes)
+	if err != nil {
+		return "", err
+	}
+	if used+devices > *access.MaxHPUs {
+		return fmt.Sprintf("namespace %s would request %d HPUs, exceeding its maximum of %d", pod.Namespace, used+devices, *access.MaxHPUs), nil
+	}
+
+	return "", nil
+}
+
+// getPools returns the node selectors of the nodes restricted by the policy,
+// i.e. the ones of its DeviceConfigs and its own. An empty selector restricts
+// all the nodes, as do the policies whose DeviceConfigs are all missing, since
+// the nodes they meant to restrict are unknown.
+func (v *PodValidator) getPools(ctx context.Context, policy *hlaiv1alpha1.HPUAccessPolicy) ([]labels.Set, error) {
+	if len(policy.Spec.DeviceConfigs) == 0 && len(policy.Spec.NodeSelector) == 0 {
+		return []labels.Set{{}}, nil
+	}
+
+	pools := []labels.Set{}
+	for _, name := range policy.Spec.DeviceConfigs {
+		dc := &hlaiv1alpha1.DeviceConfig{}
+		if err := v.client.Get(ctx, types.NamespacedName{Name: name}, dc); err != nil {
+			if client.IgnoreNotFound(err) == nil {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get DeviceConfig %s: %w", name, err)
+		}
+		pools = append(pools, dc.GetNodeSelector())
+	}
+	if len(policy.Spec.NodeSelector) > 0 {
+		pools = append(pools, policy.Spec.NodeSelector)
+	}
+	if len(pools) == 0 {
+		return []labels.Set{{}}, nil
+	}
+	return pools, nil
+}
+
+// isRestricted returns whether the pod may run on the nodes of the pools. A
+// pod bound to a node is restricted when the node is part of a pool. Otherwise,
+// it is restricted unless its node selector excludes all the pools, as its
+// node affinity is not evaluated.
+func (v *PodValidator) isRestricted(ctx context.Context, pools []labels.Set, pod *corev1.Pod, nodes map[string]labels.Set) (bool, error) {
+	if pod.Spec.NodeName != "" {
+		nodeLabels, err := v.getNodeLabels(ctx, pod.Spec.NodeName, nodes)
+		if err != nil {
+			return false, err
+		}
+		for _, pool := range pools {
+			if pool.AsSelector().Matches(nodeLabels) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	for _, pool := range pools {
+		if !labels.Conflicts(pool, pod.Spec.NodeSelector) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getNamespaceRequest returns the number of HPUs requested by the running
+// pods of the namespace restricted by the pools.
+func (v *PodValidator) getNamespaceRequest(ctx context.Context, pools []labels.Set, namespace string, nodes map[string]labels.Set) (int64, error) {
+	podList := &corev1.PodList{}
+	if err := v.client.List(ctx, podList, client.InNamespace(namespace)); err != nil {
+		return 0, fmt.Errorf("failed to list the pods of namespace %s: %w", namespace, err)
+	}
+
+	var total int64
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if isTerminated(pod) {
+			continue
+		}
+
+		devices := capacity.GetPodRequest(pod)
+		if devices == 0 {
+			continue
+		}
+
+		restricted, err := v.isRestricted(ctx, pools, pod, nodes)
+		if err != nil {
+			return 0, err
+		}
+		if restricted {
+			total += devices
+		}
+	}
+	return total, nil
+}
+
+func (v *PodValidator) getNodeLabels(ctx context.Context, name string, nodes map[string]labels.Set) (labels.Set, error) {
+	if l, ok := nodes[name]; ok {
+		return l, nil
+	}
+
+	node := &corev1.Node{}
+	if err := v.client.Get(ctx, types.NamespacedName{Name: name}, node); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("failed to get node %s: %w", name, err)
+		}
+	}
+	nodes[name] = node.Labels
+	return node.Labels, nil
+}
+
+func isTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2022.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
+	"github.com/HabanaAI/habana-ai-operator/internal/constants"
+)
+
+var _ = Describe("PodValidator", func() {
+	var (
+		dc       *hlaiv1alpha1.DeviceConfig
+		policy   *hlaiv1alpha1.HPUAccessPolicy
+		recorder *record.FakeRecorder
+		v        *PodValidator
+		ctx      context.Context
+	)
+
+	hpuPod := func(namespace, name string, devices int64) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name: "train",
+						Resources: corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{
+								constants.HabanaResourceName: *resource.NewQuantity(devices, resource.DecimalSI),
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	newValidator := func(objs ...client.Object) *PodValidator {
+		s := scheme.Scheme
+		Expect(hlaiv1alpha1.AddToScheme(s)).ToNot(HaveOccurred())
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+
+		recorder = record.NewFakeRecorder(10)
+		v := NewPodValidator(c, recorder)
+		d, err := admission.NewDecoder(s)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(v.InjectDecoder(d)).ToNot(HaveOccurred())
+		return v
+	}
+
+	handle := func(pod *corev1.Pod) admission.Response {
+		raw, err := json.Marshal(pod)
+		Expect(err).ToNot(HaveOccurred())
+
+		return v.Handle(ctx, admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		})
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		dc = &hlaiv1alpha1.DeviceConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "a-device-config"},
+			Spec: hlaiv1alpha1.DeviceConfigSpec{
+				NodeSelector: map[string]string{"pool": "a"},
+			},
+		}
+		policy = &hlaiv1alpha1.HPUAccessPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "a-policy"},
+			Spec: hlaiv1alpha1.HPUAccessPolicySpec{
+				DeviceConfigs: []string{dc.Name},
+				Namespaces: []hlaiv1alpha1.NamespaceAccess{
+					{Name: "training", MaxHPUs: pointer.Int64(8)},
+					{Name: "inference"},
+				},
+			},
+		}
+	})
+
+	It("should allow the pods not requesting HPUs", func() {
+		v = newValidator(dc, policy)
+
+		pod := hpuPod("other", "pod", 0)
+		pod.Spec.Containers[0].Resources = corev1.ResourceRequirements{}
+
+		Expect(handle(pod).Allowed).To(BeTrue())
+	})
+
+	It("should allow all the pods without policies", func() {
+		v = newValidator(dc)
+
+		Expect(handle(hpuPod("other", "pod", 8)).Allowed).To(BeTrue())
+	})
+
+	It("should deny the namespaces not allowed and report the violation", func() {
+		v = newValidator(dc, policy)
+
+		res := handle(hpuPod("other", "pod", 1))
+		Expect(res.Allowed).To(BeFalse())
+		Expect(string(res.Result.Reason)).To(ContainSubstring("namespace other is not allowed"))
+		Expect(recorder.Events).To(Receive(ContainSubstring(ReasonHPUAccessDenied)))
+	})
+
+	It("should not report the violations of the dry runs", func() {
+		v = newValidator(dc, policy)
+
+		raw, err := json.Marshal(hpuPod("other", "pod", 1))
+		Expect(err).ToNot(HaveOccurred())
+		res := v.Handle(ctx, admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Name:      "pod",
+				Namespace: "other",
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+				DryRun:    pointer.Bool(true),
+			},
+		})
+		Expect(res.Allowed).To(BeFalse())
+		Expect(recorder.Events).ToNot(Receive())
+	})
+
+	It("should allow the namespaces without maximum", func() {
+		v = newValidator(dc, policy)
+
+		Expect(handle(hpuPod("inference", "pod", 64)).Allowed).To(BeTrue())
+	})
+
+	It("should allow the pods excluded from the restricted nodes by their node selector", func() {
+		v = newValidator(dc, policy)
+
+		pod := hpuPod("other", "pod", 1)
+		pod.Spec.NodeSelector = map[string]string{"pool": "b"}
+
+		Expect(handle(pod).Allowed).To(BeTrue())
+	})
+
+	It("should only restrict the nodes of the policy for the pods bound to a node", func() {
+		nodeA := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"pool": "a"}}}
+		nodeB := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{"pool": "b"}}}
+		v = newValidator(dc, policy, nodeA, nodeB)
+
+		pod := hpuPod("other", "pod", 1)
+		pod.Spec.NodeName = nodeB.Name
+		Expect(handle(pod).Allowed).To(BeTrue())
+
+		pod.Spec.NodeName = nodeA.Name
+		Expect(handle(pod).Allowed).To(BeFalse())
+	})
+
+	It("should restrict the nodes selected by the policy node selector", func() {
+		policy.Spec.DeviceConfigs = nil
+		policy.Spec.NodeSelector = map[string]string{"pool": "b"}
+		v = newValidator(dc, policy)
+
+		pod := hpuPod("other", "pod", 1)
+		pod.Spec.NodeSelector = map[string]string{"pool": "a"}
+		Expect(handle(pod).Allowed).To(BeTrue())
+
+		pod.Spec.NodeSelector = map[string]string{"pool": "b"}
+		Expect(handle(pod).Allowed).To(BeFalse())
+	})
+
+	It("should restrict all the nodes when the DeviceConfigs of the policy are missing", func() {
+		v = newValidator(policy)
+
+		pod := hpuPod("other", "pod", 1)
+		pod.Spec.NodeSelector = map[string]string{"pool": "b"}
+
+		Expect(handle(pod).Allowed).To(BeFalse())
+	})
+
+	It("should deny the pods exceeding the namespace maximum", func() {
+		running := hpuPod("training", "running", 4)
+		done := hpuPod("training", "done", 8)
+		done.Status.Phase = corev1.PodSucceeded
+		elsewhere := hpuPod("training", "elsewhere", 8)
+		elsewhere.Spec.NodeSelector = map[string]string{"pool": "b"}
+		v = newValidator(dc, policy, running, done, elsewhere)
+
+		Expect(handle(hpuPod("training", "pod", 4)).Allowed).To(BeTrue())
+
+		res := handle(hpuPod("training", "pod", 5))
+		Expect(res.Allowed).To(BeFalse())
+		Expect(string(res.Result.Reason)).To(ContainSubstring("would request 9 HPUs, exceeding its maximum of 8"))
+	})
+})
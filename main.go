@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -27,21 +28,41 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	kmmv1beta1 "github.com/kubernetes-sigs/kernel-module-management/api/v1beta1"
 
 	hlaiv1alpha1 "github.com/HabanaAI/habana-ai-operator/api/v1alpha1"
 	"github.com/HabanaAI/habana-ai-operator/controllers"
+	"github.com/HabanaAI/habana-ai-operator/internal/capacity"
+	"github.com/HabanaAI/habana-ai-operator/internal/certificates"
 	"github.com/HabanaAI/habana-ai-operator/internal/conditions"
+	"github.com/HabanaAI/habana-ai-operator/internal/dependencies"
 	"github.com/HabanaAI/habana-ai-operator/internal/finalizers"
 	"github.com/HabanaAI/habana-ai-operator/internal/module"
+	"github.com/HabanaAI/habana-ai-operator/internal/monitoring"
+	nodeCDI "github.com/HabanaAI/habana-ai-operator/internal/node/cdi"
+	nodeCleanup "github.com/HabanaAI/habana-ai-operator/internal/node/cleanup"
+	nodeContainerRuntime "github.com/HabanaAI/habana-ai-operator/internal/node/containerruntime"
+	nodeDriver "github.com/HabanaAI/habana-ai-operator/internal/node/driver"
+	nodeHealth "github.com/HabanaAI/habana-ai-operator/internal/node/health"
+	nodeHugePages "github.com/HabanaAI/habana-ai-operator/internal/node/hugepages"
+	nodeKubelet "github.com/HabanaAI/habana-ai-operator/internal/node/kubelet"
 	nodeLabeler "github.com/HabanaAI/habana-ai-operator/internal/node/labeler"
 	nodeMetrics "github.com/HabanaAI/habana-ai-operator/internal/node/metrics"
+	nodeNetwork "github.com/HabanaAI/habana-ai-operator/internal/node/network"
+	nodeReadiness "github.com/HabanaAI/habana-ai-operator/internal/node/readiness"
+	nodeSimulation "github.com/HabanaAI/habana-ai-operator/internal/node/simulation"
+	nodeTopology "github.com/HabanaAI/habana-ai-operator/internal/node/topology"
+	"github.com/HabanaAI/habana-ai-operator/internal/rbac"
+	"github.com/HabanaAI/habana-ai-operator/internal/telemetry"
+	workloadWebhook "github.com/HabanaAI/habana-ai-operator/internal/webhook"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -76,6 +97,7 @@ func main() {
 		metricsAddr          string
 		enableLeaderElection bool
 		probeAddr            string
+		enableWebhooks       bool
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
@@ -83,6 +105,9 @@ func main() {
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Enable the admission webhooks injecting the Habana runtime requirements in the workloads "+
+			"and enforcing the HPUAccessPolicies.")
 
 	klog.InitFlags(flag.CommandLine)
 
@@ -114,16 +139,49 @@ func main() {
 		os.Exit(1)
 	}
 
+	dep := dependencies.NewChecker(
+		discovery.NewDiscoveryClientForConfigOrDie(mgr.GetConfig()),
+		dependencies.DefaultRefreshInterval,
+		dependencies.All...,
+	)
+	if err := dep.Refresh(context.Background()); err != nil {
+		setupLogger.Error(err, "unable to discover the operator dependencies")
+	}
+	for _, d := range dep.Missing() {
+		setupLogger.Info("dependency not found, the DeviceConfigs will not be reconciled until it is installed", "dependency", d.String())
+	}
+	if err := mgr.Add(dep); err != nil {
+		setupLogger.Error(err, "unable to set up the dependency checker")
+		os.Exit(1)
+	}
+
 	c := mgr.GetClient()
 	s := mgr.GetScheme()
 
 	mr := module.NewReconciler(c, s)
 	nmr := nodeMetrics.NewReconciler(c, s)
+	cer := certificates.NewReconciler(c, s)
+	mor := monitoring.NewReconciler(c, s)
 	nlr := nodeLabeler.NewReconciler(c, s)
+	ncr := nodeCleanup.NewReconciler(c, s)
+	nhr := nodeHealth.NewReconciler(c, s)
+	nrr := nodeReadiness.NewReconciler(c, s)
+	cdr := nodeCDI.NewReconciler(c, s)
+	crr := nodeContainerRuntime.NewReconciler(c, s)
+	nwr := nodeNetwork.NewReconciler(c, s)
+	hpr := nodeHugePages.NewReconciler(c, s)
+	tpr := nodeTopology.NewReconciler(c, s)
+	kbr := nodeKubelet.NewReconciler(c, s)
+	smr := nodeSimulation.NewReconciler(c, s)
+	dvr := nodeDriver.NewReconciler(c, s)
+	tc := telemetry.NewCollector(c, mgr.GetConfig())
+	ca := capacity.NewAccountant(mgr.GetAPIReader())
+	rr := rbac.NewReconciler(c, s)
 	fu := finalizers.NewUpdater(c)
-	cu := conditions.NewUpdater(c)
+	cu := conditions.NewUpdater(c.Status())
 	nsv := controllers.NewNodeSelectorValidator(c)
-	dcc := controllers.NewReconciler(c, s, mgr.GetEventRecorderFor("deviceconfig-controller"), mr, nmr, nlr, fu, cu, nsv)
+	hpv := controllers.NewHostPortValidator(mgr.GetAPIReader())
+	dcc := controllers.NewReconciler(c, s, mgr.GetEventRecorderFor("deviceconfig-controller"), mr, nmr, cer, mor, nlr, ncr, nhr, nrr, cdr, crr, nwr, hpr, tpr, kbr, smr, dvr, tc, ca, rr, fu, cu, nsv, hpv, dep)
 
 	if err := dcc.SetupWithManager(mgr); err != nil {
 		setupLogger.Error(err, "unable to create controller", "controller", "DeviceConfig")
@@ -131,11 +189,18 @@ func main() {
 	}
 	//+kubebuilder:scaffold:builder
 
+	if enableWebhooks {
+		mgr.GetWebhookServer().Register(workloadWebhook.MutatePodPath, &webhook.Admission{Handler: workloadWebhook.NewPodMutator(c)})
+		mgr.GetWebhookServer().Register(workloadWebhook.ValidatePodPath, &webhook.Admission{
+			Handler: workloadWebhook.NewPodValidator(mgr.GetAPIReader(), mgr.GetEventRecorderFor("hpuaccesspolicy-webhook")),
+		})
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLogger.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	if err := mgr.AddReadyzCheck("readyz", dep.ReadyzCheck); err != nil {
 		setupLogger.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}